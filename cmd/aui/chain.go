@@ -0,0 +1,167 @@
+package main
+
+import (
+	gocontext "context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/models"
+	"github.com/yourusername/aui/internal/orchestrator"
+	"github.com/yourusername/aui/internal/providers"
+	"github.com/yourusername/aui/internal/redact"
+	"github.com/yourusername/aui/internal/render"
+	"github.com/yourusername/aui/internal/storage"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// runChainCommand handles "aui chain <subcommand>"
+func runChainCommand(args []string, cfg *config.Config, store *storage.SQLiteStore) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: aui chain <list|run>")
+	}
+
+	switch args[0] {
+	case "list":
+		return listChains(cfg)
+	case "run":
+		return runChain(args[1:], cfg, store)
+	default:
+		return fmt.Errorf("unknown chain command: %s", args[0])
+	}
+}
+
+// listChains prints the configured escalation chains
+func listChains(cfg *config.Config) error {
+	if len(cfg.Chains) == 0 {
+		fmt.Println("No chains configured. Add them under chains: in the config file.")
+		return nil
+	}
+
+	names := make([]string, 0, len(cfg.Chains))
+	for name := range cfg.Chains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		chain := cfg.Chains[name]
+		fmt.Printf("%s: %s\n", name, strings.Join(chain.Tiers, " → "))
+		if chain.Timeout > 0 {
+			fmt.Printf("  timeout: %s per tier\n", chain.Timeout)
+		}
+		if len(chain.Require) > 0 {
+			fmt.Printf("  require: %s\n", strings.Join(chain.Require, ", "))
+		}
+	}
+	return nil
+}
+
+// runChain handles "aui chain run [--context name] <chain> <prompt>",
+// printing the first acceptable answer and reporting the tier that gave it
+func runChain(args []string, cfg *config.Config, store *storage.SQLiteStore) error {
+	fs := flag.NewFlagSet("chain run", flag.ContinueOnError)
+	contextName := fs.String("context", "", "Context to send with the prompt")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 {
+		return fmt.Errorf("usage: aui chain run [--context name] <chain> <prompt>")
+	}
+
+	chainCfg, ok := cfg.Chains[fs.Arg(0)]
+	if !ok {
+		return fmt.Errorf("unknown chain: %s", fs.Arg(0))
+	}
+
+	catalog, err := models.Load(models.OverridePath())
+	if err != nil {
+		return err
+	}
+	agents, err := store.ListAgents()
+	if err != nil {
+		return err
+	}
+	chain, err := orchestrator.NewChain(fs.Arg(0), chainCfg, agents, catalog)
+	if err != nil {
+		return err
+	}
+
+	request, err := sharedRequest(cfg, store, catalog, chain.Tiers, *contextName)
+	if err != nil {
+		return err
+	}
+	req, err := request(strings.Join(fs.Args()[1:], " "))
+	if err != nil {
+		return err
+	}
+
+	o := orchestrator.New(cfg, store, catalog)
+	serverTools, disconnect, err := mcpTools(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	defer disconnect()
+	if o.Tools, err = projectTools(cfg, serverTools...); err != nil {
+		return err
+	}
+	attempts, err := o.RunChain(gocontext.Background(), chain, req, func(ag *agent.Agent) (api.Provider, error) {
+		return providers.New(ag.Provider, cfg)
+	})
+	if err != nil {
+		return err
+	}
+
+	answer := attempts[len(attempts)-1]
+	fmt.Println(answer.Answer)
+	fmt.Fprintf(os.Stderr, "\nanswered by tier %d of %d (%s)\n", answer.Tier, len(chain.Tiers), answer.Agent.Name)
+	for _, a := range attempts[:len(attempts)-1] {
+		fmt.Fprintf(os.Stderr, "  tier %d (%s): %s\n", a.Tier, a.Agent.Name, a.Escalated)
+	}
+	return nil
+}
+
+// sharedRequest returns a function that builds the request for a prompt
+// with a context attached. The context is prepared once, packed to the
+// smallest input budget among agents so that each of them can take it.
+func sharedRequest(cfg *config.Config, store *storage.SQLiteStore, catalog *models.Catalog, agents []*agent.Agent, contextName string) (func(prompt string) (*api.Request, error), error) {
+	ctx := context.NewContext("prompt", "")
+	budget := cfg.Context.TokenBudget
+	if contextName != "" {
+		var err error
+		if ctx, err = findContext(store, contextName); err != nil {
+			return nil, err
+		}
+	}
+	for _, ag := range agents {
+		if m := catalog.Get(ag.Model); m != nil {
+			budget = min(budget, m.InputBudget(ag.Params.MaxTokens))
+		}
+	}
+
+	redactor, err := redact.FromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	prepared, err := render.Prepare(store, redactor, ctx, budget)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := render.Options{Format: render.DefaultFormat(agents[0].Provider), LineNumbers: cfg.Context.LineNumbers}
+	if cfg.Context.Format != "" {
+		if opts.Format, err = render.ParseFormat(cfg.Context.Format); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(prompt string) (*api.Request, error) {
+		prompt, _ = redactor.Redact("prompt", prompt)
+		return render.Request(prepared.Context, prompt, opts)
+	}, nil
+}
@@ -0,0 +1,494 @@
+package main
+
+import (
+	gocontext "context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/aui/internal/bundle"
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/models"
+	"github.com/yourusername/aui/internal/redact"
+	"github.com/yourusername/aui/internal/render"
+	"github.com/yourusername/aui/internal/storage"
+	"github.com/yourusername/aui/internal/summarize"
+	"github.com/yourusername/aui/internal/templates"
+)
+
+// runCommand runs a non-interactive subcommand such as "context export"
+func runCommand(args []string, cfg *config.Config, store *storage.SQLiteStore) error {
+	switch args[0] {
+	case "context":
+		return runContextCommand(args[1:], cfg, store)
+	case "models":
+		return listModels(args[1:])
+	case "usage":
+		return showUsage(args[1:], store)
+	case "queue":
+		return listJobs(args[1:], store)
+	case "chain":
+		return runChainCommand(args[1:], cfg, store)
+	case "pipeline":
+		return runPipelineCommand(args[1:], cfg, store)
+	case "rubrics":
+		return listRubrics(cfg)
+	case "tools":
+		return listTools(cfg)
+	case "commands":
+		return listCommands(args[1:], store)
+	case "mcp":
+		return runMCPCommand(args[1:], cfg, store)
+	default:
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+}
+
+// runContextCommand handles "aui context <subcommand>"
+func runContextCommand(args []string, cfg *config.Config, store *storage.SQLiteStore) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: aui context <list|new|templates|include|symbols|summarize|preview|export|import> [arguments]")
+	}
+
+	switch args[0] {
+	case "list":
+		return listContexts(store)
+	case "new":
+		return newContext(args[1:], store)
+	case "templates":
+		return listTemplates()
+	case "include":
+		return includeContext(args[1:], store)
+	case "symbols":
+		return addSymbols(args[1:], store)
+	case "summarize":
+		return summarizeContext(args[1:], cfg, store)
+	case "preview":
+		return previewContext(args[1:], cfg, store)
+	case "export":
+		return exportContext(args[1:], store)
+	case "import":
+		return importContext(args[1:], store)
+	default:
+		return fmt.Errorf("unknown context command: %s", args[0])
+	}
+}
+
+// listContexts prints the stored contexts
+func listContexts(store *storage.SQLiteStore) error {
+	contexts, err := store.ListContexts()
+	if err != nil {
+		return err
+	}
+
+	for _, ctx := range contexts {
+		fmt.Printf("%s  %-24s %8d tokens  %s\n", ctx.ID, ctx.Name, ctx.TotalTokens, ctx.Description)
+	}
+	return nil
+}
+
+// newContext handles "aui context new [--template name] [--description text] [name]"
+func newContext(args []string, store *storage.SQLiteStore) error {
+	fs := flag.NewFlagSet("context new", flag.ContinueOnError)
+	templateName := fs.String("template", "", "Template to build the context from")
+	description := fs.String("description", "", "Context description")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 || (fs.NArg() == 0 && *templateName == "") {
+		return fmt.Errorf("usage: aui context new [--template name] [--description text] [name]")
+	}
+	name := fs.Arg(0)
+
+	var ctx *context.Context
+	if *templateName == "" {
+		ctx = context.NewContext(name, *description)
+	} else {
+		root, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+
+		all, err := templates.LoadAll(templates.Dirs(root)...)
+		if err != nil {
+			return err
+		}
+		tmpl := templates.Find(all, *templateName)
+		if tmpl == nil {
+			return fmt.Errorf("template not found: %s (available: %s)", *templateName, strings.Join(templates.Names(all), ", "))
+		}
+
+		var missing []string
+		ctx, missing, err = tmpl.Instantiate(root, name)
+		if err != nil {
+			return err
+		}
+		for _, ref := range missing {
+			fmt.Printf("  skipped missing file %s\n", ref)
+		}
+		if *description != "" {
+			ctx.Description = *description
+		}
+	}
+
+	if err := store.SaveContext(ctx); err != nil {
+		return fmt.Errorf("failed to save context: %w", err)
+	}
+
+	fmt.Printf("Created %s (%d files, %d tokens)\n", ctx.Name, len(ctx.Files), ctx.TotalTokens)
+	return nil
+}
+
+// listTemplates prints the templates available in the current directory
+func listTemplates() error {
+	root, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	all, err := templates.LoadAll(templates.Dirs(root)...)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range all {
+		fmt.Printf("%-16s %-40s %s\n", t.Name, t.Description, t.Source)
+	}
+	return nil
+}
+
+// includeContext handles "aui context include [--remove] <context> <included>"
+func includeContext(args []string, store *storage.SQLiteStore) error {
+	fs := flag.NewFlagSet("context include", flag.ContinueOnError)
+	remove := fs.Bool("remove", false, "Remove the include instead of adding it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: aui context include [--remove] <context> <included>")
+	}
+
+	ctx, err := findContext(store, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	inc, err := findContext(store, fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	if *remove {
+		ctx.RemoveInclude(inc.ID)
+	} else {
+		ctx.Include(inc.ID)
+	}
+	if err := store.SaveContext(ctx); err != nil {
+		return fmt.Errorf("failed to save context: %w", err)
+	}
+
+	flat, err := store.GetFlattenedContext(ctx.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: %d own tokens, %d with includes\n", ctx.Name, ctx.TotalTokens, flat.TotalTokens)
+	return nil
+}
+
+// addSymbols handles "aui context symbols <context> <file.go|package dir>
+// [symbol...]", adding the named Go declarations and the signatures they
+// reference to a context, or the exported API without symbols
+func addSymbols(args []string, store *storage.SQLiteStore) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: aui context symbols <context> <file.go|package dir> [symbol...]")
+	}
+	ctx, err := findContext(store, args[0])
+	if err != nil {
+		return err
+	}
+
+	path := filepath.ToSlash(filepath.Clean(args[1]))
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	var f *context.File
+	if info.IsDir() {
+		f, err = context.ExtractGoPackage(path, args[2:])
+	} else {
+		var src []byte
+		if src, err = os.ReadFile(path); err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+		f, err = context.ExtractGoSymbols(path, src, args[2:])
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx.AddFile(f)
+	if err := store.SaveContext(ctx); err != nil {
+		return fmt.Errorf("failed to save context: %w", err)
+	}
+	fmt.Printf("Added %s to %s (%d tokens, %d in the context)\n", f.Path, ctx.Name, f.Tokens, ctx.TotalTokens)
+	return nil
+}
+
+// summarizeContext handles "aui context summarize [--budget n] <context>"
+func summarizeContext(args []string, cfg *config.Config, store *storage.SQLiteStore) error {
+	fs := flag.NewFlagSet("context summarize", flag.ContinueOnError)
+	budget := fs.Int("budget", cfg.Context.TokenBudget, "Token budget to pack the context into")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: aui context summarize [--budget n] <context>")
+	}
+
+	found, err := findContext(store, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	ctx, err := store.GetFlattenedContext(found.ID)
+	if err != nil {
+		return err
+	}
+
+	redactor, err := redact.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	summarizer, err := summarize.New(cfg, store, redactor)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range ctx.Files {
+		if f.Tokens < summarizer.MinTokens {
+			continue
+		}
+		summary, cached, err := summarizer.Summarize(gocontext.Background(), f)
+		if err != nil {
+			return err
+		}
+		source := "new"
+		if cached {
+			source = "cached"
+		}
+		fmt.Printf("  %-40s %7d -> %5d tokens (%s)\n", f.Path, f.Tokens, summary.Tokens, source)
+	}
+
+	hashes := make([]string, len(ctx.Files))
+	for i, f := range ctx.Files {
+		hashes[i] = f.Hash
+	}
+	summaries, err := store.GetSummaries(hashes)
+	if err != nil {
+		return err
+	}
+
+	packed := context.Pack(ctx, *budget, summaries)
+	fmt.Printf("%s: %d tokens, %d packed into a budget of %d\n", ctx.Name, ctx.TotalTokens, packed.Context.TotalTokens, *budget)
+	if packed.OverBudget {
+		fmt.Println("  still over budget after using every summary")
+	}
+	return nil
+}
+
+// previewContext handles "aui context preview [flags] <context> [prompt]",
+// printing exactly what would be sent to a provider
+func previewContext(args []string, cfg *config.Config, store *storage.SQLiteStore) error {
+	fs := flag.NewFlagSet("context preview", flag.ContinueOnError)
+	format := fs.String("format", cfg.Context.Format, "Prompt format: xml, markdown, or json (default depends on provider)")
+	provider := fs.String("provider", "anthropic", "Provider whose default format is used")
+	model := fs.String("model", "", "Model whose context window sets the default budget and provider")
+	lineNumbers := fs.Bool("line-numbers", cfg.Context.LineNumbers, "Number the lines of each file")
+	budget := fs.Int("budget", cfg.Context.TokenBudget, "Token budget; cached summaries replace large files above it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 {
+		return fmt.Errorf("usage: aui context preview [--format f] [--model id] [--line-numbers] [--budget n] <context> [prompt]")
+	}
+
+	if *model != "" {
+		catalog, err := models.Load(models.OverridePath())
+		if err != nil {
+			return err
+		}
+		m := catalog.Get(*model)
+		if m == nil {
+			return fmt.Errorf("unknown model: %s", *model)
+		}
+
+		set := make(map[string]bool)
+		fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		if !set["provider"] {
+			*provider = m.Provider
+		}
+		if !set["budget"] {
+			*budget = m.InputBudget(0)
+		}
+	}
+
+	opts := render.Options{Format: render.DefaultFormat(*provider), LineNumbers: *lineNumbers}
+	if *format != "" {
+		f, err := render.ParseFormat(*format)
+		if err != nil {
+			return err
+		}
+		opts.Format = f
+	}
+
+	ctx, err := findContext(store, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	redactor, err := redact.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	prepared, err := render.Prepare(store, redactor, ctx, *budget)
+	if err != nil {
+		return err
+	}
+
+	req, err := render.Request(prepared.Context, strings.Join(fs.Args()[1:], " "), opts)
+	if err != nil {
+		return err
+	}
+
+	if req.System != "" {
+		fmt.Printf("=== system ===\n%s\n\n", req.System)
+	}
+	for _, m := range req.Messages {
+		fmt.Printf("=== %s ===\n%s\n\n", m.Role, m.Content)
+	}
+
+	tokens := context.EstimateTokens(req.System)
+	for _, m := range req.Messages {
+		tokens += context.EstimateTokens(m.Content)
+	}
+	fmt.Printf("=== about %d tokens, %d files ===\n", tokens, len(prepared.Context.Files))
+	if len(prepared.Summarized) > 0 {
+		fmt.Printf("summarized to fit the budget: %s\n", strings.Join(prepared.Summarized, ", "))
+	}
+	if prepared.OverBudget {
+		fmt.Printf("over the budget of %d tokens\n", *budget)
+	}
+	if prepared.Redactions.HasFindings() {
+		fmt.Printf("redacted: %s\n", prepared.Redactions.Summary())
+	}
+	return nil
+}
+
+// findContext loads a stored context by ID or name
+func findContext(store *storage.SQLiteStore, nameOrID string) (*context.Context, error) {
+	if ctx, err := store.GetContext(nameOrID); err == nil {
+		return ctx, nil
+	}
+
+	contexts, err := store.ListContexts()
+	if err != nil {
+		return nil, err
+	}
+	for _, ctx := range contexts {
+		if ctx.Name == nameOrID {
+			return store.GetContext(ctx.ID)
+		}
+	}
+
+	return nil, fmt.Errorf("context not found: %s", nameOrID)
+}
+
+// exportContext handles "aui context export [--format json|tar.gz] <context> <file>"
+func exportContext(args []string, store *storage.SQLiteStore) error {
+	fs := flag.NewFlagSet("context export", flag.ContinueOnError)
+	format := fs.String("format", "", "Bundle format: json or tar.gz (default from file extension)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: aui context export [--format json|tar.gz] <context> <file>")
+	}
+
+	found, err := findContext(store, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	// Bundles are self-contained, so included contexts are flattened in
+	ctx, err := store.GetFlattenedContext(found.ID)
+	if err != nil {
+		return err
+	}
+
+	path := fs.Arg(1)
+	bundleFormat := bundle.FormatForPath(path)
+	if *format != "" {
+		bundleFormat = bundle.Format(*format)
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer out.Close()
+
+	if err := bundle.Export(out, ctx, bundleFormat); err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %s (%d files, %d tokens) to %s\n", ctx.Name, len(ctx.Files), ctx.TotalTokens, path)
+	return out.Close()
+}
+
+// importContext handles "aui context import [--on-conflict mode] [--verify] <file>"
+func importContext(args []string, store *storage.SQLiteStore) error {
+	fs := flag.NewFlagSet("context import", flag.ContinueOnError)
+	onConflict := fs.String("on-conflict", "rename", "What to do if the context exists: rename, merge, or overwrite")
+	verify := fs.Bool("verify", false, "Check file hashes against the current directory")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: aui context import [--on-conflict rename|merge|overwrite] [--verify] <file>")
+	}
+
+	mode, err := bundle.ParseConflictMode(*onConflict)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer in.Close()
+
+	ctx, err := bundle.Import(in)
+	if err != nil {
+		return err
+	}
+
+	if *verify {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		for _, m := range bundle.Verify(ctx, wd) {
+			fmt.Printf("  %-12s %s\n", m.Reason, m.Path)
+		}
+	}
+
+	saved, err := bundle.Save(store, ctx, mode)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %s (%d files, %d tokens)\n", saved.Name, len(saved.Files), saved.TotalTokens)
+	return nil
+}
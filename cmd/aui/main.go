@@ -57,8 +57,33 @@ func main() {
 	}
 	defer store.Close()
 
+	// Run a subcommand instead of the TUI if one was given
+	if flag.NArg() > 0 {
+		err := runCommand(flag.Args(), cfg, store)
+		store.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Create the initial app state with config and storage
 	app := ui.InitialAppWithDependencies(cfg, store)
+	if wd, err := os.Getwd(); err == nil {
+		app.Root = wd
+	}
+	// Agents answering through the queue may read the project and call the
+	// tools of MCP servers; chat adds its own tools for the attached context
+	serverTools, disconnect, err := mcpTools(cfg)
+	if err != nil {
+		app.Status = err.Error()
+	}
+	defer disconnect()
+	app.MCPTools = serverTools
+	if toolbox, err := projectTools(cfg, serverTools...); err == nil {
+		app.Orchestrator.Tools = toolbox
+	}
 
 	// Set up logging if configured
 	if cfg.Logging.File != "" {
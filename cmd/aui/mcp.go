@@ -0,0 +1,243 @@
+package main
+
+import (
+	gocontext "context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/internal/mcp"
+	"github.com/yourusername/aui/internal/redact"
+	"github.com/yourusername/aui/internal/render"
+	"github.com/yourusername/aui/internal/storage"
+	"github.com/yourusername/aui/internal/tools"
+)
+
+// mcpConnectTimeout bounds starting and initializing the MCP servers
+const mcpConnectTimeout = 30 * time.Second
+
+// mcpTools connects to the MCP servers whose tools agents may call and
+// returns their tools and a function that disconnects. Servers that fail are
+// left out and their errors returned with the tools of the rest.
+func mcpTools(cfg *config.Config) ([]tools.Tool, func(), error) {
+	exposed := make(map[string]config.MCPServerConfig)
+	for name, server := range cfg.MCPServers {
+		if server.ExposeTools {
+			exposed[name] = server
+		}
+	}
+	if !cfg.Tools.Enabled || len(exposed) == 0 {
+		return nil, func() {}, nil
+	}
+
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), mcpConnectTimeout)
+	defer cancel()
+	clients, connectErr := mcp.ConnectAll(ctx, exposed)
+	disconnect := func() {
+		for _, c := range clients {
+			c.Close()
+		}
+	}
+
+	all, err := mcp.Tools(ctx, clients)
+	if connectErr != nil {
+		err = fmt.Errorf("some MCP servers are unavailable: %w", connectErr)
+	} else if err != nil {
+		err = fmt.Errorf("failed to list MCP tools: %w", err)
+	}
+	return all, disconnect, err
+}
+
+// runMCPCommand handles "aui mcp <subcommand>"
+func runMCPCommand(args []string, cfg *config.Config, store *storage.SQLiteStore) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: aui mcp <list|add|prompt|serve> [arguments]")
+	}
+
+	switch args[0] {
+	case "list":
+		return listMCPServers(cfg)
+	case "add":
+		return addMCPResources(args[1:], cfg, store)
+	case "prompt":
+		return getMCPPrompt(args[1:], cfg)
+	case "serve":
+		return serveMCP(args[1:], cfg, store)
+	default:
+		return fmt.Errorf("unknown mcp command: %s", args[0])
+	}
+}
+
+// connectMCP connects to one configured server
+func connectMCP(ctx gocontext.Context, cfg *config.Config, name string) (*mcp.Client, error) {
+	server, ok := cfg.MCPServers[name]
+	if !ok {
+		return nil, fmt.Errorf("MCP server not configured: %s", name)
+	}
+	return mcp.Connect(ctx, name, server)
+}
+
+// listMCPServers handles "aui mcp list", printing the tools, resources and
+// prompts of every configured server
+func listMCPServers(cfg *config.Config) error {
+	if len(cfg.MCPServers) == 0 {
+		fmt.Println("No MCP servers configured. Add them under mcp_servers in the config file.")
+		return nil
+	}
+
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), mcpConnectTimeout)
+	defer cancel()
+	clients, err := mcp.ConnectAll(ctx, cfg.MCPServers)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	for _, c := range clients {
+		defer c.Close()
+		fmt.Printf("%s (%s %s)\n", c.Name, c.Server.Name, c.Server.Version)
+
+		list, err := c.ListTools(ctx)
+		if err != nil {
+			fmt.Printf("  tools: %v\n", err)
+		}
+		for i, t := range list {
+			if i == 0 && cfg.MCPServers[c.Name].ExposeTools {
+				fmt.Println("  tools:")
+			} else if i == 0 {
+				fmt.Println("  tools (not offered to agents; set expose_tools to allow):")
+			}
+			fmt.Printf("    %-32s %s\n", mcp.ToolName(c.Name, t.Name), firstLine(t.Description, 60))
+		}
+
+		resources, err := c.ListResources(ctx)
+		if err != nil {
+			fmt.Printf("  resources: %v\n", err)
+		}
+		for i, r := range resources {
+			if i == 0 {
+				fmt.Println("  resources:")
+			}
+			fmt.Printf("    %-32s %s\n", r.URI, r.Name)
+		}
+
+		prompts, err := c.ListPrompts(ctx)
+		if err != nil {
+			fmt.Printf("  prompts: %v\n", err)
+		}
+		for i, p := range prompts {
+			if i == 0 {
+				fmt.Println("  prompts:")
+			}
+			names := make([]string, len(p.Arguments))
+			for j, arg := range p.Arguments {
+				names[j] = arg.Name
+				if arg.Required {
+					names[j] += "*"
+				}
+			}
+			fmt.Printf("    %-32s %s\n", p.Name+"("+strings.Join(names, ", ")+")", firstLine(p.Description, 60))
+		}
+	}
+	return nil
+}
+
+// addMCPResources handles "aui mcp add <context> <server> <uri>...", adding
+// resources of a server to a context as files
+func addMCPResources(args []string, cfg *config.Config, store *storage.SQLiteStore) error {
+	if len(args) < 3 {
+		return fmt.Errorf("usage: aui mcp add <context> <server> <uri>...")
+	}
+	ctx, err := findContext(store, args[0])
+	if err != nil {
+		return err
+	}
+
+	reqCtx, cancel := gocontext.WithTimeout(gocontext.Background(), mcpConnectTimeout)
+	defer cancel()
+	c, err := connectMCP(reqCtx, cfg, args[1])
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	added := 0
+	for _, uri := range args[2:] {
+		files, err := mcp.ResourceFiles(reqCtx, c, uri)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			ctx.AddFile(f)
+			added++
+		}
+	}
+
+	if err := store.SaveContext(ctx); err != nil {
+		return fmt.Errorf("failed to save context: %w", err)
+	}
+	fmt.Printf("Added %d files to %s (%d tokens)\n", added, ctx.Name, ctx.TotalTokens)
+	return nil
+}
+
+// getMCPPrompt handles "aui mcp prompt <server> <prompt> [name=value...]",
+// printing a prompt of a server filled in with arguments
+func getMCPPrompt(args []string, cfg *config.Config) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: aui mcp prompt <server> <prompt> [name=value...]")
+	}
+	arguments := make(map[string]string)
+	for _, arg := range args[2:] {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return fmt.Errorf("invalid prompt argument %q (want name=value)", arg)
+		}
+		arguments[name] = value
+	}
+
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), mcpConnectTimeout)
+	defer cancel()
+	c, err := connectMCP(ctx, cfg, args[0])
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	prompt, err := c.GetPrompt(ctx, args[1], arguments)
+	if err != nil {
+		return err
+	}
+	for _, m := range prompt.Messages {
+		fmt.Printf("%s: %s\n", m.Role, m.Content)
+	}
+	return nil
+}
+
+// serveMCP handles "aui mcp serve [--root dir] [--format f]", offering the
+// saved contexts to MCP clients over stdin and stdout
+func serveMCP(args []string, cfg *config.Config, store *storage.SQLiteStore) error {
+	fs := flag.NewFlagSet("mcp serve", flag.ContinueOnError)
+	root := fs.String("root", ".", "Project root that add_file reads paths below")
+	format := fs.String("format", cfg.Context.Format, "Format contexts are rendered in: xml, markdown, or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	server := &mcp.Server{Store: store, Root: *root}
+	if *format != "" {
+		f, err := render.ParseFormat(*format)
+		if err != nil {
+			return err
+		}
+		server.Format = f
+	}
+	redactor, err := redact.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	server.Redactor = redactor
+
+	return server.Serve(os.Stdin, os.Stdout)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/aui/internal/ledger"
+	"github.com/yourusername/aui/internal/models"
+	"github.com/yourusername/aui/internal/storage"
+)
+
+// listModels handles "aui models [provider]", printing the model catalog
+func listModels(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: aui models [provider]")
+	}
+
+	catalog, err := models.Load(models.OverridePath())
+	if err != nil {
+		return err
+	}
+
+	list := catalog.All()
+	if len(args) == 1 {
+		list = catalog.ForProvider(args[0])
+		if len(list) == 0 {
+			return fmt.Errorf("no models for provider: %s", args[0])
+		}
+	}
+
+	fmt.Printf("%-26s %-10s %9s %8s %8s %8s  %s\n", "MODEL", "PROVIDER", "CONTEXT", "OUTPUT", "IN $/M", "OUT $/M", "FEATURES")
+	for _, m := range list {
+		features := make([]string, len(m.Features))
+		for i, f := range m.Features {
+			features[i] = string(f)
+		}
+		fmt.Printf("%-26s %-10s %9d %8d %8.2f %8.2f  %s\n",
+			m.ID, m.Provider, m.ContextWindow, m.MaxOutput, m.InputPrice, m.OutputPrice, strings.Join(features, ","))
+	}
+	return nil
+}
+
+// showUsage handles "aui usage [--since duration]", printing token use and
+// cost from the ledger by model
+func showUsage(args []string, store *storage.SQLiteStore) error {
+	fs := flag.NewFlagSet("usage", flag.ContinueOnError)
+	since := fs.Duration("since", 30*24*time.Hour, "How far back to report")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("usage: aui usage [--since duration]")
+	}
+
+	entries, err := store.ListUsage(time.Now().Add(-*since))
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Printf("No usage recorded in the last %s\n", *since)
+		return nil
+	}
+
+	fmt.Printf("%-26s %-10s %8s %12s %12s %10s\n", "MODEL", "PROVIDER", "REQUESTS", "INPUT", "OUTPUT", "COST")
+	for _, t := range ledger.Totals(entries) {
+		fmt.Printf("%-26s %-10s %8d %12d %12d %10s\n", t.Model, t.Provider, t.Requests, t.InputTokens, t.OutputTokens, fmt.Sprintf("$%.4f", t.Cost))
+	}
+	sum := ledger.Sum(entries)
+	fmt.Printf("%-37s %8d %12d %12d %10s\n", "TOTAL", sum.Requests, sum.InputTokens, sum.OutputTokens, fmt.Sprintf("$%.4f", sum.Cost))
+
+	if tiers := ledger.Tiers(entries); len(tiers) > 0 {
+		fmt.Printf("\n%-16s %4s %-26s %8s %9s %10s\n", "CHAIN", "TIER", "MODEL", "ANSWERED", "ESCALATED", "COST")
+		for _, t := range tiers {
+			fmt.Printf("%-16s %4d %-26s %8d %9d %10s\n", t.Chain, t.Tier, t.Model, t.Answered, t.Escalated, fmt.Sprintf("$%.4f", t.Cost))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	gocontext "context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/internal/models"
+	"github.com/yourusername/aui/internal/orchestrator"
+	"github.com/yourusername/aui/internal/pipeline"
+	"github.com/yourusername/aui/internal/providers"
+	"github.com/yourusername/aui/internal/storage"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// runPipelineCommand handles "aui pipeline <subcommand>"
+func runPipelineCommand(args []string, cfg *config.Config, store *storage.SQLiteStore) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: aui pipeline <list|run|runs>")
+	}
+
+	switch args[0] {
+	case "list":
+		return listPipelines()
+	case "run":
+		return runPipeline(args[1:], cfg, store)
+	case "runs":
+		return listPipelineRuns(store)
+	default:
+		return fmt.Errorf("unknown pipeline command: %s", args[0])
+	}
+}
+
+// loadPipelines returns the pipelines available in the current directory
+func loadPipelines() ([]*pipeline.Pipeline, error) {
+	root, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	return pipeline.LoadAll(pipeline.Dirs(root)...)
+}
+
+// listPipelines prints the pipelines available in the current directory
+func listPipelines() error {
+	all, err := loadPipelines()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range all {
+		steps := make([]string, len(p.Steps))
+		for i, s := range p.Steps {
+			steps[i] = s.Name
+			if s.Agent != "" {
+				steps[i] += " (" + s.Agent + ")"
+			}
+		}
+		fmt.Printf("%-24s %s\n", p.Name, p.Description)
+		fmt.Printf("  %s\n  source: %s\n", strings.Join(steps, " → "), p.Source)
+	}
+	return nil
+}
+
+// runPipeline handles "aui pipeline run [--context name] [--agent name]
+// <pipeline> <input>", reporting each step as it finishes and printing the
+// output of the last step
+func runPipeline(args []string, cfg *config.Config, store *storage.SQLiteStore) error {
+	fs := flag.NewFlagSet("pipeline run", flag.ContinueOnError)
+	contextName := fs.String("context", "", "Context attached to every step")
+	agentName := fs.String("agent", "", "Agent for steps that name none")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 {
+		return fmt.Errorf("usage: aui pipeline run [--context name] [--agent name] <pipeline> <input>")
+	}
+
+	all, err := loadPipelines()
+	if err != nil {
+		return err
+	}
+	p := pipeline.Find(all, fs.Arg(0))
+	if p == nil {
+		return fmt.Errorf("pipeline not found: %s (available: %s)", fs.Arg(0), strings.Join(pipeline.Names(all), ", "))
+	}
+
+	catalog, err := models.Load(models.OverridePath())
+	if err != nil {
+		return err
+	}
+	agents, err := store.ListAgents()
+	if err != nil {
+		return err
+	}
+	var fallback *agent.Agent
+	if *agentName != "" {
+		if fallback, err = orchestrator.ResolveAgent(*agentName, agents, catalog); err != nil {
+			return err
+		}
+	}
+	stepAgents, err := orchestrator.PipelineAgents(p, agents, catalog, fallback)
+	if err != nil {
+		return fmt.Errorf("%w (pass --agent)", err)
+	}
+
+	request, err := sharedRequest(cfg, store, catalog, stepAgents, *contextName)
+	if err != nil {
+		return err
+	}
+
+	contextID := ""
+	if *contextName != "" {
+		ctx, err := findContext(store, *contextName)
+		if err != nil {
+			return err
+		}
+		contextID = ctx.ID
+	}
+	run := pipeline.NewRun(p, strings.Join(fs.Args()[1:], " "), contextID)
+
+	// Each step is reported once it finishes
+	reported := 0
+	save := func(r *pipeline.Run) {
+		store.SavePipelineRun(r)
+		for ; reported < len(r.Steps); reported++ {
+			s := r.Steps[reported]
+			if s.Status == pipeline.StatusRunning || s.Status == pipeline.StatusPending {
+				return
+			}
+			fmt.Fprintf(os.Stderr, "%s %s\n", stepMarker(s.Status), describeStep(s))
+			if s.Error != "" {
+				fmt.Fprintf(os.Stderr, "  %s\n", s.Error)
+			}
+		}
+	}
+
+	o := orchestrator.New(cfg, store, catalog)
+	serverTools, disconnect, err := mcpTools(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	defer disconnect()
+	if o.Tools, err = projectTools(cfg, serverTools...); err != nil {
+		return err
+	}
+	err = o.RunPipeline(gocontext.Background(), run, stepAgents, request, func(ag *agent.Agent) (api.Provider, error) {
+		return providers.New(ag.Provider, cfg)
+	}, save)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(run.Output())
+	usage, cost := run.Usage()
+	fmt.Fprintf(os.Stderr, "\nrun %s: %d in / %d out tokens, $%.4f\n", run.ID, usage.InputTokens, usage.OutputTokens, cost)
+	return nil
+}
+
+// listPipelineRuns prints the pipeline runs, most recent last
+func listPipelineRuns(store *storage.SQLiteStore) error {
+	runs, err := store.ListPipelineRuns()
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 {
+		fmt.Println("No pipeline runs.")
+		return nil
+	}
+
+	for _, r := range runs {
+		fmt.Printf("%s %s %-24s %-10s %s\n", r.ID, r.CreatedAt.Format("2006-01-02 15:04"), r.Pipeline, r.Status, firstLine(r.Input, 40))
+		for _, s := range r.Steps {
+			fmt.Printf("  %s %s\n", stepMarker(s.Status), describeStep(s))
+		}
+	}
+	return nil
+}
+
+// describeStep returns a step's name, agent, status and duration
+func describeStep(s *pipeline.StepRun) string {
+	text := s.Name
+	if s.Agent != "" {
+		text += " (" + s.Agent + ")"
+	}
+	text += " " + string(s.Status)
+	if !s.StartedAt.IsZero() {
+		text += " " + s.Duration(time.Now()).Round(time.Second).String()
+	}
+	return text
+}
+
+// stepMarker returns the symbol shown for a step status
+func stepMarker(status pipeline.Status) string {
+	switch status {
+	case pipeline.StatusDone:
+		return "✓"
+	case pipeline.StatusFailed:
+		return "✗"
+	case pipeline.StatusRunning:
+		return "…"
+	case pipeline.StatusCancelled, pipeline.StatusSkipped:
+		return "-"
+	default:
+		return "·"
+	}
+}
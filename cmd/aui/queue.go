@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/aui/internal/queue"
+	"github.com/yourusername/aui/internal/storage"
+)
+
+// listJobs handles "aui queue [--all]", printing queued and running jobs, or
+// every job with --all
+func listJobs(args []string, store *storage.SQLiteStore) error {
+	fs := flag.NewFlagSet("queue", flag.ContinueOnError)
+	all := fs.Bool("all", false, "Include finished jobs")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("usage: aui queue [--all]")
+	}
+
+	statuses := []queue.Status{queue.StatusQueued, queue.StatusRunning}
+	if *all {
+		statuses = nil
+	}
+	jobs, err := store.ListJobs(statuses...)
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		fmt.Println("No jobs in the queue")
+		return nil
+	}
+
+	now := time.Now()
+	fmt.Printf("%-16s %-9s %-10s %-26s %8s %6s  %s\n", "ID", "STATUS", "PROVIDER", "MODEL", "PRIORITY", "WAIT", "RESULT")
+	for _, j := range jobs {
+		result := j.Error
+		if result == "" {
+			result = j.Output
+		}
+		fmt.Printf("%-16s %-9s %-10s %-26s %8d %6s  %s\n",
+			j.ID, j.Status, j.Provider, j.Model, j.Priority, j.Wait(now).Round(time.Second), firstLine(result, 40))
+	}
+	return nil
+}
+
+// firstLine returns the first line of s, cut to n runes
+func firstLine(s string, n int) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return s
+}
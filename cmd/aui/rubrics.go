@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/internal/judge"
+)
+
+// listRubrics handles "aui rubrics", printing the rubrics a judge can score
+// comparisons with
+func listRubrics(cfg *config.Config) error {
+	if cfg.Judge.Agent == "" {
+		fmt.Println("No judge configured. Set judge.agent in the config file to score comparisons.")
+	} else {
+		fmt.Printf("Judge: %s (default rubric %s)\n", cfg.Judge.Agent, cfg.Judge.Rubric)
+	}
+
+	for _, rubric := range judge.Rubrics(cfg) {
+		fmt.Printf("\n%s:\n", rubric.Name)
+		for _, c := range rubric.Criteria {
+			weight := c.Weight
+			if weight == 0 {
+				weight = 1
+			}
+			fmt.Printf("  %-20s weight %-4g %s\n", c.Name, weight, c.Description)
+		}
+	}
+	return nil
+}
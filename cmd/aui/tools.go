@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/redact"
+	"github.com/yourusername/aui/internal/storage"
+	"github.com/yourusername/aui/internal/tools"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// projectTools returns the tools agents may call to read the project in the
+// current directory along with extra ones, or nil if tools are disabled
+func projectTools(cfg *config.Config, extra ...tools.Tool) (*tools.Toolbox, error) {
+	if !cfg.Tools.Enabled {
+		return nil, nil
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	redactor, err := redact.FromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &tools.Toolbox{Tools: append(tools.Builtin(root, nil, redactor), extra...), MaxTurns: cfg.Tools.MaxTurns, Redactor: redactor}, nil
+}
+
+// listTools handles "aui tools", printing the tools agents may call
+func listTools(cfg *config.Config) error {
+	if !cfg.Tools.Enabled {
+		fmt.Println("Tools are disabled. Set tools.enabled in the config file to let agents read the project.")
+		return nil
+	}
+
+	fmt.Printf("Agents may call these tools, up to %d rounds per reply:\n", (&tools.Toolbox{MaxTurns: cfg.Tools.MaxTurns}).Turns())
+	for _, t := range tools.Builtin(".", context.NewContext("<context>", ""), nil) {
+		spec := t.Spec()
+		if _, ok := t.(tools.ContextFile); ok {
+			spec.Name += " (in chat, with a context attached)"
+		}
+		printTool(spec)
+	}
+	if cfg.Tools.Shell.Enabled {
+		spec := (&tools.Shell{}).Spec()
+		spec.Name += " (in chat, after you approve each command)"
+		printTool(spec)
+	}
+	if len(cfg.MCPServers) > 0 {
+		fmt.Println("\nAgents may also call the tools of MCP servers with expose_tools set; run \"aui mcp list\" to see them.")
+	}
+	return nil
+}
+
+// listCommands handles "aui commands [--since duration]", printing the shell
+// commands agents asked to run and what became of them
+func listCommands(args []string, store *storage.SQLiteStore) error {
+	fs := flag.NewFlagSet("commands", flag.ContinueOnError)
+	since := fs.Duration("since", 7*24*time.Hour, "How far back to list")
+	output := fs.Bool("output", false, "Print the output of each command")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("usage: aui commands [--since duration] [--output]")
+	}
+
+	commands, err := store.ListCommands(time.Now().Add(-*since))
+	if err != nil {
+		return err
+	}
+	if len(commands) == 0 {
+		fmt.Printf("No commands requested in the last %s\n", *since)
+		return nil
+	}
+
+	fmt.Printf("%-16s %-16s %-16s %-9s %-10s %s\n", "TIME", "AGENT", "DIR", "APPROVED", "RESULT", "COMMAND")
+	for _, c := range commands {
+		approved, result := "no", c.Error
+		if c.Approved {
+			approved = "yes"
+		}
+		if result == "" {
+			result = fmt.Sprintf("exit %d", c.ExitCode)
+		}
+		fmt.Printf("%-16s %-16s %-16s %-9s %-10s %s\n",
+			c.StartedAt.Format("2006-01-02 15:04"), firstLine(c.Agent, 16), firstLine(c.Dir, 16), approved, firstLine(result, 10), c.Command)
+		if *output && c.Output != "" {
+			fmt.Printf("  %s\n", strings.ReplaceAll(strings.TrimRight(c.Output, "\n"), "\n", "\n  "))
+		}
+	}
+	return nil
+}
+
+// printTool prints a tool's name, description and parameters
+func printTool(spec api.Tool) {
+	fmt.Printf("\n  %s\n    %s\n", spec.Name, spec.Description)
+
+	properties, _ := spec.Parameters["properties"].(map[string]interface{})
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) > 0 {
+		fmt.Printf("    parameters: %s\n", strings.Join(names, ", "))
+	}
+}
@@ -3,15 +3,18 @@ package agent
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"fmt"
+	"time"
 )
 
 // Status represents the current state of an agent
 type Status string
 
 const (
-	StatusReady   Status = "ready"
-	StatusWorking Status = "working"
-	StatusError   Status = "error"
+	StatusReady     Status = "ready"
+	StatusWorking   Status = "working"
+	StatusError     Status = "error"
+	StatusCancelled Status = "cancelled"
 )
 
 // Agent represents an AI agent that can perform tasks
@@ -23,6 +26,11 @@ type Agent struct {
 	Status      Status
 	CurrentTask string
 	LastError   string
+
+	SystemPrompt string // Persona or standing instructions sent with every request
+	Params       Params
+
+	waitingTask string // CurrentTask while it shows a retry wait
 }
 
 // NewAgent creates a new agent with the given name, model, and provider
@@ -48,14 +56,55 @@ func (a *Agent) CompleteTask() {
 	a.CurrentTask = ""
 	a.Status = StatusReady
 	a.LastError = ""
+	a.waitingTask = ""
+}
+
+// WaitForRetry shows in CurrentTask that the task waits before it is retried
+// and why, e.g. "retrying after rate limit (12s)"
+func (a *Agent) WaitForRetry(wait time.Duration, reason string) {
+	if a.waitingTask == "" {
+		a.waitingTask = a.CurrentTask
+	}
+	wait = wait.Round(time.Second)
+	if wait < time.Second {
+		wait = time.Second
+	}
+	if reason == "" {
+		a.CurrentTask = fmt.Sprintf("retrying (%s)", wait)
+	} else {
+		a.CurrentTask = fmt.Sprintf("retrying after %s (%s)", reason, wait)
+	}
+}
+
+// Resume shows the task again after WaitForRetry
+func (a *Agent) Resume() {
+	if a.waitingTask != "" {
+		a.CurrentTask = a.waitingTask
+		a.waitingTask = ""
+	}
+}
+
+// Waiting reports whether the task waits to be retried
+func (a *Agent) Waiting() bool {
+	return a.waitingTask != ""
 }
 
 // SetError sets the agent to error state with the given error message
 func (a *Agent) SetError(err string) {
+	a.Resume()
 	a.Status = StatusError
 	a.LastError = err
 }
 
+// Cancel marks the current task as cancelled by the user. Unlike SetError it
+// clears LastError, since nothing went wrong.
+func (a *Agent) Cancel() {
+	a.CurrentTask = ""
+	a.Status = StatusCancelled
+	a.LastError = ""
+	a.waitingTask = ""
+}
+
 // generateID generates a random ID for an agent
 func generateID() string {
 	bytes := make([]byte, 8)
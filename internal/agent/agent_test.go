@@ -2,6 +2,7 @@ package agent
 
 import (
 	"testing"
+	"time"
 )
 
 func TestNewAgent(t *testing.T) {
@@ -128,6 +129,41 @@ func TestAgentSetError(t *testing.T) {
 	}
 }
 
+func TestAgentCancel(t *testing.T) {
+	agent := NewAgent("Claude", "claude-3.5-sonnet", "anthropic")
+	agent.SetError("timeout")
+	agent.AssignTask("Retry review")
+
+	agent.Cancel()
+
+	if agent.Status != StatusCancelled {
+		t.Errorf("After Cancel(), Status = %v, want %v", agent.Status, StatusCancelled)
+	}
+
+	if agent.CurrentTask != "" || agent.LastError != "" {
+		t.Errorf("After Cancel(), CurrentTask = %q, LastError = %q, want both empty", agent.CurrentTask, agent.LastError)
+	}
+}
+
+func TestAgentWaitForRetry(t *testing.T) {
+	agent := NewAgent("Claude", "claude-3.5-sonnet", "anthropic")
+	agent.AssignTask("Review code")
+
+	agent.WaitForRetry(11600*time.Millisecond, "rate limit")
+	if agent.CurrentTask != "retrying after rate limit (12s)" {
+		t.Errorf("After WaitForRetry(), CurrentTask = %q", agent.CurrentTask)
+	}
+	agent.WaitForRetry(200*time.Millisecond, "server error")
+	if agent.CurrentTask != "retrying after server error (1s)" {
+		t.Errorf("After a short wait, CurrentTask = %q", agent.CurrentTask)
+	}
+
+	agent.Resume()
+	if agent.CurrentTask != "Review code" || agent.Status != StatusWorking {
+		t.Errorf("After Resume(), CurrentTask = %q, Status = %v", agent.CurrentTask, agent.Status)
+	}
+}
+
 func TestGenerateID(t *testing.T) {
 	// Test that IDs are unique
 	id1 := generateID()
@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// Params are an agent's generation parameters. Zero values leave the
+// provider's defaults in place.
+type Params struct {
+	Temperature     *float64               `json:"temperature,omitempty"`
+	MaxTokens       int                    `json:"max_tokens,omitempty"`
+	TopP            *float64               `json:"top_p,omitempty"`
+	StopSequences   []string               `json:"stop,omitempty"`
+	ReasoningEffort string                 `json:"reasoning_effort,omitempty"` // "low", "medium" or "high"
+	ThinkingBudget  int                    `json:"thinking_budget,omitempty"`  // Thinking tokens, for providers that take a budget
+	Extensions      map[string]interface{} `json:"extensions,omitempty"`       // Provider-specific request fields
+}
+
+// reasoningEfforts are the accepted effort levels
+var reasoningEfforts = map[string]bool{"low": true, "medium": true, "high": true}
+
+// ParseParams parses space-separated key=value pairs, e.g.
+// "temperature=0.2 max_tokens=2048 stop=END,STOP reasoning=high ext.top_k=40".
+// Keys prefixed with "ext." are provider extensions whose values are parsed
+// as JSON when possible and kept as strings otherwise.
+func ParseParams(s string) (Params, error) {
+	var p Params
+
+	for _, field := range strings.Fields(s) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || value == "" {
+			return Params{}, fmt.Errorf("invalid parameter %q (want key=value)", field)
+		}
+
+		var err error
+		switch key {
+		case "temperature":
+			p.Temperature, err = parseFloat(key, value, 0, 2)
+		case "top_p":
+			p.TopP, err = parseFloat(key, value, 0, 1)
+		case "max_tokens":
+			p.MaxTokens, err = parsePositive(key, value)
+		case "thinking_budget":
+			p.ThinkingBudget, err = parsePositive(key, value)
+		case "stop":
+			p.StopSequences = strings.Split(value, ",")
+		case "reasoning":
+			if !reasoningEfforts[value] {
+				err = fmt.Errorf("invalid reasoning %q (must be low, medium, or high)", value)
+			}
+			p.ReasoningEffort = value
+		default:
+			name, isExt := strings.CutPrefix(key, "ext.")
+			if !isExt || name == "" {
+				return Params{}, fmt.Errorf("unknown parameter %q", key)
+			}
+			if p.Extensions == nil {
+				p.Extensions = make(map[string]interface{})
+			}
+			var decoded interface{}
+			if json.Unmarshal([]byte(value), &decoded) == nil {
+				p.Extensions[name] = decoded
+			} else {
+				p.Extensions[name] = value
+			}
+		}
+		if err != nil {
+			return Params{}, err
+		}
+	}
+
+	return p, nil
+}
+
+// parseFloat parses a float parameter within [min, max]
+func parseFloat(key, value string, min, max float64) (*float64, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < min || f > max {
+		return nil, fmt.Errorf("invalid %s %q (must be between %g and %g)", key, value, min, max)
+	}
+	return &f, nil
+}
+
+// parsePositive parses a positive integer parameter
+func parsePositive(key, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s %q (must be a positive number)", key, value)
+	}
+	return n, nil
+}
+
+// String formats the parameters in the form accepted by ParseParams
+func (p Params) String() string {
+	var fields []string
+	if p.Temperature != nil {
+		fields = append(fields, "temperature="+strconv.FormatFloat(*p.Temperature, 'g', -1, 64))
+	}
+	if p.TopP != nil {
+		fields = append(fields, "top_p="+strconv.FormatFloat(*p.TopP, 'g', -1, 64))
+	}
+	if p.MaxTokens > 0 {
+		fields = append(fields, fmt.Sprintf("max_tokens=%d", p.MaxTokens))
+	}
+	if len(p.StopSequences) > 0 {
+		fields = append(fields, "stop="+strings.Join(p.StopSequences, ","))
+	}
+	if p.ReasoningEffort != "" {
+		fields = append(fields, "reasoning="+p.ReasoningEffort)
+	}
+	if p.ThinkingBudget > 0 {
+		fields = append(fields, fmt.Sprintf("thinking_budget=%d", p.ThinkingBudget))
+	}
+
+	names := make([]string, 0, len(p.Extensions))
+	for name := range p.Extensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, _ := json.Marshal(p.Extensions[name])
+		if s, ok := p.Extensions[name].(string); ok {
+			value = []byte(s)
+		}
+		fields = append(fields, fmt.Sprintf("ext.%s=%s", name, value))
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// ApplyTo configures a request for this agent: its model, its system prompt
+// ahead of any the request already has, and its generation parameters
+func (a *Agent) ApplyTo(req *api.Request) {
+	req.Model = a.Model
+
+	if a.SystemPrompt != "" {
+		if req.System != "" {
+			req.System = a.SystemPrompt + "\n\n" + req.System
+		} else {
+			req.System = a.SystemPrompt
+		}
+	}
+
+	p := a.Params
+	if p.MaxTokens > 0 {
+		req.MaxTokens = p.MaxTokens
+	}
+	req.Temperature = p.Temperature
+	req.TopP = p.TopP
+	req.StopSequences = p.StopSequences
+	if p.ReasoningEffort != "" || p.ThinkingBudget > 0 {
+		req.Reasoning = &api.Reasoning{Effort: p.ReasoningEffort, BudgetTokens: p.ThinkingBudget}
+	}
+	req.Extensions = p.Extensions
+}
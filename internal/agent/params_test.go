@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/yourusername/aui/pkg/api"
+)
+
+func TestParseParams(t *testing.T) {
+	p, err := ParseParams("temperature=0.3 top_p=0.9 max_tokens=1024 stop=END,DONE reasoning=medium thinking_budget=4000 ext.top_k=40 ext.mode=fast")
+	if err != nil {
+		t.Fatalf("ParseParams() error = %v", err)
+	}
+
+	if p.Temperature == nil || *p.Temperature != 0.3 {
+		t.Errorf("Temperature = %v, want 0.3", p.Temperature)
+	}
+	if p.TopP == nil || *p.TopP != 0.9 {
+		t.Errorf("TopP = %v, want 0.9", p.TopP)
+	}
+	if p.MaxTokens != 1024 {
+		t.Errorf("MaxTokens = %d, want 1024", p.MaxTokens)
+	}
+	if len(p.StopSequences) != 2 || p.StopSequences[1] != "DONE" {
+		t.Errorf("StopSequences = %v, want [END DONE]", p.StopSequences)
+	}
+	if p.ReasoningEffort != "medium" || p.ThinkingBudget != 4000 {
+		t.Errorf("reasoning = %q/%d, want medium/4000", p.ReasoningEffort, p.ThinkingBudget)
+	}
+	if p.Extensions["top_k"] != float64(40) || p.Extensions["mode"] != "fast" {
+		t.Errorf("Extensions = %v, want top_k=40 mode=fast", p.Extensions)
+	}
+}
+
+func TestParseParamsInvalid(t *testing.T) {
+	tests := []string{
+		"temperature",
+		"temperature=hot",
+		"temperature=3",
+		"top_p=1.5",
+		"max_tokens=-1",
+		"reasoning=extreme",
+		"seed=1",
+		"ext.=1",
+	}
+
+	for _, s := range tests {
+		if _, err := ParseParams(s); err == nil {
+			t.Errorf("ParseParams(%q) expected error", s)
+		}
+	}
+}
+
+func TestParamsStringRoundTrip(t *testing.T) {
+	s := "temperature=0.5 top_p=1 max_tokens=200 stop=a,b reasoning=low thinking_budget=2048 ext.mode=fast ext.top_k=40"
+
+	p, err := ParseParams(s)
+	if err != nil {
+		t.Fatalf("ParseParams() error = %v", err)
+	}
+	if got := p.String(); got != s {
+		t.Errorf("String() = %q, want %q", got, s)
+	}
+
+	if got := (Params{}).String(); got != "" {
+		t.Errorf("String() of empty params = %q, want empty", got)
+	}
+}
+
+func TestAgentApplyTo(t *testing.T) {
+	a := NewAgent("Reviewer", "claude-sonnet-4", "anthropic")
+	a.SystemPrompt = "You review code."
+	a.Params, _ = ParseParams("temperature=0.2 max_tokens=2048 reasoning=high ext.top_k=40")
+
+	req := &api.Request{System: "Focus on the auth package.", MaxTokens: api.DefaultMaxTokens}
+	a.ApplyTo(req)
+
+	if req.Model != "claude-sonnet-4" {
+		t.Errorf("Model = %q, want claude-sonnet-4", req.Model)
+	}
+	if want := "You review code.\n\nFocus on the auth package."; req.System != want {
+		t.Errorf("System = %q, want %q", req.System, want)
+	}
+	if req.MaxTokens != 2048 {
+		t.Errorf("MaxTokens = %d, want 2048", req.MaxTokens)
+	}
+	if req.Temperature == nil || *req.Temperature != 0.2 {
+		t.Errorf("Temperature = %v, want 0.2", req.Temperature)
+	}
+	if req.Reasoning == nil || req.Reasoning.Effort != "high" {
+		t.Errorf("Reasoning = %v, want effort high", req.Reasoning)
+	}
+	if req.Extensions["top_k"] != float64(40) {
+		t.Errorf("Extensions = %v, want top_k=40", req.Extensions)
+	}
+
+	plain := NewAgent("Plain", "gpt-4o", "openai")
+	req = &api.Request{System: "Context prompt", MaxTokens: api.DefaultMaxTokens}
+	plain.ApplyTo(req)
+	if req.System != "Context prompt" || req.MaxTokens != api.DefaultMaxTokens || req.Reasoning != nil {
+		t.Errorf("ApplyTo() without params changed request: %+v", req)
+	}
+}
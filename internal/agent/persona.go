@@ -0,0 +1,24 @@
+package agent
+
+import "sort"
+
+// Personas are ready-made system prompts that can be given to an agent
+var Personas = map[string]string{
+	"architect": "You are a senior software architect. Focus on structure, boundaries and trade-offs. " +
+		"Propose designs that fit the existing code and explain what they cost.",
+	"debugger": "You are an expert debugger. Form hypotheses from the evidence, name the most likely " +
+		"root cause first and say how to confirm it before proposing a fix.",
+	"reviewer": "You are a meticulous code reviewer. Point out bugs, missing tests, unclear naming and " +
+		"risky changes. Cite file and line, and keep praise short.",
+	"concise": "Answer as briefly as possible. Prefer code over prose and skip preamble.",
+}
+
+// PersonaNames returns the persona names in alphabetical order
+func PersonaNames() []string {
+	names := make([]string, 0, len(Personas))
+	for name := range Personas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,264 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/yourusername/aui/internal/context"
+)
+
+// Version is the bundle format version written by Export
+const Version = 1
+
+// Format is the on-disk encoding of a bundle
+type Format string
+
+const (
+	FormatJSON  Format = "json"
+	FormatTarGz Format = "tar.gz"
+)
+
+// manifestName is the manifest entry inside tar.gz bundles
+const manifestName = "manifest.json"
+
+// Manifest describes a bundled context. In JSON bundles file content is
+// inline; in tar.gz bundles it is stored as separate entries under files/.
+type Manifest struct {
+	Version      int       `json:"version"`
+	ExportedAt   time.Time `json:"exported_at"`
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description,omitempty"`
+	SystemPrompt string    `json:"system_prompt,omitempty"`
+	TotalTokens  int       `json:"total_tokens"`
+	Files        []File    `json:"files"`
+}
+
+// File is a bundled context file
+type File struct {
+	Path       string    `json:"path"`
+	Name       string    `json:"name"`
+	Language   string    `json:"language,omitempty"`
+	Hash       string    `json:"hash"`
+	Size       int64     `json:"size"`
+	Tokens     int       `json:"tokens"`
+	ModifiedAt time.Time `json:"modified_at"`
+	Content    string    `json:"content,omitempty"`
+}
+
+// FormatForPath picks a format from a file name, defaulting to JSON
+func FormatForPath(name string) Format {
+	if strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz") {
+		return FormatTarGz
+	}
+	return FormatJSON
+}
+
+// newManifest builds a manifest for ctx, with file content inline if requested
+func newManifest(ctx *context.Context, inline bool) *Manifest {
+	m := &Manifest{
+		Version:      Version,
+		ExportedAt:   time.Now().UTC(),
+		ID:           ctx.ID,
+		Name:         ctx.Name,
+		Description:  ctx.Description,
+		SystemPrompt: ctx.SystemPrompt,
+		TotalTokens:  ctx.TotalTokens,
+		Files:        make([]File, 0, len(ctx.Files)),
+	}
+
+	for _, f := range ctx.Files {
+		bf := File{
+			Path:       f.Path,
+			Name:       f.Name,
+			Language:   f.Language,
+			Hash:       f.Hash,
+			Size:       f.Size,
+			Tokens:     f.Tokens,
+			ModifiedAt: f.ModifiedAt,
+		}
+		if inline {
+			bf.Content = f.Content
+		}
+		m.Files = append(m.Files, bf)
+	}
+
+	return m
+}
+
+// Export writes ctx to w as a bundle in the given format
+func Export(w io.Writer, ctx *context.Context, format Format) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(newManifest(ctx, true)); err != nil {
+			return fmt.Errorf("failed to encode bundle: %w", err)
+		}
+		return nil
+
+	case FormatTarGz:
+		return exportTarGz(w, ctx)
+
+	default:
+		return fmt.Errorf("unknown bundle format: %s", format)
+	}
+}
+
+// exportTarGz writes a manifest entry followed by one entry per file
+func exportTarGz(w io.Writer, ctx *context.Context) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifest, err := json.MarshalIndent(newManifest(ctx, false), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	now := time.Now()
+	if err := writeTarEntry(tw, manifestName, []byte(manifest), now); err != nil {
+		return err
+	}
+
+	for _, f := range ctx.Files {
+		if err := writeTarEntry(tw, entryName(f.Path), []byte(f.Content), f.ModifiedAt); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return gz.Close()
+}
+
+// entryName maps a context file path to its archive entry name
+func entryName(filePath string) string {
+	return path.Join("files", strings.TrimPrefix(path.Clean("/"+filePath), "/"))
+}
+
+// writeTarEntry writes a single regular file entry
+func writeTarEntry(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// Import reads a bundle in either format, detected from its content, and
+// returns the context it describes. Imported files get fresh IDs.
+func Import(r io.Reader) (*context.Context, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := br.Peek(2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	var m *Manifest
+	if magic[0] == 0x1f && magic[1] == 0x8b {
+		m, err = importTarGz(br)
+	} else {
+		m = &Manifest{}
+		err = json.NewDecoder(br).Decode(m)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode bundle: %w", err)
+	}
+
+	if m.Version > Version {
+		return nil, fmt.Errorf("bundle version %d is newer than supported version %d", m.Version, Version)
+	}
+
+	return m.toContext()
+}
+
+// importTarGz reads the manifest and file entries of a tar.gz bundle
+func importTarGz(r io.Reader) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var m *Manifest
+	contents := make(map[string]string)
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var buf bytes.Buffer
+		if _, err := io.Copy(&buf, tr); err != nil {
+			return nil, err
+		}
+
+		if hdr.Name == manifestName {
+			m = &Manifest{}
+			if err := json.Unmarshal(buf.Bytes(), m); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		contents[hdr.Name] = buf.String()
+	}
+
+	if m == nil {
+		return nil, fmt.Errorf("bundle has no %s", manifestName)
+	}
+
+	for i, f := range m.Files {
+		content, ok := contents[entryName(f.Path)]
+		if !ok {
+			return nil, fmt.Errorf("bundle is missing content for %s", f.Path)
+		}
+		m.Files[i].Content = content
+	}
+
+	return m, nil
+}
+
+// toContext converts a manifest into a context, checking each file's hash
+func (m *Manifest) toContext() (*context.Context, error) {
+	ctx := context.NewContext(m.Name, m.Description)
+	if m.ID != "" {
+		ctx.ID = m.ID
+	}
+	ctx.SystemPrompt = m.SystemPrompt
+
+	for _, bf := range m.Files {
+		if bf.Hash != "" && context.HashContent(bf.Content) != bf.Hash {
+			return nil, fmt.Errorf("content of %s does not match its hash", bf.Path)
+		}
+
+		f := context.NewFile(bf.Path, bf.Name)
+		f.Content = bf.Content
+		f.UpdateMetadata(bf.Size, bf.Hash, bf.Language, bf.Tokens, bf.ModifiedAt)
+		ctx.AddFile(f)
+	}
+
+	return ctx, nil
+}
@@ -0,0 +1,125 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/aui/internal/context"
+)
+
+// testContext returns a context with two files
+func testContext() *context.Context {
+	ctx := context.NewContext("auth-bug", "Login fails after refresh")
+	ctx.SystemPrompt = "Find the root cause."
+
+	main := context.NewFile("cmd/app/main.go", "main.go")
+	main.SetContent("package main\n\nfunc main() {}\n")
+	main.Language = "go"
+	ctx.AddFile(main)
+
+	readme := context.NewFile("README.md", "README.md")
+	readme.SetContent("# App\n")
+	readme.Language = "markdown"
+	ctx.AddFile(readme)
+
+	return ctx
+}
+
+func TestFormatForPath(t *testing.T) {
+	tests := []struct {
+		name string
+		want Format
+	}{
+		{"ctx.json", FormatJSON},
+		{"ctx.tar.gz", FormatTarGz},
+		{"ctx.tgz", FormatTarGz},
+		{"ctx", FormatJSON},
+	}
+
+	for _, tt := range tests {
+		if got := FormatForPath(tt.name); got != tt.want {
+			t.Errorf("FormatForPath(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	for _, format := range []Format{FormatJSON, FormatTarGz} {
+		t.Run(string(format), func(t *testing.T) {
+			ctx := testContext()
+
+			var buf bytes.Buffer
+			if err := Export(&buf, ctx, format); err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+
+			imported, err := Import(&buf)
+			if err != nil {
+				t.Fatalf("Import() error = %v", err)
+			}
+
+			if imported.ID != ctx.ID || imported.Name != ctx.Name || imported.Description != ctx.Description {
+				t.Errorf("Import() = %s %q %q, want %s %q %q", imported.ID, imported.Name, imported.Description,
+					ctx.ID, ctx.Name, ctx.Description)
+			}
+			if imported.SystemPrompt != ctx.SystemPrompt {
+				t.Errorf("SystemPrompt = %q, want %q", imported.SystemPrompt, ctx.SystemPrompt)
+			}
+			if imported.TotalTokens != ctx.TotalTokens {
+				t.Errorf("TotalTokens = %d, want %d", imported.TotalTokens, ctx.TotalTokens)
+			}
+			if len(imported.Files) != len(ctx.Files) {
+				t.Fatalf("len(Files) = %d, want %d", len(imported.Files), len(ctx.Files))
+			}
+
+			for i, f := range imported.Files {
+				want := ctx.Files[i]
+				if f.Path != want.Path || f.Content != want.Content || f.Hash != want.Hash || f.Language != want.Language {
+					t.Errorf("Files[%d] = %+v, want %+v", i, f, want)
+				}
+				if f.ID == want.ID {
+					t.Errorf("Files[%d] kept ID %s, want a fresh ID", i, f.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestExportUnknownFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Export(&buf, testContext(), Format("zip")); err == nil {
+		t.Error("Export() with unknown format should fail")
+	}
+}
+
+func TestImportRejectsTamperedContent(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Export(&buf, testContext(), FormatJSON); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	tampered := strings.Replace(buf.String(), "func main() {}", "func main() { evil() }", 1)
+	_, err := Import(strings.NewReader(tampered))
+	if err == nil || !strings.Contains(err.Error(), "cmd/app/main.go") {
+		t.Errorf("Import() error = %v, want hash mismatch for cmd/app/main.go", err)
+	}
+}
+
+func TestImportRejectsNewerVersion(t *testing.T) {
+	data, err := json.Marshal(&Manifest{Version: Version + 1, Name: "future"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Import(bytes.NewReader(data)); err == nil {
+		t.Error("Import() of a newer bundle version should fail")
+	}
+}
+
+func TestImportInvalidBundle(t *testing.T) {
+	if _, err := Import(strings.NewReader("not a bundle")); err == nil {
+		t.Error("Import() of garbage should fail")
+	}
+}
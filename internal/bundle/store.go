@@ -0,0 +1,182 @@
+package bundle
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/storage"
+)
+
+// ConflictMode decides what happens when an imported context has the same ID
+// or name as one already stored
+type ConflictMode string
+
+const (
+	ConflictRename    ConflictMode = "rename"    // Store as a new context with a unique name
+	ConflictMerge     ConflictMode = "merge"     // Add imported files to the existing context
+	ConflictOverwrite ConflictMode = "overwrite" // Replace the existing context's contents
+)
+
+// ParseConflictMode validates a conflict mode name
+func ParseConflictMode(s string) (ConflictMode, error) {
+	switch mode := ConflictMode(s); mode {
+	case ConflictRename, ConflictMerge, ConflictOverwrite:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid conflict mode: %s (must be rename, merge, or overwrite)", s)
+}
+
+// Save stores an imported context, resolving any conflict with an existing
+// context of the same ID or name, and returns the context as stored. Files
+// are stored once per path, so an import that would change the content of
+// files other contexts use is refused.
+func Save(store *storage.SQLiteStore, ctx *context.Context, mode ConflictMode) (*context.Context, error) {
+	existing, err := findExisting(store, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only a merged or overwritten context may see its files change
+	target := ""
+	if existing != nil && mode != ConflictRename {
+		target = existing.ID
+	}
+	if err := checkSharedFiles(store, ctx, target); err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		return ctx, store.SaveContext(ctx)
+	}
+
+	switch mode {
+	case ConflictRename:
+		name, err := uniqueName(store, ctx.Name)
+		if err != nil {
+			return nil, err
+		}
+		renamed := context.NewContext(name, ctx.Description)
+		renamed.SystemPrompt = ctx.SystemPrompt
+		for _, f := range ctx.Files {
+			renamed.AddFile(f)
+		}
+		return renamed, store.SaveContext(renamed)
+
+	case ConflictMerge:
+		for _, f := range ctx.Files {
+			existing.AddFile(f)
+		}
+		return existing, store.SaveContext(existing)
+
+	case ConflictOverwrite:
+		ctx.ID = existing.ID
+		return ctx, store.SaveContext(ctx)
+	}
+
+	return nil, fmt.Errorf("invalid conflict mode: %s", mode)
+}
+
+// checkSharedFiles refuses imported files whose content differs from the
+// stored file at the same path when a context other than target uses it
+func checkSharedFiles(store *storage.SQLiteStore, ctx *context.Context, target string) error {
+	var conflicts []string
+	for _, f := range ctx.Files {
+		hash, ids, err := store.FileContexts(f.Path)
+		if err != nil {
+			return fmt.Errorf("failed to check %s: %w", f.Path, err)
+		}
+		if hash == "" || hash == f.Hash {
+			continue
+		}
+		for _, id := range ids {
+			if id != target {
+				conflicts = append(conflicts, f.Path)
+				break
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("bundle has different content for files other contexts use, which importing would change: %s", strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+// findExisting returns the stored context with the same ID or, failing
+// that, the same name, loaded with its files
+func findExisting(store *storage.SQLiteStore, ctx *context.Context) (*context.Context, error) {
+	if existing, err := store.GetContext(ctx.ID); err == nil {
+		return existing, nil
+	}
+
+	contexts, err := store.ListContexts()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range contexts {
+		if c.Name == ctx.Name {
+			return store.GetContext(c.ID)
+		}
+	}
+
+	return nil, nil
+}
+
+// uniqueName returns name, or name with a " (n)" suffix not used by any stored context
+func uniqueName(store *storage.SQLiteStore, name string) (string, error) {
+	contexts, err := store.ListContexts()
+	if err != nil {
+		return "", err
+	}
+
+	taken := make(map[string]bool)
+	for _, c := range contexts {
+		taken[c.Name] = true
+	}
+
+	candidate := name
+	for n := 2; taken[candidate]; n++ {
+		candidate = fmt.Sprintf("%s (%d)", name, n)
+	}
+	return candidate, nil
+}
+
+// Mismatch describes a bundled file that differs from the local checkout
+type Mismatch struct {
+	Path   string
+	Reason string // "missing", "modified" or "unverifiable"
+}
+
+// Verify compares each file's hash with the file below root, including line
+// ranges such as "path:120-240". Synthetic files, such as extracted Go
+// symbols, cannot be checked and are reported as unverifiable.
+func Verify(ctx *context.Context, root string) []Mismatch {
+	var mismatches []Mismatch
+
+	for _, f := range ctx.Files {
+		if strings.Contains(f.Path, "#") {
+			mismatches = append(mismatches, Mismatch{Path: f.Path, Reason: "unverifiable"})
+			continue
+		}
+
+		local, _, err := context.LoadRange(root, f.Path)
+		if errors.Is(err, os.ErrNotExist) {
+			mismatches = append(mismatches, Mismatch{Path: f.Path, Reason: "missing"})
+			continue
+		}
+		if err != nil {
+			// The range no longer exists in a shorter file
+			mismatches = append(mismatches, Mismatch{Path: f.Path, Reason: "modified"})
+			continue
+		}
+
+		if local.Hash != f.Hash {
+			mismatches = append(mismatches, Mismatch{Path: f.Path, Reason: "modified"})
+		}
+	}
+
+	return mismatches
+}
@@ -0,0 +1,245 @@
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/storage"
+)
+
+// newTestStore opens a store in a temporary directory
+func newTestStore(t *testing.T) *storage.SQLiteStore {
+	t.Helper()
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestParseConflictMode(t *testing.T) {
+	for _, name := range []string{"rename", "merge", "overwrite"} {
+		if mode, err := ParseConflictMode(name); err != nil || string(mode) != name {
+			t.Errorf("ParseConflictMode(%q) = %v, %v", name, mode, err)
+		}
+	}
+	if _, err := ParseConflictMode("replace"); err == nil {
+		t.Error("ParseConflictMode(\"replace\") should fail")
+	}
+}
+
+func TestSaveWithoutConflict(t *testing.T) {
+	store := newTestStore(t)
+
+	saved, err := Save(store, testContext(), ConflictRename)
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := store.GetContext(saved.ID)
+	if err != nil {
+		t.Fatalf("GetContext() error = %v", err)
+	}
+	if loaded.Name != "auth-bug" || len(loaded.Files) != 2 {
+		t.Errorf("stored context = %q with %d files, want auth-bug with 2", loaded.Name, len(loaded.Files))
+	}
+}
+
+func TestSaveConflictModes(t *testing.T) {
+	// An existing context with the same ID and one file of its own
+	setup := func(t *testing.T) (*storage.SQLiteStore, *context.Context) {
+		store := newTestStore(t)
+		existing := testContext()
+		existing.Files = nil
+		extra := context.NewFile("go.mod", "go.mod")
+		extra.SetContent("module example.com/app\n")
+		existing.AddFile(extra)
+		if err := store.SaveContext(existing); err != nil {
+			t.Fatalf("SaveContext() error = %v", err)
+		}
+
+		imported := testContext()
+		imported.ID = existing.ID
+		return store, imported
+	}
+
+	t.Run("rename", func(t *testing.T) {
+		store, imported := setup(t)
+
+		saved, err := Save(store, imported, ConflictRename)
+		if err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+		if saved.ID == imported.ID {
+			t.Error("renamed context should get a new ID")
+		}
+		if saved.Name != "auth-bug (2)" {
+			t.Errorf("Name = %q, want %q", saved.Name, "auth-bug (2)")
+		}
+
+		contexts, _ := store.ListContexts()
+		if len(contexts) != 2 {
+			t.Errorf("stored %d contexts, want 2", len(contexts))
+		}
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		store, imported := setup(t)
+
+		saved, err := Save(store, imported, ConflictMerge)
+		if err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+
+		loaded, _ := store.GetContext(saved.ID)
+		if len(loaded.Files) != 3 {
+			t.Errorf("merged context has %d files, want 3", len(loaded.Files))
+		}
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		store, imported := setup(t)
+
+		saved, err := Save(store, imported, ConflictOverwrite)
+		if err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+
+		loaded, _ := store.GetContext(saved.ID)
+		if len(loaded.Files) != 2 || loaded.HasFile("go.mod") {
+			t.Errorf("overwritten context has %d files, want the 2 imported ones", len(loaded.Files))
+		}
+		contexts, _ := store.ListContexts()
+		if len(contexts) != 1 {
+			t.Errorf("stored %d contexts, want 1", len(contexts))
+		}
+	})
+}
+
+func TestSaveKeepsSharedFiles(t *testing.T) {
+	for _, mode := range []ConflictMode{ConflictRename, ConflictMerge, ConflictOverwrite} {
+		t.Run(string(mode), func(t *testing.T) {
+			store := newTestStore(t)
+
+			// An unrelated context using one of the bundled paths, and a
+			// context the import conflicts with
+			other := context.NewContext("other", "")
+			readme := context.NewFile("README.md", "README.md")
+			readme.SetContent("# Other\n")
+			other.AddFile(readme)
+			existing := testContext()
+			existing.Files = nil
+			for _, ctx := range []*context.Context{other, existing} {
+				if err := store.SaveContext(ctx); err != nil {
+					t.Fatalf("SaveContext() error = %v", err)
+				}
+			}
+
+			imported := testContext()
+			imported.ID = existing.ID
+			if _, err := Save(store, imported, mode); err == nil || !strings.Contains(err.Error(), "README.md") {
+				t.Errorf("Save() error = %v, want the conflicting README.md", err)
+			}
+
+			loaded, err := store.GetContext(other.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f := loaded.GetFile("README.md"); f == nil || f.Content != "# Other\n" {
+				t.Errorf("other context file = %+v, want its content kept", f)
+			}
+		})
+	}
+}
+
+func TestSaveSharedFileOfTarget(t *testing.T) {
+	// Files only the merged context uses may change
+	store := newTestStore(t)
+	existing := testContext()
+	existing.Files[1].SetContent("# Old\n")
+	if err := store.SaveContext(existing); err != nil {
+		t.Fatalf("SaveContext() error = %v", err)
+	}
+
+	imported := testContext()
+	imported.ID = existing.ID
+	saved, err := Save(store, imported, ConflictMerge)
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, _ := store.GetContext(saved.ID)
+	if f := loaded.GetFile("README.md"); f == nil || f.Content != "# App\n" {
+		t.Errorf("merged file = %+v, want the imported content", f)
+	}
+}
+
+func TestSaveMatchesByName(t *testing.T) {
+	store := newTestStore(t)
+	if err := store.SaveContext(testContext()); err != nil {
+		t.Fatalf("SaveContext() error = %v", err)
+	}
+
+	// Same name, different ID
+	saved, err := Save(store, testContext(), ConflictRename)
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if saved.Name != "auth-bug (2)" {
+		t.Errorf("Name = %q, want %q", saved.Name, "auth-bug (2)")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	root := t.TempDir()
+	write := func(path, content string) {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := testContext()
+	write("cmd/app/main.go", "package main\n\nfunc main() {}\n")
+	write("README.md", "# Changed\n")
+
+	// A line range that still matches, one that is missing and a symbol file
+	chunk, err := ctx.Files[0].Lines(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx.AddFile(chunk.ToFile("go"))
+
+	gone := context.NewFile("gone.go", "gone.go")
+	gone.SetContent("package gone\n")
+	ctx.AddFile(gone)
+
+	symbols := context.NewFile("cmd/app#main", "main.go")
+	symbols.SetContent("func main() {}\n")
+	ctx.AddFile(symbols)
+
+	got := make(map[string]string)
+	for _, m := range Verify(ctx, root) {
+		got[m.Path] = m.Reason
+	}
+
+	want := map[string]string{
+		"README.md":    "modified",
+		"gone.go":      "missing",
+		"cmd/app#main": "unverifiable",
+	}
+	if len(got) != len(want) {
+		t.Errorf("Verify() = %v, want %v", got, want)
+	}
+	for path, reason := range want {
+		if got[path] != reason {
+			t.Errorf("Verify()[%s] = %q, want %q", path, got[path], reason)
+		}
+	}
+}
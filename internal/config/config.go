@@ -4,17 +4,31 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the application configuration
 type Config struct {
-	APIKeys  map[string]string `yaml:"api_keys"`
-	Database DatabaseConfig    `yaml:"database"`
-	UI       UIConfig          `yaml:"ui"`
-	Logging  LoggingConfig     `yaml:"logging"`
+	APIKeys   map[string]string      `yaml:"api_keys"`
+	Database  DatabaseConfig         `yaml:"database"`
+	UI        UIConfig               `yaml:"ui"`
+	Logging   LoggingConfig          `yaml:"logging"`
+	Context   ContextConfig          `yaml:"context"`
+	Redaction RedactionConfig        `yaml:"redaction"`
+	Summarize SummarizeConfig        `yaml:"summarize"`
+	Retry     RetryConfig            `yaml:"retry"`
+	Queue     QueueConfig            `yaml:"queue"`
+	Chains    map[string]ChainConfig `yaml:"chains,omitempty"`
+	Judge     JudgeConfig            `yaml:"judge"`
+	Rubrics   map[string][]Criterion `yaml:"rubrics,omitempty"`
+	Tools     ToolsConfig            `yaml:"tools"`
+	Patches   PatchesConfig          `yaml:"patches"`
+
+	MCPServers map[string]MCPServerConfig `yaml:"mcp_servers,omitempty"`
 }
 
 // DatabaseConfig contains database-related settings
@@ -34,6 +48,102 @@ type LoggingConfig struct {
 	File  string `yaml:"file,omitempty"`
 }
 
+// ContextConfig contains context-building settings
+type ContextConfig struct {
+	TokenBudget int    `yaml:"token_budget"`           // Token budget when auto-selecting files
+	MaxFiles    int    `yaml:"max_files"`              // Maximum files proposed by relevance ranking
+	Format      string `yaml:"format,omitempty"`       // Prompt format: xml, markdown or json; empty picks per provider
+	LineNumbers bool   `yaml:"line_numbers,omitempty"` // Number the lines of files in prompts
+}
+
+// RedactionConfig controls scrubbing of secrets before content is sent to providers
+type RedactionConfig struct {
+	Enabled  bool              `yaml:"enabled"`
+	Patterns map[string]string `yaml:"patterns,omitempty"` // Extra regexes by name
+}
+
+// SummarizeConfig controls summarization of large files to shrink contexts
+type SummarizeConfig struct {
+	Agent     string `yaml:"agent,omitempty"` // Name of the (cheap) agent that writes summaries
+	MinTokens int    `yaml:"min_tokens"`      // Files smaller than this are never summarized
+	MaxTokens int    `yaml:"max_tokens"`      // Length limit for each summary
+}
+
+// RetryConfig controls retries of provider calls that hit rate limits,
+// overloads or network errors
+type RetryConfig struct {
+	MaxAttempts int           `yaml:"max_attempts"` // Attempts per request including the first; 1 disables retries
+	MaxElapsed  time.Duration `yaml:"max_elapsed"`  // Stop retrying once this long has passed, e.g. 2m
+}
+
+// QueueConfig limits the provider requests running at once and the tokens
+// they spend. Keys are a provider ("anthropic") or a provider and model
+// ("anthropic/claude-opus-4-1"); missing keys are unlimited.
+type QueueConfig struct {
+	Concurrency     map[string]int `yaml:"concurrency,omitempty"`
+	TokensPerMinute map[string]int `yaml:"tokens_per_minute,omitempty"`
+}
+
+// ChainConfig is an escalation chain: its tiers are asked in turn until one
+// answers without an error, refusal or timeout and passes every check
+type ChainConfig struct {
+	Tiers   []string      `yaml:"tiers"`             // Agent names or model IDs, cheapest first
+	Timeout time.Duration `yaml:"timeout,omitempty"` // Per tier; zero waits as long as the provider takes
+	Require []string      `yaml:"require,omitempty"` // Checks such as code_block or contains:TEXT
+}
+
+// JudgeConfig picks the agent that scores the responses of a comparison
+type JudgeConfig struct {
+	Agent  string `yaml:"agent,omitempty"` // Agent name or model ID; empty disables judging
+	Rubric string `yaml:"rubric"`          // Rubric used unless another is picked
+}
+
+// Criterion is one thing a rubric scores responses on
+type Criterion struct {
+	Name        string  `yaml:"name"`
+	Description string  `yaml:"description,omitempty"` // What a good score means, shown to the judge
+	Weight      float64 `yaml:"weight,omitempty"`      // Relative weight in the total; zero counts as 1
+}
+
+// ToolsConfig controls the tools agents may call to read the project and
+// the attached context while answering
+type ToolsConfig struct {
+	Enabled  bool        `yaml:"enabled"`
+	MaxTurns int         `yaml:"max_turns"` // Rounds of tool calls allowed per reply
+	Shell    ShellConfig `yaml:"shell"`
+}
+
+// ShellConfig controls the tool that runs shell commands in the project. It
+// is off unless enabled, and every command waits for the user to approve it
+// in the chat.
+type ShellConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Timeout   time.Duration `yaml:"timeout"`       // Commands running longer are stopped, e.g. 2m
+	MaxOutput int           `yaml:"max_output"`    // Bytes of output kept from each command
+	Env       []string      `yaml:"env,omitempty"` // Environment variables passed through besides the defaults
+}
+
+// PatchesConfig controls applying code from replies to the working tree
+type PatchesConfig struct {
+	BackupDir string `yaml:"backup_dir"` // Files are copied here before they are patched
+}
+
+// MCPServerConfig is an MCP server whose resources and prompts can be added
+// to contexts, and whose tools agents may call if exposed. Command starts a server
+// that speaks over stdio; URL reaches one over streamable HTTP.
+type MCPServerConfig struct {
+	Command string            `yaml:"command,omitempty"`
+	Args    []string          `yaml:"args,omitempty"`
+	Env     map[string]string `yaml:"env,omitempty"` // Added to the environment of the command
+	URL     string            `yaml:"url,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"` // Sent with every HTTP request, e.g. Authorization
+	Timeout time.Duration     `yaml:"timeout,omitempty"` // Per request; zero waits a minute
+
+	// ExposeTools lets agents call the server's tools, which run without
+	// asking. Off by default; resources and prompts are available either way.
+	ExposeTools bool `yaml:"expose_tools,omitempty"`
+}
+
 // NewDefault creates a new configuration with default values
 func NewDefault() *Config {
 	home, _ := os.UserHomeDir()
@@ -50,6 +160,45 @@ func NewDefault() *Config {
 			Level: "info",
 			File:  filepath.Join(home, ".config", "aui", "aui.log"),
 		},
+		Context: ContextConfig{
+			TokenBudget: 100000,
+			MaxFiles:    20,
+		},
+		Redaction: RedactionConfig{
+			Enabled: true,
+		},
+		Summarize: SummarizeConfig{
+			MinTokens: 2000,
+			MaxTokens: 500,
+		},
+		Retry: RetryConfig{
+			MaxAttempts: 6,
+			MaxElapsed:  2 * time.Minute,
+		},
+		Queue: QueueConfig{
+			Concurrency: map[string]int{"anthropic": 4, "openai": 4, "google": 4},
+		},
+		Judge: JudgeConfig{
+			Rubric: "default",
+		},
+		Rubrics: map[string][]Criterion{
+			"default": {
+				{Name: "correctness", Description: "The answer is right and would work as written"},
+				{Name: "minimal diff", Description: "Changes only what the task needs"},
+				{Name: "explains tradeoffs", Description: "Says what the approach costs and what the alternatives were"},
+			},
+		},
+		Tools: ToolsConfig{
+			Enabled:  true,
+			MaxTurns: 8,
+			Shell: ShellConfig{
+				Timeout:   2 * time.Minute,
+				MaxOutput: 32 * 1024,
+			},
+		},
+		Patches: PatchesConfig{
+			BackupDir: filepath.Join(home, ".config", "aui", "backups"),
+		},
 	}
 }
 
@@ -70,6 +219,7 @@ func LoadFromFile(path string) (*Config, error) {
 	if cfg.Logging.File != "" {
 		cfg.Logging.File = expandPath(cfg.Logging.File)
 	}
+	cfg.Patches.BackupDir = expandPath(cfg.Patches.BackupDir)
 
 	return cfg, nil
 }
@@ -155,6 +305,10 @@ func (c *Config) LoadFromEnv() {
 	}
 }
 
+// mcpServerName matches the names MCP servers may be configured under, which
+// prefix the names of their tools
+var mcpServerName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
 // Validate checks if the configuration is valid
 func (c *Config) Validate() error {
 	// Database path is required
@@ -180,6 +334,90 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("refresh rate must be positive")
 	}
 
+	// Validate context limits; zero means use the default
+	if c.Context.TokenBudget < 0 || c.Context.MaxFiles < 0 {
+		return fmt.Errorf("context token budget and max files must not be negative")
+	}
+
+	switch c.Context.Format {
+	case "", "xml", "markdown", "json":
+	default:
+		return fmt.Errorf("invalid prompt format: %s (must be xml, markdown, or json)", c.Context.Format)
+	}
+
+	if c.Summarize.MinTokens < 0 || c.Summarize.MaxTokens < 0 {
+		return fmt.Errorf("summarize min and max tokens must not be negative")
+	}
+
+	if c.Retry.MaxAttempts < 0 || c.Retry.MaxElapsed < 0 {
+		return fmt.Errorf("retry max attempts and max elapsed must not be negative")
+	}
+
+	for name, chain := range c.Chains {
+		if len(chain.Tiers) == 0 {
+			return fmt.Errorf("chain %s has no tiers", name)
+		}
+		if chain.Timeout < 0 {
+			return fmt.Errorf("chain %s timeout must not be negative", name)
+		}
+	}
+
+	if c.Tools.MaxTurns < 0 {
+		return fmt.Errorf("tools max turns must not be negative")
+	}
+	if c.Tools.Shell.Timeout < 0 || c.Tools.Shell.MaxOutput < 0 {
+		return fmt.Errorf("shell timeout and max output must not be negative")
+	}
+
+	for name, server := range c.MCPServers {
+		if !mcpServerName.MatchString(name) {
+			return fmt.Errorf("invalid MCP server name %q: use letters, digits, - and _", name)
+		}
+		if (server.Command == "") == (server.URL == "") {
+			return fmt.Errorf("MCP server %s needs either a command or a URL", name)
+		}
+		if server.Timeout < 0 {
+			return fmt.Errorf("MCP server %s timeout must not be negative", name)
+		}
+	}
+
+	for name, criteria := range c.Rubrics {
+		if len(criteria) == 0 {
+			return fmt.Errorf("rubric %s has no criteria", name)
+		}
+		seen := make(map[string]bool)
+		for _, criterion := range criteria {
+			if criterion.Name == "" || seen[criterion.Name] {
+				return fmt.Errorf("rubric %s: criteria need distinct names", name)
+			}
+			if criterion.Weight < 0 {
+				return fmt.Errorf("rubric %s: weight of %s must not be negative", name, criterion.Name)
+			}
+			seen[criterion.Name] = true
+		}
+	}
+	if _, ok := c.Rubrics[c.Judge.Rubric]; c.Judge.Rubric != "" && !ok {
+		return fmt.Errorf("unknown judge rubric: %s", c.Judge.Rubric)
+	}
+
+	for key, n := range c.Queue.Concurrency {
+		if n < 0 {
+			return fmt.Errorf("invalid queue concurrency for %s: must not be negative", key)
+		}
+	}
+	for key, n := range c.Queue.TokensPerMinute {
+		if n < 0 {
+			return fmt.Errorf("invalid queue tokens per minute for %s: must not be negative", key)
+		}
+	}
+
+	// Validate custom redaction patterns
+	for name, pattern := range c.Redaction.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid redaction pattern %s: %w", name, err)
+		}
+	}
+
 	return nil
 }
 
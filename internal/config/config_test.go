@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestLoadDefaultConfig(t *testing.T) {
@@ -28,6 +29,10 @@ func TestLoadDefaultConfig(t *testing.T) {
 	if cfg.Logging.Level != "info" {
 		t.Errorf("Expected log level info, got %s", cfg.Logging.Level)
 	}
+
+	if !cfg.Tools.Enabled || cfg.Tools.Shell.Enabled {
+		t.Errorf("Expected tools on and the shell tool off, got %+v", cfg.Tools)
+	}
 }
 
 func TestLoadConfigFromFile(t *testing.T) {
@@ -46,6 +51,14 @@ ui:
 logging:
   level: "debug"
   file: "/custom/log/aui.log"
+retry:
+  max_elapsed: 30s
+mcp_servers:
+  docs:
+    url: "http://localhost:8080/mcp"
+  github:
+    command: "github-mcp"
+    expose_tools: true
 `
 
 	err := os.WriteFile(configFile, []byte(configContent), 0644)
@@ -69,6 +82,14 @@ logging:
 	if cfg.UI.Theme != "dark" {
 		t.Errorf("Expected dark theme, got %s", cfg.UI.Theme)
 	}
+
+	if cfg.Retry.MaxElapsed != 30*time.Second || cfg.Retry.MaxAttempts != 6 {
+		t.Errorf("Expected 30s retries with default attempts, got %+v", cfg.Retry)
+	}
+
+	if cfg.MCPServers["docs"].ExposeTools || !cfg.MCPServers["github"].ExposeTools {
+		t.Errorf("Expected only github to expose its tools, got %+v", cfg.MCPServers)
+	}
 }
 
 func TestLoadConfigWithEnvironmentOverrides(t *testing.T) {
@@ -147,6 +168,106 @@ func TestConfigValidation(t *testing.T) {
 			},
 			wantError: false,
 		},
+		{
+			name: "invalid config - negative token budget",
+			config: &Config{
+				Database: DatabaseConfig{Path: "/path/to/db"},
+				UI:       UIConfig{Theme: "default", RefreshRate: 100},
+				Logging:  LoggingConfig{Level: "info"},
+				Context:  ContextConfig{TokenBudget: -1},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid config - negative summary length",
+			config: &Config{
+				Database:  DatabaseConfig{Path: "/path/to/db"},
+				UI:        UIConfig{Theme: "default", RefreshRate: 100},
+				Logging:   LoggingConfig{Level: "info"},
+				Summarize: SummarizeConfig{MaxTokens: -1},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid config - negative retry attempts",
+			config: &Config{
+				Database: DatabaseConfig{Path: "/path/to/db"},
+				UI:       UIConfig{Theme: "default", RefreshRate: 100},
+				Logging:  LoggingConfig{Level: "info"},
+				Retry:    RetryConfig{MaxAttempts: -1},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid config - chain without tiers",
+			config: &Config{
+				Database: DatabaseConfig{Path: "/path/to/db"},
+				UI:       UIConfig{Theme: "default", RefreshRate: 100},
+				Logging:  LoggingConfig{Level: "info"},
+				Chains:   map[string]ChainConfig{"coding": {}},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid config - rubric with repeated criteria",
+			config: &Config{
+				Database: DatabaseConfig{Path: "/path/to/db"},
+				UI:       UIConfig{Theme: "default", RefreshRate: 100},
+				Logging:  LoggingConfig{Level: "info"},
+				Rubrics:  map[string][]Criterion{"review": {{Name: "correctness"}, {Name: "correctness"}}},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid config - unknown judge rubric",
+			config: &Config{
+				Database: DatabaseConfig{Path: "/path/to/db"},
+				UI:       UIConfig{Theme: "default", RefreshRate: 100},
+				Logging:  LoggingConfig{Level: "info"},
+				Judge:    JudgeConfig{Rubric: "review"},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid config - unknown prompt format",
+			config: &Config{
+				Database: DatabaseConfig{Path: "/path/to/db"},
+				UI:       UIConfig{Theme: "default", RefreshRate: 100},
+				Logging:  LoggingConfig{Level: "info"},
+				Context:  ContextConfig{Format: "yaml"},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid config - negative tool turns",
+			config: &Config{
+				Database: DatabaseConfig{Path: "/path/to/db"},
+				UI:       UIConfig{Theme: "default", RefreshRate: 100},
+				Logging:  LoggingConfig{Level: "info"},
+				Tools:    ToolsConfig{MaxTurns: -1},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid config - MCP server with command and URL",
+			config: &Config{
+				Database:   DatabaseConfig{Path: "/path/to/db"},
+				UI:         UIConfig{Theme: "default", RefreshRate: 100},
+				Logging:    LoggingConfig{Level: "info"},
+				MCPServers: map[string]MCPServerConfig{"github": {Command: "github-mcp", URL: "http://localhost:8080/mcp"}},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid config - MCP server name with spaces",
+			config: &Config{
+				Database:   DatabaseConfig{Path: "/path/to/db"},
+				UI:         UIConfig{Theme: "default", RefreshRate: 100},
+				Logging:    LoggingConfig{Level: "info"},
+				MCPServers: map[string]MCPServerConfig{"issue tracker": {URL: "http://localhost:8080/mcp"}},
+			},
+			wantError: true,
+		},
 	}
 
 	for _, tt := range tests {
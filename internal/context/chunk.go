@@ -0,0 +1,302 @@
+package context
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultChunkLines is the chunk size used when no boundaries are known
+const DefaultChunkLines = 200
+
+// Chunk is a contiguous, 1-based inclusive line range of a file
+type Chunk struct {
+	Path      string
+	StartLine int
+	EndLine   int
+	Content   string
+	Hash      string
+	Tokens    int
+}
+
+// Ref returns the chunk's location as "path:start-end"
+func (c *Chunk) Ref() string {
+	return fmt.Sprintf("%s:%d-%d", c.Path, c.StartLine, c.EndLine)
+}
+
+// ToFile returns a synthetic file holding the chunk, whose Path is the chunk
+// reference so it can sit alongside whole files in a context
+func (c *Chunk) ToFile(language string) *File {
+	file := NewFile(c.Ref(), filepath.Base(c.Path))
+	file.Content = c.Content
+	file.Size = int64(len(c.Content))
+	file.Hash = c.Hash
+	file.Tokens = c.Tokens
+	file.Language = language
+	file.ModifiedAt = time.Now()
+	return file
+}
+
+// newChunk builds a chunk from lines[start-1:end]
+func newChunk(path string, lines []string, start, end int) *Chunk {
+	content := strings.Join(lines[start-1:end], "")
+	return &Chunk{
+		Path:      path,
+		StartLine: start,
+		EndLine:   end,
+		Content:   content,
+		Hash:      HashContent(content),
+		Tokens:    EstimateTokens(content),
+	}
+}
+
+// splitLines splits content into lines that keep their trailing newline
+func splitLines(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Lines returns the given 1-based inclusive line range of the file
+func (f *File) Lines(start, end int) (*Chunk, error) {
+	lines := splitLines(f.Content)
+	if start < 1 || end < start || start > len(lines) {
+		return nil, fmt.Errorf("invalid line range %d-%d for %s (%d lines)", start, end, f.Path, len(lines))
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+
+	return newChunk(f.Path, lines, start, end), nil
+}
+
+// ChunkByLines splits a file into chunks of at most n lines
+func ChunkByLines(f *File, n int) []*Chunk {
+	if n <= 0 {
+		n = DefaultChunkLines
+	}
+
+	lines := splitLines(f.Content)
+	var chunks []*Chunk
+	for start := 1; start <= len(lines); start += n {
+		end := start + n - 1
+		if end > len(lines) {
+			end = len(lines)
+		}
+		chunks = append(chunks, newChunk(f.Path, lines, start, end))
+	}
+	return chunks
+}
+
+// ChunkBySize splits a file into chunks of whole lines of at most maxTokens
+// each. A single line longer than maxTokens becomes its own chunk.
+func ChunkBySize(f *File, maxTokens int) []*Chunk {
+	lines := splitLines(f.Content)
+	return packSections(f.Path, lines, lineSections(1, len(lines)), maxTokens)
+}
+
+// ChunkByFunctions splits a file at top-level declaration boundaries for
+// languages DetectLanguage recognizes, merging adjacent declarations up to
+// maxTokens and splitting larger ones by size. Files in other languages are
+// chunked by size.
+func ChunkByFunctions(f *File, maxTokens int) []*Chunk {
+	lines := splitLines(f.Content)
+
+	starts := declarationStarts(f)
+	if len(starts) == 0 {
+		return ChunkBySize(f, maxTokens)
+	}
+
+	// Anything before the first declaration (package clause, imports) is its own section
+	if starts[0] != 1 {
+		starts = append([]int{1}, starts...)
+	}
+
+	var sections [][2]int
+	for i, start := range starts {
+		end := len(lines)
+		if i+1 < len(starts) {
+			end = starts[i+1] - 1
+		}
+		if end >= start {
+			sections = append(sections, [2]int{start, end})
+		}
+	}
+
+	return packSections(f.Path, lines, sections, maxTokens)
+}
+
+// lineSections returns one section per line from start to end inclusive
+func lineSections(start, end int) [][2]int {
+	var sections [][2]int
+	for line := start; line <= end; line++ {
+		sections = append(sections, [2]int{line, line})
+	}
+	return sections
+}
+
+// packSections merges consecutive line sections into chunks of at most
+// maxTokens, splitting sections that are too large on their own by line
+func packSections(path string, lines []string, sections [][2]int, maxTokens int) []*Chunk {
+	var chunks []*Chunk
+	start, tokens := 0, 0
+
+	flush := func(end int) {
+		if start > 0 {
+			chunks = append(chunks, newChunk(path, lines, start, end))
+			start, tokens = 0, 0
+		}
+	}
+
+	for _, s := range sections {
+		t := EstimateTokens(strings.Join(lines[s[0]-1:s[1]], ""))
+
+		if maxTokens > 0 && t > maxTokens && s[1] > s[0] {
+			flush(s[0] - 1)
+			chunks = append(chunks, packSections(path, lines, lineSections(s[0], s[1]), maxTokens)...)
+			continue
+		}
+
+		if start > 0 && maxTokens > 0 && tokens+t > maxTokens {
+			flush(s[0] - 1)
+		}
+		if start == 0 {
+			start = s[0]
+		}
+		tokens += t
+	}
+
+	if len(sections) > 0 {
+		flush(sections[len(sections)-1][1])
+	}
+
+	return chunks
+}
+
+// declarationPatterns match lines that start a top-level declaration
+var declarationPatterns = map[string]*regexp.Regexp{
+	"python":     regexp.MustCompile(`^(?:async\s+def|def|class)\s`),
+	"javascript": regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:async\s+)?(?:function\*?|class)\s|^(?:export\s+)?(?:const|let)\s+\w+\s*=\s*(?:async\s*)?(?:\(|function)`),
+	"typescript": regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:async\s+)?(?:function\*?|class|interface|type|enum)\s|^(?:export\s+)?(?:const|let)\s+\w+\s*(?::[^=]+)?=\s*(?:async\s*)?(?:\(|function)`),
+	"rust":       regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:async\s+)?(?:fn|struct|enum|impl|trait|mod)\s`),
+	"ruby":       regexp.MustCompile(`^(?:def|class|module)\s`),
+	"php":        regexp.MustCompile(`^(?:(?:abstract|final)\s+)?(?:function|class|interface|trait)\s`),
+	"shell":      regexp.MustCompile(`^(?:function\s+\w+|\w+\s*\(\)\s*\{)`),
+	"java":       regexp.MustCompile(`^\s{0,4}(?:public|private|protected|static|final|abstract|\s)*(?:class|interface|enum|[\w<>\[\]]+\s+\w+\s*\()`),
+	"c":          regexp.MustCompile(`^[A-Za-z_][\w\s\*]*\s\**\w+\s*\([^;]*$`),
+	"cpp":        regexp.MustCompile(`^(?:[A-Za-z_][\w:<>\s\*&]*\s[\*&]*[\w:~]+\s*\([^;]*$|class\s|struct\s|namespace\s)`),
+}
+
+// declarationStarts returns the sorted 1-based lines where top-level
+// declarations begin, including their leading doc comments for Go
+func declarationStarts(f *File) []int {
+	if f.Language == "go" {
+		return goDeclarationStarts(f)
+	}
+
+	re, ok := declarationPatterns[f.Language]
+	if !ok {
+		return nil
+	}
+
+	var starts []int
+	for i, line := range splitLines(f.Content) {
+		if re.MatchString(line) {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// goDeclarationStarts uses the Go parser to find declaration boundaries
+func goDeclarationStarts(f *File) []int {
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, f.Path, f.Content, parser.ParseComments)
+	if err != nil {
+		return nil
+	}
+
+	var starts []int
+	for _, decl := range parsed.Decls {
+		pos := decl.Pos()
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				pos = d.Doc.Pos()
+			}
+		case *ast.GenDecl:
+			if d.Doc != nil {
+				pos = d.Doc.Pos()
+			}
+		}
+		starts = append(starts, fset.Position(pos).Line)
+	}
+	return starts
+}
+
+// ParseLineRange parses a reference like "path:120-240" or "path:120". A
+// path without a range returns zero start and end.
+func ParseLineRange(ref string) (path string, start, end int, err error) {
+	i := strings.LastIndex(ref, ":")
+	if i < 0 {
+		return ref, 0, 0, nil
+	}
+
+	path, spec := ref[:i], ref[i+1:]
+	if spec == "" || strings.Trim(spec, "0123456789-") != "" {
+		// Not a line range (e.g. a Windows drive letter or odd file name)
+		return ref, 0, 0, nil
+	}
+
+	from, to, isRange := strings.Cut(spec, "-")
+	start, err = strconv.Atoi(from)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("invalid line range %q: %w", spec, err)
+	}
+	end = start
+	if isRange {
+		end, err = strconv.Atoi(to)
+		if err != nil {
+			return "", 0, 0, fmt.Errorf("invalid line range %q: %w", spec, err)
+		}
+	}
+
+	if start < 1 || end < start {
+		return "", 0, 0, fmt.Errorf("invalid line range %q", spec)
+	}
+
+	return path, start, end, nil
+}
+
+// LoadRange loads a file reference below root. A plain path loads the whole
+// file; "path:start-end" loads only those lines as a synthetic file whose
+// Path is the reference, and also returns the chunk for citation.
+func LoadRange(root, ref string) (*File, *Chunk, error) {
+	path, start, end, err := ParseLineRange(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := LoadFile(root, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if start == 0 {
+		return file, nil, nil
+	}
+
+	chunk, err := file.Lines(start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return chunk.ToFile(file.Language), chunk, nil
+}
@@ -0,0 +1,203 @@
+package context
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// numberedFile returns a file with n lines "line 1" ... "line n"
+func numberedFile(path string, n int) *File {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	f := NewFile(path, path)
+	f.SetContent(b.String())
+	f.DetectLanguage()
+	return f
+}
+
+// chunkRefs returns the references of chunks joined by spaces
+func chunkRefs(chunks []*Chunk) string {
+	refs := make([]string, len(chunks))
+	for i, c := range chunks {
+		refs[i] = c.Ref()
+	}
+	return strings.Join(refs, " ")
+}
+
+func TestFileLines(t *testing.T) {
+	f := numberedFile("app.log", 10)
+
+	chunk, err := f.Lines(3, 5)
+	if err != nil {
+		t.Fatalf("Lines() error = %v", err)
+	}
+
+	if chunk.Content != "line 3\nline 4\nline 5\n" {
+		t.Errorf("Lines(3, 5) content = %q", chunk.Content)
+	}
+	if chunk.Ref() != "app.log:3-5" {
+		t.Errorf("Ref() = %v, want app.log:3-5", chunk.Ref())
+	}
+	if chunk.Hash != HashContent(chunk.Content) || chunk.Tokens != EstimateTokens(chunk.Content) {
+		t.Error("Chunk hash and tokens should match its content")
+	}
+
+	// End past the last line is clamped
+	chunk, err = f.Lines(9, 100)
+	if err != nil || chunk.EndLine != 10 {
+		t.Errorf("Lines(9, 100) = %v, %v; want clamped to line 10", chunk, err)
+	}
+
+	for _, r := range [][2]int{{0, 1}, {5, 4}, {11, 12}} {
+		if _, err := f.Lines(r[0], r[1]); err == nil {
+			t.Errorf("Lines(%d, %d) should fail", r[0], r[1])
+		}
+	}
+}
+
+func TestChunkByLines(t *testing.T) {
+	f := numberedFile("app.log", 10)
+
+	got := chunkRefs(ChunkByLines(f, 4))
+	if got != "app.log:1-4 app.log:5-8 app.log:9-10" {
+		t.Errorf("ChunkByLines() = %v", got)
+	}
+
+	// Chunks reassemble into the original content
+	var b strings.Builder
+	for _, c := range ChunkByLines(f, 3) {
+		b.WriteString(c.Content)
+	}
+	if b.String() != f.Content {
+		t.Error("Chunks should cover the whole file")
+	}
+}
+
+func TestChunkBySize(t *testing.T) {
+	f := numberedFile("app.log", 10) // Each line is 2 tokens
+
+	chunks := ChunkBySize(f, 6)
+	if got := chunkRefs(chunks); got != "app.log:1-3 app.log:4-6 app.log:7-9 app.log:10-10" {
+		t.Errorf("ChunkBySize() = %v", got)
+	}
+
+	for _, c := range chunks {
+		if c.Tokens > 6 {
+			t.Errorf("Chunk %s has %d tokens, want at most 6", c.Ref(), c.Tokens)
+		}
+	}
+}
+
+func TestChunkByFunctionsGo(t *testing.T) {
+	f := NewFile("auth.go", "auth.go")
+	f.SetContent(`package auth
+
+import "errors"
+
+// Login logs in
+func Login() error {
+	return errors.New("no")
+}
+
+// Logout logs out
+func Logout() {}
+`)
+	f.DetectLanguage()
+
+	// Small declarations are merged while they fit; Login's doc comment
+	// stays with it
+	got := chunkRefs(ChunkByFunctions(f, 18))
+	want := "auth.go:1-4 auth.go:5-9 auth.go:10-11"
+	if got != want {
+		t.Errorf("ChunkByFunctions() = %v, want %v", got, want)
+	}
+
+	// A generous budget merges everything into one chunk
+	if got := chunkRefs(ChunkByFunctions(f, 1000)); got != "auth.go:1-11" {
+		t.Errorf("ChunkByFunctions() with large budget = %v, want auth.go:1-11", got)
+	}
+
+	// A declaration larger than the budget is split by size
+	for _, c := range ChunkByFunctions(f, 6) {
+		if c.Tokens > 6 && c.StartLine != c.EndLine {
+			t.Errorf("Chunk %s has %d tokens, want at most 6", c.Ref(), c.Tokens)
+		}
+	}
+}
+
+func TestChunkByFunctionsPython(t *testing.T) {
+	f := NewFile("app.py", "app.py")
+	f.SetContent("import os\n\ndef a():\n    pass\n\nclass B:\n    pass\n")
+	f.DetectLanguage()
+
+	got := chunkRefs(ChunkByFunctions(f, 5))
+	if got != "app.py:1-2 app.py:3-5 app.py:6-7" {
+		t.Errorf("ChunkByFunctions() = %v", got)
+	}
+}
+
+func TestChunkByFunctionsFallsBackToSize(t *testing.T) {
+	f := numberedFile("app.log", 4)
+
+	if got := chunkRefs(ChunkByFunctions(f, 4)); got != "app.log:1-2 app.log:3-4" {
+		t.Errorf("ChunkByFunctions() for unknown language = %v", got)
+	}
+}
+
+func TestParseLineRange(t *testing.T) {
+	tests := []struct {
+		ref       string
+		wantPath  string
+		wantStart int
+		wantEnd   int
+		wantErr   bool
+	}{
+		{"main.go", "main.go", 0, 0, false},
+		{"logs/app.log:120-240", "logs/app.log", 120, 240, false},
+		{"main.go:42", "main.go", 42, 42, false},
+		{"main.go:10-5", "", 0, 0, true},
+		{"main.go:0-5", "", 0, 0, true},
+		{"main.go:abc", "main.go:abc", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		path, start, end, err := ParseLineRange(tt.ref)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLineRange(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			continue
+		}
+		if path != tt.wantPath || start != tt.wantStart || end != tt.wantEnd {
+			t.Errorf("ParseLineRange(%q) = %q, %d, %d; want %q, %d, %d",
+				tt.ref, path, start, end, tt.wantPath, tt.wantStart, tt.wantEnd)
+		}
+	}
+}
+
+func TestLoadRange(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{"src/app.py": "a = 1\nb = 2\nc = 3\n"})
+
+	file, chunk, err := LoadRange(root, "src/app.py:2-3")
+	if err != nil {
+		t.Fatalf("LoadRange() error = %v", err)
+	}
+
+	if file.Path != "src/app.py:2-3" || file.Content != "b = 2\nc = 3\n" || file.Language != "python" {
+		t.Errorf("LoadRange() file = %q %q %q", file.Path, file.Content, file.Language)
+	}
+	if chunk == nil || chunk.Path != "src/app.py" || chunk.StartLine != 2 {
+		t.Errorf("LoadRange() chunk = %+v", chunk)
+	}
+
+	file, chunk, err = LoadRange(root, "src/app.py")
+	if err != nil || chunk != nil || file.Path != "src/app.py" {
+		t.Errorf("LoadRange() without range = %v, %v, %v", file, chunk, err)
+	}
+
+	if _, _, err := LoadRange(root, "src/app.py:10-20"); err == nil {
+		t.Error("Expected error for range past end of file")
+	}
+}
@@ -0,0 +1,115 @@
+package context
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Resolver loads a context by ID, with its files and includes
+type Resolver func(id string) (*Context, error)
+
+// Include layers another context beneath this one. Including the same
+// context twice, or the context itself, has no effect.
+func (c *Context) Include(id string) {
+	if id == c.ID || c.IsIncluded(id) {
+		return
+	}
+	c.Includes = append(c.Includes, id)
+}
+
+// RemoveInclude removes an included context by ID
+func (c *Context) RemoveInclude(id string) {
+	includes := []string{}
+	for _, inc := range c.Includes {
+		if inc != id {
+			includes = append(includes, inc)
+		}
+	}
+	c.Includes = includes
+}
+
+// IsIncluded reports whether the context directly includes id
+func (c *Context) IsIncluded(id string) bool {
+	for _, inc := range c.Includes {
+		if inc == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Flatten returns a copy of the context with the files of every included
+// context, recursively, merged into Files. Included layers come first, in
+// include order, followed by the context's own files. A file whose path
+// appears in more than one layer is kept once, with the content of the
+// outermost layer; files at different paths are all kept, even with the same
+// content. TotalTokens counts each kept file once. Include cycles are an error.
+func (c *Context) Flatten(resolve Resolver) (*Context, error) {
+	flat := &Context{
+		ID:           c.ID,
+		Name:         c.Name,
+		Description:  c.Description,
+		SystemPrompt: c.SystemPrompt,
+		Files:        []*File{},
+		Includes:     append([]string{}, c.Includes...),
+	}
+
+	layers, err := collectLayers(c, resolve, []string{c.ID}, make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+
+	// Outer layers win, so pick each path's file from the last layer that has it
+	winners := make(map[string]*File)
+	for _, layer := range layers {
+		for _, f := range layer.Files {
+			winners[f.Path] = f
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, layer := range layers {
+		for _, f := range layer.Files {
+			if seen[f.Path] {
+				continue
+			}
+			seen[f.Path] = true
+			flat.Files = append(flat.Files, winners[f.Path])
+		}
+	}
+
+	flat.RecalculateTokens()
+	return flat, nil
+}
+
+// collectLayers returns the contexts beneath c in flattening order, ending
+// with c itself. Each included context appears once even if it is reachable
+// along several paths.
+func collectLayers(c *Context, resolve Resolver, stack []string, done map[string]bool) ([]*Context, error) {
+	var layers []*Context
+
+	for _, id := range c.Includes {
+		for _, visiting := range stack {
+			if visiting == id {
+				return nil, fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), id)
+			}
+		}
+		if done[id] {
+			continue
+		}
+
+		inc, err := resolve(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load included context %s: %w", id, err)
+		}
+
+		sub, err := collectLayers(inc, resolve, append(stack, id), done)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, sub...)
+		done[id] = true
+	}
+
+	return append(layers, c), nil
+}
@@ -0,0 +1,139 @@
+package context
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// newTestFile returns a file with the given content
+func newTestFile(path, content string) *File {
+	f := NewFile(path, path)
+	f.SetContent(content)
+	return f
+}
+
+// mapResolver resolves contexts from a map keyed by ID
+func mapResolver(contexts ...*Context) Resolver {
+	byID := make(map[string]*Context)
+	for _, c := range contexts {
+		byID[c.ID] = c
+	}
+	return func(id string) (*Context, error) {
+		if c, ok := byID[id]; ok {
+			return c, nil
+		}
+		return nil, fmt.Errorf("context not found: %s", id)
+	}
+}
+
+func TestInclude(t *testing.T) {
+	ctx := NewContext("feature", "")
+	ctx.Include("overview")
+	ctx.Include("overview")
+	ctx.Include(ctx.ID)
+	ctx.Include("style")
+
+	if got := strings.Join(ctx.Includes, ","); got != "overview,style" {
+		t.Errorf("Includes = %s, want overview,style", got)
+	}
+
+	ctx.RemoveInclude("overview")
+	if ctx.IsIncluded("overview") || !ctx.IsIncluded("style") {
+		t.Errorf("RemoveInclude() left %v", ctx.Includes)
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	overview := NewContext("project-overview", "")
+	overview.AddFile(newTestFile("README.md", "# Project\n"))
+	overview.AddFile(newTestFile("docs/arch.md", "old architecture\n"))
+
+	style := NewContext("style", "")
+	style.AddFile(newTestFile("README.md", "# Project\n"))          // Same path and content as overview
+	style.AddFile(newTestFile("docs/style-copy.md", "# Project\n")) // Same content, other path
+	style.AddFile(newTestFile("docs/style.md", "tabs\n"))
+
+	feature := NewContext("feature", "Add login")
+	feature.SystemPrompt = "Be brief."
+	feature.AddFile(newTestFile("docs/arch.md", "new architecture\n"))
+	feature.AddFile(newTestFile("login.go", "package login\n"))
+	feature.Include(overview.ID)
+	feature.Include(style.ID)
+
+	flat, err := feature.Flatten(mapResolver(overview, style))
+	if err != nil {
+		t.Fatalf("Flatten() error = %v", err)
+	}
+
+	var paths []string
+	for _, f := range flat.Files {
+		paths = append(paths, f.Path)
+	}
+	if got := strings.Join(paths, ","); got != "README.md,docs/arch.md,docs/style-copy.md,docs/style.md,login.go" {
+		t.Errorf("flattened paths = %s, want each path once and copies at other paths kept", got)
+	}
+
+	if got := flat.GetFile("docs/arch.md").Content; got != "new architecture\n" {
+		t.Errorf("docs/arch.md = %q, want the including context's version", got)
+	}
+
+	want := 0
+	for _, f := range flat.Files {
+		want += f.Tokens
+	}
+	if flat.TotalTokens != want {
+		t.Errorf("TotalTokens = %d, want %d", flat.TotalTokens, want)
+	}
+	if flat.SystemPrompt != "Be brief." || flat.Name != "feature" {
+		t.Errorf("Flatten() lost metadata: %q %q", flat.Name, flat.SystemPrompt)
+	}
+
+	// The original is unchanged
+	if len(feature.Files) != 2 {
+		t.Errorf("Flatten() modified the context: %d files", len(feature.Files))
+	}
+}
+
+func TestFlattenDiamond(t *testing.T) {
+	base := NewContext("base", "")
+	base.AddFile(newTestFile("go.mod", "module app\n"))
+
+	left := NewContext("left", "")
+	left.Include(base.ID)
+	right := NewContext("right", "")
+	right.Include(base.ID)
+
+	top := NewContext("top", "")
+	top.Include(left.ID)
+	top.Include(right.ID)
+
+	flat, err := top.Flatten(mapResolver(base, left, right))
+	if err != nil {
+		t.Fatalf("Flatten() error = %v", err)
+	}
+	if len(flat.Files) != 1 || flat.TotalTokens != base.TotalTokens {
+		t.Errorf("Flatten() = %d files, %d tokens; want 1 file, %d tokens", len(flat.Files), flat.TotalTokens, base.TotalTokens)
+	}
+}
+
+func TestFlattenCycle(t *testing.T) {
+	a := NewContext("a", "")
+	b := NewContext("b", "")
+	a.Include(b.ID)
+	b.Include(a.ID)
+
+	_, err := a.Flatten(mapResolver(a, b))
+	if err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("Flatten() error = %v, want an include cycle", err)
+	}
+}
+
+func TestFlattenMissingInclude(t *testing.T) {
+	ctx := NewContext("orphan", "")
+	ctx.Include("gone")
+
+	if _, err := ctx.Flatten(mapResolver(ctx)); err == nil {
+		t.Error("Flatten() with a missing include should fail")
+	}
+}
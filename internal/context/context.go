@@ -3,15 +3,18 @@ package context
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"fmt"
 )
 
 // Context represents a collection of files and metadata for AI agent consumption
 type Context struct {
-	ID          string
-	Name        string
-	Description string
-	Files       []*File
-	TotalTokens int
+	ID           string
+	Name         string
+	Description  string
+	SystemPrompt string // Instructions sent ahead of the files
+	Files        []*File
+	Includes     []string // IDs of contexts layered beneath this one
+	TotalTokens  int
 }
 
 // NewContext creates a new context with the given name and description
@@ -101,3 +104,22 @@ func (c *Context) RecalculateTokens() {
 	}
 	c.TotalTokens = total
 }
+
+// Refresh reloads files of the context from below root after they changed
+// on disk, updating their content, hash and token count
+func (c *Context) Refresh(root string, paths ...string) error {
+	for _, path := range paths {
+		f := c.GetFile(path)
+		if f == nil {
+			return fmt.Errorf("file not in context: %s", path)
+		}
+		loaded, err := LoadFile(root, path)
+		if err != nil {
+			return err
+		}
+		f.SetContent(loaded.Content)
+		f.ModifiedAt = loaded.ModifiedAt
+	}
+	c.RecalculateTokens()
+	return nil
+}
@@ -1,6 +1,8 @@
 package context
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -238,3 +240,39 @@ func TestContextRecalculateTokens(t *testing.T) {
 		t.Errorf("After RecalculateTokens(), TotalTokens = %v, want 300", ctx.TotalTokens)
 	}
 }
+
+func TestContextRefresh(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "main.go")
+	if err := os.WriteFile(path, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := NewContext("test", "")
+	f, err := LoadFile(root, "main.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx.AddFile(f)
+	id := f.ID
+
+	content := "package main\n\nfunc main() {}\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.Refresh(root, "main.go"); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	f = ctx.GetFile("main.go")
+	if f.ID != id || f.Content != content || f.Hash != HashContent(content) {
+		t.Errorf("Refresh() file = %+v, want the new content under the same ID", f)
+	}
+	if ctx.TotalTokens != EstimateTokens(content) {
+		t.Errorf("Refresh() TotalTokens = %d, want %d", ctx.TotalTokens, EstimateTokens(content))
+	}
+
+	if err := ctx.Refresh(root, "other.go"); err == nil {
+		t.Error("Refresh() of a file not in the context should fail")
+	}
+}
@@ -2,6 +2,7 @@ package context
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"path/filepath"
 	"strings"
@@ -19,6 +20,7 @@ type File struct {
 	Language   string
 	Tokens     int       // Renamed from TokenCount for consistency with storage
 	ModifiedAt time.Time // Renamed from LastModified for consistency
+	Summarized bool      // Content is a summary of the file, see Pack
 }
 
 // NewFile creates a new file with the given path and name
@@ -47,6 +49,29 @@ func (f *File) UpdateMetadata(size int64, hash, language string, tokens int, mod
 	f.ModifiedAt = modifiedAt
 }
 
+// SetContent replaces the file's content and recomputes size, hash and token count
+func (f *File) SetContent(content string) {
+	f.Content = content
+	f.Size = int64(len(content))
+	f.Hash = HashContent(content)
+	f.Tokens = EstimateTokens(content)
+}
+
+// HashContent returns the hex-encoded SHA-256 hash of the given content
+func HashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// EstimateTokens returns a rough token count for text, using the common
+// approximation of four characters per token
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	return (len(text) + 3) / 4
+}
+
 // DetectLanguage detects the programming language based on file extension
 func (f *File) DetectLanguage() {
 	ext := strings.ToLower(filepath.Ext(f.Path))
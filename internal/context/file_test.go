@@ -214,3 +214,58 @@ func TestGenerateFileID(t *testing.T) {
 		t.Errorf("generateFileID() should produce 16-character hex strings, got %d", len(id1))
 	}
 }
+
+func TestFileSetContent(t *testing.T) {
+	file := NewFile("main.go", "main.go")
+	content := "package main\n\nfunc main() {}\n"
+
+	file.SetContent(content)
+
+	if file.Content != content {
+		t.Errorf("After SetContent(), Content = %q, want %q", file.Content, content)
+	}
+
+	if file.Size != int64(len(content)) {
+		t.Errorf("After SetContent(), Size = %v, want %v", file.Size, len(content))
+	}
+
+	if file.Hash != HashContent(content) {
+		t.Errorf("After SetContent(), Hash = %v, want %v", file.Hash, HashContent(content))
+	}
+
+	if file.Tokens != EstimateTokens(content) {
+		t.Errorf("After SetContent(), Tokens = %v, want %v", file.Tokens, EstimateTokens(content))
+	}
+}
+
+func TestHashContent(t *testing.T) {
+	if HashContent("a") != HashContent("a") {
+		t.Error("HashContent() should be deterministic")
+	}
+
+	if HashContent("a") == HashContent("b") {
+		t.Error("HashContent() should differ for different content")
+	}
+
+	if len(HashContent("")) != 64 {
+		t.Errorf("HashContent() should produce 64-character hex strings, got %d", len(HashContent("")))
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"a", 1},
+		{"abcd", 1},
+		{"abcde", 2},
+	}
+
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.text); got != tt.want {
+			t.Errorf("EstimateTokens(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package context
+
+import (
+	"bufio"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ImportResolver finds the local files that a source file imports
+type ImportResolver interface {
+	// Imports returns the slash-separated paths, relative to root, of the
+	// local files imported by file. External imports are ignored.
+	Imports(root string, file *File) ([]string, error)
+}
+
+var (
+	resolversMu sync.RWMutex
+	resolvers   = map[string]ImportResolver{
+		"go": GoResolver{},
+	}
+)
+
+// RegisterResolver registers an import resolver for a language as reported by
+// DetectLanguage, replacing any existing resolver
+func RegisterResolver(language string, r ImportResolver) {
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+	resolvers[language] = r
+}
+
+// ResolverFor returns the import resolver registered for a language, or nil
+func ResolverFor(language string) ImportResolver {
+	resolversMu.RLock()
+	defer resolversMu.RUnlock()
+	return resolvers[language]
+}
+
+// Ring is the set of files at one import distance from the file being expanded
+type Ring struct {
+	Depth  int
+	Files  []*File
+	Tokens int
+}
+
+// ExpandOptions controls import graph expansion
+type ExpandOptions struct {
+	Depth   int  // Number of rings to follow
+	Reverse bool // Also follow files that import the current ring
+}
+
+// ExpandImports follows the local imports of file to opts.Depth and returns
+// one Ring per distance, so the cost of each ring can be shown before adding.
+// Files are loaded from root; file.Path must be relative to root.
+func ExpandImports(root string, file *File, opts ExpandOptions) ([]Ring, error) {
+	resolver := ResolverFor(file.Language)
+	if resolver == nil {
+		return nil, fmt.Errorf("no import resolver for language: %q", file.Language)
+	}
+
+	var dependents map[string][]string
+	if opts.Reverse {
+		var err error
+		dependents, err = reverseImports(root, file.Language, resolver)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	visited := map[string]bool{file.Path: true}
+	frontier := []*File{file}
+	var rings []Ring
+
+	for depth := 1; depth <= opts.Depth && len(frontier) > 0; depth++ {
+		ring := Ring{Depth: depth}
+
+		for _, f := range frontier {
+			imports, err := resolver.Imports(root, f)
+			if err != nil {
+				return nil, err
+			}
+			neighbours := append(imports, dependents[f.Path]...)
+
+			for _, p := range neighbours {
+				if visited[p] {
+					continue
+				}
+				visited[p] = true
+
+				loaded, err := LoadFile(root, p)
+				if err != nil {
+					return nil, err
+				}
+				ring.Files = append(ring.Files, loaded)
+				ring.Tokens += loaded.Tokens
+			}
+		}
+
+		if len(ring.Files) == 0 {
+			break
+		}
+
+		sort.Slice(ring.Files, func(i, j int) bool {
+			return ring.Files[i].Path < ring.Files[j].Path
+		})
+		rings = append(rings, ring)
+		frontier = ring.Files
+	}
+
+	return rings, nil
+}
+
+// reverseImports maps each file of a language below root to the files that import it
+func reverseImports(root, language string, resolver ImportResolver) (map[string][]string, error) {
+	files, err := ScanDirectory(root, ScanOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	dependents := make(map[string][]string)
+	for _, f := range files {
+		if f.Language != language {
+			continue
+		}
+
+		imports, err := resolver.Imports(root, f)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range imports {
+			dependents[p] = append(dependents[p], f.Path)
+		}
+	}
+
+	return dependents, nil
+}
+
+// GoResolver resolves Go imports that fall inside the module rooted at root.
+// An imported package resolves to all of its non-test Go files.
+type GoResolver struct{}
+
+// Imports implements ImportResolver
+func (GoResolver) Imports(root string, file *File) ([]string, error) {
+	module, err := goModulePath(root)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, file.Path, file.Content, parser.ImportsOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse imports of %s: %w", file.Path, err)
+	}
+
+	var paths []string
+	for _, imp := range parsed.Imports {
+		importPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		var dir string
+		switch {
+		case importPath == module:
+			dir = "."
+		case strings.HasPrefix(importPath, module+"/"):
+			dir = strings.TrimPrefix(importPath, module+"/")
+		default:
+			continue
+		}
+
+		files, err := goPackageFiles(root, dir)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, files...)
+	}
+
+	return paths, nil
+}
+
+// goModulePath reads the module path from root/go.mod
+func goModulePath(root string) (string, error) {
+	f, err := os.Open(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("failed to open go.mod: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read go.mod: %w", err)
+	}
+
+	return "", fmt.Errorf("no module directive in go.mod")
+}
+
+// goPackageFiles lists the non-test Go files of a package directory relative to root
+func goPackageFiles(root, dir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(root, filepath.FromSlash(dir)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		files = append(files, path.Join(dir, name))
+	}
+
+	return files, nil
+}
@@ -0,0 +1,147 @@
+package context
+
+import (
+	"strings"
+	"testing"
+)
+
+// importsTestModule is a small module where cmd imports api, api imports
+// store, and store imports nothing local
+var importsTestModule = map[string]string{
+	"go.mod":              "module example.com/app\n\ngo 1.24\n",
+	"cmd/main.go":         "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/app/api\"\n)\n\nfunc main() { fmt.Println(api.Handler()) }\n",
+	"api/handler.go":      "package api\n\nimport \"example.com/app/store\"\n\nfunc Handler() string { return store.Get() }\n",
+	"api/routes.go":       "package api\n",
+	"api/handler_test.go": "package api\n",
+	"store/store.go":      "package store\n\nfunc Get() string { return \"\" }\n",
+	"tools/gen.go":        "package main\n\nimport \"example.com/app/store\"\n\nfunc main() { store.Get() }\n",
+}
+
+func ringPaths(ring Ring) []string {
+	var paths []string
+	for _, f := range ring.Files {
+		paths = append(paths, f.Path)
+	}
+	return paths
+}
+
+func TestExpandImports(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, importsTestModule)
+
+	seed, err := LoadFile(root, "cmd/main.go")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	rings, err := ExpandImports(root, seed, ExpandOptions{Depth: 3})
+	if err != nil {
+		t.Fatalf("ExpandImports() error = %v", err)
+	}
+
+	if len(rings) != 2 {
+		t.Fatalf("ExpandImports() returned %d rings, want 2", len(rings))
+	}
+
+	want := [][]string{
+		{"api/handler.go", "api/routes.go"},
+		{"store/store.go"},
+	}
+	for i, ring := range rings {
+		if ring.Depth != i+1 {
+			t.Errorf("Ring %d Depth = %v, want %v", i, ring.Depth, i+1)
+		}
+
+		got := strings.Join(ringPaths(ring), ",")
+		if got != strings.Join(want[i], ",") {
+			t.Errorf("Ring %d files = %v, want %v", i, got, want[i])
+		}
+
+		tokens := 0
+		for _, f := range ring.Files {
+			tokens += f.Tokens
+		}
+		if ring.Tokens != tokens {
+			t.Errorf("Ring %d Tokens = %v, want %v", i, ring.Tokens, tokens)
+		}
+	}
+}
+
+func TestExpandImportsDepthLimit(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, importsTestModule)
+
+	seed, _ := LoadFile(root, "cmd/main.go")
+
+	rings, err := ExpandImports(root, seed, ExpandOptions{Depth: 1})
+	if err != nil {
+		t.Fatalf("ExpandImports() error = %v", err)
+	}
+
+	if len(rings) != 1 {
+		t.Errorf("ExpandImports() with Depth 1 returned %d rings, want 1", len(rings))
+	}
+}
+
+func TestExpandImportsReverse(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, importsTestModule)
+
+	seed, _ := LoadFile(root, "store/store.go")
+
+	rings, err := ExpandImports(root, seed, ExpandOptions{Depth: 1, Reverse: true})
+	if err != nil {
+		t.Fatalf("ExpandImports() error = %v", err)
+	}
+
+	if len(rings) != 1 {
+		t.Fatalf("ExpandImports() returned %d rings, want 1", len(rings))
+	}
+
+	got := strings.Join(ringPaths(rings[0]), ",")
+	if got != "api/handler.go,tools/gen.go" {
+		t.Errorf("Reverse ring files = %v, want api/handler.go,tools/gen.go", got)
+	}
+}
+
+type stubResolver struct {
+	imports map[string][]string
+}
+
+func (r stubResolver) Imports(root string, file *File) ([]string, error) {
+	return r.imports[file.Path], nil
+}
+
+func TestExpandImportsCustomResolver(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"app.py":   "import util\n",
+		"util.py":  "import app\n",
+		"other.py": "",
+	})
+
+	RegisterResolver("python", stubResolver{imports: map[string][]string{
+		"app.py":  {"util.py"},
+		"util.py": {"app.py"}, // Cycles back to the seed
+	}})
+	defer RegisterResolver("python", nil)
+
+	seed, _ := LoadFile(root, "app.py")
+
+	rings, err := ExpandImports(root, seed, ExpandOptions{Depth: 5})
+	if err != nil {
+		t.Fatalf("ExpandImports() error = %v", err)
+	}
+
+	if len(rings) != 1 || rings[0].Files[0].Path != "util.py" {
+		t.Errorf("ExpandImports() with custom resolver = %v, want single ring with util.py", rings)
+	}
+}
+
+func TestExpandImportsNoResolver(t *testing.T) {
+	file := NewFile("notes.txt", "notes.txt")
+
+	if _, err := ExpandImports(t.TempDir(), file, ExpandOptions{Depth: 1}); err == nil {
+		t.Error("Expected error for language without resolver")
+	}
+}
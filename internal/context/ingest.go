@@ -0,0 +1,185 @@
+package context
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ScanOptions controls which files ScanDirectory ingests
+type ScanOptions struct {
+	Include     []string // Glob patterns; empty includes everything
+	Exclude     []string // Glob patterns checked after Include
+	MaxFileSize int64    // Files larger than this are skipped; 0 means no limit
+}
+
+// skippedDirs are never descended into when scanning
+var skippedDirs = map[string]bool{
+	".git":         true,
+	".hg":          true,
+	".svn":         true,
+	"node_modules": true,
+	"vendor":       true,
+	"__pycache__":  true,
+}
+
+// LoadFile reads a file below root and returns it with content and metadata
+// populated. The file's Path is relPath in slash-separated form.
+func LoadFile(root, relPath string) (*File, error) {
+	fullPath := filepath.Join(root, filepath.FromSlash(relPath))
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("not a file: %s", relPath)
+	}
+
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	file := NewFile(filepath.ToSlash(relPath), filepath.Base(fullPath))
+	file.SetContent(string(data))
+	file.DetectLanguage()
+	file.ModifiedAt = info.ModTime()
+	return file, nil
+}
+
+// ScanDirectory walks root and loads every text file matching the options,
+// skipping VCS and dependency directories. Files are returned sorted by path.
+func ScanDirectory(root string, opts ScanOptions) ([]*File, error) {
+	var files []*File
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if rel != "." && (skippedDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() || !opts.matches(rel) {
+			return nil
+		}
+
+		if opts.MaxFileSize > 0 {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if info.Size() > opts.MaxFileSize {
+				return nil
+			}
+		}
+
+		file, err := LoadFile(root, rel)
+		if err != nil {
+			return err
+		}
+		if isBinary(file.Content) {
+			return nil
+		}
+
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan directory: %w", err)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+
+	return files, nil
+}
+
+// matches checks a slash-separated relative path against the include and exclude patterns
+func (o ScanOptions) matches(rel string) bool {
+	if len(o.Include) > 0 && !MatchAny(o.Include, rel) {
+		return false
+	}
+	return !MatchAny(o.Exclude, rel)
+}
+
+// MatchAny reports whether a slash-separated path matches any of the glob
+// patterns. Patterns without a slash match the base name; "**" matches any
+// number of directories.
+func MatchAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if MatchGlob(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchGlob reports whether a slash-separated path matches a single glob pattern
+func MatchGlob(pattern, path string) bool {
+	if !strings.Contains(pattern, "/") {
+		matched, _ := filepath.Match(pattern, filepath.Base(filepath.FromSlash(path)))
+		return matched
+	}
+
+	re, err := globToRegexp(pattern)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(path)
+}
+
+// globToRegexp converts a glob pattern with "**" support into an anchored regexp
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '*' && i+1 < len(pattern) && pattern[i+1] == '*':
+			i++
+			if i+1 < len(pattern) && pattern[i+1] == '/' {
+				// "**/" matches zero or more directories
+				i++
+				b.WriteString("(?:.*/)?")
+			} else {
+				b.WriteString(".*")
+			}
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// isBinary reports whether content looks like binary data
+func isBinary(content string) bool {
+	sample := content
+	if len(sample) > 8000 {
+		sample = sample[:8000]
+	}
+	return strings.IndexByte(sample, 0) >= 0
+}
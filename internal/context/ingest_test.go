@@ -0,0 +1,148 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTree creates files below root from a map of slash-separated paths to content
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		full := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", rel, err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", rel, err)
+		}
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{"auth/login.go": "package auth\n"})
+
+	file, err := LoadFile(root, "auth/login.go")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	if file.Path != "auth/login.go" {
+		t.Errorf("Path = %v, want auth/login.go", file.Path)
+	}
+	if file.Name != "login.go" {
+		t.Errorf("Name = %v, want login.go", file.Name)
+	}
+	if file.Language != "go" {
+		t.Errorf("Language = %v, want go", file.Language)
+	}
+	if file.Content != "package auth\n" {
+		t.Errorf("Content = %q, want %q", file.Content, "package auth\n")
+	}
+	if file.Hash != HashContent("package auth\n") {
+		t.Errorf("Hash = %v, want content hash", file.Hash)
+	}
+
+	if _, err := LoadFile(root, "missing.go"); err == nil {
+		t.Error("Expected error for missing file")
+	}
+	if _, err := LoadFile(root, "auth"); err == nil {
+		t.Error("Expected error for directory")
+	}
+}
+
+func TestScanDirectory(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"main.go":                 "package main\n",
+		"main_test.go":            "package main\n",
+		"internal/auth/login.go":  "package auth\n",
+		"docs/README.md":          "# docs\n",
+		"node_modules/x/index.js": "ignored\n",
+		".git/config":             "ignored\n",
+		"big.log":                 "0123456789",
+		"image.png":               "\x89PNG\x00\x00",
+	})
+
+	tests := []struct {
+		name string
+		opts ScanOptions
+		want []string
+	}{
+		{
+			name: "all files",
+			opts: ScanOptions{},
+			want: []string{"big.log", "docs/README.md", "internal/auth/login.go", "main.go", "main_test.go"},
+		},
+		{
+			name: "include base name glob",
+			opts: ScanOptions{Include: []string{"*.go"}},
+			want: []string{"internal/auth/login.go", "main.go", "main_test.go"},
+		},
+		{
+			name: "include and exclude",
+			opts: ScanOptions{Include: []string{"**/*.go"}, Exclude: []string{"*_test.go"}},
+			want: []string{"internal/auth/login.go", "main.go"},
+		},
+		{
+			name: "directory glob",
+			opts: ScanOptions{Include: []string{"internal/**"}},
+			want: []string{"internal/auth/login.go"},
+		},
+		{
+			name: "max file size",
+			opts: ScanOptions{MaxFileSize: 9},
+			want: []string{"docs/README.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := ScanDirectory(root, tt.opts)
+			if err != nil {
+				t.Fatalf("ScanDirectory() error = %v", err)
+			}
+
+			var got []string
+			for _, f := range files {
+				got = append(got, f.Path)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("ScanDirectory() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ScanDirectory()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "internal/auth/login.go", true},
+		{"*.go", "main.py", false},
+		{"internal/*.go", "internal/a.go", true},
+		{"internal/*.go", "internal/auth/a.go", false},
+		{"internal/**/*.go", "internal/a.go", true},
+		{"internal/**/*.go", "internal/auth/a.go", true},
+		{"**/testdata/**", "pkg/testdata/x.json", true},
+		{"logs/?.log", "logs/a.log", true},
+		{"logs/?.log", "logs/ab.log", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchGlob(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
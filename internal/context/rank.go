@@ -0,0 +1,195 @@
+package context
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 tuning parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	// pathBoost is how many times path terms are counted, since a file's
+	// location is usually a strong relevance signal
+	pathBoost = 3
+)
+
+// stopWords are common English and keyword terms that carry no relevance
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "this": true, "that": true,
+	"with": true, "from": true, "are": true, "was": true, "not": true,
+	"but": true, "have": true, "has": true, "you": true, "can": true,
+	"how": true, "what": true, "why": true, "does": true, "when": true,
+	"where": true, "which": true, "into": true, "its": true, "our": true,
+	"func": true, "return": true, "var": true, "const": true, "nil": true,
+	"err": true, "if": true, "else": true, "string": true, "int": true,
+}
+
+// Index is an offline BM25 index over file paths, identifiers and comments
+type Index struct {
+	docs   []indexedFile
+	df     map[string]int
+	avgLen float64
+}
+
+// indexedFile holds the term frequencies of one file
+type indexedFile struct {
+	file   *File
+	tf     map[string]int
+	length int
+}
+
+// Ranked is a file with its relevance score for a query
+type Ranked struct {
+	File  *File
+	Score float64
+}
+
+// NewIndex builds a BM25 index over the given files
+func NewIndex(files []*File) *Index {
+	idx := &Index{df: make(map[string]int)}
+
+	total := 0
+	for _, f := range files {
+		doc := indexedFile{file: f, tf: make(map[string]int)}
+
+		for _, term := range Tokenize(f.Path) {
+			doc.tf[term] += pathBoost
+			doc.length += pathBoost
+		}
+		for _, term := range Tokenize(f.Content) {
+			doc.tf[term]++
+			doc.length++
+		}
+
+		for term := range doc.tf {
+			idx.df[term]++
+		}
+
+		total += doc.length
+		idx.docs = append(idx.docs, doc)
+	}
+
+	if len(idx.docs) > 0 {
+		idx.avgLen = float64(total) / float64(len(idx.docs))
+	}
+
+	return idx
+}
+
+// Rank scores every indexed file against the query and returns those with a
+// positive score, best first
+func (idx *Index) Rank(query string) []Ranked {
+	terms := Tokenize(query)
+	n := float64(len(idx.docs))
+
+	var ranked []Ranked
+	for _, doc := range idx.docs {
+		score := 0.0
+		for _, term := range terms {
+			tf := float64(doc.tf[term])
+			if tf == 0 {
+				continue
+			}
+
+			df := float64(idx.df[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := tf + bm25K1*(1-bm25B+bm25B*float64(doc.length)/idx.avgLen)
+			score += idf * tf * (bm25K1 + 1) / norm
+		}
+
+		if score > 0 {
+			ranked = append(ranked, Ranked{File: doc.file, Score: score})
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+
+	return ranked
+}
+
+// SelectWithinBudget picks up to k ranked files, in order, whose combined
+// tokens fit the budget. Files that do not fit are skipped so smaller,
+// lower-ranked files can still be used. A k of zero means no limit.
+func SelectWithinBudget(ranked []Ranked, k, budget int) []*File {
+	var selected []*File
+	used := 0
+
+	for _, r := range ranked {
+		if k > 0 && len(selected) >= k {
+			break
+		}
+		if used+r.File.Tokens > budget {
+			continue
+		}
+		selected = append(selected, r.File)
+		used += r.File.Tokens
+	}
+
+	return selected
+}
+
+// Tokenize splits text into lowercase search terms. Identifiers are split on
+// camelCase and snake_case boundaries and also kept whole, so "parseConfig"
+// yields "parseconfig", "parse" and "config".
+func Tokenize(text string) []string {
+	var terms []string
+
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+
+	for _, word := range words {
+		parts := splitIdentifier(word)
+		if len(parts) > 1 {
+			terms = appendTerm(terms, strings.ToLower(strings.ReplaceAll(word, "_", "")))
+		}
+		for _, part := range parts {
+			terms = appendTerm(terms, strings.ToLower(part))
+		}
+	}
+
+	return terms
+}
+
+// appendTerm adds a term unless it is too short, numeric or a stop word
+func appendTerm(terms []string, term string) []string {
+	if len(term) < 3 || stopWords[term] {
+		return terms
+	}
+	if strings.IndexFunc(term, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+		return terms
+	}
+	return append(terms, term)
+}
+
+// splitIdentifier splits an identifier on underscores and case changes,
+// keeping acronyms together ("HTTPServer" becomes "HTTP", "Server")
+func splitIdentifier(word string) []string {
+	var parts []string
+
+	for _, chunk := range strings.Split(word, "_") {
+		runes := []rune(chunk)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			prev, cur := runes[i-1], runes[i]
+			lowerToUpper := unicode.IsLower(prev) && unicode.IsUpper(cur)
+			acronymEnd := unicode.IsUpper(prev) && unicode.IsUpper(cur) &&
+				i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if lowerToUpper || acronymEnd {
+				parts = append(parts, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			parts = append(parts, string(runes[start:]))
+		}
+	}
+
+	return parts
+}
@@ -0,0 +1,110 @@
+package context
+
+import (
+	"strings"
+	"testing"
+)
+
+func rankTestFile(path, content string, tokens int) *File {
+	f := NewFile(path, path)
+	f.Content = content
+	f.Tokens = tokens
+	return f
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"parseConfig", "parseconfig parse config"},
+		{"HTTPServer", "httpserver http server"},
+		{"load_user_session", "loadusersession load user session"},
+		{"internal/auth/login.go", "internal auth login"},
+		{"// Validate the token for this user", "validate token user"},
+		{"x := 42", ""},
+	}
+
+	for _, tt := range tests {
+		got := strings.Join(Tokenize(tt.text), " ")
+		if got != tt.want {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestIndexRank(t *testing.T) {
+	files := []*File{
+		rankTestFile("internal/auth/login.go", "package auth\n\n// Login checks the password and creates a session\nfunc Login(user, password string) {}\n", 30),
+		rankTestFile("internal/auth/session.go", "package auth\n\ntype Session struct{ Token string }\n", 20),
+		rankTestFile("internal/storage/sqlite.go", "package storage\n\n// SaveAgent persists an agent\nfunc SaveAgent() {}\n", 25),
+		rankTestFile("README.md", "# aui\n\nTerminal agents.\n", 10),
+	}
+
+	idx := NewIndex(files)
+
+	ranked := idx.Rank("why does login fail with a wrong password?")
+	if len(ranked) == 0 {
+		t.Fatal("Rank() returned no results")
+	}
+	if ranked[0].File.Path != "internal/auth/login.go" {
+		t.Errorf("Top result = %s, want internal/auth/login.go", ranked[0].File.Path)
+	}
+
+	for i := 1; i < len(ranked); i++ {
+		if ranked[i].Score > ranked[i-1].Score {
+			t.Errorf("Results not sorted by score at %d", i)
+		}
+	}
+
+	// Path terms count, so "storage" finds the storage file
+	ranked = idx.Rank("storage")
+	if len(ranked) != 1 || ranked[0].File.Path != "internal/storage/sqlite.go" {
+		t.Errorf("Rank(storage) = %v, want only internal/storage/sqlite.go", ranked)
+	}
+
+	if ranked := idx.Rank("kubernetes"); len(ranked) != 0 {
+		t.Errorf("Rank() for unknown term should be empty, got %d results", len(ranked))
+	}
+}
+
+func TestIndexRankEmpty(t *testing.T) {
+	idx := NewIndex(nil)
+
+	if ranked := idx.Rank("anything"); len(ranked) != 0 {
+		t.Errorf("Rank() on empty index should be empty, got %d results", len(ranked))
+	}
+}
+
+func TestSelectWithinBudget(t *testing.T) {
+	ranked := []Ranked{
+		{File: rankTestFile("a.go", "", 50), Score: 4},
+		{File: rankTestFile("b.go", "", 80), Score: 3},
+		{File: rankTestFile("c.go", "", 30), Score: 2},
+		{File: rankTestFile("d.go", "", 10), Score: 1},
+	}
+
+	tests := []struct {
+		name   string
+		k      int
+		budget int
+		want   string
+	}{
+		{"budget skips large file", 0, 100, "a.go c.go d.go"},
+		{"top k limit", 2, 1000, "a.go b.go"},
+		{"everything fits", 0, 1000, "a.go b.go c.go d.go"},
+		{"nothing fits", 0, 5, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			for _, f := range SelectWithinBudget(ranked, tt.k, tt.budget) {
+				paths = append(paths, f.Path)
+			}
+			if got := strings.Join(paths, " "); got != tt.want {
+				t.Errorf("SelectWithinBudget() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package context
+
+import (
+	"sort"
+	"time"
+)
+
+// Summary is a shorter stand-in for a file's content. It is keyed by the
+// hash of the content it summarizes, so it stays valid until the file changes.
+type Summary struct {
+	Hash      string
+	Content   string
+	Tokens    int
+	Model     string
+	CreatedAt time.Time
+}
+
+// NewSummary creates a summary of content with the given hash
+func NewSummary(hash, content, model string) *Summary {
+	return &Summary{
+		Hash:      hash,
+		Content:   content,
+		Tokens:    EstimateTokens(content),
+		Model:     model,
+		CreatedAt: time.Now(),
+	}
+}
+
+// Summarize returns a copy of the file with its content replaced by the summary
+func (f *File) Summarize(s *Summary) *File {
+	copied := *f
+	copied.Content = s.Content
+	copied.Tokens = s.Tokens
+	copied.Summarized = true
+	return &copied
+}
+
+// PackResult is a context packed to fit a token budget
+type PackResult struct {
+	Context    *Context
+	Summarized []string // Paths whose content was replaced by a summary
+	OverBudget bool     // Still over budget after using every summary
+}
+
+// Pack fits a context into a token budget by substituting summaries, keyed
+// by content hash, for full file content. Files with the largest savings are
+// summarized first, and only as many as needed. The context is not modified;
+// a budget of zero or less means no limit.
+func Pack(ctx *Context, budget int, summaries map[string]*Summary) *PackResult {
+	packed := *ctx
+	packed.Files = append([]*File{}, ctx.Files...)
+	packed.RecalculateTokens()
+
+	result := &PackResult{Context: &packed}
+	if budget <= 0 || packed.TotalTokens <= budget {
+		return result
+	}
+
+	type candidate struct {
+		index   int
+		summary *Summary
+		savings int
+	}
+	var candidates []candidate
+	for i, f := range packed.Files {
+		s, ok := summaries[f.Hash]
+		if ok && s.Tokens < f.Tokens {
+			candidates = append(candidates, candidate{index: i, summary: s, savings: f.Tokens - s.Tokens})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].savings > candidates[j].savings
+	})
+
+	for _, c := range candidates {
+		if packed.TotalTokens <= budget {
+			break
+		}
+		f := packed.Files[c.index]
+		packed.Files[c.index] = f.Summarize(c.summary)
+		packed.TotalTokens -= c.savings
+		result.Summarized = append(result.Summarized, f.Path)
+	}
+
+	result.OverBudget = packed.TotalTokens > budget
+	return result
+}
@@ -0,0 +1,71 @@
+package context
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFileSummarize(t *testing.T) {
+	f := newTestFile("big.go", strings.Repeat("x", 400))
+	s := NewSummary(f.Hash, "Short.", "cheap")
+
+	summarized := f.Summarize(s)
+	if !summarized.Summarized || summarized.Content != "Short." || summarized.Tokens != s.Tokens {
+		t.Errorf("Summarize() = %+v", summarized)
+	}
+	if summarized.Hash != f.Hash || summarized.Path != f.Path {
+		t.Error("Summarize() should keep the file's identity")
+	}
+	if f.Summarized || f.Content == "Short." {
+		t.Error("Summarize() modified the original file")
+	}
+}
+
+func TestPack(t *testing.T) {
+	ctx := NewContext("big", "")
+	a := newTestFile("a.go", strings.Repeat("a", 4000)) // 1000 tokens
+	b := newTestFile("b.go", strings.Repeat("b", 2000)) // 500 tokens
+	c := newTestFile("c.go", strings.Repeat("c", 400))  // 100 tokens
+	ctx.AddFile(a)
+	ctx.AddFile(b)
+	ctx.AddFile(c)
+
+	summaries := map[string]*Summary{
+		a.Hash: NewSummary(a.Hash, strings.Repeat("s", 200), "m"), // 50 tokens
+		b.Hash: NewSummary(b.Hash, strings.Repeat("s", 200), "m"),
+	}
+
+	tests := []struct {
+		name       string
+		budget     int
+		summarized string
+		tokens     int
+		over       bool
+	}{
+		{"fits", 2000, "", 1600, false},
+		{"no limit", 0, "", 1600, false},
+		{"largest savings first", 1000, "a.go", 650, false},
+		{"both needed", 300, "a.go,b.go", 200, false},
+		{"still over", 100, "a.go,b.go", 200, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Pack(ctx, tt.budget, summaries)
+
+			if got := strings.Join(result.Summarized, ","); got != tt.summarized {
+				t.Errorf("Summarized = %s, want %s", got, tt.summarized)
+			}
+			if result.Context.TotalTokens != tt.tokens {
+				t.Errorf("TotalTokens = %d, want %d", result.Context.TotalTokens, tt.tokens)
+			}
+			if result.OverBudget != tt.over {
+				t.Errorf("OverBudget = %v, want %v", result.OverBudget, tt.over)
+			}
+		})
+	}
+
+	if ctx.TotalTokens != 1600 || ctx.Files[0].Summarized {
+		t.Error("Pack() modified the original context")
+	}
+}
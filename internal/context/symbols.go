@@ -0,0 +1,391 @@
+package context
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// goDecl is a top-level Go declaration that can be selected by name
+type goDecl struct {
+	name  string
+	file  *ast.File
+	fn    *ast.FuncDecl // set for funcs and methods
+	gen   *ast.GenDecl  // set for types, vars and consts
+	spec  ast.Spec
+	order int
+}
+
+// goPackage holds the parsed files of a single Go package
+type goPackage struct {
+	name  string
+	fset  *token.FileSet
+	files []*ast.File
+	decls map[string]*goDecl
+	uses  map[*ast.Ident]types.Object // What each identifier refers to
+	scope *types.Scope                // Package-level objects
+}
+
+// stubImporter stands in for the packages a package imports, which are not
+// loaded: they resolve to empty packages, so only names declared in the
+// package itself are typed
+type stubImporter struct{}
+
+// Import returns an empty package for path
+func (stubImporter) Import(path string) (*types.Package, error) {
+	pkg := types.NewPackage(path, filepath.Base(path))
+	pkg.MarkComplete()
+	return pkg, nil
+}
+
+// ExtractGoSymbols parses a single Go source file and returns a synthetic file
+// containing the named declarations with their doc comments, followed by the
+// signatures of package-level identifiers they reference. Methods are named
+// "Type.Method". With no symbols, the file's exported API is returned.
+func ExtractGoSymbols(path string, src []byte, symbols []string) (*File, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	pkg := newGoPackage(fset, []*ast.File{f})
+	return pkg.extract(path, symbols)
+}
+
+// ExtractGoPackage parses the non-test Go files in dir and returns a synthetic
+// file with the named declarations, as ExtractGoSymbols does for one file
+func ExtractGoPackage(dir string, symbols []string) (*File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package directory: %w", err)
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		files = append(files, f)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+
+	pkg := newGoPackage(fset, files)
+	return pkg.extract(dir, symbols)
+}
+
+// newGoPackage indexes the top-level declarations of the given files
+func newGoPackage(fset *token.FileSet, files []*ast.File) *goPackage {
+	pkg := &goPackage{
+		name:  files[0].Name.Name,
+		fset:  fset,
+		files: files,
+		decls: make(map[string]*goDecl),
+	}
+
+	// Type-checking resolves each identifier by scope, so names declared
+	// inside a function are not mistaken for package-level ones. Errors from
+	// the stubbed imports are expected and ignored.
+	info := &types.Info{Uses: make(map[*ast.Ident]types.Object)}
+	conf := types.Config{Importer: stubImporter{}, FakeImportC: true, Error: func(error) {}}
+	checked, _ := conf.Check(pkg.name, fset, files, info)
+	pkg.uses, pkg.scope = info.Uses, checked.Scope()
+
+	order := 0
+	add := func(d *goDecl) {
+		d.order = order
+		order++
+		pkg.decls[d.name] = d
+	}
+
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				add(&goDecl{name: funcName(d), file: f, fn: d})
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						add(&goDecl{name: s.Name.Name, file: f, gen: d, spec: s})
+					case *ast.ValueSpec:
+						for _, n := range s.Names {
+							if n.Name == "_" {
+								continue
+							}
+							add(&goDecl{name: n.Name, file: f, gen: d, spec: s})
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return pkg
+}
+
+// funcName returns "Name" for functions and "Recv.Name" for methods
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+
+	expr := fn.Recv.List[0].Type
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+			continue
+		case *ast.IndexExpr:
+			expr = t.X
+			continue
+		case *ast.IndexListExpr:
+			expr = t.X
+			continue
+		case *ast.Ident:
+			return t.Name + "." + fn.Name.Name
+		}
+		return fn.Name.Name
+	}
+}
+
+// extract renders the selected declarations and referenced signatures into a file
+func (p *goPackage) extract(source string, symbols []string) (*File, error) {
+	selected := make(map[*goDecl]bool)
+	var selectedSpecs []*goDecl
+
+	if len(symbols) == 0 {
+		for _, d := range p.decls {
+			if ast.IsExported(lastSegment(d.name)) {
+				selectedSpecs = append(selectedSpecs, d)
+			}
+		}
+	} else {
+		for _, sym := range symbols {
+			d, ok := p.decls[sym]
+			if !ok {
+				return nil, fmt.Errorf("symbol not found in %s: %s", source, sym)
+			}
+			selectedSpecs = append(selectedSpecs, d)
+		}
+	}
+
+	for _, d := range selectedSpecs {
+		selected[d] = true
+	}
+
+	// Without explicit symbols the exported API is rendered as an outline, so
+	// there is nothing further to reference
+	outline := len(symbols) == 0
+
+	// Collect package-level identifiers referenced by the selection, plus the
+	// methods of any selected type
+	referenced := make(map[*goDecl]bool)
+	if !outline {
+		for _, d := range selectedSpecs {
+			p.collectReferences(d, selected, referenced)
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Extracted from %s\npackage %s\n", source, p.name)
+
+	written := make(map[ast.Spec]bool)
+	for _, d := range sortDecls(selected) {
+		buf.WriteString("\n")
+		if err := p.writeDecl(&buf, d, !outline, written); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(referenced) > 0 {
+		buf.WriteString("\n// Referenced declarations (signatures only)\n")
+		for _, d := range sortDecls(referenced) {
+			buf.WriteString("\n")
+			if err := p.writeDecl(&buf, d, false, written); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	path := source
+	if len(symbols) > 0 {
+		path = source + "#" + strings.Join(symbols, ",")
+	}
+
+	file := NewFile(path, filepath.Base(source))
+	file.Language = "go"
+	file.ModifiedAt = time.Now()
+	file.SetContent(buf.String())
+	return file, nil
+}
+
+// collectReferences records the package-level declarations used by d and,
+// for a type, its methods
+func (p *goPackage) collectReferences(d *goDecl, selected, referenced map[*goDecl]bool) {
+	visit := func(n ast.Node) bool {
+		// Locals, fields, methods and imported names resolve to objects
+		// outside the package scope
+		if x, ok := n.(*ast.Ident); ok {
+			if obj := p.uses[x]; obj != nil && obj.Parent() == p.scope {
+				p.reference(x.Name, selected, referenced)
+			}
+		}
+		return true
+	}
+
+	if d.fn != nil {
+		if d.fn.Recv != nil {
+			ast.Inspect(d.fn.Recv, visit)
+		}
+		ast.Inspect(d.fn.Type, visit)
+		if d.fn.Body != nil {
+			ast.Inspect(d.fn.Body, visit)
+		}
+		return
+	}
+
+	switch s := d.spec.(type) {
+	case *ast.TypeSpec:
+		ast.Inspect(s.Type, visit)
+		prefix := d.name + "."
+		for name, m := range p.decls {
+			if strings.HasPrefix(name, prefix) && !selected[m] {
+				referenced[m] = true
+			}
+		}
+	case *ast.ValueSpec:
+		if s.Type != nil {
+			ast.Inspect(s.Type, visit)
+		}
+		for _, v := range s.Values {
+			ast.Inspect(v, visit)
+		}
+	}
+}
+
+// reference marks a package-level declaration as referenced unless already selected
+func (p *goPackage) reference(name string, selected, referenced map[*goDecl]bool) {
+	if d, ok := p.decls[name]; ok && !selected[d] {
+		referenced[d] = true
+	}
+}
+
+// writeDecl prints a declaration. Full declarations keep bodies and interior
+// comments; otherwise functions are reduced to their signature.
+func (p *goPackage) writeDecl(buf *bytes.Buffer, d *goDecl, full bool, written map[ast.Spec]bool) error {
+	if d.fn != nil {
+		// The doc comment is written separately so it is not printed twice
+		fn := *d.fn
+		fn.Doc = nil
+		if !full {
+			fn.Body = nil
+		}
+		writeDoc(buf, d.fn.Doc)
+		return p.print(buf, d.file, &fn, full)
+	}
+
+	// Value specs declaring several names are printed once
+	if written[d.spec] {
+		return nil
+	}
+	written[d.spec] = true
+
+	doc := d.gen.Doc
+	var spec ast.Spec
+	switch s := d.spec.(type) {
+	case *ast.TypeSpec:
+		if s.Doc != nil {
+			doc = s.Doc
+		}
+		c := *s
+		c.Doc, c.Comment = nil, nil
+		spec = &c
+	case *ast.ValueSpec:
+		if s.Doc != nil {
+			doc = s.Doc
+		}
+		c := *s
+		c.Doc, c.Comment = nil, nil
+		spec = &c
+	}
+
+	writeDoc(buf, doc)
+	buf.WriteString(d.gen.Tok.String() + " ")
+	return p.print(buf, d.file, spec, full)
+}
+
+// print formats a node, keeping the comments inside it when full is set
+func (p *goPackage) print(buf *bytes.Buffer, file *ast.File, node ast.Node, full bool) error {
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+
+	var target any = node
+	if full {
+		var comments []*ast.CommentGroup
+		for _, cg := range file.Comments {
+			if cg.Pos() > node.Pos() && cg.End() < node.End() {
+				comments = append(comments, cg)
+			}
+		}
+		target = &printer.CommentedNode{Node: node, Comments: comments}
+	}
+
+	if err := cfg.Fprint(buf, p.fset, target); err != nil {
+		return fmt.Errorf("failed to print declaration: %w", err)
+	}
+	buf.WriteString("\n")
+	return nil
+}
+
+// writeDoc writes a doc comment group as line comments
+func writeDoc(buf *bytes.Buffer, doc *ast.CommentGroup) {
+	if doc == nil {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimRight(doc.Text(), "\n"), "\n") {
+		if line == "" {
+			buf.WriteString("//\n")
+		} else {
+			buf.WriteString("// " + line + "\n")
+		}
+	}
+}
+
+// sortDecls returns the declarations of a set in source order
+func sortDecls(set map[*goDecl]bool) []*goDecl {
+	decls := make([]*goDecl, 0, len(set))
+	for d := range set {
+		decls = append(decls, d)
+	}
+	sort.Slice(decls, func(i, j int) bool {
+		return decls[i].order < decls[j].order
+	})
+	return decls
+}
+
+// lastSegment returns the method name of "Type.Method" or the name itself
+func lastSegment(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
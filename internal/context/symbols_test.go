@@ -0,0 +1,215 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const symbolsTestSource = `package auth
+
+import "errors"
+
+// ErrExpired is returned when a session has expired
+var ErrExpired = errors.New("session expired")
+
+// maxAge is the session lifetime in seconds
+const maxAge = 3600
+
+// Session holds an authenticated user session
+type Session struct {
+	User string
+	Age  int
+}
+
+// Valid reports whether the session is still usable
+func (s *Session) Valid() bool {
+	return s.Age < maxAge
+}
+
+// Login authenticates a user and returns a session
+func Login(user string) (*Session, error) {
+	// Reject anonymous logins
+	if user == "" {
+		return nil, ErrExpired
+	}
+	return &Session{User: user}, nil
+}
+
+// Logout ends a session
+func Logout(s *Session) {
+	s.Age = maxAge
+}
+
+func helper() {}
+`
+
+func TestExtractGoSymbols(t *testing.T) {
+	file, err := ExtractGoSymbols("auth/login.go", []byte(symbolsTestSource), []string{"Login"})
+	if err != nil {
+		t.Fatalf("ExtractGoSymbols() error = %v", err)
+	}
+
+	if file.Language != "go" {
+		t.Errorf("Language = %v, want go", file.Language)
+	}
+
+	if file.Path != "auth/login.go#Login" {
+		t.Errorf("Path = %v, want auth/login.go#Login", file.Path)
+	}
+
+	if file.Hash == "" || file.Tokens == 0 || file.Size != int64(len(file.Content)) {
+		t.Errorf("Metadata not populated: hash=%q tokens=%d size=%d", file.Hash, file.Tokens, file.Size)
+	}
+
+	wantContains := []string{
+		"package auth",
+		"// Login authenticates a user and returns a session",
+		"// Reject anonymous logins", // Interior comments are kept
+		"return &Session{User: user}, nil",
+		"// Referenced declarations",
+		"type Session struct",
+		"var ErrExpired = errors.New",
+	}
+
+	// Valid is a method of a referenced (not selected) type, so it is omitted
+	wantMissing := []string{
+		"func Logout",
+		"func helper",
+		"Valid()",
+	}
+
+	for _, want := range wantContains {
+		if !strings.Contains(file.Content, want) {
+			t.Errorf("Content should contain %q, got:\n%s", want, file.Content)
+		}
+	}
+	for _, unwanted := range wantMissing {
+		if strings.Contains(file.Content, unwanted) {
+			t.Errorf("Content should not contain %q, got:\n%s", unwanted, file.Content)
+		}
+	}
+}
+
+func TestExtractGoSymbolsSkipsLocalNames(t *testing.T) {
+	src := symbolsTestSource + `
+// Renew starts a new session, shadowing package-level names
+func Renew(user string) int {
+	maxAge := 60
+	for _, Session := range []string{user} {
+		_ = Session
+	}
+	return maxAge
+}
+`
+	file, err := ExtractGoSymbols("auth/login.go", []byte(src), []string{"Renew"})
+	if err != nil {
+		t.Fatalf("ExtractGoSymbols() error = %v", err)
+	}
+
+	for _, unwanted := range []string{"// Referenced declarations", "const maxAge", "type Session"} {
+		if strings.Contains(file.Content, unwanted) {
+			t.Errorf("Content should not contain %q for local names, got:\n%s", unwanted, file.Content)
+		}
+	}
+}
+
+func TestExtractGoSymbolsTypeIncludesMethodSignatures(t *testing.T) {
+	file, err := ExtractGoSymbols("auth/login.go", []byte(symbolsTestSource), []string{"Session"})
+	if err != nil {
+		t.Fatalf("ExtractGoSymbols() error = %v", err)
+	}
+
+	if !strings.Contains(file.Content, "// Valid reports whether the session is still usable\nfunc (s *Session) Valid() bool\n") {
+		t.Errorf("Selected type should include method signatures, got:\n%s", file.Content)
+	}
+
+	if strings.Contains(file.Content, "maxAge") {
+		t.Errorf("Method bodies should not be included, got:\n%s", file.Content)
+	}
+}
+
+func TestExtractGoSymbolsMethod(t *testing.T) {
+	file, err := ExtractGoSymbols("auth/login.go", []byte(symbolsTestSource), []string{"Session.Valid"})
+	if err != nil {
+		t.Fatalf("ExtractGoSymbols() error = %v", err)
+	}
+
+	for _, want := range []string{"return s.Age < maxAge", "const maxAge = 3600", "type Session struct"} {
+		if !strings.Contains(file.Content, want) {
+			t.Errorf("Content should contain %q, got:\n%s", want, file.Content)
+		}
+	}
+}
+
+func TestExtractGoSymbolsOutline(t *testing.T) {
+	file, err := ExtractGoSymbols("auth/login.go", []byte(symbolsTestSource), nil)
+	if err != nil {
+		t.Fatalf("ExtractGoSymbols() error = %v", err)
+	}
+
+	if file.Path != "auth/login.go" {
+		t.Errorf("Path = %v, want auth/login.go", file.Path)
+	}
+
+	for _, want := range []string{"func Login(user string) (*Session, error)\n", "func Logout(s *Session)\n", "type Session struct"} {
+		if !strings.Contains(file.Content, want) {
+			t.Errorf("Outline should contain %q, got:\n%s", want, file.Content)
+		}
+	}
+
+	for _, unwanted := range []string{"helper", "maxAge", "return s."} {
+		if strings.Contains(file.Content, unwanted) {
+			t.Errorf("Outline should not contain %q, got:\n%s", unwanted, file.Content)
+		}
+	}
+}
+
+func TestExtractGoSymbolsErrors(t *testing.T) {
+	if _, err := ExtractGoSymbols("bad.go", []byte("not go"), nil); err == nil {
+		t.Error("Expected parse error for invalid source")
+	}
+
+	if _, err := ExtractGoSymbols("auth/login.go", []byte(symbolsTestSource), []string{"Missing"}); err == nil {
+		t.Error("Expected error for unknown symbol")
+	}
+}
+
+func TestExtractGoPackage(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"login.go":      symbolsTestSource,
+		"token.go":      "package auth\n\n// Token signs a session\nfunc Token(s *Session) string {\n\treturn s.User\n}\n",
+		"login_test.go": "package auth\n\nfunc TestOnly() {}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	file, err := ExtractGoPackage(dir, []string{"Token"})
+	if err != nil {
+		t.Fatalf("ExtractGoPackage() error = %v", err)
+	}
+
+	for _, want := range []string{"return s.User", "type Session struct"} {
+		if !strings.Contains(file.Content, want) {
+			t.Errorf("Content should contain %q, got:\n%s", want, file.Content)
+		}
+	}
+
+	outline, err := ExtractGoPackage(dir, nil)
+	if err != nil {
+		t.Fatalf("ExtractGoPackage() outline error = %v", err)
+	}
+	if strings.Contains(outline.Content, "TestOnly") {
+		t.Error("Test files should be excluded from package extraction")
+	}
+
+	if _, err := ExtractGoPackage(t.TempDir(), nil); err == nil {
+		t.Error("Expected error for directory without Go files")
+	}
+}
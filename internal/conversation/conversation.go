@@ -0,0 +1,206 @@
+package conversation
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/render"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// Status is the state of an assistant message
+type Status string
+
+const (
+	StatusDone      Status = ""
+	StatusStreaming Status = "streaming"
+	StatusCancelled Status = "cancelled"
+	StatusFailed    Status = "failed"
+)
+
+// Message is one turn of a conversation. Messages form a tree through
+// ParentID, so a conversation can hold alternative replies to the same turn.
+type Message struct {
+	ID        string
+	ParentID  string // Empty for the first message
+	Role      api.Role
+	Content   string
+	AgentID   string // Agent that wrote an assistant message
+	Model     string
+	Status    Status
+	Error     string   // Why the reply failed
+	ToolCalls []string // Tools the agent called while replying, see tools.Describe
+	Usage     api.TokenUsage
+	Cost      float64 // USD
+	CreatedAt time.Time
+}
+
+// Conversation is a chat between the user and one or more agents about an
+// optional context
+type Conversation struct {
+	ID        string
+	ContextID string
+	Messages  []*Message // Every message, in the order they were added
+	Head      string     // ID of the last message of the current thread
+	CreatedAt time.Time
+}
+
+// New creates an empty conversation about the given context, which may be
+// empty for none
+func New(contextID string) *Conversation {
+	return &Conversation{
+		ID:        generateID(),
+		ContextID: contextID,
+		CreatedAt: time.Now(),
+	}
+}
+
+// generateID generates a random ID for a conversation or message
+func generateID() string {
+	bytes := make([]byte, 8)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+// Append adds a message after the head of the current thread and makes it
+// the new head
+func (c *Conversation) Append(role api.Role, content string) *Message {
+	return c.AppendTo(c.Head, role, content)
+}
+
+// AppendTo adds a message after the given parent, which may be empty for the
+// first message, and makes it the new head. A parent that already has
+// replies gets a sibling version.
+func (c *Conversation) AppendTo(parentID string, role api.Role, content string) *Message {
+	m := &Message{
+		ID:        generateID(),
+		ParentID:  parentID,
+		Role:      role,
+		Content:   content,
+		CreatedAt: time.Now(),
+	}
+	c.Messages = append(c.Messages, m)
+	c.Head = m.ID
+	return m
+}
+
+// Get returns the message with the given ID, or nil
+func (c *Conversation) Get(id string) *Message {
+	for _, m := range c.Messages {
+		if m.ID == id {
+			return m
+		}
+	}
+	return nil
+}
+
+// Remove deletes a message that has no replies, moving the head to its
+// parent if it was the head
+func (c *Conversation) Remove(id string) {
+	if len(c.Children(id)) > 0 {
+		return
+	}
+	for i, m := range c.Messages {
+		if m.ID == id {
+			c.Messages = append(c.Messages[:i:i], c.Messages[i+1:]...)
+			if c.Head == id {
+				c.Head = m.ParentID
+			}
+			return
+		}
+	}
+}
+
+// Children returns the replies to a message, oldest first
+func (c *Conversation) Children(id string) []*Message {
+	var children []*Message
+	for _, m := range c.Messages {
+		if m.ParentID == id {
+			children = append(children, m)
+		}
+	}
+	return children
+}
+
+// Siblings returns the versions of a message: every message with the same
+// parent, including itself, oldest first
+func (c *Conversation) Siblings(id string) []*Message {
+	m := c.Get(id)
+	if m == nil {
+		return nil
+	}
+	return c.Children(m.ParentID)
+}
+
+// Select makes the thread through the given message current, following its
+// latest replies down to a leaf
+func (c *Conversation) Select(id string) {
+	if c.Get(id) == nil {
+		return
+	}
+	for {
+		children := c.Children(id)
+		if len(children) == 0 {
+			break
+		}
+		id = children[len(children)-1].ID
+	}
+	c.Head = id
+}
+
+// Thread returns the messages from the first one to the head
+func (c *Conversation) Thread() []*Message {
+	var thread []*Message
+	for m := c.Get(c.Head); m != nil; m = c.Get(m.ParentID) {
+		thread = append([]*Message{m}, thread...)
+	}
+	return thread
+}
+
+// Usage returns the tokens and cost of every reply in the conversation,
+// including replies that are no longer on the current thread
+func (c *Conversation) Usage() (api.TokenUsage, float64) {
+	var usage api.TokenUsage
+	var cost float64
+	for _, m := range c.Messages {
+		usage = usage.Add(m.Usage)
+		cost += m.Cost
+	}
+	return usage, cost
+}
+
+// Request builds the request for the current thread, which must end with a
+// user message. The context, if not nil, is rendered into the first user
+// message so it stays attached on every turn.
+func (c *Conversation) Request(ctx *context.Context, opts render.Options) (*api.Request, error) {
+	thread := c.Thread()
+	if len(thread) == 0 || thread[len(thread)-1].Role != api.RoleUser {
+		return nil, fmt.Errorf("conversation does not end with a user message")
+	}
+
+	if ctx == nil {
+		ctx = &context.Context{}
+	}
+	req, err := render.Request(ctx, thread[0].Content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range thread[1:] {
+		// Replies cut short before any text are left out, and the turns
+		// around them joined, since providers expect roles to alternate
+		if m.Content == "" && m.Status != StatusDone {
+			continue
+		}
+		last := &req.Messages[len(req.Messages)-1]
+		if last.Role == m.Role {
+			last.Content += "\n\n" + m.Content
+			continue
+		}
+		req.Messages = append(req.Messages, api.Message{Role: m.Role, Content: m.Content})
+	}
+	return req, nil
+}
@@ -0,0 +1,159 @@
+package conversation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/render"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+func TestConversationThread(t *testing.T) {
+	c := New("ctx1")
+
+	if thread := c.Thread(); len(thread) != 0 {
+		t.Errorf("Thread() of a new conversation = %v, want empty", thread)
+	}
+
+	first := c.Append(api.RoleUser, "Why does login fail?")
+	reply := c.Append(api.RoleAssistant, "The token expired.")
+	followUp := c.Append(api.RoleUser, "How do I fix it?")
+
+	if reply.ParentID != first.ID || followUp.ParentID != reply.ID || c.Head != followUp.ID {
+		t.Error("Append() should chain messages and move the head")
+	}
+
+	thread := c.Thread()
+	if len(thread) != 3 || thread[0] != first || thread[2] != followUp {
+		t.Errorf("Thread() = %v, want the three messages in order", thread)
+	}
+
+	if c.Get(reply.ID) != reply || c.Get("missing") != nil {
+		t.Error("Get() should find messages by ID")
+	}
+}
+
+func TestConversationUsage(t *testing.T) {
+	c := New("")
+	c.Append(api.RoleUser, "hi")
+	a := c.Append(api.RoleAssistant, "hello")
+	a.Usage = api.TokenUsage{InputTokens: 100, OutputTokens: 10}
+	a.Cost = 0.25
+
+	c.Head = ""
+	b := c.Append(api.RoleAssistant, "hey")
+	b.Usage = api.TokenUsage{InputTokens: 50, OutputTokens: 5}
+	b.Cost = 0.5
+
+	usage, cost := c.Usage()
+	if usage.InputTokens != 150 || usage.OutputTokens != 15 || cost != 0.75 {
+		t.Errorf("Usage() = %+v, %v; want 150/15, 0.75", usage, cost)
+	}
+}
+
+func TestConversationRequest(t *testing.T) {
+	ctx := context.NewContext("auth", "")
+	ctx.SystemPrompt = "Be brief."
+	f := context.NewFile("auth.go", "auth.go")
+	f.SetContent("package auth")
+	ctx.AddFile(f)
+
+	c := New(ctx.ID)
+	if _, err := c.Request(ctx, render.Options{Format: render.FormatMarkdown}); err == nil {
+		t.Error("Request() of an empty conversation should fail")
+	}
+
+	c.Append(api.RoleUser, "Why does login fail?")
+	c.Append(api.RoleAssistant, "The token expired.")
+	c.Append(api.RoleUser, "How do I fix it?")
+
+	req, err := c.Request(ctx, render.Options{Format: render.FormatMarkdown})
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	if req.System != "Be brief." || len(req.Messages) != 3 {
+		t.Fatalf("Request() = %+v", req)
+	}
+	if first := req.Messages[0].Content; !strings.Contains(first, "package auth") || !strings.HasSuffix(first, "Why does login fail?") {
+		t.Errorf("first message should hold the context then the prompt, got %q", first)
+	}
+	if req.Messages[1].Role != api.RoleAssistant || req.Messages[2].Content != "How do I fix it?" {
+		t.Errorf("later messages = %+v", req.Messages[1:])
+	}
+
+	noContext, err := c.Request(nil, render.Options{Format: render.FormatMarkdown})
+	if err != nil || noContext.Messages[0].Content != "Why does login fail?" {
+		t.Errorf("Request(nil) = %+v, %v", noContext, err)
+	}
+
+	c.Append(api.RoleAssistant, "Refresh it.")
+	if _, err := c.Request(ctx, render.Options{Format: render.FormatMarkdown}); err == nil {
+		t.Error("Request() should fail when the thread ends with a reply")
+	}
+}
+
+func TestConversationVersions(t *testing.T) {
+	c := New("")
+	question := c.Append(api.RoleUser, "Name a color")
+	first := c.Append(api.RoleAssistant, "Red")
+	followUp := c.Append(api.RoleUser, "Another?")
+
+	second := c.AppendTo(question.ID, api.RoleAssistant, "Blue")
+	if c.Head != second.ID || second.ParentID != question.ID {
+		t.Error("AppendTo() should add a sibling and make it the head")
+	}
+
+	siblings := c.Siblings(first.ID)
+	if len(siblings) != 2 || siblings[0] != first || siblings[1] != second {
+		t.Errorf("Siblings() = %v, want both versions oldest first", siblings)
+	}
+	if c.Siblings("missing") != nil {
+		t.Error("Siblings() of an unknown message should be nil")
+	}
+
+	// Selecting a version follows its replies to the end of the thread
+	c.Select(first.ID)
+	if c.Head != followUp.ID {
+		t.Errorf("Select() head = %s, want the follow-up of the first version", c.Head)
+	}
+	c.Select(second.ID)
+	if thread := c.Thread(); len(thread) != 2 || thread[1] != second {
+		t.Errorf("Thread() after Select() = %v", thread)
+	}
+
+	// Only messages without replies can be removed
+	c.Remove(first.ID)
+	if c.Get(first.ID) == nil {
+		t.Error("Remove() should keep messages that have replies")
+	}
+	c.Remove(second.ID)
+	if c.Get(second.ID) != nil || c.Head != question.ID {
+		t.Errorf("Remove() should delete the head and move it to the parent, head %s", c.Head)
+	}
+}
+
+func TestConversationRequestSkipsUnfinishedReplies(t *testing.T) {
+	c := New("")
+	c.Append(api.RoleUser, "First question")
+	c.Append(api.RoleAssistant, "").Status = StatusCancelled
+	c.Append(api.RoleUser, "Second question")
+	partial := c.Append(api.RoleAssistant, "Partial answer")
+	partial.Status = StatusFailed
+	c.Append(api.RoleUser, "Go on")
+
+	req, err := c.Request(nil, render.Options{Format: render.FormatMarkdown})
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+
+	if len(req.Messages) != 3 {
+		t.Fatalf("len(Messages) = %d, want 3: %+v", len(req.Messages), req.Messages)
+	}
+	if req.Messages[0].Content != "First question\n\nSecond question" {
+		t.Errorf("turns around an empty reply should be joined, got %q", req.Messages[0].Content)
+	}
+	if req.Messages[1].Content != "Partial answer" || req.Messages[2].Role != api.RoleUser {
+		t.Errorf("partial replies should be kept, got %+v", req.Messages[1:])
+	}
+}
@@ -0,0 +1,245 @@
+package judge
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// systemPrompt instructs the judging model
+const systemPrompt = `You judge responses that several assistants gave to the same task. Score
+every response on each criterion of the rubric from 0 (worst) to 10 (best),
+judging the responses on their merits and not on their length or order.
+Then pick the best response.
+
+Reply with JSON only, in this form:
+{"scores": [{"candidate": "A", "criteria": {"<criterion>": 7}, "comment": "<one sentence>"}],
+ "pick": "A", "reason": "<why it is the best>"}`
+
+// maxScore is the highest score a criterion can get
+const maxScore = 10
+
+// Rubric is a named set of criteria responses are scored on
+type Rubric struct {
+	Name     string
+	Criteria []config.Criterion
+}
+
+// Rubrics returns the configured rubrics, sorted by name
+func Rubrics(cfg *config.Config) []Rubric {
+	rubrics := make([]Rubric, 0, len(cfg.Rubrics))
+	for name, criteria := range cfg.Rubrics {
+		rubrics = append(rubrics, Rubric{Name: name, Criteria: criteria})
+	}
+	sort.Slice(rubrics, func(i, j int) bool { return rubrics[i].Name < rubrics[j].Name })
+	return rubrics
+}
+
+// Find returns the configured rubric with a name
+func Find(cfg *config.Config, name string) (Rubric, error) {
+	criteria, ok := cfg.Rubrics[name]
+	if !ok {
+		return Rubric{}, fmt.Errorf("unknown rubric: %s", name)
+	}
+	return Rubric{Name: name, Criteria: criteria}, nil
+}
+
+// weight returns the weight of a criterion, counting zero as 1
+func weight(c config.Criterion) float64 {
+	if c.Weight == 0 {
+		return 1
+	}
+	return c.Weight
+}
+
+// Candidate is the response of one agent to the prompt of a comparison
+type Candidate struct {
+	AgentID string
+	Agent   string // Agent name when it answered
+	Model   string
+	Answer  string
+	Usage   api.TokenUsage
+	Cost    float64
+	Error   string // Set when the agent gave no answer
+}
+
+// Score is the judge's assessment of one candidate
+type Score struct {
+	Candidate int                `json:"candidate"` // Index into the comparison's candidates
+	Criteria  map[string]float64 `json:"criteria"`
+	Total     float64            `json:"total"` // Weighted average of the criteria, out of 10
+	Comment   string             `json:"comment,omitempty"`
+}
+
+// Verdict is a judge's scores for the candidates of a comparison and its pick
+type Verdict struct {
+	Judge  string  `json:"judge"` // Name of the judging agent
+	Model  string  `json:"model"`
+	Rubric string  `json:"rubric"`
+	Scores []Score `json:"scores"`
+	Pick   int     `json:"pick"` // Index into the comparison's candidates
+	Reason string  `json:"reason,omitempty"`
+}
+
+// ScoreFor returns the score of a candidate, or nil if it was not judged
+func (v *Verdict) ScoreFor(candidate int) *Score {
+	for i := range v.Scores {
+		if v.Scores[i].Candidate == candidate {
+			return &v.Scores[i]
+		}
+	}
+	return nil
+}
+
+// Comparison is one prompt sent to several agents, with their responses and
+// the verdict of a judge once they have been judged
+type Comparison struct {
+	ID         string
+	Prompt     string
+	ContextID  string // Empty when no context was sent
+	Candidates []Candidate
+	Verdict    *Verdict
+	CreatedAt  time.Time
+}
+
+// NewComparison creates a comparison of the responses to a prompt
+func NewComparison(prompt, contextID string) *Comparison {
+	return &Comparison{
+		ID:        generateID(),
+		Prompt:    prompt,
+		ContextID: contextID,
+		CreatedAt: time.Now(),
+	}
+}
+
+// labels names the candidates that answered A, B, C and so on, so that the
+// judge does not see which agent wrote which response
+func labels(candidates []Candidate) map[string]int {
+	index := make(map[string]int)
+	for i, c := range candidates {
+		if c.Error == "" {
+			index[string(rune('A'+len(index)))] = i
+		}
+	}
+	return index
+}
+
+// Label returns the letter a candidate is shown to the judge as, or "" if it
+// has no answer to judge
+func Label(candidates []Candidate, candidate int) string {
+	for label, i := range labels(candidates) {
+		if i == candidate {
+			return label
+		}
+	}
+	return ""
+}
+
+// Request builds the request asking a judge to score the candidates that
+// answered prompt. The context sent with the prompt is left out to keep the
+// request small.
+func Request(rubric Rubric, prompt string, candidates []Candidate) (*api.Request, error) {
+	index := labels(candidates)
+	if len(index) < 2 {
+		return nil, fmt.Errorf("need at least two responses to judge, have %d", len(index))
+	}
+
+	var b strings.Builder
+	b.WriteString("Rubric:\n")
+	for _, c := range rubric.Criteria {
+		fmt.Fprintf(&b, "- %s (weight %g)", c.Name, weight(c))
+		if c.Description != "" {
+			b.WriteString(": " + c.Description)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\nTask:\n" + prompt + "\n")
+
+	names := make([]string, 0, len(index))
+	for label := range index {
+		names = append(names, label)
+	}
+	sort.Strings(names)
+	for _, label := range names {
+		fmt.Fprintf(&b, "\n<response candidate=%q>\n%s\n</response>\n", label, candidates[index[label]].Answer)
+	}
+
+	return &api.Request{
+		System:   systemPrompt,
+		Messages: []api.Message{{Role: api.RoleUser, Content: b.String()}},
+	}, nil
+}
+
+// reply is the JSON a judge answers with
+type reply struct {
+	Scores []struct {
+		Candidate string             `json:"candidate"`
+		Criteria  map[string]float64 `json:"criteria"`
+		Comment   string             `json:"comment"`
+	} `json:"scores"`
+	Pick   string `json:"pick"`
+	Reason string `json:"reason"`
+}
+
+// Parse reads the verdict in a judge's answer. The JSON may be wrapped in a
+// code fence or surrounded by text. Scores are clamped to 0-10 and criteria
+// the judge left out count as 0.
+func Parse(answer string, rubric Rubric, candidates []Candidate) (*Verdict, error) {
+	start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("judge did not answer with JSON")
+	}
+
+	var r reply
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &r); err != nil {
+		return nil, fmt.Errorf("failed to parse judge answer: %w", err)
+	}
+
+	index := labels(candidates)
+	pick, ok := index[strings.TrimSpace(r.Pick)]
+	if !ok {
+		return nil, fmt.Errorf("judge picked unknown candidate %q", r.Pick)
+	}
+	v := &Verdict{Rubric: rubric.Name, Pick: pick, Reason: r.Reason}
+
+	for _, s := range r.Scores {
+		i, ok := index[strings.TrimSpace(s.Candidate)]
+		if !ok {
+			return nil, fmt.Errorf("judge scored unknown candidate %q", s.Candidate)
+		}
+
+		score := Score{Candidate: i, Criteria: make(map[string]float64), Comment: s.Comment}
+		var total, weights float64
+		for _, c := range rubric.Criteria {
+			value := min(max(s.Criteria[c.Name], 0), maxScore)
+			score.Criteria[c.Name] = value
+			total += value * weight(c)
+			weights += weight(c)
+		}
+		if weights > 0 {
+			score.Total = total / weights
+		}
+		v.Scores = append(v.Scores, score)
+	}
+	if len(v.Scores) == 0 {
+		return nil, fmt.Errorf("judge gave no scores")
+	}
+
+	sort.Slice(v.Scores, func(a, b int) bool { return v.Scores[a].Candidate < v.Scores[b].Candidate })
+	return v, nil
+}
+
+// generateID generates a random ID for a comparison
+func generateID() string {
+	bytes := make([]byte, 8)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
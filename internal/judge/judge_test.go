@@ -0,0 +1,105 @@
+package judge
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yourusername/aui/internal/config"
+)
+
+var testRubric = Rubric{Name: "review", Criteria: []config.Criterion{
+	{Name: "correctness", Weight: 3},
+	{Name: "minimal diff"},
+}}
+
+var testCandidates = []Candidate{
+	{Agent: "Claude", Answer: "use a mutex"},
+	{Agent: "GPT", Error: "rate limited"},
+	{Agent: "Gemini", Answer: "use a channel"},
+}
+
+func TestRubrics(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.Rubrics["review"] = testRubric.Criteria
+
+	rubrics := Rubrics(cfg)
+	if len(rubrics) != 2 || rubrics[0].Name != "default" || rubrics[1].Name != "review" {
+		t.Errorf("Rubrics() = %+v, want default and review", rubrics)
+	}
+	if _, err := Find(cfg, "missing"); err == nil {
+		t.Error("Find() should fail for an unknown rubric")
+	}
+}
+
+func TestRequest(t *testing.T) {
+	req, err := Request(testRubric, "fix the race", testCandidates)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := req.Messages[0].Content
+	for _, want := range []string{"correctness (weight 3)", "minimal diff (weight 1)", "fix the race", `candidate="A">` + "\nuse a mutex", `candidate="B">` + "\nuse a channel"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Request() content missing %q:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "Claude") || strings.Contains(content, "rate limited") {
+		t.Errorf("Request() should hide agents and skip failed candidates:\n%s", content)
+	}
+
+	if _, err := Request(testRubric, "fix", testCandidates[:2]); err == nil {
+		t.Error("Request() should need two answers to judge")
+	}
+}
+
+func TestLabel(t *testing.T) {
+	if got := Label(testCandidates, 2); got != "B" {
+		t.Errorf("Label() = %q, want B", got)
+	}
+	if got := Label(testCandidates, 1); got != "" {
+		t.Errorf("Label() of a failed candidate = %q, want none", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	answer := "Here are my scores:\n```json\n" + `{"scores": [
+		{"candidate": "A", "criteria": {"correctness": 6, "minimal diff": 10}, "comment": "works"},
+		{"candidate": "B", "criteria": {"correctness": 12}}
+	], "pick": "B", "reason": "no locking"}` + "\n```"
+
+	v, err := Parse(answer, testRubric, testCandidates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Pick != 2 || v.Reason != "no locking" || v.Rubric != "review" {
+		t.Errorf("Parse() = %+v, want Gemini picked", v)
+	}
+	if len(v.Scores) != 2 {
+		t.Fatalf("Parse() scores = %+v, want two", v.Scores)
+	}
+
+	if s := v.ScoreFor(0); s == nil || s.Total != 7 || s.Comment != "works" {
+		t.Errorf("score of A = %+v, want weighted total 7", s)
+	}
+	// Scores are clamped and missing criteria count as 0
+	if s := v.ScoreFor(2); s == nil || s.Criteria["correctness"] != 10 || s.Total != 7.5 {
+		t.Errorf("score of B = %+v, want total 7.5", s)
+	}
+	if v.ScoreFor(1) != nil {
+		t.Error("the failed candidate should have no score")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"not JSON":          "A is better",
+		"unknown pick":      `{"scores": [{"candidate": "A"}], "pick": "C"}`,
+		"unknown candidate": `{"scores": [{"candidate": "D"}], "pick": "A"}`,
+		"no scores":         `{"scores": [], "pick": "A"}`,
+	}
+	for name, answer := range tests {
+		if _, err := Parse(answer, testRubric, testCandidates); err == nil {
+			t.Errorf("Parse() with %s should fail", name)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package ledger
+
+import (
+	"sort"
+	"time"
+
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/models"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// Entry records the tokens used by one request and what they cost
+type Entry struct {
+	ID           int64
+	AgentID      string
+	Provider     string
+	Model        string
+	InputTokens  int
+	OutputTokens int
+	Cost         float64 // USD; zero for models missing from the catalog
+	CreatedAt    time.Time
+
+	// Set for requests made by an escalation chain
+	Chain     string
+	Tier      int    // 1-based tier of the chain
+	Escalated string // Why the chain moved on to the next tier; empty if this tier answered
+}
+
+// NewEntry records a request made by an agent, priced from the catalog
+func NewEntry(a *agent.Agent, usage api.TokenUsage, catalog *models.Catalog) *Entry {
+	e := &Entry{
+		AgentID:      a.ID,
+		Provider:     a.Provider,
+		Model:        a.Model,
+		InputTokens:  usage.InputTokens,
+		OutputTokens: usage.OutputTokens,
+		CreatedAt:    time.Now(),
+	}
+	if catalog != nil {
+		if m := catalog.Get(a.Model); m != nil {
+			e.Cost = m.Cost(usage)
+		}
+	}
+	return e
+}
+
+// Total sums the entries for one provider and model
+type Total struct {
+	Provider     string
+	Model        string
+	Requests     int
+	InputTokens  int
+	OutputTokens int
+	Cost         float64
+}
+
+// Totals groups entries by provider and model, most expensive first
+func Totals(entries []*Entry) []Total {
+	index := make(map[[2]string]int)
+	var totals []Total
+
+	for _, e := range entries {
+		key := [2]string{e.Provider, e.Model}
+		i, ok := index[key]
+		if !ok {
+			i = len(totals)
+			index[key] = i
+			totals = append(totals, Total{Provider: e.Provider, Model: e.Model})
+		}
+		totals[i].Requests++
+		totals[i].InputTokens += e.InputTokens
+		totals[i].OutputTokens += e.OutputTokens
+		totals[i].Cost += e.Cost
+	}
+
+	sort.SliceStable(totals, func(i, j int) bool {
+		return totals[i].Cost > totals[j].Cost
+	})
+	return totals
+}
+
+// Sum returns the combined total of all entries
+func Sum(entries []*Entry) Total {
+	var t Total
+	for _, e := range entries {
+		t.Requests++
+		t.InputTokens += e.InputTokens
+		t.OutputTokens += e.OutputTokens
+		t.Cost += e.Cost
+	}
+	return t
+}
+
+// TierTotal sums the requests made by one tier of an escalation chain
+type TierTotal struct {
+	Chain     string
+	Tier      int
+	Model     string
+	Answered  int // Requests this tier answered
+	Escalated int // Requests passed on to the next tier
+	Cost      float64
+}
+
+// Tiers groups the entries made by chains by chain, tier and model, in that
+// order, leaving out entries made outside chains
+func Tiers(entries []*Entry) []TierTotal {
+	type key struct {
+		chain string
+		tier  int
+		model string
+	}
+	index := make(map[key]int)
+	var totals []TierTotal
+
+	for _, e := range entries {
+		if e.Chain == "" {
+			continue
+		}
+		k := key{e.Chain, e.Tier, e.Model}
+		i, ok := index[k]
+		if !ok {
+			i = len(totals)
+			index[k] = i
+			totals = append(totals, TierTotal{Chain: e.Chain, Tier: e.Tier, Model: e.Model})
+		}
+		if e.Escalated == "" {
+			totals[i].Answered++
+		} else {
+			totals[i].Escalated++
+		}
+		totals[i].Cost += e.Cost
+	}
+
+	sort.SliceStable(totals, func(i, j int) bool {
+		if totals[i].Chain != totals[j].Chain {
+			return totals[i].Chain < totals[j].Chain
+		}
+		return totals[i].Tier < totals[j].Tier
+	})
+	return totals
+}
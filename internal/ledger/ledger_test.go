@@ -0,0 +1,75 @@
+package ledger
+
+import (
+	"math"
+	"testing"
+
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/models"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+func TestNewEntry(t *testing.T) {
+	catalog := models.Default()
+	a := agent.NewAgent("Sonnet", "claude-sonnet-4-5", "anthropic")
+
+	e := NewEntry(a, api.TokenUsage{InputTokens: 1000000, OutputTokens: 100000}, catalog)
+	if e.AgentID != a.ID || e.Provider != "anthropic" || e.Model != "claude-sonnet-4-5" {
+		t.Errorf("NewEntry() = %+v", e)
+	}
+	if want := 3 + 1.5; math.Abs(e.Cost-want) > 1e-9 {
+		t.Errorf("Cost = %v, want %v", e.Cost, want)
+	}
+
+	unknown := agent.NewAgent("Local", "llama", "openai")
+	if e := NewEntry(unknown, api.TokenUsage{InputTokens: 10}, catalog); e.Cost != 0 {
+		t.Errorf("Cost of an unknown model = %v, want 0", e.Cost)
+	}
+	if e := NewEntry(a, api.TokenUsage{InputTokens: 10}, nil); e.Cost != 0 {
+		t.Errorf("Cost without a catalog = %v, want 0", e.Cost)
+	}
+}
+
+func TestTotals(t *testing.T) {
+	entries := []*Entry{
+		{Provider: "openai", Model: "gpt-4o-mini", InputTokens: 100, OutputTokens: 10, Cost: 0.01},
+		{Provider: "anthropic", Model: "claude-opus-4-1", InputTokens: 200, OutputTokens: 20, Cost: 0.5},
+		{Provider: "openai", Model: "gpt-4o-mini", InputTokens: 300, OutputTokens: 30, Cost: 0.02},
+	}
+
+	totals := Totals(entries)
+	if len(totals) != 2 {
+		t.Fatalf("len(Totals()) = %d, want 2", len(totals))
+	}
+	if totals[0].Model != "claude-opus-4-1" {
+		t.Errorf("Totals()[0] = %s, want most expensive first", totals[0].Model)
+	}
+	if mini := totals[1]; mini.Requests != 2 || mini.InputTokens != 400 || mini.OutputTokens != 40 || math.Abs(mini.Cost-0.03) > 1e-9 {
+		t.Errorf("Totals()[1] = %+v", mini)
+	}
+
+	if sum := Sum(entries); sum.Requests != 3 || sum.InputTokens != 600 || math.Abs(sum.Cost-0.53) > 1e-9 {
+		t.Errorf("Sum() = %+v", sum)
+	}
+}
+
+func TestTiers(t *testing.T) {
+	entries := []*Entry{
+		{Model: "claude-sonnet-4-5", Cost: 0.01},
+		{Chain: "coding", Tier: 2, Model: "claude-sonnet-4-5", Cost: 0.02},
+		{Chain: "coding", Tier: 1, Model: "gemini-2.5-flash", Escalated: "refusal", Cost: 0.001},
+		{Chain: "coding", Tier: 1, Model: "gemini-2.5-flash", Cost: 0.001},
+		{Chain: "coding", Tier: 1, Model: "gemini-2.5-flash", Escalated: "timeout"},
+	}
+
+	got := Tiers(entries)
+	if len(got) != 2 {
+		t.Fatalf("Tiers() = %+v, want 2 tiers", got)
+	}
+	if got[0].Tier != 1 || got[0].Answered != 1 || got[0].Escalated != 2 || math.Abs(got[0].Cost-0.002) > 1e-9 {
+		t.Errorf("Tiers()[0] = %+v", got[0])
+	}
+	if got[1].Tier != 2 || got[1].Answered != 1 || got[1].Escalated != 0 {
+		t.Errorf("Tiers()[1] = %+v", got[1])
+	}
+}
@@ -0,0 +1,268 @@
+package mcp
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/yourusername/aui/internal/config"
+)
+
+// DefaultTimeout bounds a request to a server unless configured otherwise
+const DefaultTimeout = 60 * time.Second
+
+// auiInfo is how aui introduces itself, as a client or a server
+var auiInfo = Implementation{Name: "aui", Version: "0.1.0"}
+
+// transport carries messages to a server
+type transport interface {
+	// roundTrip sends a request and returns the response to it
+	roundTrip(ctx gocontext.Context, req *message) (*message, error)
+	notify(ctx gocontext.Context, n *message) error
+	close() error
+}
+
+// Client is a session with an MCP server
+type Client struct {
+	Name         string         // Server name in the config
+	Server       Implementation // What the server says it is
+	Instructions string         // How the server says to use it, if it does
+
+	capabilities map[string]json.RawMessage
+	timeout      time.Duration
+	transport    transport
+	nextID       atomic.Int64
+}
+
+// Connect starts or reaches a configured server and opens a session
+func Connect(ctx gocontext.Context, name string, cfg config.MCPServerConfig) (*Client, error) {
+	c := &Client{Name: name, timeout: cfg.Timeout}
+	if c.timeout <= 0 {
+		c.timeout = DefaultTimeout
+	}
+
+	switch {
+	case cfg.Command != "":
+		t, err := startStdio(cfg.Command, cfg.Args, cfg.Env)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to %s: %w", name, err)
+		}
+		c.transport = t
+	case cfg.URL != "":
+		c.transport = newHTTP(cfg.URL, cfg.Headers)
+	default:
+		return nil, fmt.Errorf("server %s has neither a command nor a URL", name)
+	}
+
+	if err := c.initialize(ctx); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to initialize %s: %w", name, err)
+	}
+	return c, nil
+}
+
+// ConnectAll connects to every configured server, in name order. Servers
+// that fail are left out and their errors joined.
+func ConnectAll(ctx gocontext.Context, servers map[string]config.MCPServerConfig) ([]*Client, error) {
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var clients []*Client
+	var errs []error
+	for _, name := range names {
+		c, err := Connect(ctx, name, servers[name])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		clients = append(clients, c)
+	}
+	return clients, errors.Join(errs...)
+}
+
+// initialize agrees on the protocol with the server
+func (c *Client) initialize(ctx gocontext.Context) error {
+	var result initializeResult
+	params := initializeParams{ProtocolVersion: ProtocolVersion, Capabilities: map[string]json.RawMessage{}, ClientInfo: auiInfo}
+	if err := c.call(ctx, "initialize", params, &result); err != nil {
+		return err
+	}
+	c.Server = result.ServerInfo
+	c.Instructions = result.Instructions
+	c.capabilities = result.Capabilities
+	return c.notify(ctx, "notifications/initialized")
+}
+
+// Has reports whether the server offers a capability: "tools", "resources"
+// or "prompts"
+func (c *Client) Has(capability string) bool {
+	_, ok := c.capabilities[capability]
+	return ok
+}
+
+// call sends a request and decodes its result into result
+func (c *Client) call(ctx gocontext.Context, method string, params, result interface{}) error {
+	ctx, cancel := gocontext.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req := &message{JSONRPC: "2.0", ID: json.RawMessage(strconv.FormatInt(c.nextID.Add(1), 10)), Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		req.Params = data
+	}
+
+	resp, err := c.transport.roundTrip(ctx, req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("%s: %w", method, resp.Error)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("%s: failed to decode result: %w", method, err)
+	}
+	return nil
+}
+
+// notify sends a notification
+func (c *Client) notify(ctx gocontext.Context, method string) error {
+	return c.transport.notify(ctx, &message{JSONRPC: "2.0", Method: method})
+}
+
+// page is a cursor for lists that come in pages
+type page struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// list calls a list method until the server has no more pages, adding
+// each page's items with add
+func (c *Client) list(ctx gocontext.Context, method string, add func(json.RawMessage) (string, error)) error {
+	cursor := ""
+	for {
+		var result json.RawMessage
+		if err := c.call(ctx, method, page{Cursor: cursor}, &result); err != nil {
+			return err
+		}
+		next, err := add(result)
+		if err != nil {
+			return fmt.Errorf("%s: failed to decode result: %w", method, err)
+		}
+		if next == "" {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// ListTools returns the tools the server offers
+func (c *Client) ListTools(ctx gocontext.Context) ([]Tool, error) {
+	if !c.Has("tools") {
+		return nil, nil
+	}
+	var tools []Tool
+	err := c.list(ctx, "tools/list", func(data json.RawMessage) (string, error) {
+		var result struct {
+			Tools      []Tool `json:"tools"`
+			NextCursor string `json:"nextCursor"`
+		}
+		err := json.Unmarshal(data, &result)
+		tools = append(tools, result.Tools...)
+		return result.NextCursor, err
+	})
+	return tools, err
+}
+
+// CallTool calls a tool with arguments, a JSON object
+func (c *Client) CallTool(ctx gocontext.Context, name string, arguments json.RawMessage) (*CallToolResult, error) {
+	params := struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}{name, arguments}
+	var result CallToolResult
+	if err := c.call(ctx, "tools/call", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListResources returns the resources the server offers
+func (c *Client) ListResources(ctx gocontext.Context) ([]Resource, error) {
+	if !c.Has("resources") {
+		return nil, nil
+	}
+	var resources []Resource
+	err := c.list(ctx, "resources/list", func(data json.RawMessage) (string, error) {
+		var result struct {
+			Resources  []Resource `json:"resources"`
+			NextCursor string     `json:"nextCursor"`
+		}
+		err := json.Unmarshal(data, &result)
+		resources = append(resources, result.Resources...)
+		return result.NextCursor, err
+	})
+	return resources, err
+}
+
+// ReadResource returns the contents of a resource
+func (c *Client) ReadResource(ctx gocontext.Context, uri string) ([]ResourceContents, error) {
+	params := struct {
+		URI string `json:"uri"`
+	}{uri}
+	var result struct {
+		Contents []ResourceContents `json:"contents"`
+	}
+	if err := c.call(ctx, "resources/read", params, &result); err != nil {
+		return nil, err
+	}
+	return result.Contents, nil
+}
+
+// ListPrompts returns the prompts the server offers
+func (c *Client) ListPrompts(ctx gocontext.Context) ([]Prompt, error) {
+	if !c.Has("prompts") {
+		return nil, nil
+	}
+	var prompts []Prompt
+	err := c.list(ctx, "prompts/list", func(data json.RawMessage) (string, error) {
+		var result struct {
+			Prompts    []Prompt `json:"prompts"`
+			NextCursor string   `json:"nextCursor"`
+		}
+		err := json.Unmarshal(data, &result)
+		prompts = append(prompts, result.Prompts...)
+		return result.NextCursor, err
+	})
+	return prompts, err
+}
+
+// GetPrompt fills in a prompt with arguments
+func (c *Client) GetPrompt(ctx gocontext.Context, name string, arguments map[string]string) (*GetPromptResult, error) {
+	params := struct {
+		Name      string            `json:"name"`
+		Arguments map[string]string `json:"arguments,omitempty"`
+	}{name, arguments}
+	var result GetPromptResult
+	if err := c.call(ctx, "prompts/get", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Close ends the session, stopping the server if it was started for it
+func (c *Client) Close() error {
+	return c.transport.close()
+}
@@ -0,0 +1,283 @@
+package mcp
+
+import (
+	"bufio"
+	gocontext "context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yourusername/aui/internal/config"
+)
+
+// TestMain runs the test binary as a stub server when asked to, so the
+// stdio transport can be tested against a real process
+func TestMain(m *testing.M) {
+	switch os.Getenv("AUI_MCP_STUB") {
+	case "serve":
+		stubServe(os.Stdin, os.Stdout)
+		os.Exit(0)
+	case "crash":
+		fmt.Fprintln(os.Stderr, "stub: missing token")
+		os.Exit(1)
+	case "aui":
+		os.Exit(serveStore())
+	}
+	os.Exit(m.Run())
+}
+
+// stubServe answers the messages read from in, one per line
+func stubServe(in io.Reader, out io.Writer) {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		if resp := stubHandle(&msg); resp != nil {
+			data, _ := json.Marshal(resp)
+			fmt.Fprintf(out, "%s\n", data)
+		}
+	}
+}
+
+// stubHandle answers a message as a small server with two tools, a resource
+// and a prompt would, or returns nil for notifications
+func stubHandle(msg *message) *message {
+	if len(msg.ID) == 0 {
+		return nil
+	}
+	var params struct {
+		Cursor    string            `json:"cursor"`
+		Name      string            `json:"name"`
+		URI       string            `json:"uri"`
+		Arguments map[string]string `json:"arguments"`
+	}
+	json.Unmarshal(msg.Params, &params)
+
+	var result interface{}
+	switch msg.Method {
+	case "initialize":
+		result = initializeResult{
+			ProtocolVersion: ProtocolVersion,
+			Capabilities:    map[string]json.RawMessage{"tools": json.RawMessage("{}"), "resources": json.RawMessage("{}"), "prompts": json.RawMessage("{}")},
+			ServerInfo:      Implementation{Name: "stub", Version: "1.0"},
+			Instructions:    "Use echo to test.",
+		}
+	case "tools/list":
+		// Two pages, to test paging
+		if params.Cursor == "" {
+			result = map[string]interface{}{"tools": []Tool{{Name: "echo", Description: "Echo text", InputSchema: map[string]interface{}{"type": "object"}}}, "nextCursor": "2"}
+		} else {
+			result = map[string]interface{}{"tools": []Tool{{Name: "fail.now"}}}
+		}
+	case "tools/call":
+		switch params.Name {
+		case "echo":
+			result = CallToolResult{Content: []Content{{Type: "text", Text: params.Arguments["text"]}}}
+		case "fail.now":
+			result = CallToolResult{Content: []Content{{Type: "text", Text: "it broke"}}, IsError: true}
+		default:
+			return &message{JSONRPC: "2.0", ID: msg.ID, Error: &Error{Code: CodeInvalidParams, Message: "unknown tool: " + params.Name}}
+		}
+	case "resources/list":
+		result = map[string]interface{}{"resources": []Resource{{URI: "notes://team/standup.md", Name: "standup"}}}
+	case "resources/read":
+		result = map[string]interface{}{"contents": []ResourceContents{
+			{URI: params.URI, MimeType: "text/markdown", Text: "# Standup\n"},
+			{URI: params.URI, MimeType: "image/png", Blob: "iVBORw0KGgo="},
+		}}
+	case "prompts/list":
+		result = map[string]interface{}{"prompts": []Prompt{{Name: "review", Arguments: []PromptArgument{{Name: "file", Required: true}}}}}
+	case "prompts/get":
+		result = GetPromptResult{Messages: []PromptMessage{{Role: "user", Content: Content{Type: "text", Text: "Review " + params.Arguments["file"]}}}}
+	default:
+		return &message{JSONRPC: "2.0", ID: msg.ID, Error: &Error{Code: CodeMethodNotFound, Message: "method not found: " + msg.Method}}
+	}
+
+	data, _ := json.Marshal(result)
+	return &message{JSONRPC: "2.0", ID: msg.ID, Result: data}
+}
+
+// connectStub starts the test binary as a stub server over stdio
+func connectStub(t *testing.T) *Client {
+	t.Helper()
+	c, err := Connect(gocontext.Background(), "stub", config.MCPServerConfig{
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^$"},
+		Env:     map[string]string{"AUI_MCP_STUB": "serve"},
+	})
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// testClient exercises every request against a stub server
+func testClient(t *testing.T, c *Client) {
+	t.Helper()
+	ctx := gocontext.Background()
+
+	if c.Server.Name != "stub" || c.Instructions != "Use echo to test." || !c.Has("tools") {
+		t.Errorf("Connect() server = %+v, instructions %q", c.Server, c.Instructions)
+	}
+
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) != 2 || tools[0].Name != "echo" || tools[1].Name != "fail.now" {
+		t.Errorf("ListTools() = %+v, want both pages", tools)
+	}
+
+	result, err := c.CallTool(ctx, "echo", json.RawMessage(`{"text":"hi"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Text() != "hi" || result.IsError {
+		t.Errorf("CallTool() = %+v, want hi", result)
+	}
+	if _, err := c.CallTool(ctx, "missing", json.RawMessage(`{}`)); err == nil || !strings.Contains(err.Error(), "unknown tool: missing") {
+		t.Errorf("CallTool() of a missing tool error = %v", err)
+	}
+
+	resources, err := c.ListResources(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 1 || resources[0].URI != "notes://team/standup.md" {
+		t.Errorf("ListResources() = %+v", resources)
+	}
+	contents, err := c.ReadResource(ctx, "notes://team/standup.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contents) != 2 || contents[0].Text != "# Standup\n" {
+		t.Errorf("ReadResource() = %+v", contents)
+	}
+
+	prompts, err := c.ListPrompts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prompts) != 1 || prompts[0].Name != "review" || !prompts[0].Arguments[0].Required {
+		t.Errorf("ListPrompts() = %+v", prompts)
+	}
+	prompt, err := c.GetPrompt(ctx, "review", map[string]string{"file": "main.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prompt.Messages) != 1 || prompt.Messages[0].Content.Text != "Review main.go" {
+		t.Errorf("GetPrompt() = %+v", prompt)
+	}
+}
+
+func TestStdioClient(t *testing.T) {
+	testClient(t, connectStub(t))
+}
+
+func TestStdioServerCrash(t *testing.T) {
+	_, err := Connect(gocontext.Background(), "stub", config.MCPServerConfig{
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^$"},
+		Env:     map[string]string{"AUI_MCP_STUB": "crash"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "stub: missing token") {
+		t.Errorf("Connect() error = %v, want the server's stderr", err)
+	}
+}
+
+// stubHTTP serves the stub over streamable HTTP, answering tool calls as an
+// event stream and requiring the session after initialization
+type stubHTTP struct {
+	mu     sync.Mutex
+	closed bool
+}
+
+func (s *stubHTTP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer token" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method == http.MethodDelete {
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+		return
+	}
+
+	var msg message
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if msg.Method == "initialize" {
+		w.Header().Set(sessionHeader, "session-1")
+	} else if r.Header.Get(sessionHeader) != "session-1" {
+		http.Error(w, "missing session", http.StatusBadRequest)
+		return
+	}
+
+	resp := stubHandle(&msg)
+	if resp == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	data, _ := json.Marshal(resp)
+	if msg.Method != "tools/call" {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	fmt.Fprintf(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\",\"params\":{}}\n\n")
+	fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+}
+
+func TestHTTPClient(t *testing.T) {
+	stub := &stubHTTP{}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	cfg := config.MCPServerConfig{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}}
+	c, err := Connect(gocontext.Background(), "stub", cfg)
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	testClient(t, c)
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !stub.closed {
+		t.Error("Close() should end the session")
+	}
+
+	cfg.Headers = nil
+	if _, err := Connect(gocontext.Background(), "stub", cfg); err == nil || !strings.Contains(err.Error(), "HTTP 401") {
+		t.Errorf("Connect() without the header error = %v, want HTTP 401", err)
+	}
+}
+
+func TestConnectAll(t *testing.T) {
+	clients, err := ConnectAll(gocontext.Background(), map[string]config.MCPServerConfig{
+		"good":   {Command: os.Args[0], Args: []string{"-test.run=^$"}, Env: map[string]string{"AUI_MCP_STUB": "serve"}},
+		"broken": {Command: os.Args[0], Args: []string{"-test.run=^$"}, Env: map[string]string{"AUI_MCP_STUB": "crash"}},
+	})
+	for _, c := range clients {
+		defer c.Close()
+	}
+	if len(clients) != 1 || clients[0].Name != "good" {
+		t.Errorf("ConnectAll() connected %d servers, want good only", len(clients))
+	}
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("ConnectAll() error = %v, want the broken server's", err)
+	}
+}
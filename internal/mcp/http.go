@@ -0,0 +1,156 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	gocontext "context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sessionHeader carries the session a server assigned at initialization
+const sessionHeader = "Mcp-Session-Id"
+
+// httpTransport talks to a server over streamable HTTP: every message is
+// POSTed, and the response comes back as JSON or an event stream
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	mu      sync.Mutex
+	session string
+}
+
+// newHTTP returns a transport to a server at url, sending headers with
+// every request
+func newHTTP(url string, headers map[string]string) *httpTransport {
+	return &httpTransport{url: url, headers: headers, client: &http.Client{}}
+}
+
+// post sends a message
+func (t *httpTransport) post(ctx gocontext.Context, msg *message) (*http.Response, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	t.setHeaders(req)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach server: %w", err)
+	}
+	if session := resp.Header.Get(sessionHeader); session != "" {
+		t.mu.Lock()
+		t.session = session
+		t.mu.Unlock()
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if resp.StatusCode == http.StatusNotFound && t.sessionID() != "" {
+			return nil, fmt.Errorf("session expired (HTTP 404)")
+		}
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+// setHeaders adds the configured headers and the session to a request
+func (t *httpTransport) setHeaders(req *http.Request) {
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	if session := t.sessionID(); session != "" {
+		req.Header.Set(sessionHeader, session)
+	}
+}
+
+// sessionID returns the session the server assigned, if any
+func (t *httpTransport) sessionID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.session
+}
+
+func (t *httpTransport) roundTrip(ctx gocontext.Context, req *message) (*message, error) {
+	resp, err := t.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/event-stream" {
+		var msg message
+		if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		return &msg, nil
+	}
+
+	// The stream may carry notifications and requests ahead of the response
+	var data []string
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), maxLine)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			data = append(data, strings.TrimPrefix(value, " "))
+			continue
+		}
+		if line != "" || len(data) == 0 {
+			continue
+		}
+		var msg message
+		err := json.Unmarshal([]byte(strings.Join(data, "\n")), &msg)
+		data = nil
+		if err == nil && msg.isResponse() && string(msg.ID) == string(req.ID) {
+			return &msg, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read event stream: %w", err)
+	}
+	return nil, fmt.Errorf("server ended the event stream without a response")
+}
+
+func (t *httpTransport) notify(ctx gocontext.Context, n *message) error {
+	resp, err := t.post(ctx, n)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// close ends the session, if the server assigned one
+func (t *httpTransport) close() error {
+	if t.sessionID() == "" {
+		return nil
+	}
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, t.url, nil)
+	if err != nil {
+		return err
+	}
+	t.setHeaders(req)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -0,0 +1,153 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ProtocolVersion is the MCP revision spoken
+const ProtocolVersion = "2025-03-26"
+
+// JSON-RPC error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// message is a JSON-RPC 2.0 request, response or notification
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` // Absent for notifications
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// isResponse reports whether the message answers a request
+func (m *message) isResponse() bool {
+	return m.Method == "" && len(m.ID) > 0
+}
+
+// Error is the error a request was answered with
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// Implementation names a client or server and its version
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// initializeParams opens a session
+type initializeParams struct {
+	ProtocolVersion string                     `json:"protocolVersion"`
+	Capabilities    map[string]json.RawMessage `json:"capabilities"`
+	ClientInfo      Implementation             `json:"clientInfo"`
+}
+
+// initializeResult is what a server says about itself
+type initializeResult struct {
+	ProtocolVersion string                     `json:"protocolVersion"`
+	Capabilities    map[string]json.RawMessage `json:"capabilities"`
+	ServerInfo      Implementation             `json:"serverInfo"`
+	Instructions    string                     `json:"instructions,omitempty"`
+}
+
+// Tool is a tool a server offers
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// Content is a piece of a tool result or prompt message: text, an image or
+// audio (base64 Data) or an embedded resource
+type Content struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Data     string            `json:"data,omitempty"`
+	MimeType string            `json:"mimeType,omitempty"`
+	Resource *ResourceContents `json:"resource,omitempty"`
+}
+
+// String returns the content as text, describing what is not text
+func (c Content) String() string {
+	switch {
+	case c.Type == "text":
+		return c.Text
+	case c.Resource != nil && c.Resource.Blob == "":
+		return c.Resource.Text
+	case c.Resource != nil:
+		return fmt.Sprintf("[%s resource %s]", c.Resource.MimeType, c.Resource.URI)
+	}
+	return fmt.Sprintf("[%s %s]", c.MimeType, c.Type)
+}
+
+// CallToolResult is the outcome of a tool call
+type CallToolResult struct {
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// Text returns the content of the result as text
+func (r *CallToolResult) Text() string {
+	parts := make([]string, len(r.Content))
+	for i, c := range r.Content {
+		parts[i] = c.String()
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Resource is a piece of data a server offers, such as a file or a record
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContents is the content of a resource, as Text or base64 Blob
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// Prompt is a prompt template a server offers
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument is an argument a prompt is filled in with
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptMessage is a message of a filled-in prompt
+type PromptMessage struct {
+	Role    string  `json:"role"`
+	Content Content `json:"content"`
+}
+
+// GetPromptResult is a filled-in prompt
+type GetPromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
@@ -0,0 +1,376 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/redact"
+	"github.com/yourusername/aui/internal/render"
+	"github.com/yourusername/aui/internal/storage"
+)
+
+// uriPrefix starts the URIs of the resources aui serves:
+// aui://contexts/<id> for a rendered context and
+// aui://contexts/<id>/files/<path> for a file of one
+const uriPrefix = "aui://contexts/"
+
+// serverInstructions tells clients what the server is for
+const serverInstructions = "Contexts are curated sets of project files saved in aui. " +
+	"Call list_contexts to find one and get_context to read it, or read its files as resources."
+
+// serverTools are the tools the server offers
+var serverTools = []Tool{
+	{
+		Name:        "list_contexts",
+		Description: "List the saved contexts with their IDs, token counts and descriptions.",
+		InputSchema: map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+	},
+	{
+		Name:        "get_context",
+		Description: "Read every file of a context, including the contexts it includes.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"context": map[string]interface{}{"type": "string", "description": "Name or ID of the context"},
+			},
+			"required": []string{"context"},
+		},
+	},
+	{
+		Name:        "add_file",
+		Description: "Add a file of the project to a context, or replace it if the context has it. Pass content to add text that is not on disk.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"context": map[string]interface{}{"type": "string", "description": "Name or ID of the context"},
+				"path":    map[string]interface{}{"type": "string", "description": "Path relative to the project root"},
+				"content": map[string]interface{}{"type": "string", "description": "Content of the file; read from disk if omitted"},
+			},
+			"required": []string{"context", "path"},
+		},
+	},
+}
+
+// Server offers the contexts of a store to MCP clients: each context and its
+// files as resources, and tools to list, read and add to them
+type Server struct {
+	Store    *storage.SQLiteStore
+	Root     string           // Directory add_file reads paths below
+	Redactor *redact.Redactor // Scrubs secrets from what clients read; may be nil
+	Format   render.Format    // How contexts are rendered; markdown if empty
+}
+
+// Serve answers the requests read from in, one JSON message per line, until
+// in ends
+func (s *Server) Serve(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxLine)
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var msg message
+		var resp *message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			resp = &message{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &Error{Code: CodeParseError, Message: err.Error()}}
+		} else {
+			resp = s.handle(&msg)
+		}
+		if resp == nil {
+			continue
+		}
+		if err := enc.Encode(resp); err != nil {
+			return fmt.Errorf("failed to write response: %w", err)
+		}
+	}
+	return scanner.Err()
+}
+
+// handle answers a request, or returns nil for notifications and responses
+func (s *Server) handle(msg *message) *message {
+	if msg.Method == "" || len(msg.ID) == 0 {
+		return nil
+	}
+
+	resp := &message{JSONRPC: "2.0", ID: msg.ID}
+	result, err := s.dispatch(msg.Method, msg.Params)
+	if err == nil {
+		resp.Result, err = json.Marshal(result)
+	}
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		resp.Result, resp.Error = nil, rpcErr
+	}
+	return resp
+}
+
+// dispatch runs a request method. Errors that are not an *Error are
+// internal errors.
+func (s *Server) dispatch(method string, params json.RawMessage) (interface{}, error) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+		URI       string          `json:"uri"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+	}
+
+	switch method {
+	case "initialize":
+		return initializeResult{
+			ProtocolVersion: ProtocolVersion,
+			Capabilities:    map[string]json.RawMessage{"tools": json.RawMessage("{}"), "resources": json.RawMessage("{}")},
+			ServerInfo:      auiInfo,
+			Instructions:    serverInstructions,
+		}, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": serverTools}, nil
+	case "tools/call":
+		return s.callTool(p.Name, p.Arguments)
+	case "resources/list":
+		resources, err := s.listResources()
+		return map[string]interface{}{"resources": resources}, err
+	case "resources/read":
+		contents, err := s.readResource(p.URI)
+		return map[string]interface{}{"contents": []ResourceContents{contents}}, err
+	default:
+		return nil, &Error{Code: CodeMethodNotFound, Message: "method not found: " + method}
+	}
+}
+
+// callTool runs one of serverTools. Failures are reported in the result so
+// the agent calling it can see them.
+func (s *Server) callTool(name string, arguments json.RawMessage) (*CallToolResult, error) {
+	var args struct {
+		Context string `json:"context"`
+		Path    string `json:"path"`
+		Content string `json:"content"`
+	}
+	if len(arguments) > 0 {
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return toolError(fmt.Errorf("invalid arguments: %w", err)), nil
+		}
+	}
+
+	var text string
+	var err error
+	switch name {
+	case "list_contexts":
+		text, err = s.listContexts()
+	case "get_context":
+		text, err = s.getContext(args.Context)
+	case "add_file":
+		text, err = s.addFile(args.Context, args.Path, args.Content)
+	default:
+		return nil, &Error{Code: CodeInvalidParams, Message: "unknown tool: " + name}
+	}
+	if err != nil {
+		return toolError(err), nil
+	}
+	return &CallToolResult{Content: []Content{{Type: "text", Text: text}}}, nil
+}
+
+// toolError returns the result of a tool call that failed
+func toolError(err error) *CallToolResult {
+	return &CallToolResult{Content: []Content{{Type: "text", Text: err.Error()}}, IsError: true}
+}
+
+// listContexts describes every saved context, one per line
+func (s *Server) listContexts() (string, error) {
+	contexts, err := s.Store.ListContexts()
+	if err != nil {
+		return "", fmt.Errorf("failed to list contexts: %w", err)
+	}
+	if len(contexts) == 0 {
+		return "No contexts saved.", nil
+	}
+
+	var b strings.Builder
+	for _, ctx := range contexts {
+		fmt.Fprintf(&b, "%s (%s): %d tokens", ctx.Name, ctx.ID, ctx.TotalTokens)
+		if ctx.Description != "" {
+			fmt.Fprintf(&b, " - %s", ctx.Description)
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// getContext renders the files of a context
+func (s *Server) getContext(nameOrID string) (string, error) {
+	ctx, err := s.findContext(nameOrID)
+	if err != nil {
+		return "", err
+	}
+	return s.render(ctx)
+}
+
+// addFile adds a file to a context and saves it, reading the file below
+// Root unless content is given
+func (s *Server) addFile(nameOrID, path, content string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+	if !filepath.IsLocal(filepath.FromSlash(path)) {
+		return "", fmt.Errorf("path must be relative to the project root: %s", path)
+	}
+	ctx, err := s.findContext(nameOrID)
+	if err != nil {
+		return "", err
+	}
+
+	var f *context.File
+	if content != "" {
+		f = context.NewFile(filepath.ToSlash(path), filepath.Base(path))
+		f.SetContent(content)
+		f.DetectLanguage()
+	} else if f, err = context.LoadFile(s.Root, path); err != nil {
+		return "", err
+	}
+
+	ctx.AddFile(f)
+	if err := s.Store.SaveContext(ctx); err != nil {
+		return "", fmt.Errorf("failed to save context: %w", err)
+	}
+	return fmt.Sprintf("Added %s to %s (%d tokens)", f.Path, ctx.Name, ctx.TotalTokens), nil
+}
+
+// listResources returns every context and its own files
+func (s *Server) listResources() ([]Resource, error) {
+	contexts, err := s.Store.ListContexts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contexts: %w", err)
+	}
+
+	resources := []Resource{}
+	for _, listed := range contexts {
+		ctx, err := s.Store.GetContext(listed.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load context %s: %w", listed.Name, err)
+		}
+		resources = append(resources, Resource{
+			URI:         uriPrefix + ctx.ID,
+			Name:        ctx.Name,
+			Description: ctx.Description,
+			MimeType:    mimeType(s.format()),
+		})
+		for _, f := range ctx.Files {
+			resources = append(resources, Resource{
+				URI:      fileURI(ctx.ID, f.Path),
+				Name:     ctx.Name + ": " + f.Path,
+				MimeType: "text/plain",
+			})
+		}
+	}
+	return resources, nil
+}
+
+// readResource reads a context or one of its files by URI
+func (s *Server) readResource(uri string) (ResourceContents, error) {
+	id, escaped, _ := strings.Cut(strings.TrimPrefix(uri, uriPrefix), "/files/")
+	path, err := url.PathUnescape(escaped)
+	if err != nil || !strings.HasPrefix(uri, uriPrefix) || id == "" || strings.Contains(id, "/") {
+		return ResourceContents{}, &Error{Code: CodeInvalidParams, Message: "unknown resource: " + uri}
+	}
+	ctx, err := s.Store.GetContext(id)
+	if err != nil {
+		return ResourceContents{}, &Error{Code: CodeInvalidParams, Message: "context not found: " + id}
+	}
+
+	if path == "" {
+		text, err := s.render(ctx)
+		return ResourceContents{URI: uri, MimeType: mimeType(s.format()), Text: text}, err
+	}
+	f := ctx.GetFile(path)
+	if f == nil {
+		return ResourceContents{}, &Error{Code: CodeInvalidParams, Message: "file not in context: " + path}
+	}
+	text, _ := s.Redactor.Redact(f.Path, f.Content)
+	return ResourceContents{URI: uri, MimeType: "text/plain", Text: text}, nil
+}
+
+// fileURI returns the URI of a file of a context, escaping each segment of
+// its path since paths such as "git#diff HEAD" are not valid in URIs
+func fileURI(id, path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return uriPrefix + id + "/files/" + strings.Join(segments, "/")
+}
+
+// findContext loads a stored context by ID or name
+func (s *Server) findContext(nameOrID string) (*context.Context, error) {
+	if nameOrID == "" {
+		return nil, fmt.Errorf("context is required")
+	}
+	if ctx, err := s.Store.GetContext(nameOrID); err == nil {
+		return ctx, nil
+	}
+
+	contexts, err := s.Store.ListContexts()
+	if err != nil {
+		return nil, err
+	}
+	for _, ctx := range contexts {
+		if ctx.Name == nameOrID {
+			return s.Store.GetContext(ctx.ID)
+		}
+	}
+	return nil, fmt.Errorf("context not found: %s", nameOrID)
+}
+
+// render serializes a context with the files of the contexts it includes,
+// redacted
+func (s *Server) render(ctx *context.Context) (string, error) {
+	flat, err := ctx.Flatten(s.Store.GetContext)
+	if err != nil {
+		return "", err
+	}
+	if len(flat.Files) == 0 {
+		return fmt.Sprintf("Context %s has no files.", ctx.Name), nil
+	}
+	redacted, _ := s.Redactor.RedactContext(flat)
+	return render.Render(redacted, render.Options{Format: s.format()})
+}
+
+// format returns the format contexts are rendered in
+func (s *Server) format() render.Format {
+	if s.Format == "" {
+		return render.FormatMarkdown
+	}
+	return s.Format
+}
+
+// mimeType returns the MIME type of a rendered context
+func mimeType(format render.Format) string {
+	switch format {
+	case render.FormatXML:
+		return "application/xml"
+	case render.FormatJSON:
+		return "application/json"
+	default:
+		return "text/markdown"
+	}
+}
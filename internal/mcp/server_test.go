@@ -0,0 +1,165 @@
+package mcp
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/redact"
+	"github.com/yourusername/aui/internal/storage"
+)
+
+// serveStore serves the store at AUI_MCP_DB over stdio, redacting the
+// secret abc123, and returns the exit code
+func serveStore() int {
+	store, err := storage.NewSQLiteStore(os.Getenv("AUI_MCP_DB"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer store.Close()
+
+	redactor, err := redact.New(nil, []string{"abc123"})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	s := &Server{Store: store, Root: os.Getenv("AUI_MCP_ROOT"), Redactor: redactor}
+	if err := s.Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "project")
+	if err := os.MkdirAll(filepath.Join(root, "auth"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "auth", "login.go"), []byte("package auth\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	db := filepath.Join(dir, "aui.db")
+	store, err := storage.NewSQLiteStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	base := context.NewContext("base", "")
+	readme := context.NewFile("README.md", "README.md")
+	readme.SetContent("# Base\n")
+	base.AddFile(readme)
+	auth := context.NewContext("auth", "Login flow")
+	auth.Includes = []string{base.ID}
+	env := context.NewFile("auth/.env", ".env")
+	env.SetContent("API_TOKEN=abc123\n")
+	auth.AddFile(env)
+	diff := context.NewFile("git#diff HEAD", "diff HEAD")
+	diff.SetContent("+token\n")
+	auth.AddFile(diff)
+	for _, ctx := range []*context.Context{base, auth} {
+		if err := store.SaveContext(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := Connect(gocontext.Background(), "aui", config.MCPServerConfig{
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^$"},
+		Env:     map[string]string{"AUI_MCP_STUB": "aui", "AUI_MCP_DB": db, "AUI_MCP_ROOT": root},
+	})
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer c.Close()
+	ctx := gocontext.Background()
+
+	if c.Server.Name != "aui" || !c.Has("tools") || !c.Has("resources") || c.Has("prompts") {
+		t.Errorf("Connect() server = %+v", c.Server)
+	}
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) != 3 || tools[0].Name != "list_contexts" || tools[1].Name != "get_context" || tools[2].Name != "add_file" {
+		t.Errorf("ListTools() = %+v", tools)
+	}
+
+	call := func(name, arguments string) *CallToolResult {
+		t.Helper()
+		result, err := c.CallTool(ctx, name, json.RawMessage(arguments))
+		if err != nil {
+			t.Fatalf("CallTool(%s) error = %v", name, err)
+		}
+		return result
+	}
+	if got := call("list_contexts", `{}`).Text(); !strings.Contains(got, "auth ("+auth.ID+")") || !strings.Contains(got, "Login flow") {
+		t.Errorf("list_contexts = %q", got)
+	}
+	got := call("get_context", `{"context":"auth"}`).Text()
+	if !strings.Contains(got, "# Base") || !strings.Contains(got, "API_TOKEN=") || strings.Contains(got, "abc123") {
+		t.Errorf("get_context = %q, want the included files and the secret redacted", got)
+	}
+	if result := call("get_context", `{"context":"missing"}`); !result.IsError || result.Text() != "context not found: missing" {
+		t.Errorf("get_context of a missing context = %+v", result)
+	}
+
+	resources, err := c.ListResources(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uris := make(map[string]bool)
+	for _, r := range resources {
+		uris[r.URI] = true
+	}
+	envURI := "aui://contexts/" + auth.ID + "/files/auth/.env"
+	diffURI := "aui://contexts/" + auth.ID + "/files/git%23diff%20HEAD"
+	if len(resources) != 5 || !uris["aui://contexts/"+auth.ID] || !uris[envURI] || !uris[diffURI] {
+		t.Errorf("ListResources() = %+v", resources)
+	}
+	contents, err := c.ReadResource(ctx, envURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contents) != 1 || contents[0].URI != envURI || !strings.HasPrefix(contents[0].Text, "API_TOKEN=") || strings.Contains(contents[0].Text, "abc123") {
+		t.Errorf("ReadResource() = %+v, want the file redacted", contents)
+	}
+	if contents, err := c.ReadResource(ctx, diffURI); err != nil || contents[0].Text != "+token\n" {
+		t.Errorf("ReadResource() of an escaped path = %+v, %v", contents, err)
+	}
+	if _, err := c.ReadResource(ctx, "aui://contexts/missing"); err == nil || !strings.Contains(err.Error(), "context not found") {
+		t.Errorf("ReadResource() of a missing context error = %v", err)
+	}
+
+	if got := call("add_file", `{"context":"auth","path":"auth/login.go"}`).Text(); !strings.HasPrefix(got, "Added auth/login.go to auth") {
+		t.Errorf("add_file = %q", got)
+	}
+	call("add_file", `{"context":"auth","path":"notes.md","content":"# Notes\n"}`)
+	if result := call("add_file", `{"context":"auth","path":"../secret.txt"}`); !result.IsError {
+		t.Errorf("add_file outside the root = %+v, want an error", result)
+	}
+	saved, err := store.GetContext(auth.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := saved.GetFile("auth/login.go"); f == nil || f.Content != "package auth\n" || f.Language != "go" {
+		t.Errorf("saved file = %+v, want the file read from disk", f)
+	}
+	if f := saved.GetFile("notes.md"); f == nil || f.Content != "# Notes\n" {
+		t.Errorf("saved file = %+v, want the given content", f)
+	}
+
+	if _, err := c.CallTool(ctx, "missing", json.RawMessage(`{}`)); err == nil || !strings.Contains(err.Error(), "unknown tool: missing") {
+		t.Errorf("CallTool() of a missing tool error = %v", err)
+	}
+}
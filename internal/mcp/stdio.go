@@ -0,0 +1,195 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	gocontext "context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxLine caps a message read from a server over stdio
+const maxLine = 16 << 20
+
+// stdioTransport talks to a server process over its stdin and stdout, one
+// JSON message per line
+type stdioTransport struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	mu      sync.Mutex // Guards writes to stdin and pending
+	pending map[string]chan *message
+	stderr  tailBuffer
+
+	done chan struct{} // Closed once stdout ends and the server has exited
+	err  error         // Why the connection ended, set before done is closed
+}
+
+// startStdio starts a server process with env added to the environment
+func startStdio(command string, args []string, env map[string]string) (*stdioTransport, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	t := &stdioTransport{cmd: cmd, pending: make(map[string]chan *message), done: make(chan struct{})}
+	cmd.Stderr = &t.stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", command, err)
+	}
+	t.stdin = stdin
+
+	go t.read(stdout)
+	return t, nil
+}
+
+// read delivers the responses the server writes until its stdout ends
+func (t *stdioTransport) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxLine)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var msg message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			continue
+		}
+
+		switch {
+		case msg.isResponse():
+			t.mu.Lock()
+			ch := t.pending[string(msg.ID)]
+			delete(t.pending, string(msg.ID))
+			t.mu.Unlock()
+			if ch != nil {
+				ch <- &msg
+			}
+		case msg.Method != "" && len(msg.ID) > 0:
+			// Requests from the server, such as for sampling, are not
+			// supported beyond pings
+			reply := &message{JSONRPC: "2.0", ID: msg.ID, Result: json.RawMessage("{}")}
+			if msg.Method != "ping" {
+				reply = &message{JSONRPC: "2.0", ID: msg.ID, Error: &Error{Code: CodeMethodNotFound, Message: "method not found: " + msg.Method}}
+			}
+			t.write(reply)
+		}
+	}
+
+	// The server's stderr says why it stopped once it has exited
+	scanErr := scanner.Err()
+	waitErr := t.cmd.Wait()
+	switch {
+	case scanErr != nil:
+		t.err = scanErr
+	case waitErr != nil:
+		t.err = fmt.Errorf("server exited: %w", waitErr)
+	default:
+		t.err = fmt.Errorf("server closed the connection")
+	}
+	if stderr := t.stderr.String(); stderr != "" {
+		t.err = fmt.Errorf("%w: %s", t.err, stderr)
+	}
+	close(t.done)
+}
+
+// write sends a message as a line
+func (t *stdioTransport) write(msg *message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err = t.stdin.Write(append(data, '\n'))
+	return err
+}
+
+func (t *stdioTransport) roundTrip(ctx gocontext.Context, req *message) (*message, error) {
+	ch := make(chan *message, 1)
+	t.mu.Lock()
+	t.pending[string(req.ID)] = ch
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, string(req.ID))
+		t.mu.Unlock()
+	}()
+
+	if err := t.write(req); err != nil {
+		select {
+		case <-t.done:
+			return nil, t.err
+		default:
+			return nil, fmt.Errorf("failed to send request: %w", err)
+		}
+	}
+
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-t.done:
+		return nil, t.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (t *stdioTransport) notify(_ gocontext.Context, n *message) error {
+	return t.write(n)
+}
+
+// close closes the server's stdin, which asks it to exit, and kills it if
+// it has not within a few seconds
+func (t *stdioTransport) close() error {
+	t.stdin.Close()
+	select {
+	case <-t.done:
+	case <-time.After(3 * time.Second):
+		t.cmd.Process.Kill()
+		<-t.done
+	}
+	return nil
+}
+
+// tailBuffer keeps the last lines a server writes to stderr, for errors
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+// maxStderr caps the stderr kept
+const maxStderr = 2048
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > maxStderr {
+		b.buf = b.buf[len(b.buf)-maxStderr:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.TrimSpace(string(b.buf))
+}
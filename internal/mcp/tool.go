@@ -0,0 +1,108 @@
+package mcp
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/tools"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// invalidToolChars are the characters providers refuse in tool names
+var invalidToolChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// ToolName returns the name agents call a server's tool by, e.g.
+// "github__create_issue"
+func ToolName(server, tool string) string {
+	name := invalidToolChars.ReplaceAllString(server+"__"+tool, "_")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// ServerTool lets agents call a tool of an MCP server
+type ServerTool struct {
+	Client *Client
+	Tool   Tool
+}
+
+// Spec describes the tool under its ToolName
+func (t ServerTool) Spec() api.Tool {
+	description := t.Tool.Description
+	if description == "" {
+		description = t.Tool.Name
+	}
+	parameters := t.Tool.InputSchema
+	if parameters == nil {
+		parameters = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+	return api.Tool{
+		Name:        ToolName(t.Client.Name, t.Tool.Name),
+		Description: fmt.Sprintf("[%s] %s", t.Client.Name, description),
+		Parameters:  parameters,
+	}
+}
+
+// Run calls the tool on the server
+func (t ServerTool) Run(ctx gocontext.Context, input json.RawMessage) (string, error) {
+	result, err := t.Client.CallTool(ctx, t.Tool.Name, input)
+	if err != nil {
+		return "", err
+	}
+	if result.IsError {
+		return "", errors.New(result.Text())
+	}
+	return result.Text(), nil
+}
+
+// Tools returns the tools of every client for agents. Servers whose tools
+// cannot be listed are left out and their errors joined.
+func Tools(ctx gocontext.Context, clients []*Client) ([]tools.Tool, error) {
+	var all []tools.Tool
+	var errs []error
+	for _, c := range clients {
+		list, err := c.ListTools(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.Name, err))
+			continue
+		}
+		for _, tool := range list {
+			all = append(all, ServerTool{Client: c, Tool: tool})
+		}
+	}
+	return all, errors.Join(errs...)
+}
+
+// ResourceFiles reads a resource of a server as context files, one per text
+// content, with the URI as path. Binary contents are skipped.
+func ResourceFiles(ctx gocontext.Context, c *Client, uri string) ([]*context.File, error) {
+	contents, err := c.ReadResource(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*context.File
+	for _, content := range contents {
+		if content.Blob != "" {
+			continue
+		}
+		if content.URI == "" {
+			content.URI = uri
+		}
+		f := context.NewFile(content.URI, path.Base(strings.TrimRight(content.URI, "/")))
+		f.SetContent(content.Text)
+		f.DetectLanguage()
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("resource %s has no text content", uri)
+	}
+	return files, nil
+}
@@ -0,0 +1,69 @@
+package mcp
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestToolName(t *testing.T) {
+	tests := []struct {
+		server, tool string
+		want         string
+	}{
+		{"github", "create_issue", "github__create_issue"},
+		{"docs", "search.pages", "docs__search_pages"},
+		{"db", strings.Repeat("x", 70), "db__" + strings.Repeat("x", 60)},
+	}
+	for _, tt := range tests {
+		if got := ToolName(tt.server, tt.tool); got != tt.want {
+			t.Errorf("ToolName(%q, %q) = %q, want %q", tt.server, tt.tool, got, tt.want)
+		}
+	}
+}
+
+func TestServerTools(t *testing.T) {
+	c := connectStub(t)
+	ctx := gocontext.Background()
+
+	all, err := Tools(ctx, []*Client{c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("Tools() = %d tools, want 2", len(all))
+	}
+
+	spec := all[0].Spec()
+	if spec.Name != "stub__echo" || spec.Description != "[stub] Echo text" || spec.Parameters["type"] != "object" {
+		t.Errorf("Spec() = %+v", spec)
+	}
+	if spec := all[1].Spec(); spec.Name != "stub__fail_now" || spec.Parameters["type"] != "object" {
+		t.Errorf("Spec() without a schema = %+v", spec)
+	}
+
+	got, err := all[0].Run(ctx, json.RawMessage(`{"text":"hello"}`))
+	if err != nil || got != "hello" {
+		t.Errorf("Run() = %q, %v, want hello", got, err)
+	}
+	if _, err := all[1].Run(ctx, json.RawMessage(`{}`)); err == nil || err.Error() != "it broke" {
+		t.Errorf("Run() of a failing tool error = %v, want it broke", err)
+	}
+}
+
+func TestResourceFiles(t *testing.T) {
+	c := connectStub(t)
+
+	files, err := ResourceFiles(gocontext.Background(), c, "notes://team/standup.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("ResourceFiles() = %d files, want the text content only", len(files))
+	}
+	f := files[0]
+	if f.Path != "notes://team/standup.md" || f.Name != "standup.md" || f.Content != "# Standup\n" || f.Language != "markdown" || f.Hash == "" {
+		t.Errorf("ResourceFiles() file = %+v", f)
+	}
+}
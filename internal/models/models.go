@@ -0,0 +1,216 @@
+package models
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/pkg/api"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed catalog.yaml
+var builtin []byte
+
+// Feature is a capability a model may support
+type Feature string
+
+const (
+	FeatureVision    Feature = "vision"
+	FeatureTools     Feature = "tools"
+	FeatureJSON      Feature = "json"
+	FeatureStreaming Feature = "streaming"
+	FeatureThinking  Feature = "thinking"
+)
+
+// Model describes a model's limits, capabilities and prices
+type Model struct {
+	ID            string    `yaml:"id"`
+	Provider      string    `yaml:"provider"`
+	Name          string    `yaml:"name"`
+	ContextWindow int       `yaml:"context_window"` // Input and output tokens combined
+	MaxOutput     int       `yaml:"max_output"`
+	Features      []Feature `yaml:"features"`
+	InputPrice    float64   `yaml:"input_price"`  // USD per million input tokens
+	OutputPrice   float64   `yaml:"output_price"` // USD per million output tokens
+}
+
+// Has reports whether the model supports a feature
+func (m *Model) Has(f Feature) bool {
+	for _, feature := range m.Features {
+		if feature == f {
+			return true
+		}
+	}
+	return false
+}
+
+// Cost returns the price in USD of a request's token usage
+func (m *Model) Cost(usage api.TokenUsage) float64 {
+	return (float64(usage.InputTokens)*m.InputPrice + float64(usage.OutputTokens)*m.OutputPrice) / 1e6
+}
+
+// InputBudget returns how many prompt tokens fit in the context window while
+// leaving room for maxOutput tokens of reply, or the model's default output
+// limit if maxOutput is zero
+func (m *Model) InputBudget(maxOutput int) int {
+	if maxOutput <= 0 {
+		maxOutput = min(m.MaxOutput, api.DefaultMaxTokens)
+	}
+	if budget := m.ContextWindow - maxOutput; budget > 0 {
+		return budget
+	}
+	return 0
+}
+
+// Catalog is a set of models looked up by ID
+type Catalog struct {
+	models []*Model
+}
+
+// Default returns the built-in catalog
+func Default() *Catalog {
+	c := &Catalog{}
+	if err := c.merge(builtin); err != nil {
+		panic(fmt.Sprintf("invalid built-in model catalog: %v", err))
+	}
+	return c
+}
+
+// OverridePath returns the user's catalog override file
+func OverridePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "aui", "models.yaml")
+}
+
+// Load returns the built-in catalog with the given override files applied
+// in order. Missing files are skipped. An override entry with a known ID
+// replaces only the fields it sets; other entries add models.
+func Load(paths ...string) (*Catalog, error) {
+	c := Default()
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read model catalog: %w", err)
+		}
+		if err := c.merge(data); err != nil {
+			return nil, fmt.Errorf("invalid model catalog %s: %w", path, err)
+		}
+	}
+	return c, nil
+}
+
+// merge applies the models in a YAML catalog document
+func (c *Catalog) merge(data []byte) error {
+	var doc struct {
+		Models []yaml.Node `yaml:"models"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	for _, node := range doc.Models {
+		var id struct {
+			ID string `yaml:"id"`
+		}
+		if err := node.Decode(&id); err != nil {
+			return err
+		}
+		if id.ID == "" {
+			return fmt.Errorf("model without an id at line %d", node.Line)
+		}
+
+		if existing := c.Get(id.ID); existing != nil {
+			updated := *existing
+			if err := node.Decode(&updated); err != nil {
+				return fmt.Errorf("model %s: %w", id.ID, err)
+			}
+			*existing = updated
+			continue
+		}
+
+		m := &Model{}
+		if err := node.Decode(m); err != nil {
+			return fmt.Errorf("model %s: %w", id.ID, err)
+		}
+		if m.Provider == "" {
+			return fmt.Errorf("model %s has no provider", m.ID)
+		}
+		c.models = append(c.models, m)
+	}
+
+	return nil
+}
+
+// Get returns the model with the given ID, or nil
+func (c *Catalog) Get(id string) *Model {
+	for _, m := range c.models {
+		if m.ID == id {
+			return m
+		}
+	}
+	return nil
+}
+
+// All returns every model in catalog order
+func (c *Catalog) All() []*Model {
+	return c.models
+}
+
+// ForProvider returns a provider's models in catalog order
+func (c *Catalog) ForProvider(provider string) []*Model {
+	var models []*Model
+	for _, m := range c.models {
+		if m.Provider == provider {
+			models = append(models, m)
+		}
+	}
+	return models
+}
+
+// IDs returns the IDs of a provider's models
+func (c *Catalog) IDs(provider string) []string {
+	var ids []string
+	for _, m := range c.ForProvider(provider) {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+// Validate checks that an agent's model is in the catalog, belongs to its
+// provider and supports the parameters the agent sets
+func (c *Catalog) Validate(a *agent.Agent) error {
+	m := c.Get(a.Model)
+	if m == nil {
+		return fmt.Errorf("unknown model %s (add it to %s)", a.Model, OverridePath())
+	}
+	if m.Provider != a.Provider {
+		return fmt.Errorf("model %s is served by %s, not %s", m.ID, m.Provider, a.Provider)
+	}
+
+	p := a.Params
+	if m.MaxOutput > 0 && p.MaxTokens > m.MaxOutput {
+		return fmt.Errorf("max_tokens %d exceeds the %d output tokens of %s", p.MaxTokens, m.MaxOutput, m.ID)
+	}
+	if (p.ReasoningEffort != "" || p.ThinkingBudget > 0) && !m.Has(FeatureThinking) {
+		return fmt.Errorf("model %s does not support reasoning", m.ID)
+	}
+	if p.ThinkingBudget > 0 && m.ContextWindow > 0 && p.ThinkingBudget >= m.ContextWindow {
+		return fmt.Errorf("thinking_budget %d exceeds the context window of %s", p.ThinkingBudget, m.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+func TestDefault(t *testing.T) {
+	c := Default()
+
+	seen := make(map[string]bool)
+	for _, m := range c.All() {
+		if seen[m.ID] {
+			t.Errorf("duplicate model %s", m.ID)
+		}
+		seen[m.ID] = true
+
+		if m.ContextWindow <= 0 || m.MaxOutput <= 0 || m.MaxOutput > m.ContextWindow {
+			t.Errorf("model %s has invalid limits %d/%d", m.ID, m.ContextWindow, m.MaxOutput)
+		}
+		if m.InputPrice <= 0 || m.OutputPrice <= 0 {
+			t.Errorf("model %s has no prices", m.ID)
+		}
+		if !m.Has(FeatureStreaming) {
+			t.Errorf("model %s should support streaming", m.ID)
+		}
+	}
+
+	for _, provider := range []string{"anthropic", "openai", "google"} {
+		if len(c.ForProvider(provider)) == 0 {
+			t.Errorf("ForProvider(%q) returned no models", provider)
+		}
+	}
+
+	if c.Get("no-such-model") != nil {
+		t.Error("Get() of an unknown model should return nil")
+	}
+}
+
+func TestLoadOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.yaml")
+	override := `models:
+  - id: gpt-4o
+    input_price: 1
+  - id: local-llama
+    provider: openai
+    context_window: 8192
+    max_output: 2048
+    features: [streaming]
+`
+	if err := os.WriteFile(path, []byte(override), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(path, filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	m := c.Get("gpt-4o")
+	if m.InputPrice != 1 || m.OutputPrice != 10 || m.ContextWindow != 128000 {
+		t.Errorf("override should change only input_price, got %+v", m)
+	}
+	if Default().Get("gpt-4o").InputPrice != 2.5 {
+		t.Error("Load() should not change the built-in catalog")
+	}
+	if local := c.Get("local-llama"); local == nil || local.Provider != "openai" {
+		t.Errorf("Get(local-llama) = %+v, want added model", local)
+	}
+	if ids := c.IDs("openai"); ids[len(ids)-1] != "local-llama" {
+		t.Errorf("IDs() = %v, want added model last", ids)
+	}
+
+	for _, bad := range []string{"models: [{name: x}]", "models: [{id: x}]", "models: {"} {
+		if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("Load(%q) expected error", bad)
+		}
+	}
+}
+
+func TestModelCost(t *testing.T) {
+	m := &Model{InputPrice: 3, OutputPrice: 15}
+	got := m.Cost(api.TokenUsage{InputTokens: 10000, OutputTokens: 2000})
+	if want := 0.06; math.Abs(got-want) > 1e-9 {
+		t.Errorf("Cost() = %v, want %v", got, want)
+	}
+}
+
+func TestModelInputBudget(t *testing.T) {
+	m := &Model{ContextWindow: 200000, MaxOutput: 64000}
+	if got := m.InputBudget(8000); got != 192000 {
+		t.Errorf("InputBudget(8000) = %d, want 192000", got)
+	}
+	if got := m.InputBudget(0); got != 200000-api.DefaultMaxTokens {
+		t.Errorf("InputBudget(0) = %d, want %d", got, 200000-api.DefaultMaxTokens)
+	}
+	if got := m.InputBudget(300000); got != 0 {
+		t.Errorf("InputBudget(300000) = %d, want 0", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := Default()
+
+	tests := []struct {
+		name     string
+		model    string
+		provider string
+		params   string
+		wantErr  bool
+	}{
+		{"valid", "claude-sonnet-4-5", "anthropic", "max_tokens=8000 reasoning=high", false},
+		{"unknown model", "claude-9", "anthropic", "", true},
+		{"wrong provider", "gpt-4o", "anthropic", "", true},
+		{"too many output tokens", "gpt-4o", "openai", "max_tokens=20000", true},
+		{"no thinking support", "gpt-4o", "openai", "reasoning=low", true},
+		{"thinking budget", "gemini-2.5-flash", "google", "thinking_budget=2048", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := agent.NewAgent("test", tt.model, tt.provider)
+			a.Params, _ = agent.ParseParams(tt.params)
+			err := c.Validate(a)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
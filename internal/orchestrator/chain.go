@@ -0,0 +1,163 @@
+package orchestrator
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/internal/ledger"
+	"github.com/yourusername/aui/internal/models"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// Chain asks its tiers in turn, cheapest first, until one gives an answer
+// that is not an error, refusal or timeout and that passes every check
+type Chain struct {
+	Name    string
+	Tiers   []*agent.Agent
+	Timeout time.Duration // Per tier; zero for none
+	Checks  []Check
+}
+
+// NewChain resolves a configured chain. Each tier names an agent or, failing
+// that, a model from the catalog, which is asked without a system prompt or
+// parameters of its own.
+func NewChain(name string, cfg config.ChainConfig, agents []*agent.Agent, catalog *models.Catalog) (*Chain, error) {
+	if len(cfg.Tiers) == 0 {
+		return nil, fmt.Errorf("chain %s has no tiers", name)
+	}
+	chain := &Chain{Name: name, Timeout: cfg.Timeout}
+
+	for _, tier := range cfg.Tiers {
+		ag, err := ResolveAgent(tier, agents, catalog)
+		if err != nil {
+			return nil, fmt.Errorf("chain %s: %w", name, err)
+		}
+		chain.Tiers = append(chain.Tiers, ag)
+	}
+
+	for _, s := range cfg.Require {
+		check, err := ParseCheck(s)
+		if err != nil {
+			return nil, fmt.Errorf("chain %s: %w", name, err)
+		}
+		chain.Checks = append(chain.Checks, check)
+	}
+
+	return chain, nil
+}
+
+// ResolveAgent returns the agent with a name or, failing that, an agent for
+// the catalog model with that ID
+func ResolveAgent(name string, agents []*agent.Agent, catalog *models.Catalog) (*agent.Agent, error) {
+	if ag := findAgent(agents, name); ag != nil {
+		return ag, nil
+	}
+	m := catalog.Get(name)
+	if m == nil {
+		return nil, fmt.Errorf("%s is neither an agent nor a known model", name)
+	}
+	return &agent.Agent{Name: m.ID, Model: m.ID, Provider: m.Provider, Status: agent.StatusReady}, nil
+}
+
+// findAgent returns the agent with a name, ignoring case, or nil
+func findAgent(agents []*agent.Agent, name string) *agent.Agent {
+	for _, ag := range agents {
+		if strings.EqualFold(ag.Name, name) {
+			return ag
+		}
+	}
+	return nil
+}
+
+// Attempt is the outcome of asking one tier of a chain
+type Attempt struct {
+	Tier      int // 1-based
+	Agent     *agent.Agent
+	Answer    string
+	Usage     api.TokenUsage
+	Escalated string // Why the next tier was asked; empty if this tier answered
+}
+
+// RunChain sends req to each tier of a chain in turn through the queue,
+// applying the tier's agent to a copy of it, and returns every attempt made.
+// The last attempt holds the answer unless all tiers failed, which is an
+// error. Every attempt is recorded in the usage ledger with its tier.
+func (o *Orchestrator) RunChain(ctx gocontext.Context, chain *Chain, req *api.Request, providerFor func(*agent.Agent) (api.Provider, error)) ([]Attempt, error) {
+	var attempts []Attempt
+
+	for i, ag := range chain.Tiers {
+		attempt := Attempt{Tier: i + 1, Agent: ag}
+
+		// A tier that cannot be reached is skipped without a ledger entry
+		provider, err := providerFor(ag)
+		if err != nil {
+			attempt.Escalated = "error: " + err.Error()
+			attempts = append(attempts, attempt)
+			continue
+		}
+
+		attempt.Answer, attempt.Usage, attempt.Escalated = o.ask(ctx, chain, ag, req, provider)
+		if err := ctx.Err(); err != nil {
+			return attempts, err
+		}
+
+		attempts = append(attempts, attempt)
+		o.recordTier(chain, attempt)
+		if attempt.Escalated == "" {
+			return attempts, nil
+		}
+	}
+
+	reasons := make([]string, len(attempts))
+	for i, a := range attempts {
+		reasons[i] = a.Agent.Name + ": " + a.Escalated
+	}
+	return attempts, fmt.Errorf("every tier of chain %s failed (%s)", chain.Name, strings.Join(reasons, "; "))
+}
+
+// ask sends a request to one tier and returns its answer and usage, and why
+// the answer is not good enough, if it is not
+func (o *Orchestrator) ask(ctx gocontext.Context, chain *Chain, ag *agent.Agent, req *api.Request, provider api.Provider) (string, api.TokenUsage, string) {
+	tierCtx := ctx
+	if chain.Timeout > 0 {
+		var cancel gocontext.CancelFunc
+		tierCtx, cancel = gocontext.WithTimeout(ctx, chain.Timeout)
+		defer cancel()
+	}
+
+	answer, usage, err := o.collect(tierCtx, ag, req, provider)
+
+	switch {
+	case errors.Is(err, gocontext.DeadlineExceeded) || errors.Is(tierCtx.Err(), gocontext.DeadlineExceeded):
+		return answer, usage, "timeout"
+	case err != nil:
+		return answer, usage, "error: " + err.Error()
+	case strings.TrimSpace(answer) == "":
+		return answer, usage, "empty answer"
+	case IsRefusal(answer):
+		return answer, usage, "refusal"
+	}
+	for _, check := range chain.Checks {
+		if !check.Pass(answer) {
+			return answer, usage, "failed check " + check.Name
+		}
+	}
+	return answer, usage, ""
+}
+
+// recordTier adds the usage of one attempt to the ledger
+func (o *Orchestrator) recordTier(chain *Chain, a Attempt) {
+	if o.Store == nil {
+		return
+	}
+	entry := ledger.NewEntry(a.Agent, a.Usage, o.Catalog)
+	entry.Chain = chain.Name
+	entry.Tier = a.Tier
+	entry.Escalated = a.Escalated
+	o.Store.RecordUsage(entry)
+}
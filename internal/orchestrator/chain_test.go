@@ -0,0 +1,147 @@
+package orchestrator
+
+import (
+	gocontext "context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/internal/models"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// scriptedProvider answers each model with a fixed reply or error, and
+// hangs for models in slow
+type scriptedProvider struct {
+	replies map[string]string
+	errs    map[string]error
+	slow    map[string]bool
+}
+
+func (p *scriptedProvider) Name() string { return "fake" }
+
+func (p *scriptedProvider) ValidateConfig(map[string]string) error { return nil }
+
+func (p *scriptedProvider) SendMessage(ctx gocontext.Context, req *api.Request) (<-chan api.Response, error) {
+	if err := p.errs[req.Model]; err != nil {
+		return nil, err
+	}
+	ch := make(chan api.Response, 1)
+	go func() {
+		defer close(ch)
+		if p.slow[req.Model] {
+			<-ctx.Done()
+			ch <- api.Response{Error: ctx.Err()}
+			return
+		}
+		ch <- api.Response{Content: p.replies[req.Model], Done: true, Usage: api.TokenUsage{InputTokens: 100, OutputTokens: 10}}
+	}()
+	return ch, nil
+}
+
+func TestNewChain(t *testing.T) {
+	sonnet := agent.NewAgent("Sonnet", "claude-sonnet-4-5", "anthropic")
+	cfg := config.ChainConfig{Tiers: []string{"gemini-2.5-flash", "sonnet"}, Require: []string{"code_block"}}
+
+	chain, err := NewChain("coding", cfg, []*agent.Agent{sonnet}, models.Default())
+	if err != nil {
+		t.Fatalf("NewChain() error = %v", err)
+	}
+	if len(chain.Tiers) != 2 || chain.Tiers[0].Provider != "google" || chain.Tiers[1] != sonnet || len(chain.Checks) != 1 {
+		t.Errorf("NewChain() = %+v", chain)
+	}
+
+	for _, bad := range []config.ChainConfig{
+		{},
+		{Tiers: []string{"no-such-model"}},
+		{Tiers: []string{"sonnet"}, Require: []string{"telepathy"}},
+	} {
+		if _, err := NewChain("bad", bad, []*agent.Agent{sonnet}, models.Default()); err == nil {
+			t.Errorf("NewChain(%+v) should fail", bad)
+		}
+	}
+}
+
+func TestRunChain(t *testing.T) {
+	o := testOrchestrator(t)
+	provider := &scriptedProvider{
+		replies: map[string]string{
+			"gemini-2.5-flash":  "I can't help with that.",
+			"claude-haiku-4-5":  "Just rename the variable.",
+			"claude-sonnet-4-5": "Rename it:\n```go\nuser := load()\n```",
+		},
+		errs: map[string]error{"gpt-4.1": errors.New("401 unauthorized")},
+		slow: map[string]bool{"o3": true},
+	}
+	providerFor := func(*agent.Agent) (api.Provider, error) { return provider, nil }
+
+	cfg := config.ChainConfig{
+		Tiers:   []string{"gemini-2.5-flash", "gpt-4.1", "o3", "claude-haiku-4-5", "claude-sonnet-4-5", "claude-opus-4-1"},
+		Timeout: 50 * time.Millisecond,
+		Require: []string{"code_block"},
+	}
+	chain, err := NewChain("coding", cfg, nil, models.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &api.Request{Messages: []api.Message{{Role: api.RoleUser, Content: "Fix the shadowed variable"}}}
+	attempts, err := o.RunChain(gocontext.Background(), chain, req, providerFor)
+	if err != nil {
+		t.Fatalf("RunChain() error = %v", err)
+	}
+
+	reasons := make([]string, len(attempts))
+	for i, a := range attempts {
+		reasons[i] = a.Escalated
+	}
+	want := []string{"refusal", "error: 401 unauthorized", "timeout", "failed check code_block", ""}
+	if strings.Join(reasons, "|") != strings.Join(want, "|") {
+		t.Errorf("escalations = %q, want %q", reasons, want)
+	}
+	if answer := attempts[len(attempts)-1]; answer.Tier != 5 || !strings.Contains(answer.Answer, "```go") {
+		t.Errorf("answer = %+v, want tier 5", answer)
+	}
+	if req.Model != "" {
+		t.Error("RunChain() should not modify the request")
+	}
+
+	entries, _ := o.Store.ListUsage(time.Time{})
+	if len(entries) != 5 {
+		t.Fatalf("ledger has %d entries, want one per attempt", len(entries))
+	}
+	last := entries[len(entries)-1]
+	if last.Chain != "coding" || last.Tier != 5 || last.Escalated != "" || last.Model != "claude-sonnet-4-5" || last.Cost == 0 {
+		t.Errorf("answering entry = %+v", last)
+	}
+}
+
+func TestRunChainAllFail(t *testing.T) {
+	o := testOrchestrator(t)
+	provider := &scriptedProvider{replies: map[string]string{"gemini-2.5-flash": "", "claude-haiku-4-5": "I cannot do that."}}
+	chain, err := NewChain("cheap", config.ChainConfig{Tiers: []string{"gemini-2.5-flash", "claude-haiku-4-5"}}, nil, models.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain.Tiers = append(chain.Tiers, &agent.Agent{Name: "offline", Model: "m", Provider: "unknown"})
+
+	attempts, err := o.RunChain(gocontext.Background(), chain, &api.Request{}, func(ag *agent.Agent) (api.Provider, error) {
+		if ag.Provider == "unknown" {
+			return nil, errors.New("unknown provider")
+		}
+		return provider, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "gemini-2.5-flash: empty answer; claude-haiku-4-5: refusal; offline: error: unknown provider") {
+		t.Errorf("RunChain() error = %v", err)
+	}
+	if len(attempts) != 3 {
+		t.Errorf("RunChain() made %d attempts, want 3", len(attempts))
+	}
+	if entries, _ := o.Store.ListUsage(time.Time{}); len(entries) != 2 {
+		t.Errorf("ledger has %d entries, want none for the unreachable tier", len(entries))
+	}
+}
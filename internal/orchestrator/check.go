@@ -0,0 +1,81 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Check is a test an answer must pass for a chain to accept it
+type Check struct {
+	Name string
+	Pass func(answer string) bool
+}
+
+// ParseCheck parses a check from the configuration:
+//
+//	code_block      the answer contains a fenced code block
+//	json            the answer is valid JSON
+//	contains:TEXT   the answer contains TEXT
+//	matches:REGEX   the answer matches REGEX
+//	min_length:N    the answer is at least N characters long
+func ParseCheck(s string) (Check, error) {
+	name, arg, _ := strings.Cut(s, ":")
+
+	switch name {
+	case "code_block":
+		return Check{Name: s, Pass: func(answer string) bool {
+			return strings.Count(answer, "```") >= 2
+		}}, nil
+	case "json":
+		return Check{Name: s, Pass: func(answer string) bool {
+			return json.Valid([]byte(strings.TrimSpace(answer)))
+		}}, nil
+	case "contains":
+		if arg == "" {
+			return Check{}, fmt.Errorf("check %q needs text to look for", s)
+		}
+		return Check{Name: s, Pass: func(answer string) bool {
+			return strings.Contains(answer, arg)
+		}}, nil
+	case "matches":
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return Check{}, fmt.Errorf("invalid check %q: %w", s, err)
+		}
+		return Check{Name: s, Pass: re.MatchString}, nil
+	case "min_length":
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 0 {
+			return Check{}, fmt.Errorf("invalid check %q: length must be a number", s)
+		}
+		return Check{Name: s, Pass: func(answer string) bool {
+			return utf8.RuneCountInString(answer) >= n
+		}}, nil
+	default:
+		return Check{}, fmt.Errorf("unknown check: %s (must be code_block, json, contains:, matches: or min_length:)", s)
+	}
+}
+
+// refusalOpenings start answers in which a model declines the request
+var refusalOpenings = []string{
+	"i can't", "i cannot", "i can not", "i won't", "i will not",
+	"i'm unable", "i am unable", "i'm not able", "i am not able",
+	"i'm sorry, but", "i am sorry, but", "sorry, but i", "sorry, i can't",
+}
+
+// IsRefusal reports whether an answer declines the request. Only the opening
+// is considered, so answers that merely mention a limitation pass.
+func IsRefusal(answer string) bool {
+	opening := strings.ToLower(strings.TrimSpace(answer))
+	opening = strings.ReplaceAll(opening, "’", "'")
+	for _, prefix := range refusalOpenings {
+		if strings.HasPrefix(opening, prefix) {
+			return true
+		}
+	}
+	return false
+}
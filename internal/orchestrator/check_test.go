@@ -0,0 +1,58 @@
+package orchestrator
+
+import "testing"
+
+func TestParseCheck(t *testing.T) {
+	tests := []struct {
+		check  string
+		answer string
+		want   bool
+	}{
+		{"code_block", "Try:\n```go\nx := 1\n```", true},
+		{"code_block", "Use x := 1", false},
+		{"json", ` {"ok": true} `, true},
+		{"json", "ok", false},
+		{"contains:func ", "func main() {}", true},
+		{"contains:func ", "main", false},
+		{"matches:^[0-9]+$", "42", true},
+		{"matches:^[0-9]+$", "forty", false},
+		{"min_length:5", "héllo", true},
+		{"min_length:5", "hi", false},
+	}
+
+	for _, tt := range tests {
+		check, err := ParseCheck(tt.check)
+		if err != nil {
+			t.Errorf("ParseCheck(%q) error = %v", tt.check, err)
+			continue
+		}
+		if got := check.Pass(tt.answer); got != tt.want {
+			t.Errorf("%s.Pass(%q) = %v, want %v", tt.check, tt.answer, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"unknown", "contains:", "matches:(", "min_length:many"} {
+		if _, err := ParseCheck(bad); err == nil {
+			t.Errorf("ParseCheck(%q) should fail", bad)
+		}
+	}
+}
+
+func TestIsRefusal(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   bool
+	}{
+		{"I can't help with that request.", true},
+		{"  I’m sorry, but I cannot do that.", true},
+		{"I am unable to access files.", true},
+		{"Sure! Here is the fix.", false},
+		{"The function can't be inlined because it recurses.", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsRefusal(tt.answer); got != tt.want {
+			t.Errorf("IsRefusal(%q) = %v, want %v", tt.answer, got, tt.want)
+		}
+	}
+}
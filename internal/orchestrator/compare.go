@@ -0,0 +1,126 @@
+package orchestrator
+
+import (
+	gocontext "context"
+	"fmt"
+	"sync"
+
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/judge"
+	"github.com/yourusername/aui/internal/ledger"
+	"github.com/yourusername/aui/internal/queue"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// FanOut sends req to every agent at once through the queue, applying each
+// agent to its own copy, and returns their responses in the order of agents.
+// Agents that fail keep their error in the candidate. The usage of every
+// answer is recorded in the ledger.
+func (o *Orchestrator) FanOut(ctx gocontext.Context, agents []*agent.Agent, req *api.Request, providerFor func(*agent.Agent) (api.Provider, error)) []judge.Candidate {
+	candidates := make([]judge.Candidate, len(agents))
+
+	var wg sync.WaitGroup
+	for i, ag := range agents {
+		candidates[i] = judge.Candidate{AgentID: ag.ID, Agent: ag.Name, Model: ag.Model}
+
+		provider, err := providerFor(ag)
+		if err != nil {
+			candidates[i].Error = err.Error()
+			continue
+		}
+
+		wg.Add(1)
+		go func(c *judge.Candidate, ag *agent.Agent) {
+			defer wg.Done()
+
+			answer, usage, cost, err := o.Ask(ctx, ag, req, provider)
+			if err != nil {
+				c.Error = err.Error()
+				return
+			}
+			c.Answer, c.Usage, c.Cost = answer, usage, cost
+		}(&candidates[i], ag)
+	}
+	wg.Wait()
+
+	return candidates
+}
+
+// Judge asks a judge agent to score the candidates of a comparison on a
+// rubric and returns its verdict. The judge's usage is recorded in the
+// ledger.
+func (o *Orchestrator) Judge(ctx gocontext.Context, judgeAgent *agent.Agent, rubric judge.Rubric, c *judge.Comparison, provider api.Provider) (*judge.Verdict, error) {
+	req, err := judge.Request(rubric, c.Prompt, c.Candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	answer, _, _, err := o.Ask(ctx, judgeAgent, req, provider)
+	if err != nil {
+		return nil, fmt.Errorf("judge %s failed: %w", judgeAgent.Name, err)
+	}
+
+	verdict, err := judge.Parse(answer, rubric, c.Candidates)
+	if err != nil {
+		return nil, err
+	}
+	verdict.Judge = judgeAgent.Name
+	verdict.Model = judgeAgent.Model
+	return verdict, nil
+}
+
+// collect sends a copy of base with an agent applied through the queue and
+// waits for the whole answer. With tools set the agent may call them, each
+// round of calls being answered with a new job.
+func (o *Orchestrator) collect(ctx gocontext.Context, ag *agent.Agent, base *api.Request, provider api.Provider) (string, api.TokenUsage, error) {
+	req := *base
+	req.Messages = append([]api.Message(nil), base.Messages...)
+	ag.ApplyTo(&req)
+
+	send := func(r *api.Request) <-chan api.Response {
+		return o.Submit(ctx, queue.NewJob(ag.ID, ag.Provider, r, requestTokens(r)), provider)
+	}
+	var answer string
+	var usage api.TokenUsage
+	var err error
+	if o.Tools != nil {
+		answer, usage, err = o.Tools.Loop(ctx, &req, send)
+	} else {
+		answer, usage, err = api.Collect(send(&req))
+	}
+
+	// A stream cut short by cancellation may close without an error
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	return answer, usage, err
+}
+
+// Ask sends a copy of req with an agent applied through the queue and
+// returns the whole answer, recording its usage in the ledger and returning
+// its cost
+func (o *Orchestrator) Ask(ctx gocontext.Context, ag *agent.Agent, req *api.Request, provider api.Provider) (string, api.TokenUsage, float64, error) {
+	answer, usage, err := o.collect(ctx, ag, req, provider)
+	if err != nil {
+		return answer, usage, 0, err
+	}
+
+	entry := ledger.NewEntry(ag, usage, o.Catalog)
+	if o.Store != nil {
+		o.Store.RecordUsage(entry)
+	}
+	return answer, usage, entry.Cost, nil
+}
+
+// requestTokens estimates the prompt tokens of a request
+func requestTokens(req *api.Request) int {
+	tokens := context.EstimateTokens(req.System)
+	for _, m := range req.Messages {
+		tokens += context.EstimateTokens(m.Content)
+		for _, r := range m.ToolResults {
+			tokens += context.EstimateTokens(r.Content)
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,133 @@
+package orchestrator
+
+import (
+	gocontext "context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/internal/judge"
+	"github.com/yourusername/aui/internal/tools"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+func TestFanOut(t *testing.T) {
+	o := testOrchestrator(t)
+	provider := &scriptedProvider{
+		replies: map[string]string{"claude-sonnet-4-5": "use a mutex", "gpt-4o": "use a channel"},
+		errs:    map[string]error{"o3": errors.New("401 unauthorized")},
+	}
+	agents := []*agent.Agent{
+		agent.NewAgent("Claude", "claude-sonnet-4-5", "anthropic"),
+		agent.NewAgent("GPT", "gpt-4o", "openai"),
+		agent.NewAgent("O3", "o3", "openai"),
+		agent.NewAgent("Offline", "gemini-2.5-pro", "google"),
+	}
+	providerFor := func(ag *agent.Agent) (api.Provider, error) {
+		if ag.Provider == "google" {
+			return nil, errors.New("no API key")
+		}
+		return provider, nil
+	}
+
+	candidates := o.FanOut(gocontext.Background(), agents, &api.Request{}, providerFor)
+	if len(candidates) != 4 {
+		t.Fatalf("FanOut() = %d candidates, want 4", len(candidates))
+	}
+	if c := candidates[0]; c.Agent != "Claude" || c.Answer != "use a mutex" || c.Cost == 0 || c.Error != "" {
+		t.Errorf("first candidate = %+v", c)
+	}
+	if c := candidates[1]; c.Answer != "use a channel" {
+		t.Errorf("second candidate = %+v", c)
+	}
+	if c := candidates[2]; !strings.Contains(c.Error, "401") {
+		t.Errorf("failed candidate = %+v, want its error", c)
+	}
+	if c := candidates[3]; c.Error != "no API key" {
+		t.Errorf("unreachable candidate = %+v, want its error", c)
+	}
+
+	entries, _ := o.Store.ListUsage(time.Time{})
+	if len(entries) != 2 {
+		t.Errorf("ledger has %d entries, want one per answer", len(entries))
+	}
+}
+
+func TestJudge(t *testing.T) {
+	o := testOrchestrator(t)
+	rubric, err := judge.Find(config.NewDefault(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := judge.NewComparison("fix the race", "")
+	c.Candidates = []judge.Candidate{{Agent: "Claude", Answer: "use a mutex"}, {Agent: "GPT", Answer: "use a channel"}}
+	judgeAgent := agent.NewAgent("Judge", "claude-opus-4-1", "anthropic")
+
+	provider := &scriptedProvider{replies: map[string]string{
+		"claude-opus-4-1": `{"scores": [{"candidate": "A", "criteria": {"correctness": 9}}, {"candidate": "B", "criteria": {"correctness": 6}}], "pick": "A", "reason": "simpler"}`,
+	}}
+	verdict, err := o.Judge(gocontext.Background(), judgeAgent, rubric, c, provider)
+	if err != nil {
+		t.Fatalf("Judge() error = %v", err)
+	}
+	if verdict.Judge != "Judge" || verdict.Model != "claude-opus-4-1" || verdict.Pick != 0 || len(verdict.Scores) != 2 {
+		t.Errorf("Judge() = %+v", verdict)
+	}
+
+	entries, _ := o.Store.ListUsage(time.Time{})
+	if len(entries) != 1 || entries[0].AgentID != judgeAgent.ID {
+		t.Errorf("ledger entries = %+v, want the judge's", entries)
+	}
+
+	provider.replies["claude-opus-4-1"] = "Both are fine."
+	if _, err := o.Judge(gocontext.Background(), judgeAgent, rubric, c, provider); err == nil {
+		t.Error("Judge() should fail when the judge does not answer with JSON")
+	}
+}
+
+// toolProvider asks for list_dir once, then answers with the listing it got
+type toolProvider struct{}
+
+func (p *toolProvider) Name() string { return "fake" }
+
+func (p *toolProvider) ValidateConfig(map[string]string) error { return nil }
+
+func (p *toolProvider) SendMessage(ctx gocontext.Context, req *api.Request) (<-chan api.Response, error) {
+	ch := make(chan api.Response, 1)
+	last := req.Messages[len(req.Messages)-1]
+	if len(last.ToolResults) == 0 {
+		ch <- api.Response{Done: true, Usage: api.TokenUsage{InputTokens: 10}, ToolCalls: []api.ToolCall{{ID: "1", Name: "list_dir"}}}
+	} else {
+		ch <- api.Response{Content: "found " + last.ToolResults[0].Content, Done: true, Usage: api.TokenUsage{InputTokens: 20}}
+	}
+	close(ch)
+	return ch, nil
+}
+
+func TestAskWithTools(t *testing.T) {
+	o := testOrchestrator(t)
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	o.Tools = &tools.Toolbox{Tools: tools.Builtin(root, nil, nil)}
+
+	base := &api.Request{Messages: []api.Message{{Role: api.RoleUser, Content: "what is here?"}}}
+	ag := agent.NewAgent("Claude", "claude-sonnet-4-5", "anthropic")
+	answer, usage, _, err := o.Ask(gocontext.Background(), ag, base, &toolProvider{})
+	if err != nil || answer != "found go.mod\n" {
+		t.Fatalf("Ask() = %q, %v", answer, err)
+	}
+	if usage.InputTokens != 30 {
+		t.Errorf("usage = %+v, want both requests counted", usage)
+	}
+	if len(base.Messages) != 1 || base.Tools != nil {
+		t.Errorf("Ask() should leave the request alone, got %+v", base)
+	}
+}
@@ -0,0 +1,194 @@
+package orchestrator
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/internal/ledger"
+	"github.com/yourusername/aui/internal/models"
+	"github.com/yourusername/aui/internal/queue"
+	"github.com/yourusername/aui/internal/storage"
+	"github.com/yourusername/aui/internal/tools"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// Orchestrator sends provider requests through a queue that enforces the
+// configured concurrency limits and token budgets. Jobs are persisted as
+// they move through the queue, so those still waiting when aui exits are
+// run again by Resume.
+type Orchestrator struct {
+	Queue   *queue.Queue
+	Store   *storage.SQLiteStore // Optional
+	Catalog *models.Catalog
+	Tools   *tools.Toolbox // Offered to agents with every request if set
+}
+
+// New creates an orchestrator with the limits from the configuration
+func New(cfg *config.Config, store *storage.SQLiteStore, catalog *models.Catalog) *Orchestrator {
+	return &Orchestrator{
+		Queue: queue.New(queue.Limits{
+			Concurrency:     cfg.Queue.Concurrency,
+			TokensPerMinute: cfg.Queue.TokensPerMinute,
+		}),
+		Store:   store,
+		Catalog: catalog,
+	}
+}
+
+// Submit queues a job and returns the channel its responses are sent to once
+// it runs. Cancelling ctx takes a waiting job out of the queue, in which
+// case the channel receives a single Error response. The caller reads the
+// channel to the end and records usage.
+func (o *Orchestrator) Submit(ctx gocontext.Context, job *queue.Job, provider api.Provider) <-chan api.Response {
+	out := make(chan api.Response, 16)
+
+	o.Queue.Push(job)
+	o.save(job)
+	go func() {
+		defer close(out)
+		o.run(ctx, job, provider, out)
+	}()
+
+	return out
+}
+
+// Resume queues again the batch jobs that were waiting or running when aui
+// last exited. Nobody is waiting for their replies, so their output is kept
+// with the job and their usage is recorded in the ledger. Interactive jobs
+// and turns of a tool loop are only worth sending with someone to read the
+// reply or answer the calls, so they are marked cancelled instead. It
+// returns how many jobs were resumed.
+func (o *Orchestrator) Resume(providerFor func(job *queue.Job) (api.Provider, error)) (int, error) {
+	if o.Store == nil {
+		return 0, nil
+	}
+
+	jobs, err := o.Store.ListJobs(queue.StatusQueued, queue.StatusRunning)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load queued jobs: %w", err)
+	}
+
+	resumed := 0
+	for _, job := range jobs {
+		if job.Priority >= queue.PriorityInteractive || (job.Request != nil && len(job.Request.Tools) > 0) {
+			job.Status = queue.StatusCancelled
+			job.Error = "interrupted when aui exited"
+			job.FinishedAt = time.Now()
+			o.save(job)
+			continue
+		}
+		resumed++
+
+		provider, err := providerFor(job)
+		if err != nil {
+			job.Status = queue.StatusFailed
+			job.Error = err.Error()
+			job.FinishedAt = time.Now()
+			o.save(job)
+			continue
+		}
+
+		job.StartedAt = time.Time{}
+		responses := o.Submit(gocontext.Background(), job, provider)
+		go func(job *queue.Job) {
+			if _, usage, err := api.Collect(responses); err == nil {
+				o.record(job, usage)
+			}
+		}(job)
+	}
+
+	return resumed, nil
+}
+
+// Interrupt records a job as cancelled when aui exits before it finishes,
+// so Resume does not send it again
+func (o *Orchestrator) Interrupt(job *queue.Job) {
+	if o.Store != nil {
+		o.Store.CancelJob(job.ID, time.Now())
+	}
+}
+
+// run waits for the job's turn, sends it and forwards its responses to out
+func (o *Orchestrator) run(ctx gocontext.Context, job *queue.Job, provider api.Provider, out chan<- api.Response) {
+	if err := o.wait(ctx, job); err != nil {
+		job.Status = queue.StatusCancelled
+		job.FinishedAt = time.Now()
+		o.save(job)
+		out <- api.Response{Error: err}
+		return
+	}
+	o.save(job)
+
+	var output strings.Builder
+	var usage api.TokenUsage
+	var failure error
+	for resp := range api.Send(ctx, provider, job.Request) {
+		output.WriteString(resp.Content)
+		if resp.Done {
+			usage = resp.Usage
+		}
+		if resp.Error != nil {
+			failure = resp.Error
+		}
+		out <- resp
+	}
+	o.Queue.Finish(job, usage)
+
+	job.Output = output.String()
+	job.Usage = usage
+	job.FinishedAt = time.Now()
+	switch {
+	case errors.Is(failure, gocontext.Canceled):
+		job.Status = queue.StatusCancelled
+	case failure != nil:
+		job.Status = queue.StatusFailed
+		job.Error = failure.Error()
+	default:
+		job.Status = queue.StatusDone
+	}
+	o.save(job)
+}
+
+// wait blocks until the queue starts the job, or ctx is cancelled
+func (o *Orchestrator) wait(ctx gocontext.Context, job *queue.Job) error {
+	for {
+		started, changed, retryAt := o.Queue.TryStart(job, time.Now())
+		if started {
+			return nil
+		}
+
+		var budget <-chan time.Time
+		if !retryAt.IsZero() {
+			budget = time.After(time.Until(retryAt))
+		}
+
+		select {
+		case <-changed:
+		case <-budget:
+		case <-ctx.Done():
+			o.Queue.Remove(job)
+			return ctx.Err()
+		}
+	}
+}
+
+// record adds the usage of a job nobody is waiting for to the ledger
+func (o *Orchestrator) record(job *queue.Job, usage api.TokenUsage) {
+	if o.Store == nil {
+		return
+	}
+	ag := &agent.Agent{ID: job.AgentID, Provider: job.Provider, Model: job.Model}
+	o.Store.RecordUsage(ledger.NewEntry(ag, usage, o.Catalog))
+}
+
+// save persists a job if there is a store
+func (o *Orchestrator) save(job *queue.Job) {
+	if o.Store != nil {
+		o.Store.SaveJob(job)
+	}
+}
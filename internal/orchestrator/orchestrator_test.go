@@ -0,0 +1,173 @@
+package orchestrator
+
+import (
+	gocontext "context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/internal/models"
+	"github.com/yourusername/aui/internal/queue"
+	"github.com/yourusername/aui/internal/storage"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// gateProvider replies with the request's model once release is closed
+type gateProvider struct {
+	release chan struct{}
+}
+
+func (p *gateProvider) Name() string { return "fake" }
+
+func (p *gateProvider) ValidateConfig(map[string]string) error { return nil }
+
+func (p *gateProvider) SendMessage(ctx gocontext.Context, req *api.Request) (<-chan api.Response, error) {
+	ch := make(chan api.Response, 1)
+	go func() {
+		defer close(ch)
+		select {
+		case <-p.release:
+			ch <- api.Response{Content: req.Model, Done: true, Usage: api.TokenUsage{InputTokens: 1000, OutputTokens: 100}}
+		case <-ctx.Done():
+			ch <- api.Response{Error: ctx.Err()}
+		}
+	}()
+	return ch, nil
+}
+
+// testOrchestrator returns an orchestrator allowing one anthropic request at
+// a time, with a store
+func testOrchestrator(t *testing.T) *Orchestrator {
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	cfg := config.NewDefault()
+	cfg.Queue.Concurrency = map[string]int{"anthropic": 1}
+	return New(cfg, store, models.Default())
+}
+
+// waitFor polls cond until it holds or a second passes
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestSubmit(t *testing.T) {
+	o := testOrchestrator(t)
+	provider := &gateProvider{release: make(chan struct{})}
+
+	first := queue.NewJob("a1", "anthropic", &api.Request{Model: "claude-sonnet-4-5"}, 10)
+	second := queue.NewJob("a1", "anthropic", &api.Request{Model: "claude-haiku-4-5"}, 10)
+	firstReplies := o.Submit(gocontext.Background(), first, provider)
+	secondReplies := o.Submit(gocontext.Background(), second, provider)
+
+	waitFor(t, "the first job to start", func() bool {
+		stats := o.Queue.Stats(time.Now())
+		return len(stats) == 1 && stats[0].Running == 1
+	})
+	if o.Queue.Position(second) != 1 {
+		t.Errorf("Position() = %d, want the second job waiting", o.Queue.Position(second))
+	}
+
+	close(provider.release)
+	if text, _, err := api.Collect(firstReplies); err != nil || text != "claude-sonnet-4-5" {
+		t.Errorf("first reply = %q, %v", text, err)
+	}
+	if text, _, err := api.Collect(secondReplies); err != nil || text != "claude-haiku-4-5" {
+		t.Errorf("second reply = %q, %v", text, err)
+	}
+
+	jobs, err := o.Store.ListJobs(queue.StatusDone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 || jobs[0].Output != "claude-sonnet-4-5" || jobs[0].Usage.OutputTokens != 100 || jobs[0].StartedAt.IsZero() {
+		t.Errorf("stored jobs = %+v, want both done with their output", jobs)
+	}
+}
+
+func TestSubmitCancelWaiting(t *testing.T) {
+	o := testOrchestrator(t)
+	provider := &gateProvider{release: make(chan struct{})}
+	defer close(provider.release)
+
+	o.Submit(gocontext.Background(), queue.NewJob("a1", "anthropic", &api.Request{Model: "m"}, 10), provider)
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	waiting := queue.NewJob("a1", "anthropic", &api.Request{Model: "m"}, 10)
+	replies := o.Submit(ctx, waiting, provider)
+
+	cancel()
+	if _, _, err := api.Collect(replies); !errors.Is(err, gocontext.Canceled) {
+		t.Errorf("Collect() error = %v, want cancelled", err)
+	}
+	if o.Queue.Position(waiting) != 0 {
+		t.Error("a cancelled job should leave the queue")
+	}
+
+	jobs, _ := o.Store.ListJobs(queue.StatusCancelled)
+	if len(jobs) != 1 || jobs[0].ID != waiting.ID {
+		t.Errorf("cancelled jobs = %+v", jobs)
+	}
+}
+
+func TestResume(t *testing.T) {
+	o := testOrchestrator(t)
+
+	// Jobs left behind by an earlier run
+	queued := queue.NewJob("a1", "anthropic", &api.Request{Model: "claude-sonnet-4-5"}, 10)
+	running := queue.NewJob("a1", "openai", &api.Request{Model: "gpt-4o"}, 10)
+	running.Status = queue.StatusRunning
+	orphan := queue.NewJob("a1", "unknown", &api.Request{Model: "m"}, 10)
+	chat := queue.NewJob("a1", "anthropic", &api.Request{Model: "claude-sonnet-4-5"}, 10)
+	chat.Priority = queue.PriorityInteractive
+	turn := queue.NewJob("a1", "anthropic", &api.Request{Model: "claude-sonnet-4-5", Tools: []api.Tool{{Name: "read_file"}}}, 10)
+	for _, j := range []*queue.Job{queued, running, orphan, chat, turn} {
+		if err := o.Store.SaveJob(j); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	release := make(chan struct{})
+	close(release)
+	n, err := o.Resume(func(job *queue.Job) (api.Provider, error) {
+		if job.Provider == "unknown" {
+			return nil, errors.New("unknown provider")
+		}
+		return &gateProvider{release: release}, nil
+	})
+	if err != nil || n != 3 {
+		t.Fatalf("Resume() = %d, %v; want 3 jobs", n, err)
+	}
+
+	waitFor(t, "the resumed jobs to finish", func() bool {
+		jobs, _ := o.Store.ListJobs(queue.StatusDone)
+		entries, _ := o.Store.ListUsage(time.Time{})
+		return len(jobs) == 2 && len(entries) == 2
+	})
+
+	failed, _ := o.Store.ListJobs(queue.StatusFailed)
+	if len(failed) != 1 || failed[0].Error != "unknown provider" {
+		t.Errorf("failed jobs = %+v", failed)
+	}
+	// Nobody is there to read the chat reply or answer the tool calls
+	cancelled, _ := o.Store.ListJobs(queue.StatusCancelled)
+	if len(cancelled) != 2 || cancelled[0].ID != chat.ID || cancelled[1].ID != turn.ID {
+		t.Errorf("cancelled jobs = %+v, want the chat job and the tool turn", cancelled)
+	}
+	entries, _ := o.Store.ListUsage(time.Time{})
+	for _, e := range entries {
+		if e.AgentID != "a1" || e.InputTokens != 1000 || (e.Model == "claude-sonnet-4-5" && e.Cost == 0) {
+			t.Errorf("ledger entry = %+v", e)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package orchestrator
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/models"
+	"github.com/yourusername/aui/internal/pipeline"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// PipelineAgents resolves the agent of each step of a pipeline. Steps that
+// name no agent use fallback.
+func PipelineAgents(p *pipeline.Pipeline, agents []*agent.Agent, catalog *models.Catalog, fallback *agent.Agent) ([]*agent.Agent, error) {
+	resolved := make([]*agent.Agent, len(p.Steps))
+	for i, step := range p.Steps {
+		if step.Agent == "" {
+			if fallback == nil {
+				return nil, fmt.Errorf("step %s names no agent and none was given", step.Name)
+			}
+			resolved[i] = fallback
+			continue
+		}
+
+		ag, err := ResolveAgent(step.Agent, agents, catalog)
+		if err != nil {
+			return nil, fmt.Errorf("step %s: %w", step.Name, err)
+		}
+		resolved[i] = ag
+	}
+	return resolved, nil
+}
+
+// RunPipeline runs the remaining steps of a run in turn, asking the agent of
+// each step with the request built from its prompt, and calls save whenever
+// a step starts or finishes. It stops at the first step that fails and
+// returns its error; cancelling ctx cancels the run.
+func (o *Orchestrator) RunPipeline(ctx gocontext.Context, run *pipeline.Run, agents []*agent.Agent, request func(prompt string) (*api.Request, error), providerFor func(*agent.Agent) (api.Provider, error), save func(*pipeline.Run)) error {
+	for i := run.Next(); i >= 0; i = run.Next() {
+		ag := agents[i]
+		prompt, err := run.Start(i, ag.ID, ag.Name, ag.Model)
+		save(run)
+		if err != nil {
+			return err
+		}
+
+		output, usage, cost, err := o.RunStep(ctx, ag, prompt, request, providerFor)
+		if errors.Is(err, gocontext.Canceled) {
+			run.Cancel()
+			save(run)
+			return err
+		}
+		run.Finish(i, output, usage, cost, err)
+		save(run)
+		if err != nil {
+			return fmt.Errorf("step %s failed: %w", run.Steps[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// RunStep asks the agent of one pipeline step with the request built from
+// its prompt. It leaves the run alone, so that callers on another goroutine
+// can apply the outcome themselves.
+func (o *Orchestrator) RunStep(ctx gocontext.Context, ag *agent.Agent, prompt string, request func(string) (*api.Request, error), providerFor func(*agent.Agent) (api.Provider, error)) (string, api.TokenUsage, float64, error) {
+	provider, err := providerFor(ag)
+	if err != nil {
+		return "", api.TokenUsage{}, 0, err
+	}
+	req, err := request(prompt)
+	if err != nil {
+		return "", api.TokenUsage{}, 0, err
+	}
+	return o.Ask(ctx, ag, req, provider)
+}
@@ -0,0 +1,113 @@
+package orchestrator
+
+import (
+	gocontext "context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/models"
+	"github.com/yourusername/aui/internal/pipeline"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// echoProvider answers with the model and the last message it was sent
+type echoProvider struct{}
+
+func (p *echoProvider) Name() string { return "fake" }
+
+func (p *echoProvider) ValidateConfig(map[string]string) error { return nil }
+
+func (p *echoProvider) SendMessage(ctx gocontext.Context, req *api.Request) (<-chan api.Response, error) {
+	ch := make(chan api.Response, 1)
+	last := req.Messages[len(req.Messages)-1].Content
+	ch <- api.Response{Content: req.Model + ": " + last, Done: true, Usage: api.TokenUsage{InputTokens: 10, OutputTokens: 5}}
+	close(ch)
+	return ch, nil
+}
+
+var reviewPipeline = &pipeline.Pipeline{Name: "review", Steps: []pipeline.Step{
+	{Name: "plan", Prompt: "plan {{.Input}}"},
+	{Name: "implement", Agent: "Coder", Prompt: "do {{.Previous}}"},
+	{Name: "review", Agent: "gpt-4o", Prompt: "check {{.Previous}}"},
+}}
+
+func TestPipelineAgents(t *testing.T) {
+	planner := agent.NewAgent("Planner", "claude-opus-4-1", "anthropic")
+	coder := agent.NewAgent("Coder", "claude-sonnet-4-5", "anthropic")
+
+	agents, err := PipelineAgents(reviewPipeline, []*agent.Agent{coder}, models.Default(), planner)
+	if err != nil {
+		t.Fatalf("PipelineAgents() error = %v", err)
+	}
+	if agents[0] != planner || agents[1] != coder || agents[2].Model != "gpt-4o" {
+		t.Errorf("PipelineAgents() = %v, %v, %v", agents[0].Name, agents[1].Name, agents[2].Name)
+	}
+
+	if _, err := PipelineAgents(reviewPipeline, []*agent.Agent{coder}, models.Default(), nil); err == nil {
+		t.Error("PipelineAgents() should fail when a step needs a fallback agent")
+	}
+}
+
+func TestRunPipeline(t *testing.T) {
+	o := testOrchestrator(t)
+	agents := []*agent.Agent{
+		agent.NewAgent("Planner", "claude-opus-4-1", "anthropic"),
+		agent.NewAgent("Coder", "claude-sonnet-4-5", "anthropic"),
+		agent.NewAgent("Reviewer", "gpt-4o", "openai"),
+	}
+	request := func(prompt string) (*api.Request, error) {
+		return &api.Request{Messages: []api.Message{{Role: api.RoleUser, Content: "<files>\n" + prompt}}}, nil
+	}
+	providerFor := func(*agent.Agent) (api.Provider, error) { return &echoProvider{}, nil }
+
+	run := pipeline.NewRun(reviewPipeline, "login", "")
+	saves := 0
+	err := o.RunPipeline(gocontext.Background(), run, agents, request, providerFor, func(*pipeline.Run) { saves++ })
+	if err != nil {
+		t.Fatalf("RunPipeline() error = %v", err)
+	}
+
+	if run.Status != pipeline.StatusDone || saves != 6 {
+		t.Errorf("run = %s after %d saves, want done after 6", run.Status, saves)
+	}
+	// Every step gets the context and the output of the step before
+	if got := run.Steps[1].Output; got != "claude-sonnet-4-5: <files>\ndo claude-opus-4-1: <files>\nplan login" {
+		t.Errorf("implement output = %q", got)
+	}
+	if !strings.HasPrefix(run.Output(), "gpt-4o: <files>\ncheck claude-sonnet-4-5") {
+		t.Errorf("Output() = %q", run.Output())
+	}
+
+	entries, _ := o.Store.ListUsage(time.Time{})
+	if len(entries) != 3 {
+		t.Errorf("ledger has %d entries, want one per step", len(entries))
+	}
+}
+
+func TestRunPipelineFailure(t *testing.T) {
+	o := testOrchestrator(t)
+	ag := agent.NewAgent("Planner", "claude-opus-4-1", "anthropic")
+	agents := []*agent.Agent{ag, ag, ag}
+	request := func(prompt string) (*api.Request, error) {
+		return &api.Request{Messages: []api.Message{{Role: api.RoleUser, Content: prompt}}}, nil
+	}
+	calls := 0
+	providerFor := func(*agent.Agent) (api.Provider, error) {
+		if calls++; calls == 2 {
+			return nil, errors.New("no API key")
+		}
+		return &echoProvider{}, nil
+	}
+
+	run := pipeline.NewRun(reviewPipeline, "login", "")
+	err := o.RunPipeline(gocontext.Background(), run, agents, request, providerFor, func(*pipeline.Run) {})
+	if err == nil || !strings.Contains(err.Error(), "step implement failed") {
+		t.Errorf("RunPipeline() error = %v, want the implement step to fail", err)
+	}
+	if run.Status != pipeline.StatusFailed || run.Steps[2].Status != pipeline.StatusSkipped {
+		t.Errorf("run = %s, review %s", run.Status, run.Steps[2].Status)
+	}
+}
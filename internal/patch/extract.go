@@ -0,0 +1,227 @@
+package patch
+
+import (
+	"strings"
+)
+
+// Block is a fenced code block of a reply
+type Block struct {
+	Info    string // Text after the opening fence, e.g. "go" or "diff"
+	Content string
+	Before  string // Last non-blank line between the block and the one before, which often names its file
+}
+
+// Blocks returns the closed fenced code blocks of a markdown reply and the
+// text outside them
+func Blocks(reply string) ([]Block, string) {
+	var blocks []Block
+	var outside []string
+	var current *Block
+	var content []string
+	before := ""
+
+	for _, line := range strings.Split(reply, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case current == nil && strings.HasPrefix(trimmed, "```"):
+			current = &Block{Info: strings.TrimSpace(strings.TrimPrefix(trimmed, "```")), Before: before}
+			content = nil
+		case current != nil && trimmed == "```":
+			current.Content = strings.Join(content, "\n")
+			blocks = append(blocks, *current)
+			current, before = nil, ""
+		case current != nil:
+			content = append(content, line)
+		default:
+			outside = append(outside, line)
+			if trimmed != "" {
+				before = trimmed
+			}
+		}
+	}
+	return blocks, strings.Join(outside, "\n")
+}
+
+// Find returns the changes a reply proposes to the files at paths, one patch
+// per file. Diffs, fenced or not, are kept as they are. A code block naming
+// one of the files, in its info string, its first line or the line ahead of
+// it, is spliced into the file as read returns it (see splice) and diffed
+// against it. A later change to a file replaces an earlier one. Blocks
+// naming no file in paths are skipped, and so are those naming one read
+// fails for, such as a chunk or extracted symbols that are not on disk.
+func Find(reply string, paths []string, read func(path string) (string, error)) []*Patch {
+	blocks, outside := Blocks(reply)
+
+	found := make(map[string]*Patch)
+	var order []string
+	add := func(p *Patch) {
+		if _, ok := found[p.Path]; !ok {
+			order = append(order, p.Path)
+		}
+		found[p.Path] = p
+	}
+	addDiffs := func(text string) {
+		// Text that does not parse as a diff is not one
+		patches, _ := Parse(text)
+		for _, p := range patches {
+			if path := Match(paths, p.Path); path != "" {
+				p.Path = path
+				add(p)
+			}
+		}
+	}
+
+	for _, b := range blocks {
+		if isDiff(b) {
+			addDiffs(b.Content)
+			continue
+		}
+
+		path, content := blockPath(b, paths), b.Content
+		if path == "" {
+			continue
+		}
+		old, err := read(path)
+		if err != nil {
+			continue
+		}
+		// A first line naming the file is a label, unless the file has it
+		if first, rest, _ := strings.Cut(content, "\n"); isComment(first) && first != firstLine(old) && namesFile(first, paths) == path {
+			content = rest
+		}
+		if p := Diff(path, old, splice(old, content)); p != nil {
+			add(p)
+		}
+	}
+	addDiffs(outside)
+
+	patches := make([]*Patch, len(order))
+	for i, path := range order {
+		patches[i] = found[path]
+	}
+	return patches
+}
+
+// isDiff reports whether a block holds a unified diff
+func isDiff(b Block) bool {
+	lang, _, _ := strings.Cut(b.Info, " ")
+	return lang == "diff" || lang == "patch" ||
+		strings.HasPrefix(b.Content, "--- ") || strings.HasPrefix(b.Content, "diff --git ")
+}
+
+// blockPath returns the file a code block names, or ""
+func blockPath(b Block, paths []string) string {
+	if path := namesFile(b.Info, paths); path != "" {
+		return path
+	}
+	if first := firstLine(b.Content); isComment(first) {
+		if path := namesFile(first, paths); path != "" {
+			return path
+		}
+	}
+	return namesFile(b.Before, paths)
+}
+
+// splice returns old with the part a code block rewrites replaced by the
+// block. A block shorter than the file, such as one function, replaces the
+// lines from its first line to its last as found in the file, picking the
+// span closest to its own length; any other block is the whole new content.
+func splice(old, block string) string {
+	a, b := splitLines(old), splitLines(block)
+	if len(b) == 0 || len(b) >= len(a) {
+		return block
+	}
+	first, last := strings.TrimSpace(b[0]), strings.TrimSpace(b[len(b)-1])
+	if first == "" || last == "" {
+		return block
+	}
+
+	best, from, to := -1, 0, 0
+	for i := range a {
+		if strings.TrimSpace(a[i]) != first {
+			continue
+		}
+		for j := i; j < len(a); j++ {
+			if strings.TrimSpace(a[j]) != last {
+				continue
+			}
+			if d := abs(j - i + 1 - len(b)); best < 0 || d < best {
+				best, from, to = d, i, j
+			}
+		}
+	}
+	if best < 0 {
+		return block
+	}
+
+	lines := append(append(append([]string{}, a[:from]...), b...), a[to+1:]...)
+	return joinLines(lines, true)
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// isComment reports whether a line is a comment
+func isComment(line string) bool {
+	line = strings.TrimSpace(line)
+	for _, marker := range []string{"//", "#", "--", "/*", "<!--", ";"} {
+		if strings.HasPrefix(line, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// namesFile returns the path of the first word of text naming one of paths,
+// e.g. in "go title=\"main.go\"", "// File: internal/ui/app.go" or
+// "Update `app.go`:", or ""
+func namesFile(text string, paths []string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '=' || r == ',' || r == ':' || r == '(' || r == ')'
+	})
+	for _, word := range words {
+		word = strings.Trim(word, "`*\"';")
+		if !strings.ContainsAny(word, "./") {
+			continue
+		}
+		if path := Match(paths, word); path != "" {
+			return path
+		}
+	}
+	return ""
+}
+
+// Match returns the path among paths that name refers to: the same path,
+// or the only one ending with it, such as "app.go" for "internal/ui/app.go".
+// It returns "" if there is no such path or more than one.
+func Match(paths []string, name string) string {
+	name = strings.TrimPrefix(strings.ReplaceAll(name, "\\", "/"), "./")
+	if name == "" {
+		return ""
+	}
+
+	matches := []string{}
+	for _, path := range paths {
+		if path == name {
+			return path
+		}
+		if strings.HasSuffix(path, "/"+name) {
+			matches = append(matches, path)
+		}
+	}
+	if len(matches) != 1 {
+		return ""
+	}
+	return matches[0]
+}
+
+// firstLine returns the first line of content
+func firstLine(content string) string {
+	line, _, _ := strings.Cut(content, "\n")
+	return line
+}
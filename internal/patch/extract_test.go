@@ -0,0 +1,113 @@
+package patch
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var contextPaths = []string{"cmd/main.go", "internal/ui/app.go", "internal/ui/chat.go", "README.md"}
+
+// readFrom returns a read function over files
+func readFrom(files map[string]string) func(string) (string, error) {
+	return func(path string) (string, error) {
+		content, ok := files[path]
+		if !ok {
+			return "", fmt.Errorf("no such file: %s", path)
+		}
+		return content, nil
+	}
+}
+
+func TestBlocks(t *testing.T) {
+	reply := "Change `main.go`:\n\n```go\npackage main\n```\n\nThen:\n```\nunterminated"
+	blocks, outside := Blocks(reply)
+	if len(blocks) != 1 {
+		t.Fatalf("Blocks() = %+v, want one closed block", blocks)
+	}
+	if blocks[0].Info != "go" || blocks[0].Content != "package main" || blocks[0].Before != "Change `main.go`:" {
+		t.Errorf("block = %+v", blocks[0])
+	}
+	if !strings.Contains(outside, "Then:") || strings.Contains(outside, "package main") {
+		t.Errorf("outside = %q", outside)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"cmd/main.go", "cmd/main.go"},
+		{"./cmd/main.go", "cmd/main.go"},
+		{"main.go", "cmd/main.go"},
+		{"ui/app.go", "internal/ui/app.go"},
+		{"app.go.bak", ""},
+		{"go", ""},
+		{"other.go", ""},
+	}
+	for _, tt := range tests {
+		if got := Match(contextPaths, tt.name); got != tt.want {
+			t.Errorf("Match(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if got := Match([]string{"a/main.go", "b/main.go"}, "main.go"); got != "" {
+		t.Errorf("Match() of an ambiguous name = %q, want none", got)
+	}
+}
+
+func TestFind(t *testing.T) {
+	files := map[string]string{
+		"cmd/main.go":         mainGo,
+		"internal/ui/app.go":  "package ui\n\ntype App struct{}\n",
+		"internal/ui/chat.go": "package ui\n\nfunc chat() {\n\tsend()\n}\n\nfunc other() {}\n",
+	}
+	reply := "Here is the fix.\n\n" +
+		"```go\n// internal/ui/app.go\npackage ui\n\ntype App struct {\n\tName string\n}\n```\n\n" +
+		"In `chat.go`, retry:\n\n```go\nfunc chat() {\n\tsend()\n\tretry()\n}\n```\n\n" +
+		"```diff\n--- a/cmd/main.go\n+++ b/cmd/main.go\n@@ -9,3 +9,3 @@\n func helper() int {\n-\treturn 1\n+\treturn 2\n }\n```\n\n" +
+		"```go\nfunc unrelated() {}\n```\n"
+
+	patches := Find(reply, contextPaths, readFrom(files))
+	if len(patches) != 3 {
+		t.Fatalf("Find() found %d patches, want 3", len(patches))
+	}
+
+	want := map[string]string{
+		"internal/ui/app.go":  "package ui\n\ntype App struct {\n\tName string\n}\n",
+		"internal/ui/chat.go": "package ui\n\nfunc chat() {\n\tsend()\n\tretry()\n}\n\nfunc other() {}\n",
+		"cmd/main.go":         strings.Replace(mainGo, "return 1", "return 2", 1),
+	}
+	sources := []string{"code block", "code block", "diff"}
+	for i, p := range patches {
+		if p.Source != sources[i] {
+			t.Errorf("patch %d for %s from %s, want %s", i, p.Path, p.Source, sources[i])
+		}
+		got, err := Apply(files[p.Path], p.Hunks)
+		if err != nil {
+			t.Errorf("Apply(%s) error = %v", p.Path, err)
+			continue
+		}
+		if got != want[p.Path] {
+			t.Errorf("Apply(%s) = %q, want %q", p.Path, got, want[p.Path])
+		}
+	}
+
+	if patches := Find("No code here.", contextPaths, readFrom(files)); len(patches) != 0 {
+		t.Errorf("Find() without code = %v", patches)
+	}
+}
+
+func TestFindSkipsUnreadablePaths(t *testing.T) {
+	// Files of a context need not be on disk, e.g. extracted Go symbols
+	paths := []string{"internal/ui#App", "internal/ui/app.go"}
+	files := map[string]string{"internal/ui/app.go": "package ui\n\ntype App struct{}\n"}
+	reply := "```go\n// internal/ui#App\ntype App struct {\n\tID string\n}\n```\n\n" +
+		"```go\n// internal/ui/app.go\npackage ui\n\ntype App struct {\n\tName string\n}\n```\n"
+
+	patches := Find(reply, paths, readFrom(files))
+	if len(patches) != 1 || patches[0].Path != "internal/ui/app.go" {
+		t.Errorf("Find() = %+v, want the unreadable path skipped and the file patched", patches)
+	}
+}
@@ -0,0 +1,524 @@
+package patch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/aui/internal/tools"
+)
+
+// contextLines is how many unchanged lines surround the changes of a hunk
+const contextLines = 3
+
+// maxDiffCells caps the table used to diff two files; larger rewrites are
+// shown as one replacement
+const maxDiffCells = 4 << 20
+
+// Line is a line of a hunk: Kind is ' ' for context, '-' for a removed line
+// and '+' for an added line
+type Line struct {
+	Kind byte
+	Text string
+}
+
+// Hunk is a run of changes to a file, with the unchanged lines around them
+type Hunk struct {
+	OldStart int // First line in the file as it was, counting from 1; 0 if unknown
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []Line
+}
+
+// Patch is a change to one file
+type Patch struct {
+	Path   string // Slash-separated, relative to the project root
+	Source string // "diff" or "code block"
+	Hunks  []*Hunk
+	Delete bool // The diff removes the file: its new side is /dev/null
+}
+
+// Header returns the hunk's "@@ -1,3 +1,4 @@" line
+func (h *Hunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// old returns the lines the hunk expects in the file
+func (h *Hunk) old() []string {
+	var lines []string
+	for _, l := range h.Lines {
+		if l.Kind != '+' {
+			lines = append(lines, l.Text)
+		}
+	}
+	return lines
+}
+
+// new returns the lines the hunk leaves in the file
+func (h *Hunk) new() []string {
+	var lines []string
+	for _, l := range h.Lines {
+		if l.Kind != '-' {
+			lines = append(lines, l.Text)
+		}
+	}
+	return lines
+}
+
+// Added returns the number of lines the patch adds and removes
+func (p *Patch) Added() (added, removed int) {
+	for _, h := range p.Hunks {
+		for _, l := range h.Lines {
+			switch l.Kind {
+			case '+':
+				added++
+			case '-':
+				removed++
+			}
+		}
+	}
+	return added, removed
+}
+
+// splitLines splits content into lines without their line endings
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// joinLines joins lines back into content, ending it with a newline if
+// newline is set
+func joinLines(lines []string, newline bool) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	content := strings.Join(lines, "\n")
+	if newline {
+		content += "\n"
+	}
+	return content
+}
+
+// Diff returns the patch that turns old into new, or nil if they have the
+// same lines
+func Diff(path, old, new string) *Patch {
+	a, b := splitLines(old), splitLines(new)
+	ops := diffLines(a, b)
+
+	// Changes closer than twice the context share a hunk
+	var ranges [][2]int
+	for i, op := range ops {
+		if op.Kind == ' ' {
+			continue
+		}
+		lo, hi := max(i-contextLines, 0), min(i+contextLines+1, len(ops))
+		if n := len(ranges); n > 0 && lo <= ranges[n-1][1] {
+			ranges[n-1][1] = max(ranges[n-1][1], hi)
+		} else {
+			ranges = append(ranges, [2]int{lo, hi})
+		}
+	}
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	p := &Patch{Path: path, Source: "code block"}
+	oldLine, newLine, next := 1, 1, 0
+	for _, r := range ranges {
+		for ; next < r[0]; next++ {
+			oldLine, newLine = advance(ops[next], oldLine, newLine)
+		}
+		h := &Hunk{OldStart: oldLine, NewStart: newLine, Lines: ops[r[0]:r[1]]}
+		for ; next < r[1]; next++ {
+			oldLine, newLine = advance(ops[next], oldLine, newLine)
+		}
+		h.OldLines, h.NewLines = len(h.old()), len(h.new())
+		// As in unified diffs, an empty side starts at the line before
+		if h.OldLines == 0 {
+			h.OldStart--
+		}
+		if h.NewLines == 0 {
+			h.NewStart--
+		}
+		p.Hunks = append(p.Hunks, h)
+	}
+	return p
+}
+
+// advance returns the line numbers after an edit
+func advance(op Line, oldLine, newLine int) (int, int) {
+	if op.Kind != '+' {
+		oldLine++
+	}
+	if op.Kind != '-' {
+		newLine++
+	}
+	return oldLine, newLine
+}
+
+// diffLines returns the edits turning a into b, unchanged lines included,
+// using the longest common subsequence of the lines between their common
+// prefix and suffix
+func diffLines(a, b []string) []Line {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []Line
+	for _, line := range a[:prefix] {
+		ops = append(ops, Line{' ', line})
+	}
+	ops = append(ops, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, Line{' ', line})
+	}
+	return ops
+}
+
+// diffMiddle diffs lines that differ at both ends
+func diffMiddle(a, b []string) []Line {
+	var ops []Line
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		for _, line := range a {
+			ops = append(ops, Line{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, Line{'+', line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the length of the common subsequence of a[i:] and b[j:]
+	width := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else {
+				lcs[i*width+j] = max(lcs[(i+1)*width+j], lcs[i*width+j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, Line{' ', a[i]})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i*width+j+1] >= lcs[(i+1)*width+j]):
+			ops = append(ops, Line{'+', b[j]})
+			j++
+		default:
+			ops = append(ops, Line{'-', a[i]})
+			i++
+		}
+	}
+
+	// Removals read better ahead of the additions replacing them
+	for start := 0; start < len(ops); {
+		if ops[start].Kind == ' ' {
+			start++
+			continue
+		}
+		end := start
+		for end < len(ops) && ops[end].Kind != ' ' {
+			end++
+		}
+		run := make([]Line, 0, end-start)
+		for _, kind := range []byte{'-', '+'} {
+			for _, op := range ops[start:end] {
+				if op.Kind == kind {
+					run = append(run, op)
+				}
+			}
+		}
+		copy(ops[start:end], run)
+		start = end
+	}
+	return ops
+}
+
+// hunkHeader matches "@@ -12,5 +12,7 @@", with the counts optional
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// Parse reads the files and hunks of a unified diff. It is lenient about
+// what models write: hunk line counts are recounted, blank lines are taken
+// as blank context lines and "@@" headers without line numbers match
+// anywhere in the file.
+func Parse(diff string) ([]*Patch, error) {
+	lines := strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n")
+
+	var patches []*Patch
+	var p *Patch
+	var h *Hunk
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			path, deleted := diffPath(lines[i+1][4:]), false
+			if path == "/dev/null" {
+				path, deleted = diffPath(line[4:]), true
+			}
+			p = &Patch{Path: path, Source: "diff", Delete: deleted}
+			patches = append(patches, p)
+			h = nil
+			i++
+		case strings.HasPrefix(line, "@@"):
+			if p == nil {
+				return nil, fmt.Errorf("line %d: hunk before the file it changes", i+1)
+			}
+			h = &Hunk{}
+			if m := hunkHeader.FindStringSubmatch(line); m != nil {
+				h.OldStart, _ = strconv.Atoi(m[1])
+				h.NewStart, _ = strconv.Atoi(m[3])
+			}
+			p.Hunks = append(p.Hunks, h)
+		case h == nil:
+			// Headers such as "diff --git" and "index", or prose
+		case line == "":
+			h.Lines = append(h.Lines, Line{' ', ""})
+		case line[0] == ' ' || line[0] == '-' || line[0] == '+':
+			h.Lines = append(h.Lines, Line{line[0], line[1:]})
+		case line[0] == '\\':
+			// "\ No newline at end of file"
+		default:
+			h = nil
+		}
+	}
+
+	for _, p := range patches {
+		var hunks []*Hunk
+		for _, h := range p.Hunks {
+			// Blank lines after a hunk are the end of the diff, not context
+			for len(h.Lines) > 0 && h.Lines[len(h.Lines)-1] == (Line{' ', ""}) {
+				h.Lines = h.Lines[:len(h.Lines)-1]
+			}
+			h.OldLines, h.NewLines = len(h.old()), len(h.new())
+			if len(h.Lines) > 0 {
+				hunks = append(hunks, h)
+			}
+		}
+		if len(hunks) == 0 {
+			return nil, fmt.Errorf("%s: no hunks", p.Path)
+		}
+		p.Hunks = hunks
+	}
+	return patches, nil
+}
+
+// diffPath returns the path of a "---" or "+++" line, without the "a/" or
+// "b/" prefix and any timestamp
+func diffPath(s string) string {
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		s = s[2:]
+	}
+	return s
+}
+
+// Apply applies hunks, in file order, to content. A hunk whose lines are
+// not at the line it names is looked for nearby, ignoring trailing
+// whitespace; it is an error if it is not found.
+func Apply(content string, hunks []*Hunk) (string, error) {
+	lines := splitLines(content)
+	newline := content == "" || strings.HasSuffix(content, "\n")
+
+	var out []string
+	pos, offset := 0, 0
+	for i, h := range hunks {
+		old := h.old()
+
+		// Earlier hunks that matched elsewhere move the later ones too
+		start, want := 0, pos
+		if h.OldStart > 0 {
+			start = h.OldStart - 1
+			if h.OldLines == 0 {
+				start++
+			}
+			want = start + offset
+		}
+		at := find(lines, old, pos, want)
+		if at < 0 {
+			return "", fmt.Errorf("hunk %d (%s) does not match the file", i+1, h.Header())
+		}
+		if h.OldStart > 0 {
+			offset = at - start
+		}
+		out = append(out, lines[pos:at]...)
+		out = append(out, h.new()...)
+		pos = at + len(old)
+	}
+	out = append(out, lines[pos:]...)
+	return joinLines(out, newline), nil
+}
+
+// find returns the index at or after pos where lines holds old, the one
+// closest to want, or -1
+func find(lines, old []string, pos, want int) int {
+	want = min(max(want, pos), len(lines))
+	last := len(lines) - len(old)
+	for d := 0; want-d >= pos || want+d <= last; d++ {
+		if at := want - d; at >= pos && at <= last && matchAt(lines, old, at) {
+			return at
+		}
+		if at := want + d; d > 0 && at >= pos && at <= last && matchAt(lines, old, at) {
+			return at
+		}
+	}
+	return -1
+}
+
+// matchAt reports whether lines holds old at index at, ignoring trailing
+// whitespace
+func matchAt(lines, old []string, at int) bool {
+	for i, line := range old {
+		if strings.TrimRight(lines[at+i], " \t\r") != strings.TrimRight(line, " \t\r") {
+			return false
+		}
+	}
+	return true
+}
+
+// ApplyFile applies hunks to a file below root, first copying the file as it
+// was to the same path below backupDir. A file that does not exist is
+// created if the hunks only add lines, as in a diff from /dev/null. It
+// returns the new content.
+func ApplyFile(root, path string, hunks []*Hunk, backupDir string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(path)) {
+		return "", fmt.Errorf("path is outside the project root: %s", path)
+	}
+	full, err := tools.Resolve(root, path)
+	if err != nil {
+		if _, statErr := os.Lstat(filepath.Join(root, filepath.FromSlash(path))); os.IsNotExist(statErr) {
+			return createFile(root, path, hunks)
+		}
+		return "", err
+	}
+
+	info, err := os.Stat(full)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat file: %w", err)
+	}
+	data, err := os.ReadFile(full)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	content, err := Apply(string(data), hunks)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+
+	if err := backup(backupDir, path, data, info.Mode().Perm()); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(full, []byte(content), info.Mode().Perm()); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return content, nil
+}
+
+// createFile creates a file below root holding the lines hunks add, along
+// with any missing directories
+func createFile(root, path string, hunks []*Hunk) (string, error) {
+	for _, h := range hunks {
+		if h.OldLines > 0 {
+			return "", fmt.Errorf("no such file: %s", path)
+		}
+	}
+	content, err := Apply("", hunks)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+
+	// The directories that exist may be symlinks leading outside root
+	dir := filepath.Dir(filepath.FromSlash(path))
+	for dir != "." {
+		if _, err := os.Lstat(filepath.Join(root, dir)); err == nil {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+	if _, err := tools.Resolve(root, filepath.ToSlash(dir)); err != nil {
+		return "", err
+	}
+
+	full := filepath.Join(root, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	f, err := os.OpenFile(full, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return content, nil
+}
+
+// RemoveFile deletes a file below root as a diff to /dev/null does, first
+// copying it to the same path below backupDir. The hunks must match the
+// file and remove every line of it.
+func RemoveFile(root, path string, hunks []*Hunk, backupDir string) error {
+	if !filepath.IsLocal(filepath.FromSlash(path)) {
+		return fmt.Errorf("path is outside the project root: %s", path)
+	}
+	full, err := tools.Resolve(root, path)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(full)
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+	data, err := os.ReadFile(full)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	content, err := Apply(string(data), hunks)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if content != "" {
+		return fmt.Errorf("%s: the hunks do not remove the whole file", path)
+	}
+
+	if err := backup(backupDir, path, data, info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := os.Remove(full); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", path, err)
+	}
+	return nil
+}
+
+// backup copies the content of a file to the same path below backupDir
+func backup(backupDir, path string, data []byte, perm os.FileMode) error {
+	full := filepath.Join(backupDir, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if err := os.WriteFile(full, data, perm); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package patch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const mainGo = `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("hello")
+}
+
+func helper() int {
+	return 1
+}
+`
+
+func TestDiffAndApply(t *testing.T) {
+	changed := strings.Replace(mainGo, `"hello"`, `"hello, world"`, 1)
+	changed = strings.Replace(changed, "return 1", "return 2", 1)
+
+	p := Diff("main.go", mainGo, changed)
+	if p == nil || len(p.Hunks) != 1 {
+		t.Fatalf("Diff() = %+v, want one hunk", p)
+	}
+	h := p.Hunks[0]
+	if h.Header() != "@@ -3,9 +3,9 @@" {
+		t.Errorf("Header() = %q, want @@ -3,9 +3,9 @@", h.Header())
+	}
+	if added, removed := p.Added(); added != 2 || removed != 2 {
+		t.Errorf("Added() = %d, %d, want 2, 2", added, removed)
+	}
+
+	got, err := Apply(mainGo, p.Hunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != changed {
+		t.Errorf("Apply() = %q, want %q", got, changed)
+	}
+
+	if Diff("main.go", mainGo, mainGo) != nil {
+		t.Error("Diff() of the same content should be nil")
+	}
+}
+
+func TestDiffSeparateHunks(t *testing.T) {
+	var lines []string
+	for i := 0; i < 30; i++ {
+		lines = append(lines, strings.Repeat("x", i+1))
+	}
+	old := strings.Join(lines, "\n") + "\n"
+	lines[2], lines[25] = "changed", "also changed"
+	lines = append(lines[:10], lines[11:]...)
+	changed := strings.Join(lines, "\n") + "\n"
+
+	p := Diff("f.txt", old, changed)
+	if len(p.Hunks) != 3 {
+		t.Fatalf("Diff() has %d hunks, want 3", len(p.Hunks))
+	}
+
+	// Applying only some hunks leaves the rest of the file alone
+	got, err := Apply(old, p.Hunks[1:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "changed") || strings.Contains(got, "\n"+strings.Repeat("x", 11)+"\n") {
+		t.Errorf("Apply() of the second hunk = %q", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+index 1234567..89abcde 100644
+--- a/main.go
++++ b/main.go
+@@ -5,3 +5,4 @@ import "fmt"
+ func main() {
+-	fmt.Println("hello")
++	fmt.Println("hello, world")
++	fmt.Println("bye")
+ }
+@@
+ func helper() int {
+-	return 1
+
+--- a/other.go
++++ b/other.go
+@@ -1 +1 @@
+-package other
++package others
+`
+	patches, err := Parse(diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patches) != 2 || patches[0].Path != "main.go" || patches[1].Path != "other.go" {
+		t.Fatalf("Parse() = %+v", patches)
+	}
+	hunks := patches[0].Hunks
+	if len(hunks) != 2 || hunks[0].OldStart != 5 || hunks[0].OldLines != 3 || hunks[0].NewLines != 4 || hunks[1].OldStart != 0 {
+		t.Errorf("hunks = %+v %+v", hunks[0], hunks[1])
+	}
+
+	got, err := Apply(mainGo, hunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "fmt.Println(\"bye\")\n}") || strings.Contains(got, "return 1") {
+		t.Errorf("Apply() = %q", got)
+	}
+
+	if _, err := Parse("--- a/x\n+++ b/x\nno hunks here\n"); err == nil {
+		t.Error("Parse() of a diff without hunks should fail")
+	}
+}
+
+func TestApplyDrift(t *testing.T) {
+	// The hunk names line 2, but the file gained lines above it
+	hunks := []*Hunk{{OldStart: 2, Lines: []Line{{' ', "b"}, {'-', "c"}, {'+', "C"}, {' ', "d"}}}}
+	got, err := Apply("x\ny\na\nb\nc  \nd\n", hunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "x\ny\na\nb\nC\nd\n" {
+		t.Errorf("Apply() = %q", got)
+	}
+
+	hunks[0].Lines[0].Text = "missing"
+	if _, err := Apply("x\ny\na\nb\nc\nd\n", hunks); err == nil {
+		t.Error("Apply() of a hunk that does not match should fail")
+	}
+}
+
+func TestApplyFile(t *testing.T) {
+	root, backups := t.TempDir(), t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "cmd"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(root, "cmd", "main.go")
+	if err := os.WriteFile(path, []byte(mainGo), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := Diff("cmd/main.go", mainGo, strings.Replace(mainGo, "return 1", "return 2", 1))
+	content, err := ApplyFile(root, "cmd/main.go", p.Hunks, backups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != content || !strings.Contains(content, "return 2") {
+		t.Errorf("file = %q, want the patched content", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want it kept", info.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(filepath.Join(backups, "cmd", "main.go")); string(data) != mainGo {
+		t.Errorf("backup = %q, want the original", data)
+	}
+
+	if _, err := ApplyFile(root, "../main.go", p.Hunks, backups); err == nil {
+		t.Error("ApplyFile() outside the root should fail")
+	}
+	// A hunk that no longer matches leaves the file alone
+	if _, err := ApplyFile(root, "cmd/main.go", p.Hunks, backups); err == nil {
+		t.Error("ApplyFile() of an applied hunk should fail")
+	}
+}
+
+func TestApplyFileSymlink(t *testing.T) {
+	root, outside := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "main.go"), []byte(mainGo), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "main.go"), filepath.Join(root, "main.go")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "linked")); err != nil {
+		t.Fatal(err)
+	}
+
+	p := Diff("main.go", mainGo, strings.Replace(mainGo, "return 1", "return 2", 1))
+	if _, err := ApplyFile(root, "main.go", p.Hunks, t.TempDir()); err == nil {
+		t.Error("ApplyFile() through a symlink leading outside the root should fail")
+	}
+	if data, _ := os.ReadFile(filepath.Join(outside, "main.go")); string(data) != mainGo {
+		t.Errorf("file outside the root = %q, want it unchanged", data)
+	}
+
+	patches, err := Parse("--- /dev/null\n+++ b/linked/pkg/new.go\n@@ -0,0 +1 @@\n+package pkg\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ApplyFile(root, "linked/pkg/new.go", patches[0].Hunks, t.TempDir()); err == nil {
+		t.Error("ApplyFile() creating a file through a symlink leading outside the root should fail")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "pkg")); err == nil {
+		t.Error("no directory should be created outside the root")
+	}
+}
+
+func TestRemoveFile(t *testing.T) {
+	root, backups := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "old.go"), []byte(mainGo), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(mainGo, "\n"), "\n")
+	diff := fmt.Sprintf("--- a/old.go\n+++ /dev/null\n@@ -1,%d +0,0 @@\n-%s\n", len(lines), strings.Join(lines, "\n-"))
+	patches, err := Parse(diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patches) != 1 || !patches[0].Delete || patches[0].Path != "old.go" {
+		t.Fatalf("Parse() = %+v, want a deletion of old.go", patches)
+	}
+
+	// Changing part of the file is not deleting it
+	partial := Diff("old.go", mainGo, strings.Replace(mainGo, "return 1", "return 2", 1))
+	if err := RemoveFile(root, "old.go", partial.Hunks, backups); err == nil || !strings.Contains(err.Error(), "do not remove the whole file") {
+		t.Errorf("RemoveFile() with hunks keeping lines error = %v", err)
+	}
+
+	if err := RemoveFile(root, "old.go", patches[0].Hunks, backups); err != nil {
+		t.Fatalf("RemoveFile() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "old.go")); !os.IsNotExist(err) {
+		t.Error("RemoveFile() should delete the file")
+	}
+	if data, _ := os.ReadFile(filepath.Join(backups, "old.go")); string(data) != mainGo {
+		t.Errorf("backup = %q, want the original", data)
+	}
+}
+
+func TestApplyFileNew(t *testing.T) {
+	root := t.TempDir()
+	patches, err := Parse("--- /dev/null\n+++ b/internal/auth/token.go\n@@ -0,0 +1,3 @@\n+package auth\n+\n+const header = \"Authorization\"\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ApplyFile(root, patches[0].Path, patches[0].Hunks, t.TempDir())
+	if err != nil {
+		t.Fatalf("ApplyFile() of a new file error = %v", err)
+	}
+	want := "package auth\n\nconst header = \"Authorization\"\n"
+	if data, _ := os.ReadFile(filepath.Join(root, "internal", "auth", "token.go")); content != want || string(data) != want {
+		t.Errorf("new file = %q, returned %q; want %q", data, content, want)
+	}
+
+	// Hunks that change lines need the file to exist
+	p := Diff("missing.go", mainGo, strings.Replace(mainGo, "return 1", "return 2", 1))
+	if _, err := ApplyFile(root, "missing.go", p.Hunks, t.TempDir()); err == nil || !strings.Contains(err.Error(), "no such file") {
+		t.Errorf("ApplyFile() of a missing file error = %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Pipeline is a sequence of steps, each asking an agent to work on the
+// output of the steps before it
+type Pipeline struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Steps       []Step `yaml:"steps"`
+
+	Source string `yaml:"-"` // File the pipeline was loaded from, or "builtin"
+}
+
+// Step is one stage of a pipeline. The prompt is a Go template that can use
+// {{.Input}}, the text the pipeline was run with, {{.Previous}}, the output
+// of the step before, and {{index .Steps "name"}}, the output of any earlier
+// step.
+type Step struct {
+	Name   string `yaml:"name"`
+	Agent  string `yaml:"agent,omitempty"` // Agent name or model ID; empty uses the agent the pipeline is run with
+	Prompt string `yaml:"prompt"`
+}
+
+// Builtin are the pipelines available without any configuration. Pipelines
+// loaded from disk with the same name replace them.
+var Builtin = []*Pipeline{
+	{
+		Name:        "plan-implement-review",
+		Description: "Plan a change, implement the plan, then review the implementation",
+		Steps: []Step{
+			{
+				Name:   "plan",
+				Prompt: "Write a short, numbered implementation plan for this task. Do not write the code yet.\n\nTask:\n{{.Input}}",
+			},
+			{
+				Name:   "implement",
+				Prompt: "Implement this plan. Show the complete changed code.\n\nTask:\n{{.Input}}\n\nPlan:\n{{.Previous}}",
+			},
+			{
+				Name:   "review",
+				Prompt: "Review this implementation of the task for bugs, missed requirements and unclear code. Be specific.\n\nTask:\n{{.Input}}\n\nImplementation:\n{{.Previous}}",
+			},
+		},
+		Source: "builtin",
+	},
+}
+
+// Dirs returns the pipeline directories for a project, global first so that
+// project pipelines take precedence: ~/.config/aui/pipelines and
+// <root>/.aui/pipelines
+func Dirs(root string) []string {
+	var dirs []string
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".config", "aui", "pipelines"))
+	}
+	if root != "" {
+		dirs = append(dirs, filepath.Join(root, ".aui", "pipelines"))
+	}
+	return dirs
+}
+
+// LoadFile reads and validates a single YAML pipeline. The name defaults to
+// the file name without its extension.
+func LoadFile(path string) (*Pipeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pipeline: %w", err)
+	}
+
+	var p Pipeline
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse pipeline %s: %w", path, err)
+	}
+
+	if p.Name == "" {
+		p.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	p.Source = path
+
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid pipeline %s: %w", path, err)
+	}
+	return &p, nil
+}
+
+// LoadAll returns the built-in pipelines plus every *.yaml or *.yml file in
+// dirs, sorted by name. Later directories override earlier ones by name, and
+// directories that do not exist are skipped.
+func LoadAll(dirs ...string) ([]*Pipeline, error) {
+	byName := make(map[string]*Pipeline)
+	for _, p := range Builtin {
+		byName[p.Name] = p
+	}
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pipeline directory: %w", err)
+		}
+
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+				continue
+			}
+
+			p, err := LoadFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			byName[p.Name] = p
+		}
+	}
+
+	pipelines := make([]*Pipeline, 0, len(byName))
+	for _, p := range byName {
+		pipelines = append(pipelines, p)
+	}
+	sort.Slice(pipelines, func(i, j int) bool {
+		return pipelines[i].Name < pipelines[j].Name
+	})
+
+	return pipelines, nil
+}
+
+// Find returns the pipeline with the given name, or nil
+func Find(pipelines []*Pipeline, name string) *Pipeline {
+	for _, p := range pipelines {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// Names returns the names of the pipelines
+func Names(pipelines []*Pipeline) []string {
+	names := make([]string, len(pipelines))
+	for i, p := range pipelines {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// Validate checks that a pipeline has steps with distinct names and prompt
+// templates that parse
+func (p *Pipeline) Validate() error {
+	if len(p.Steps) == 0 {
+		return fmt.Errorf("pipeline %s has no steps", p.Name)
+	}
+
+	seen := make(map[string]bool)
+	for i, s := range p.Steps {
+		if s.Name == "" {
+			return fmt.Errorf("step %d has no name", i+1)
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("step name %s is used twice", s.Name)
+		}
+		seen[s.Name] = true
+
+		if strings.TrimSpace(s.Prompt) == "" {
+			return fmt.Errorf("step %s has no prompt", s.Name)
+		}
+		if _, err := template.New(s.Name).Option("missingkey=error").Parse(s.Prompt); err != nil {
+			return fmt.Errorf("invalid prompt for step %s: %w", s.Name, err)
+		}
+	}
+	return nil
+}
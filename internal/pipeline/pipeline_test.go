@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFile creates a file below dir
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "triage.yaml", `description: Reproduce then fix
+steps:
+  - name: reproduce
+    agent: Haiku
+    prompt: "Write a failing test for: {{.Input}}"
+  - name: fix
+    agent: claude-sonnet-4-5
+    prompt: "Make this test pass:\n{{.Previous}}"
+`)
+
+	p, err := LoadFile(filepath.Join(dir, "triage.yaml"))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if p.Name != "triage" || len(p.Steps) != 2 || p.Steps[0].Agent != "Haiku" || p.Source != filepath.Join(dir, "triage.yaml") {
+		t.Errorf("LoadFile() = %+v", p)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]*Pipeline{
+		"no steps":        {Name: "empty"},
+		"unnamed step":    {Name: "p", Steps: []Step{{Prompt: "x"}}},
+		"repeated name":   {Name: "p", Steps: []Step{{Name: "a", Prompt: "x"}, {Name: "a", Prompt: "y"}}},
+		"no prompt":       {Name: "p", Steps: []Step{{Name: "a"}}},
+		"broken template": {Name: "p", Steps: []Step{{Name: "a", Prompt: "{{.Input"}}},
+	}
+	for name, p := range tests {
+		if err := p.Validate(); err == nil {
+			t.Errorf("Validate() with %s should fail", name)
+		}
+	}
+
+	for _, p := range Builtin {
+		if err := p.Validate(); err != nil {
+			t.Errorf("built-in pipeline %s: %v", p.Name, err)
+		}
+	}
+}
+
+func TestLoadAll(t *testing.T) {
+	global, project := t.TempDir(), t.TempDir()
+	writeFile(t, global, "review.yml", "steps: [{name: review, prompt: global}]\n")
+	writeFile(t, project, "review.yaml", "steps: [{name: review, prompt: project}]\n")
+	writeFile(t, project, "notes.txt", "not a pipeline")
+
+	pipelines, err := LoadAll(global, project, filepath.Join(global, "missing"))
+	if err != nil {
+		t.Fatalf("LoadAll() error = %v", err)
+	}
+	if len(pipelines) != 2 || pipelines[0].Name != "plan-implement-review" {
+		t.Fatalf("LoadAll() = %v, want the built-in and review", Names(pipelines))
+	}
+	if review := Find(pipelines, "review"); review == nil || review.Steps[0].Prompt != "project" {
+		t.Errorf("project pipelines should override global ones: %+v", review)
+	}
+
+	writeFile(t, project, "bad.yaml", "steps: []\n")
+	if _, err := LoadAll(project); err == nil {
+		t.Error("LoadAll() should fail on an invalid pipeline")
+	}
+}
@@ -0,0 +1,212 @@
+package pipeline
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// Status is the state of a pipeline run or one of its steps
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusDone      Status = "done"
+	StatusFailed    Status = "failed"
+	StatusSkipped   Status = "skipped" // A step not run because an earlier one failed
+	StatusCancelled Status = "cancelled"
+)
+
+// Run is one execution of a pipeline
+type Run struct {
+	ID         string
+	Pipeline   string
+	Input      string
+	ContextID  string // Empty when no context is attached
+	Status     Status
+	Steps      []*StepRun
+	CreatedAt  time.Time
+	FinishedAt time.Time
+
+	prompts []string // Prompt templates of the steps
+}
+
+// StepRun is the execution of one step: the prompt sent and the answer
+type StepRun struct {
+	Name       string
+	AgentID    string
+	Agent      string // Agent name when it ran
+	Model      string
+	Status     Status
+	Prompt     string
+	Output     string
+	Error      string
+	Usage      api.TokenUsage
+	Cost       float64
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Duration returns how long a step ran, or has been running for
+func (s *StepRun) Duration(now time.Time) time.Duration {
+	switch {
+	case s.StartedAt.IsZero():
+		return 0
+	case s.FinishedAt.IsZero():
+		return now.Sub(s.StartedAt)
+	default:
+		return s.FinishedAt.Sub(s.StartedAt)
+	}
+}
+
+// NewRun starts a run of a pipeline with input
+func NewRun(p *Pipeline, input, contextID string) *Run {
+	r := &Run{
+		ID:        generateID(),
+		Pipeline:  p.Name,
+		Input:     input,
+		ContextID: contextID,
+		Status:    StatusPending,
+		CreatedAt: time.Now(),
+	}
+	for _, s := range p.Steps {
+		r.Steps = append(r.Steps, &StepRun{Name: s.Name, Status: StatusPending})
+		r.prompts = append(r.prompts, s.Prompt)
+	}
+	return r
+}
+
+// Next returns the index of the next step to run, or -1 if the run is over
+func (r *Run) Next() int {
+	if r.Status != StatusPending && r.Status != StatusRunning {
+		return -1
+	}
+	for i, s := range r.Steps {
+		if s.Status == StatusPending {
+			return i
+		}
+	}
+	return -1
+}
+
+// Output returns the output of the last step that finished
+func (r *Run) Output() string {
+	for i := len(r.Steps) - 1; i >= 0; i-- {
+		if r.Steps[i].Status == StatusDone {
+			return r.Steps[i].Output
+		}
+	}
+	return ""
+}
+
+// Start renders the prompt of a step and marks it running with an agent
+func (r *Run) Start(i int, agentID, agentName, model string) (string, error) {
+	prompt, err := r.prompt(i)
+	if err != nil {
+		r.Finish(i, "", api.TokenUsage{}, 0, err)
+		return "", err
+	}
+
+	s := r.Steps[i]
+	s.AgentID, s.Agent, s.Model = agentID, agentName, model
+	s.Prompt = prompt
+	s.Status = StatusRunning
+	s.StartedAt = time.Now()
+	r.Status = StatusRunning
+	return prompt, nil
+}
+
+// prompt renders the prompt template of a step with the outputs so far
+func (r *Run) prompt(i int) (string, error) {
+	if i >= len(r.prompts) {
+		return "", fmt.Errorf("step %s has no prompt (runs loaded from storage cannot continue)", r.Steps[i].Name)
+	}
+
+	data := struct {
+		Input    string
+		Previous string
+		Steps    map[string]string
+	}{Input: r.Input, Steps: make(map[string]string)}
+	for _, s := range r.Steps[:i] {
+		data.Steps[s.Name] = s.Output
+		data.Previous = s.Output
+	}
+
+	tmpl, err := template.New(r.Steps[i].Name).Option("missingkey=error").Parse(r.prompts[i])
+	if err != nil {
+		return "", fmt.Errorf("invalid prompt for step %s: %w", r.Steps[i].Name, err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt for step %s: %w", r.Steps[i].Name, err)
+	}
+	return b.String(), nil
+}
+
+// Finish records the outcome of a step. A failed step skips the steps after
+// it and fails the run; the run is done once its last step is.
+func (r *Run) Finish(i int, output string, usage api.TokenUsage, cost float64, err error) {
+	s := r.Steps[i]
+	s.Output, s.Usage, s.Cost = output, usage, cost
+	s.FinishedAt = time.Now()
+
+	if err != nil {
+		s.Status = StatusFailed
+		s.Error = err.Error()
+		r.end(StatusFailed)
+		return
+	}
+
+	s.Status = StatusDone
+	if r.Next() < 0 {
+		r.Status = StatusDone
+		r.FinishedAt = s.FinishedAt
+	}
+}
+
+// Cancel stops a run, marking the running step cancelled
+func (r *Run) Cancel() {
+	for _, s := range r.Steps {
+		if s.Status == StatusRunning {
+			s.Status = StatusCancelled
+			s.FinishedAt = time.Now()
+		}
+	}
+	r.end(StatusCancelled)
+}
+
+// end finishes a run early, skipping the steps that did not start
+func (r *Run) end(status Status) {
+	for _, s := range r.Steps {
+		if s.Status == StatusPending {
+			s.Status = StatusSkipped
+		}
+	}
+	r.Status = status
+	r.FinishedAt = time.Now()
+}
+
+// Usage sums the tokens and cost of the steps
+func (r *Run) Usage() (api.TokenUsage, float64) {
+	var usage api.TokenUsage
+	var cost float64
+	for _, s := range r.Steps {
+		usage.InputTokens += s.Usage.InputTokens
+		usage.OutputTokens += s.Usage.OutputTokens
+		cost += s.Cost
+	}
+	return usage, cost
+}
+
+// generateID generates a random ID for a run
+func generateID() string {
+	bytes := make([]byte, 8)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
@@ -0,0 +1,76 @@
+package pipeline
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yourusername/aui/pkg/api"
+)
+
+var testPipeline = &Pipeline{Name: "p", Steps: []Step{
+	{Name: "plan", Prompt: "Plan: {{.Input}}"},
+	{Name: "implement", Prompt: "Do: {{.Previous}}"},
+	{Name: "review", Prompt: "Check {{index .Steps \"implement\"}} against {{index .Steps \"plan\"}}"},
+}}
+
+func TestRun(t *testing.T) {
+	r := NewRun(testPipeline, "add login", "ctx1")
+
+	outputs := []string{"1. form", "func login()", "looks good"}
+	wantPrompts := []string{"Plan: add login", "Do: 1. form", "Check func login() against 1. form"}
+	for i := range testPipeline.Steps {
+		if next := r.Next(); next != i {
+			t.Fatalf("Next() = %d, want %d", next, i)
+		}
+		prompt, err := r.Start(i, "a1", "Claude", "claude-sonnet-4-5")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prompt != wantPrompts[i] {
+			t.Errorf("prompt of step %d = %q, want %q", i, prompt, wantPrompts[i])
+		}
+		if r.Status != StatusRunning || r.Steps[i].Status != StatusRunning {
+			t.Errorf("status = %s/%s while a step runs", r.Status, r.Steps[i].Status)
+		}
+		r.Finish(i, outputs[i], api.TokenUsage{InputTokens: 10, OutputTokens: 5}, 0.01, nil)
+	}
+
+	if r.Status != StatusDone || r.Next() != -1 || r.FinishedAt.IsZero() {
+		t.Errorf("run = %s, want done", r.Status)
+	}
+	if r.Output() != "looks good" {
+		t.Errorf("Output() = %q", r.Output())
+	}
+	if usage, cost := r.Usage(); usage.InputTokens != 30 || cost < 0.0299 {
+		t.Errorf("Usage() = %+v, %v", usage, cost)
+	}
+}
+
+func TestRunFailure(t *testing.T) {
+	r := NewRun(testPipeline, "add login", "")
+
+	r.Start(0, "a1", "Claude", "m")
+	r.Finish(0, "1. form", api.TokenUsage{}, 0, nil)
+	r.Start(1, "a1", "Claude", "m")
+	r.Finish(1, "", api.TokenUsage{}, 0, errors.New("overloaded"))
+
+	if r.Status != StatusFailed || r.Steps[1].Error != "overloaded" || r.Steps[2].Status != StatusSkipped {
+		t.Errorf("run = %s, steps %s/%s", r.Status, r.Steps[1].Status, r.Steps[2].Status)
+	}
+	if r.Next() != -1 {
+		t.Error("a failed run has no next step")
+	}
+	if r.Output() != "1. form" {
+		t.Errorf("Output() = %q, want the last finished step", r.Output())
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	r := NewRun(testPipeline, "add login", "")
+	r.Start(0, "a1", "Claude", "m")
+	r.Cancel()
+
+	if r.Status != StatusCancelled || r.Steps[0].Status != StatusCancelled || r.Steps[1].Status != StatusSkipped {
+		t.Errorf("run = %s, steps %s/%s", r.Status, r.Steps[0].Status, r.Steps[1].Status)
+	}
+}
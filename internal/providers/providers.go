@@ -0,0 +1,63 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/pkg/api"
+	"github.com/yourusername/aui/pkg/api/anthropic"
+	"github.com/yourusername/aui/pkg/api/google"
+	"github.com/yourusername/aui/pkg/api/openai"
+)
+
+// Names lists the supported providers
+var Names = []string{"anthropic", "openai", "google"}
+
+// New creates the API client for a provider using the configured API keys.
+// Rate-limited and failed requests are retried as configured.
+func New(name string, cfg *config.Config) (api.Provider, error) {
+	policy := api.DefaultRetryPolicy
+	if cfg.Retry.MaxAttempts > 0 {
+		policy.MaxAttempts = cfg.Retry.MaxAttempts
+	}
+	if cfg.Retry.MaxElapsed > 0 {
+		policy.MaxElapsed = cfg.Retry.MaxElapsed
+	}
+
+	provider, err := newClient(name, cfg.APIKeys[name], api.NewRetryClient(policy))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := provider.ValidateConfig(cfg.APIKeys); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// Models returns the models suggested by a provider
+func Models(name string) []string {
+	provider, err := newClient(name, "", http.DefaultClient)
+	if err != nil {
+		return nil
+	}
+	if lister, ok := provider.(api.ModelLister); ok {
+		return lister.Models()
+	}
+	return nil
+}
+
+// newClient creates the API client for a provider without validating it
+func newClient(name, apiKey string, httpClient *http.Client) (api.Provider, error) {
+	switch name {
+	case "anthropic":
+		return anthropic.NewClient(apiKey).WithHTTPClient(httpClient), nil
+	case "openai":
+		return openai.NewClient(apiKey).WithHTTPClient(httpClient), nil
+	case "google":
+		return google.NewClient(apiKey).WithHTTPClient(httpClient), nil
+	default:
+		return nil, fmt.Errorf("unknown provider: %s", name)
+	}
+}
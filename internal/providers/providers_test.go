@@ -0,0 +1,44 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/yourusername/aui/internal/config"
+)
+
+func TestNew(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.APIKeys = map[string]string{"anthropic": "a", "openai": "o", "google": "g"}
+
+	for _, name := range Names {
+		provider, err := New(name, cfg)
+		if err != nil {
+			t.Errorf("New(%q) error = %v", name, err)
+			continue
+		}
+		if provider.Name() != name {
+			t.Errorf("New(%q).Name() = %q", name, provider.Name())
+		}
+	}
+
+	if _, err := New("unknown", cfg); err == nil {
+		t.Error("New() of an unknown provider should fail")
+	}
+
+	delete(cfg.APIKeys, "google")
+	if _, err := New("google", cfg); err == nil {
+		t.Error("New() without an API key should fail")
+	}
+}
+
+func TestModels(t *testing.T) {
+	for _, name := range Names {
+		if len(Models(name)) == 0 {
+			t.Errorf("Models(%q) returned no models", name)
+		}
+	}
+
+	if models := Models("unknown"); models != nil {
+		t.Errorf("Models() of an unknown provider = %v, want nil", models)
+	}
+}
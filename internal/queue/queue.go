@@ -0,0 +1,347 @@
+package queue
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// Status is the state of a job
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusDone      Status = "done"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// Priorities of jobs; any int may be used
+const (
+	PriorityBatch       = 0
+	PriorityInteractive = 10 // A user is watching for the reply
+)
+
+// Job is a provider request waiting for, or holding, a slot under the
+// limits of its provider and model
+type Job struct {
+	ID       string
+	AgentID  string
+	Provider string
+	Model    string
+	Priority int // Higher runs first; equal priorities run in submission order
+	Tokens   int // Estimated tokens, counted against tokens-per-minute budgets
+	Request  *api.Request
+
+	Status     Status
+	Output     string
+	Error      string
+	Usage      api.TokenUsage
+	CreatedAt  time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// NewJob creates a queued job for a request made by an agent. The token
+// estimate is the prompt plus the most the reply may use.
+func NewJob(agentID, provider string, req *api.Request, promptTokens int) *Job {
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = api.DefaultMaxTokens
+	}
+	return &Job{
+		ID:        generateID(),
+		AgentID:   agentID,
+		Provider:  provider,
+		Model:     req.Model,
+		Tokens:    promptTokens + maxTokens,
+		Request:   req,
+		Status:    StatusQueued,
+		CreatedAt: time.Now(),
+	}
+}
+
+// Wait returns how long the job waited to start, or has waited so far
+func (j *Job) Wait(now time.Time) time.Duration {
+	if !j.StartedAt.IsZero() {
+		return j.StartedAt.Sub(j.CreatedAt)
+	}
+	return now.Sub(j.CreatedAt)
+}
+
+// Limits caps the requests running at once and the tokens spent per minute.
+// Both are keyed by provider ("anthropic") or by provider and model
+// ("anthropic/claude-opus-4-1"); a job must fit every limit that applies to
+// it. Missing or zero limits are unlimited.
+type Limits struct {
+	Concurrency     map[string]int
+	TokensPerMinute map[string]int
+}
+
+// keys returns the limit keys that apply to a job
+func keys(j *Job) []string {
+	return []string{j.Provider, j.Provider + "/" + j.Model}
+}
+
+// spend is tokens charged against a budget at a time
+type spend struct {
+	at     time.Time
+	key    string
+	jobID  string
+	tokens int
+}
+
+// Queue orders jobs by priority, then submission, and starts each as soon as
+// the limits allow. It is safe for concurrent use.
+type Queue struct {
+	limits Limits
+
+	mu      sync.Mutex
+	pending []*Job
+	running map[string]int             // Jobs running per limit key
+	spent   []spend                    // Token spending in the last minute
+	waits   map[string][]time.Duration // Recent start waits per provider
+	changed chan struct{}
+}
+
+// New creates an empty queue
+func New(limits Limits) *Queue {
+	return &Queue{
+		limits:  limits,
+		running: make(map[string]int),
+		waits:   make(map[string][]time.Duration),
+		changed: make(chan struct{}),
+	}
+}
+
+// Push adds a job to the queue
+func (q *Queue) Push(j *Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	j.Status = StatusQueued
+	q.pending = append(q.pending, j)
+	sort.SliceStable(q.pending, func(a, b int) bool {
+		return q.pending[a].Priority > q.pending[b].Priority
+	})
+	q.notify()
+}
+
+// TryStart starts a job if the limits allow it and no job ahead of it could
+// start instead. Otherwise it returns a channel that is closed when the
+// queue next changes and, if a budget is the obstacle, when the spending
+// that blocks it leaves the one minute window.
+func (q *Queue) TryStart(j *Job, now time.Time) (bool, <-chan struct{}, time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.expire(now)
+
+	for _, other := range q.pending {
+		if other == j {
+			break
+		}
+		// Jobs for the same provider compete for its limits
+		if ok, _ := q.fits(other, now); ok && other.Provider == j.Provider {
+			return false, q.changed, time.Time{}
+		}
+	}
+
+	ok, retryAt := q.fits(j, now)
+	if !ok {
+		return false, q.changed, retryAt
+	}
+
+	q.remove(j)
+	for _, key := range keys(j) {
+		q.running[key]++
+		q.spent = append(q.spent, spend{at: now, key: key, jobID: j.ID, tokens: j.Tokens})
+	}
+	j.Status = StatusRunning
+	j.StartedAt = now
+	q.recordWait(j.Provider, j.Wait(now))
+	q.notify()
+	return true, nil, time.Time{}
+}
+
+// Finish frees the slot of a running job and replaces its estimated token
+// spending with what it used
+func (q *Queue) Finish(j *Job, usage api.TokenUsage) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, key := range keys(j) {
+		if q.running[key] > 0 {
+			q.running[key]--
+		}
+	}
+	if used := usage.InputTokens + usage.OutputTokens; used > 0 {
+		for i := range q.spent {
+			if q.spent[i].jobID == j.ID {
+				q.spent[i].tokens = used
+			}
+		}
+	}
+	q.notify()
+}
+
+// Remove takes a job that has not started out of the queue
+func (q *Queue) Remove(j *Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.remove(j)
+	q.notify()
+}
+
+// Position returns the 1-based place of a job among those waiting for the
+// same provider, or 0 if it is not waiting
+func (q *Queue) Position(j *Job) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	position := 0
+	for _, other := range q.pending {
+		if other.Provider == j.Provider {
+			position++
+		}
+		if other == j {
+			return position
+		}
+	}
+	return 0
+}
+
+// Stats describes the queue of one provider
+type Stats struct {
+	Provider    string
+	Queued      int
+	Running     int
+	LongestWait time.Duration // Of the jobs still queued
+	AverageWait time.Duration // Of the last jobs to start
+}
+
+// Stats returns the state of each provider with queued or running jobs, or
+// with a recent wait, sorted by provider
+func (q *Queue) Stats(now time.Time) []Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	byProvider := make(map[string]*Stats)
+	get := func(provider string) *Stats {
+		if byProvider[provider] == nil {
+			byProvider[provider] = &Stats{Provider: provider}
+		}
+		return byProvider[provider]
+	}
+
+	for _, j := range q.pending {
+		s := get(j.Provider)
+		s.Queued++
+		if wait := j.Wait(now); wait > s.LongestWait {
+			s.LongestWait = wait
+		}
+	}
+	for key, n := range q.running {
+		if n > 0 && !strings.Contains(key, "/") {
+			get(key).Running = n
+		}
+	}
+	for provider, waits := range q.waits {
+		var total time.Duration
+		for _, w := range waits {
+			total += w
+		}
+		get(provider).AverageWait = total / time.Duration(len(waits))
+	}
+
+	var stats []Stats
+	for _, s := range byProvider {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(a, b int) bool { return stats[a].Provider < stats[b].Provider })
+	return stats
+}
+
+// recentWaits is how many start waits per provider are averaged in Stats
+const recentWaits = 20
+
+// recordWait remembers how long a job for a provider waited to start
+func (q *Queue) recordWait(provider string, wait time.Duration) {
+	waits := append(q.waits[provider], wait)
+	if len(waits) > recentWaits {
+		waits = waits[len(waits)-recentWaits:]
+	}
+	q.waits[provider] = waits
+}
+
+// fits reports whether a job fits the concurrency limits and token budgets
+// now, and if a budget is full, when enough of it frees up to try again
+func (q *Queue) fits(j *Job, now time.Time) (bool, time.Time) {
+	for _, key := range keys(j) {
+		if limit := q.limits.Concurrency[key]; limit > 0 && q.running[key] >= limit {
+			return false, time.Time{}
+		}
+	}
+
+	for _, key := range keys(j) {
+		budget := q.limits.TokensPerMinute[key]
+		if budget <= 0 {
+			continue
+		}
+
+		used := 0
+		var oldest time.Time
+		for _, s := range q.spent {
+			if s.key == key {
+				if used == 0 {
+					oldest = s.at
+				}
+				used += s.tokens
+			}
+		}
+		// A job larger than the whole budget runs alone rather than never
+		if used > 0 && used+j.Tokens > budget {
+			return false, oldest.Add(time.Minute)
+		}
+	}
+	return true, time.Time{}
+}
+
+// expire forgets spending older than a minute
+func (q *Queue) expire(now time.Time) {
+	i := 0
+	for i < len(q.spent) && now.Sub(q.spent[i].at) >= time.Minute {
+		i++
+	}
+	q.spent = q.spent[i:]
+}
+
+// remove deletes a job from pending
+func (q *Queue) remove(j *Job) {
+	for i, other := range q.pending {
+		if other == j {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// notify wakes everything waiting for the queue to change
+func (q *Queue) notify() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// generateID generates a random ID for a job
+func generateID() string {
+	bytes := make([]byte, 8)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
@@ -0,0 +1,143 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// job creates a job for a model with a token estimate
+func job(provider, model string, tokens int) *Job {
+	return NewJob("a1", provider, &api.Request{Model: model, MaxTokens: 1}, tokens-1)
+}
+
+func TestNewJob(t *testing.T) {
+	j := NewJob("a1", "anthropic", &api.Request{Model: "claude-sonnet-4-5"}, 100)
+	if j.ID == "" || j.Status != StatusQueued || j.Model != "claude-sonnet-4-5" || j.Tokens != 100+api.DefaultMaxTokens {
+		t.Errorf("NewJob() = %+v", j)
+	}
+}
+
+func TestQueueConcurrency(t *testing.T) {
+	q := New(Limits{Concurrency: map[string]int{"anthropic": 1}})
+	now := time.Now()
+	first, second, other := job("anthropic", "m", 10), job("anthropic", "m", 10), job("openai", "gpt-4o", 10)
+	q.Push(first)
+	q.Push(second)
+	q.Push(other)
+
+	if ok, _, _ := q.TryStart(second, now); ok {
+		t.Error("TryStart() should keep submission order")
+	}
+	if ok, _, _ := q.TryStart(first, now); !ok {
+		t.Fatal("TryStart() of the first job should succeed")
+	}
+	ok, changed, _ := q.TryStart(second, now)
+	if ok {
+		t.Error("TryStart() should respect the provider limit")
+	}
+	if ok, _, _ := q.TryStart(other, now); !ok {
+		t.Error("TryStart() of another provider should not wait")
+	}
+
+	q.Finish(first, api.TokenUsage{})
+	select {
+	case <-changed:
+	default:
+		t.Error("Finish() should wake waiting jobs")
+	}
+	if ok, _, _ := q.TryStart(second, now); !ok || second.Status != StatusRunning {
+		t.Error("TryStart() should succeed once a slot is free")
+	}
+}
+
+func TestQueuePriority(t *testing.T) {
+	q := New(Limits{})
+	low, high := job("anthropic", "m", 10), job("anthropic", "m", 10)
+	high.Priority = 10
+	q.Push(low)
+	q.Push(high)
+
+	if q.Position(high) != 1 || q.Position(low) != 2 {
+		t.Errorf("Position() = %d, %d; want the high priority job first", q.Position(high), q.Position(low))
+	}
+	if ok, _, _ := q.TryStart(low, time.Now()); ok {
+		t.Error("TryStart() should start higher priorities first")
+	}
+	if ok, _, _ := q.TryStart(high, time.Now()); !ok {
+		t.Error("TryStart() of the high priority job should succeed")
+	}
+	if q.Position(high) != 0 {
+		t.Error("Position() of a running job should be 0")
+	}
+}
+
+func TestQueueModelLimit(t *testing.T) {
+	q := New(Limits{Concurrency: map[string]int{"anthropic/opus": 1}})
+	now := time.Now()
+	running, blocked, sonnet := job("anthropic", "opus", 10), job("anthropic", "opus", 10), job("anthropic", "sonnet", 10)
+	for _, j := range []*Job{running, blocked, sonnet} {
+		q.Push(j)
+	}
+
+	q.TryStart(running, now)
+	if ok, _, _ := q.TryStart(blocked, now); ok {
+		t.Error("TryStart() should respect the model limit")
+	}
+	if ok, _, _ := q.TryStart(sonnet, now); !ok {
+		t.Error("TryStart() should pass a job blocked by another model's limit")
+	}
+}
+
+func TestQueueTokenBudget(t *testing.T) {
+	q := New(Limits{TokensPerMinute: map[string]int{"anthropic": 10000}})
+	now := time.Now()
+	first, second := job("anthropic", "m", 6000), job("anthropic", "m", 6000)
+	q.Push(first)
+	q.Push(second)
+
+	q.TryStart(first, now)
+	ok, _, retryAt := q.TryStart(second, now)
+	if ok || !retryAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("TryStart() = %v, retry at %v; want to wait a minute for the budget", ok, retryAt)
+	}
+
+	// Actual usage replaces the estimate
+	q.Finish(first, api.TokenUsage{InputTokens: 1000, OutputTokens: 500})
+	if ok, _, _ := q.TryStart(second, now.Add(time.Second)); !ok {
+		t.Error("TryStart() should fit once the estimate is replaced by usage")
+	}
+
+	big := job("anthropic", "m", 50000)
+	q.Push(big)
+	if ok, _, _ := q.TryStart(big, now.Add(2*time.Minute)); !ok {
+		t.Error("a job larger than the budget should run once the budget is unused")
+	}
+}
+
+func TestQueueStats(t *testing.T) {
+	q := New(Limits{Concurrency: map[string]int{"anthropic": 1}})
+	start := time.Now()
+	first, second := job("anthropic", "m", 10), job("anthropic", "m", 10)
+	first.CreatedAt = start
+	second.CreatedAt = start
+	q.Push(first)
+	q.Push(second)
+	q.TryStart(first, start.Add(2*time.Second))
+
+	stats := q.Stats(start.Add(5 * time.Second))
+	if len(stats) != 1 {
+		t.Fatalf("Stats() = %+v, want one provider", stats)
+	}
+	s := stats[0]
+	if s.Provider != "anthropic" || s.Queued != 1 || s.Running != 1 || s.LongestWait != 5*time.Second || s.AverageWait != 2*time.Second {
+		t.Errorf("Stats() = %+v", s)
+	}
+
+	q.Remove(second)
+	q.Finish(first, api.TokenUsage{})
+	if stats := q.Stats(start); len(stats) != 1 || stats[0].Queued != 0 || stats[0].Running != 0 {
+		t.Errorf("Stats() = %+v, want only the recent wait", stats)
+	}
+}
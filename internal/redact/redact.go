@@ -0,0 +1,239 @@
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/internal/context"
+)
+
+// Rule detects one kind of sensitive value. When Group is set, only that
+// capture group is redacted, so surrounding syntax such as "KEY=" is kept.
+type Rule struct {
+	Kind    string
+	Pattern *regexp.Regexp
+	Group   int
+	EnvOnly bool // Only applies to .env files
+}
+
+// DefaultRules are the built-in detectors, most specific first
+var DefaultRules = []Rule{
+	{Kind: "private_key", Pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{Kind: "anthropic_key", Pattern: regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_\-]{20,}`)},
+	{Kind: "openai_key", Pattern: regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9_\-]{20,}`)},
+	{Kind: "google_api_key", Pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}`)},
+	{Kind: "aws_access_key", Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{Kind: "github_token", Pattern: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}`)},
+	{Kind: "slack_token", Pattern: regexp.MustCompile(`\bxox[baprs]-[A-Za-z0-9\-]{10,}`)},
+	{Kind: "jwt", Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]+\.eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+`)},
+	{Kind: "secret_assignment", Pattern: regexp.MustCompile(`(?i)(?:password|passwd|secret|api[_-]?key|access[_-]?token|auth[_-]?token)["']?\s*[:=]\s*["']([^"'\s]{8,})["']`), Group: 1},
+	{Kind: "env_value", Pattern: regexp.MustCompile(`(?m)^[ \t]*(?:export[ \t]+)?[A-Za-z_][A-Za-z0-9_]*[ \t]*=[ \t]*(\S.*?)[ \t]*$`), Group: 1, EnvOnly: true},
+	{Kind: "email", Pattern: regexp.MustCompile(`\b[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}\b`)},
+}
+
+// Finding records one redacted value
+type Finding struct {
+	Kind        string
+	Source      string // File path, or "prompt"
+	Line        int
+	Placeholder string
+}
+
+// Report lists everything redacted from a single request
+type Report struct {
+	Findings []Finding
+}
+
+// HasFindings reports whether anything was redacted
+func (r *Report) HasFindings() bool {
+	return r != nil && len(r.Findings) > 0
+}
+
+// Summary returns a short description such as "2 anthropic_key, 1 email"
+func (r *Report) Summary() string {
+	counts := make(map[string]int)
+	for _, f := range r.Findings {
+		counts[f.Kind]++
+	}
+
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	parts := make([]string, len(kinds))
+	for i, kind := range kinds {
+		parts[i] = fmt.Sprintf("%d %s", counts[kind], kind)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Redactor replaces sensitive values with stable placeholders
+type Redactor struct {
+	rules     []Rule
+	blocklist []string
+}
+
+// New creates a redactor using the built-in rules plus extra named patterns.
+// Every blocklisted value, such as a configured API key, is always redacted.
+func New(patterns map[string]string, blocklist []string) (*Redactor, error) {
+	r := &Redactor{rules: append([]Rule{}, DefaultRules...)}
+
+	names := make([]string, 0, len(patterns))
+	for name := range patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		re, err := regexp.Compile(patterns[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %s: %w", name, err)
+		}
+		r.rules = append(r.rules, Rule{Kind: name, Pattern: re})
+	}
+
+	for _, value := range blocklist {
+		if value != "" {
+			r.blocklist = append(r.blocklist, value)
+		}
+	}
+
+	return r, nil
+}
+
+// Placeholder returns the stable placeholder for a value. The same value
+// always maps to the same placeholder, so redacted text stays consistent
+// across files and requests without revealing the value.
+func Placeholder(kind, value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return fmt.Sprintf("[REDACTED:%s:%s]", kind, hex.EncodeToString(sum[:4]))
+}
+
+// match is a region of text to replace
+type match struct {
+	start, end int
+	kind       string
+}
+
+// Redact replaces sensitive values in text and returns the findings.
+// Source is the file path the text came from, or "prompt". A nil redactor
+// returns text unchanged.
+func (r *Redactor) Redact(source, text string) (string, []Finding) {
+	if r == nil {
+		return text, nil
+	}
+
+	var matches []match
+
+	for _, value := range r.blocklist {
+		for offset := 0; ; {
+			i := strings.Index(text[offset:], value)
+			if i < 0 {
+				break
+			}
+			start := offset + i
+			matches = append(matches, match{start: start, end: start + len(value), kind: "config_api_key"})
+			offset = start + len(value)
+		}
+	}
+
+	isEnv := strings.HasPrefix(filepath.Base(source), ".env")
+	for _, rule := range r.rules {
+		if rule.EnvOnly && !isEnv {
+			continue
+		}
+		for _, loc := range rule.Pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			if rule.Group > 0 {
+				start, end = loc[2*rule.Group], loc[2*rule.Group+1]
+			}
+			if start < 0 || strings.HasPrefix(text[start:end], "[REDACTED:") {
+				continue
+			}
+			matches = append(matches, match{start: start, end: end, kind: rule.Kind})
+		}
+	}
+
+	if len(matches) == 0 {
+		return text, nil
+	}
+
+	// Earlier matches win; blocklist and specific rules were added first, so
+	// the stable sort keeps them ahead of generic rules at the same offset
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].start < matches[j].start
+	})
+
+	var b strings.Builder
+	var findings []Finding
+	last := 0
+	for _, m := range matches {
+		if m.start < last {
+			continue // Overlaps a match already replaced
+		}
+
+		placeholder := Placeholder(m.kind, text[m.start:m.end])
+		findings = append(findings, Finding{
+			Kind:        m.kind,
+			Source:      source,
+			Line:        strings.Count(text[:m.start], "\n") + 1,
+			Placeholder: placeholder,
+		})
+
+		b.WriteString(text[last:m.start])
+		b.WriteString(placeholder)
+		last = m.end
+	}
+	b.WriteString(text[last:])
+
+	return b.String(), findings
+}
+
+// RedactPrompt redacts a prompt and appends its findings to the report
+func (r *Redactor) RedactPrompt(prompt string, report *Report) string {
+	redacted, findings := r.Redact("prompt", prompt)
+	report.Findings = append(report.Findings, findings...)
+	return redacted
+}
+
+// RedactContext returns a copy of ctx whose file contents are redacted, with
+// a report of what was replaced. The original context is not modified.
+func (r *Redactor) RedactContext(ctx *context.Context) (*context.Context, *Report) {
+	report := &Report{}
+
+	redacted := *ctx
+	redacted.Files = make([]*context.File, 0, len(ctx.Files))
+
+	for _, f := range ctx.Files {
+		content, findings := r.Redact(f.Path, f.Content)
+		copied := *f
+		copied.Content = content
+		redacted.Files = append(redacted.Files, &copied)
+		report.Findings = append(report.Findings, findings...)
+	}
+
+	return &redacted, report
+}
+
+// FromConfig creates a redactor from the redaction settings, blocklisting the
+// configured API keys. It returns nil when redaction is disabled.
+func FromConfig(cfg *config.Config) (*Redactor, error) {
+	if !cfg.Redaction.Enabled {
+		return nil, nil
+	}
+
+	var blocklist []string
+	for _, key := range cfg.APIKeys {
+		blocklist = append(blocklist, key)
+	}
+
+	return New(cfg.Redaction.Patterns, blocklist)
+}
@@ -0,0 +1,49 @@
+package render
+
+import (
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/redact"
+	"github.com/yourusername/aui/internal/storage"
+)
+
+// Prepared is a context ready to be rendered and sent
+type Prepared struct {
+	Context    *context.Context
+	Summarized []string // Paths replaced by their cached summary to fit the budget
+	OverBudget bool
+	Redactions *redact.Report
+}
+
+// Prepare turns a stored context into what is sent to a provider: included
+// contexts are flattened in, cached summaries replace large files if the
+// result is over budget, and secrets are redacted. A nil store skips
+// flattening and summaries; a nil redactor skips redaction.
+func Prepare(store *storage.SQLiteStore, redactor *redact.Redactor, ctx *context.Context, budget int) (*Prepared, error) {
+	summaries := map[string]*context.Summary{}
+
+	if store != nil {
+		flat, err := ctx.Flatten(store.GetContext)
+		if err != nil {
+			return nil, err
+		}
+		ctx = flat
+
+		hashes := make([]string, len(ctx.Files))
+		for i, f := range ctx.Files {
+			hashes[i] = f.Hash
+		}
+		if summaries, err = store.GetSummaries(hashes); err != nil {
+			return nil, err
+		}
+	}
+
+	packed := context.Pack(ctx, budget, summaries)
+	redacted, report := redactor.RedactContext(packed.Context)
+
+	return &Prepared{
+		Context:    redacted,
+		Summarized: packed.Summarized,
+		OverBudget: packed.OverBudget,
+		Redactions: report,
+	}, nil
+}
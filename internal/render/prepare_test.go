@@ -0,0 +1,62 @@
+package render
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/redact"
+	"github.com/yourusername/aui/internal/storage"
+)
+
+func TestPrepare(t *testing.T) {
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	overview := context.NewContext("overview", "")
+	big := context.NewFile("docs/big.md", "big.md")
+	big.SetContent(strings.Repeat("word ", 400))
+	overview.AddFile(big)
+	if err := store.SaveContext(overview); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveSummary(context.NewSummary(big.Hash, "Overview of the project.", "m")); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.NewContext("feature", "")
+	env := context.NewFile(".env", ".env")
+	env.SetContent("TOKEN=supersecretvalue\n")
+	ctx.AddFile(env)
+	ctx.Include(overview.ID)
+	if err := store.SaveContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	redactor, _ := redact.New(nil, nil)
+
+	prepared, err := Prepare(store, redactor, ctx, 100)
+	if err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+
+	if len(prepared.Context.Files) != 2 {
+		t.Fatalf("Prepare() should flatten includes, got %d files", len(prepared.Context.Files))
+	}
+	if len(prepared.Summarized) != 1 || prepared.Summarized[0] != "docs/big.md" || prepared.OverBudget {
+		t.Errorf("Summarized = %v, OverBudget = %v", prepared.Summarized, prepared.OverBudget)
+	}
+	if !prepared.Redactions.HasFindings() || strings.Contains(prepared.Context.GetFile(".env").Content, "supersecretvalue") {
+		t.Error("Prepare() should redact secrets")
+	}
+
+	// Without a budget nothing is summarized
+	prepared, _ = Prepare(store, nil, ctx, 0)
+	if len(prepared.Summarized) != 0 || prepared.Redactions.HasFindings() {
+		t.Errorf("unexpected summaries or redactions: %+v", prepared)
+	}
+}
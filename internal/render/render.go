@@ -0,0 +1,213 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// Format is a way of serializing context files into a prompt
+type Format string
+
+const (
+	FormatXML      Format = "xml"      // XML-tagged documents, preferred by Claude
+	FormatMarkdown Format = "markdown" // Fenced code blocks under path headers
+	FormatJSON     Format = "json"     // A JSON array of files
+)
+
+// Formats lists the supported formats
+var Formats = []Format{FormatXML, FormatMarkdown, FormatJSON}
+
+// ParseFormat validates a format name
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown prompt format: %s (must be xml, markdown, or json)", s)
+}
+
+// DefaultFormat returns the format that works best for a provider
+func DefaultFormat(provider string) Format {
+	if provider == "anthropic" {
+		return FormatXML
+	}
+	return FormatMarkdown
+}
+
+// Options controls how a context is rendered
+type Options struct {
+	Format      Format
+	LineNumbers bool // Prefix each line with its number in the original file
+}
+
+// Render serializes the files of a context
+func Render(ctx *context.Context, opts Options) (string, error) {
+	switch opts.Format {
+	case FormatXML:
+		return renderXML(ctx, opts), nil
+	case FormatMarkdown:
+		return renderMarkdown(ctx, opts), nil
+	case FormatJSON:
+		return renderJSON(ctx, opts)
+	default:
+		return "", fmt.Errorf("unknown prompt format: %s", opts.Format)
+	}
+}
+
+// Request builds a provider request that sends the context's system prompt
+// and a single user message holding the rendered files followed by prompt
+func Request(ctx *context.Context, prompt string, opts Options) (*api.Request, error) {
+	var b strings.Builder
+	if len(ctx.Files) > 0 {
+		files, err := Render(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(files)
+		b.WriteString("\n\n")
+	}
+	b.WriteString(prompt)
+
+	return &api.Request{
+		System:   ctx.SystemPrompt,
+		Messages: []api.Message{{Role: api.RoleUser, Content: b.String()}},
+	}, nil
+}
+
+// content returns a file's content, numbered if requested. Line ranges such
+// as "path:120-240" are numbered from their first line, and summaries are
+// never numbered since their lines do not match the file.
+func content(f *context.File, opts Options) string {
+	if !opts.LineNumbers || f.Summarized || f.Content == "" {
+		return f.Content
+	}
+
+	start := 1
+	if _, from, _, err := context.ParseLineRange(f.Path); err == nil && from > 0 {
+		start = from
+	}
+
+	lines := strings.SplitAfter(f.Content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	width := len(fmt.Sprint(start + len(lines) - 1))
+	var b strings.Builder
+	for i, line := range lines {
+		fmt.Fprintf(&b, "%*d  %s", width, start+i, line)
+	}
+	return b.String()
+}
+
+// renderXML writes each file as a <document>, following Anthropic's long
+// context guidance. Content is not escaped so code reads naturally.
+func renderXML(ctx *context.Context, opts Options) string {
+	var b strings.Builder
+	b.WriteString("<documents>\n")
+
+	for i, f := range ctx.Files {
+		fmt.Fprintf(&b, "<document index=\"%d\">\n", i+1)
+		fmt.Fprintf(&b, "<source>%s</source>\n", html.EscapeString(f.Path))
+		if f.Language != "" {
+			fmt.Fprintf(&b, "<language>%s</language>\n", html.EscapeString(f.Language))
+		}
+		if f.Summarized {
+			b.WriteString("<note>Summary of the file, not its full content</note>\n")
+		}
+		b.WriteString("<document_content>\n")
+		text := content(f, opts)
+		b.WriteString(text)
+		if !strings.HasSuffix(text, "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString("</document_content>\n</document>\n")
+	}
+
+	b.WriteString("</documents>")
+	return b.String()
+}
+
+// renderMarkdown writes each file as a fenced code block under its path
+func renderMarkdown(ctx *context.Context, opts Options) string {
+	var parts []string
+
+	for _, f := range ctx.Files {
+		var b strings.Builder
+
+		header := f.Path
+		if f.Summarized {
+			header += " (summary)"
+		}
+		fmt.Fprintf(&b, "## %s\n\n", header)
+
+		text := content(f, opts)
+		fence := fenceFor(text)
+		language := f.Language
+		if f.Summarized {
+			language = ""
+		}
+		fmt.Fprintf(&b, "%s%s\n%s", fence, language, text)
+		if !strings.HasSuffix(text, "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString(fence)
+
+		parts = append(parts, b.String())
+	}
+
+	return strings.Join(parts, "\n\n")
+}
+
+// fenceFor returns a backtick fence longer than any run of backticks in text
+func fenceFor(text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+
+	if longest < 3 {
+		return "```"
+	}
+	return strings.Repeat("`", longest+1)
+}
+
+// jsonFile is the JSON representation of a file
+type jsonFile struct {
+	Path       string `json:"path"`
+	Language   string `json:"language,omitempty"`
+	Summarized bool   `json:"summarized,omitempty"`
+	Content    string `json:"content"`
+}
+
+// renderJSON writes the files as an indented JSON array
+func renderJSON(ctx *context.Context, opts Options) (string, error) {
+	files := make([]jsonFile, 0, len(ctx.Files))
+	for _, f := range ctx.Files {
+		files = append(files, jsonFile{
+			Path:       f.Path,
+			Language:   f.Language,
+			Summarized: f.Summarized,
+			Content:    content(f, opts),
+		})
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{"files": files}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode context: %w", err)
+	}
+	return string(data), nil
+}
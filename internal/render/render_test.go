@@ -0,0 +1,160 @@
+package render
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/aui/internal/context"
+)
+
+// testContext returns a context with a Go file and a line range of a log
+func testContext() *context.Context {
+	ctx := context.NewContext("bug", "")
+	ctx.SystemPrompt = "Find the bug."
+
+	main := context.NewFile("main.go", "main.go")
+	main.SetContent("package main\n\nfunc main() {}\n")
+	main.Language = "go"
+	ctx.AddFile(main)
+
+	log := context.NewFile("app.log:9-11", "app.log")
+	log.SetContent("start\npanic: boom\nexit\n")
+	ctx.AddFile(log)
+
+	return ctx
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range Formats {
+		if got, err := ParseFormat(string(f)); err != nil || got != f {
+			t.Errorf("ParseFormat(%q) = %v, %v", f, got, err)
+		}
+	}
+	if _, err := ParseFormat("yaml"); err == nil {
+		t.Error("ParseFormat(\"yaml\") should fail")
+	}
+}
+
+func TestDefaultFormat(t *testing.T) {
+	if DefaultFormat("anthropic") != FormatXML || DefaultFormat("openai") != FormatMarkdown {
+		t.Error("DefaultFormat() should prefer XML for Claude and markdown otherwise")
+	}
+}
+
+func TestRenderXML(t *testing.T) {
+	got, err := Render(testContext(), Options{Format: FormatXML})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	want := `<documents>
+<document index="1">
+<source>main.go</source>
+<language>go</language>
+<document_content>
+package main
+
+func main() {}
+</document_content>
+</document>
+<document index="2">
+<source>app.log:9-11</source>
+<document_content>
+start
+panic: boom
+exit
+</document_content>
+</document>
+</documents>`
+	if got != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	got, err := Render(testContext(), Options{Format: FormatMarkdown, LineNumbers: true})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	want := "## main.go\n\n```go\n1  package main\n2  \n3  func main() {}\n```\n\n" +
+		"## app.log:9-11\n\n```\n 9  start\n10  panic: boom\n11  exit\n```"
+	if got != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderMarkdownFence(t *testing.T) {
+	ctx := context.NewContext("docs", "")
+	readme := context.NewFile("README.md", "README.md")
+	readme.SetContent("Run:\n```sh\nmake\n```")
+	readme.Language = "markdown"
+	ctx.AddFile(readme)
+
+	got, _ := Render(ctx, Options{Format: FormatMarkdown})
+	if !strings.HasPrefix(got, "## README.md\n\n````markdown\n") || !strings.HasSuffix(got, "```\n````") {
+		t.Errorf("Render() should use a longer fence than the content:\n%s", got)
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	got, err := Render(testContext(), Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	var decoded struct {
+		Files []jsonFile `json:"files"`
+	}
+	if err := json.Unmarshal([]byte(got), &decoded); err != nil {
+		t.Fatalf("Render() is not valid JSON: %v", err)
+	}
+	if len(decoded.Files) != 2 || decoded.Files[0].Path != "main.go" || decoded.Files[0].Language != "go" {
+		t.Errorf("decoded = %+v", decoded.Files)
+	}
+}
+
+func TestRenderSummary(t *testing.T) {
+	ctx := testContext()
+	ctx.Files[0] = ctx.Files[0].Summarize(context.NewSummary(ctx.Files[0].Hash, "Entry point.", "m"))
+
+	xml, _ := Render(ctx, Options{Format: FormatXML, LineNumbers: true})
+	if !strings.Contains(xml, "<note>Summary of the file, not its full content</note>\n<document_content>\nEntry point.\n") {
+		t.Errorf("XML should mark summaries and not number them:\n%s", xml)
+	}
+
+	md, _ := Render(ctx, Options{Format: FormatMarkdown})
+	if !strings.Contains(md, "## main.go (summary)\n\n```\nEntry point.\n```") {
+		t.Errorf("Markdown should mark summaries:\n%s", md)
+	}
+}
+
+func TestRenderUnknownFormat(t *testing.T) {
+	if _, err := Render(testContext(), Options{Format: "yaml"}); err == nil {
+		t.Error("Render() with an unknown format should fail")
+	}
+}
+
+func TestRequest(t *testing.T) {
+	req, err := Request(testContext(), "Why does it panic?", Options{Format: FormatMarkdown})
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+
+	if req.System != "Find the bug." {
+		t.Errorf("System = %q", req.System)
+	}
+	if len(req.Messages) != 1 || req.Messages[0].Role != "user" {
+		t.Fatalf("Messages = %+v", req.Messages)
+	}
+	msg := req.Messages[0].Content
+	if !strings.HasPrefix(msg, "## main.go") || !strings.HasSuffix(msg, "```\n\nWhy does it panic?") {
+		t.Errorf("user message =\n%s", msg)
+	}
+
+	empty, _ := Request(context.NewContext("empty", ""), "Hi", Options{Format: FormatXML})
+	if empty.Messages[0].Content != "Hi" {
+		t.Errorf("empty context message = %q, want just the prompt", empty.Messages[0].Content)
+	}
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"path/filepath"
 	"time"
@@ -9,6 +10,11 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/yourusername/aui/internal/agent"
 	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/judge"
+	"github.com/yourusername/aui/internal/ledger"
+	"github.com/yourusername/aui/internal/pipeline"
+	"github.com/yourusername/aui/internal/queue"
+	"github.com/yourusername/aui/internal/tools"
 )
 
 // SQLiteStore implements storage using SQLite
@@ -38,6 +44,11 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 		return nil, fmt.Errorf("failed to initialize schema: %w", err)
 	}
 
+	if err := store.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
 	return store, nil
 }
 
@@ -93,6 +104,125 @@ func (s *SQLiteStore) initializeSchema() error {
 		FOREIGN KEY (file_id) REFERENCES files(id) ON DELETE CASCADE
 	);
 	
+	CREATE TABLE IF NOT EXISTS context_includes (
+		context_id TEXT NOT NULL,
+		include_id TEXT NOT NULL,
+		position INTEGER,
+		PRIMARY KEY (context_id, include_id)
+	);
+	
+	CREATE TABLE IF NOT EXISTS chunks (
+		path TEXT NOT NULL,
+		start_line INTEGER NOT NULL,
+		end_line INTEGER NOT NULL,
+		hash TEXT NOT NULL,
+		tokens INTEGER DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (path, start_line, end_line)
+	);
+	
+	CREATE TABLE IF NOT EXISTS summaries (
+		hash TEXT PRIMARY KEY,
+		content TEXT NOT NULL,
+		tokens INTEGER DEFAULT 0,
+		model TEXT,
+		created_at DATETIME NOT NULL
+	);
+	
+	CREATE TABLE IF NOT EXISTS usage_ledger (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		agent_id TEXT,
+		provider TEXT NOT NULL,
+		model TEXT NOT NULL,
+		input_tokens INTEGER DEFAULT 0,
+		output_tokens INTEGER DEFAULT 0,
+		cost REAL DEFAULT 0,
+		created_at DATETIME NOT NULL
+	);
+	
+	CREATE TABLE IF NOT EXISTS queue_jobs (
+		id TEXT PRIMARY KEY,
+		agent_id TEXT,
+		provider TEXT NOT NULL,
+		model TEXT NOT NULL,
+		priority INTEGER DEFAULT 0,
+		tokens INTEGER DEFAULT 0,
+		request TEXT NOT NULL,
+		status TEXT NOT NULL,
+		output TEXT,
+		error TEXT,
+		input_tokens INTEGER DEFAULT 0,
+		output_tokens INTEGER DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		started_at DATETIME,
+		finished_at DATETIME
+	);
+	
+	CREATE TABLE IF NOT EXISTS comparisons (
+		id TEXT PRIMARY KEY,
+		prompt TEXT NOT NULL,
+		context_id TEXT,
+		verdict TEXT,
+		created_at DATETIME NOT NULL
+	);
+	
+	CREATE TABLE IF NOT EXISTS comparison_candidates (
+		comparison_id TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		agent_id TEXT,
+		agent TEXT,
+		model TEXT,
+		answer TEXT,
+		error TEXT,
+		input_tokens INTEGER DEFAULT 0,
+		output_tokens INTEGER DEFAULT 0,
+		cost REAL DEFAULT 0,
+		PRIMARY KEY (comparison_id, position)
+	);
+	
+	CREATE TABLE IF NOT EXISTS pipeline_runs (
+		id TEXT PRIMARY KEY,
+		pipeline TEXT NOT NULL,
+		input TEXT NOT NULL,
+		context_id TEXT,
+		status TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		finished_at DATETIME
+	);
+	
+	CREATE TABLE IF NOT EXISTS pipeline_steps (
+		run_id TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		agent_id TEXT,
+		agent TEXT,
+		model TEXT,
+		status TEXT NOT NULL,
+		prompt TEXT,
+		output TEXT,
+		error TEXT,
+		input_tokens INTEGER DEFAULT 0,
+		output_tokens INTEGER DEFAULT 0,
+		cost REAL DEFAULT 0,
+		started_at DATETIME,
+		finished_at DATETIME,
+		PRIMARY KEY (run_id, position)
+	);
+	
+	CREATE TABLE IF NOT EXISTS commands (
+		id TEXT PRIMARY KEY,
+		agent_id TEXT,
+		agent TEXT,
+		command TEXT NOT NULL,
+		dir TEXT NOT NULL,
+		approved BOOLEAN NOT NULL,
+		exit_code INTEGER NOT NULL,
+		output TEXT,
+		error TEXT,
+		started_at DATETIME NOT NULL,
+		finished_at DATETIME
+	);
+	
 	CREATE TABLE IF NOT EXISTS schema_migrations (
 		version INTEGER PRIMARY KEY,
 		applied_at DATETIME NOT NULL
@@ -103,6 +233,48 @@ func (s *SQLiteStore) initializeSchema() error {
 	return err
 }
 
+// migrations alter tables created by earlier versions. Each entry is applied
+// once, in order, and recorded in schema_migrations by its 1-based version.
+var migrations = []string{
+	`ALTER TABLE contexts ADD COLUMN system_prompt TEXT`,
+	`ALTER TABLE agents ADD COLUMN system_prompt TEXT`,
+	`ALTER TABLE agents ADD COLUMN params TEXT`,
+	`ALTER TABLE usage_ledger ADD COLUMN chain TEXT`,
+	`ALTER TABLE usage_ledger ADD COLUMN tier INTEGER DEFAULT 0`,
+	`ALTER TABLE usage_ledger ADD COLUMN escalated TEXT`,
+}
+
+// migrate applies any migrations not yet recorded in schema_migrations
+func (s *SQLiteStore) migrate() error {
+	var current int
+	err := s.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current)
+	if err != nil {
+		return err
+	}
+
+	for i := current; i < len(migrations); i++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)", i+1, time.Now()); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // BeginTx starts a new database transaction
 func (s *SQLiteStore) BeginTx() (*sql.Tx, error) {
 	return s.db.Begin()
@@ -129,9 +301,14 @@ func (s *SQLiteStore) SaveAgent(a *agent.Agent) error {
 func (s *SQLiteStore) SaveAgentTx(tx *sql.Tx, a *agent.Agent) error {
 	now := time.Now()
 
+	params, err := json.Marshal(a.Params)
+	if err != nil {
+		return fmt.Errorf("failed to encode agent params: %w", err)
+	}
+
 	query := `
-	INSERT INTO agents (id, name, model, provider, status, current_task, last_error, created_at, updated_at)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	INSERT INTO agents (id, name, model, provider, status, current_task, last_error, system_prompt, params, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	ON CONFLICT(id) DO UPDATE SET
 		name = excluded.name,
 		model = excluded.model,
@@ -139,55 +316,56 @@ func (s *SQLiteStore) SaveAgentTx(tx *sql.Tx, a *agent.Agent) error {
 		status = excluded.status,
 		current_task = excluded.current_task,
 		last_error = excluded.last_error,
+		system_prompt = excluded.system_prompt,
+		params = excluded.params,
 		updated_at = excluded.updated_at
 	`
 
-	_, err := tx.Exec(query, a.ID, a.Name, a.Model, a.Provider, a.Status, a.CurrentTask, a.LastError, now, now)
+	_, err = tx.Exec(query, a.ID, a.Name, a.Model, a.Provider, a.Status, a.CurrentTask, a.LastError,
+		a.SystemPrompt, string(params), now, now)
 	return err
 }
 
-// GetAgent retrieves an agent by ID
-func (s *SQLiteStore) GetAgent(id string) (*agent.Agent, error) {
-	query := `
-	SELECT id, name, model, provider, status, current_task, last_error
-	FROM agents
-	WHERE id = ?
-	`
+// agentColumns are the columns read by scanAgent, in order
+const agentColumns = `id, name, model, provider, status, current_task, last_error, system_prompt, params`
 
+// scanAgent reads an agent row selected with agentColumns
+func scanAgent(row interface{ Scan(...interface{}) error }) (*agent.Agent, error) {
 	var a agent.Agent
-	var currentTask, lastError sql.NullString
+	var currentTask, lastError, systemPrompt, params sql.NullString
 
-	err := s.db.QueryRow(query, id).Scan(
+	err := row.Scan(
 		&a.ID, &a.Name, &a.Model, &a.Provider, &a.Status,
-		&currentTask, &lastError,
+		&currentTask, &lastError, &systemPrompt, &params,
 	)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("agent not found: %s", id)
-	}
 	if err != nil {
 		return nil, err
 	}
 
-	if currentTask.Valid {
-		a.CurrentTask = currentTask.String
-	}
-	if lastError.Valid {
-		a.LastError = lastError.String
+	a.CurrentTask = currentTask.String
+	a.LastError = lastError.String
+	a.SystemPrompt = systemPrompt.String
+	if params.String != "" {
+		if err := json.Unmarshal([]byte(params.String), &a.Params); err != nil {
+			return nil, fmt.Errorf("failed to decode params of agent %s: %w", a.ID, err)
+		}
 	}
 
 	return &a, nil
 }
 
+// GetAgent retrieves an agent by ID
+func (s *SQLiteStore) GetAgent(id string) (*agent.Agent, error) {
+	a, err := scanAgent(s.db.QueryRow("SELECT "+agentColumns+" FROM agents WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("agent not found: %s", id)
+	}
+	return a, err
+}
+
 // ListAgents returns all agents
 func (s *SQLiteStore) ListAgents() ([]*agent.Agent, error) {
-	query := `
-	SELECT id, name, model, provider, status, current_task, last_error
-	FROM agents
-	ORDER BY created_at DESC
-	`
-
-	rows, err := s.db.Query(query)
+	rows, err := s.db.Query("SELECT " + agentColumns + " FROM agents ORDER BY created_at DESC")
 	if err != nil {
 		return nil, err
 	}
@@ -195,25 +373,11 @@ func (s *SQLiteStore) ListAgents() ([]*agent.Agent, error) {
 
 	var agents []*agent.Agent
 	for rows.Next() {
-		var a agent.Agent
-		var currentTask, lastError sql.NullString
-
-		err := rows.Scan(
-			&a.ID, &a.Name, &a.Model, &a.Provider, &a.Status,
-			&currentTask, &lastError,
-		)
+		a, err := scanAgent(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		if currentTask.Valid {
-			a.CurrentTask = currentTask.String
-		}
-		if lastError.Valid {
-			a.LastError = lastError.String
-		}
-
-		agents = append(agents, &a)
+		agents = append(agents, a)
 	}
 
 	return agents, rows.Err()
@@ -239,20 +403,25 @@ func (s *SQLiteStore) SaveContext(ctx *context.Context) error {
 
 	// Save context
 	query := `
-	INSERT INTO contexts (id, name, description, total_tokens, created_at, updated_at)
-	VALUES (?, ?, ?, ?, ?, ?)
+	INSERT INTO contexts (id, name, description, system_prompt, total_tokens, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)
 	ON CONFLICT(id) DO UPDATE SET
 		name = excluded.name,
 		description = excluded.description,
+		system_prompt = excluded.system_prompt,
 		total_tokens = excluded.total_tokens,
 		updated_at = excluded.updated_at
 	`
 
-	_, err = tx.Exec(query, ctx.ID, ctx.Name, ctx.Description, ctx.TotalTokens, now, now)
+	_, err = tx.Exec(query, ctx.ID, ctx.Name, ctx.Description, ctx.SystemPrompt, ctx.TotalTokens, now, now)
 	if err != nil {
 		return err
 	}
 
+	if err := saveIncludesTx(tx, ctx); err != nil {
+		return err
+	}
+
 	// Delete existing file associations
 	_, err = tx.Exec("DELETE FROM context_files WHERE context_id = ?", ctx.ID)
 	if err != nil {
@@ -282,6 +451,12 @@ func (s *SQLiteStore) SaveContext(ctx *context.Context) error {
 			return err
 		}
 
+		// Files are shared by path, so an existing row keeps its original ID
+		err = tx.QueryRow("SELECT id FROM files WHERE path = ?", file.Path).Scan(&file.ID)
+		if err != nil {
+			return err
+		}
+
 		// Save association
 		assocQuery := `
 		INSERT INTO context_files (context_id, file_id, position)
@@ -297,19 +472,98 @@ func (s *SQLiteStore) SaveContext(ctx *context.Context) error {
 	return tx.Commit()
 }
 
+// saveIncludesTx replaces the contexts included by ctx, refusing includes
+// that would make ctx reachable from itself
+func saveIncludesTx(tx *sql.Tx, ctx *context.Context) error {
+	for _, id := range ctx.Includes {
+		cyclic, err := reachesTx(tx, id, ctx.ID)
+		if err != nil {
+			return err
+		}
+		if cyclic {
+			return fmt.Errorf("including %s in %s would create a cycle", id, ctx.ID)
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM context_includes WHERE context_id = ?", ctx.ID); err != nil {
+		return err
+	}
+
+	for i, id := range ctx.Includes {
+		_, err := tx.Exec("INSERT INTO context_includes (context_id, include_id, position) VALUES (?, ?, ?)", ctx.ID, id, i)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reachesTx reports whether target is from, or is included by from directly
+// or indirectly
+func reachesTx(tx *sql.Tx, from, target string) (bool, error) {
+	queue := []string{from}
+	seen := map[string]bool{from: true}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if id == target {
+			return true, nil
+		}
+
+		includes, err := queryIncludes(tx, id)
+		if err != nil {
+			return false, err
+		}
+		for _, inc := range includes {
+			if !seen[inc] {
+				seen[inc] = true
+				queue = append(queue, inc)
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// queryIncludes returns the IDs of the contexts directly included by id, in order
+func queryIncludes(q queryer, id string) ([]string, error) {
+	rows, err := q.Query("SELECT include_id FROM context_includes WHERE context_id = ? ORDER BY position", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	includes := []string{}
+	for rows.Next() {
+		var inc string
+		if err := rows.Scan(&inc); err != nil {
+			return nil, err
+		}
+		includes = append(includes, inc)
+	}
+	return includes, rows.Err()
+}
+
 // GetContext retrieves a context by ID with its files
 func (s *SQLiteStore) GetContext(id string) (*context.Context, error) {
 	// Get context
 	query := `
-	SELECT id, name, description, total_tokens
+	SELECT id, name, description, system_prompt, total_tokens
 	FROM contexts
 	WHERE id = ?
 	`
 
 	var ctx context.Context
-	var description sql.NullString
+	var description, systemPrompt sql.NullString
 
-	err := s.db.QueryRow(query, id).Scan(&ctx.ID, &ctx.Name, &description, &ctx.TotalTokens)
+	err := s.db.QueryRow(query, id).Scan(&ctx.ID, &ctx.Name, &description, &systemPrompt, &ctx.TotalTokens)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("context not found: %s", id)
 	}
@@ -320,6 +574,9 @@ func (s *SQLiteStore) GetContext(id string) (*context.Context, error) {
 	if description.Valid {
 		ctx.Description = description.String
 	}
+	if systemPrompt.Valid {
+		ctx.SystemPrompt = systemPrompt.String
+	}
 
 	// Get associated files
 	fileQuery := `
@@ -363,14 +620,32 @@ func (s *SQLiteStore) GetContext(id string) (*context.Context, error) {
 
 		ctx.Files = append(ctx.Files, &f)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ctx.Includes, err = queryIncludes(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ctx, nil
+}
 
-	return &ctx, rows.Err()
+// GetFlattenedContext retrieves a context with the files of all the contexts
+// it includes merged in, see context.Flatten
+func (s *SQLiteStore) GetFlattenedContext(id string) (*context.Context, error) {
+	ctx, err := s.GetContext(id)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.Flatten(s.GetContext)
 }
 
 // ListContexts returns all contexts
 func (s *SQLiteStore) ListContexts() ([]*context.Context, error) {
 	query := `
-	SELECT id, name, description, total_tokens
+	SELECT id, name, description, system_prompt, total_tokens
 	FROM contexts
 	ORDER BY created_at DESC
 	`
@@ -384,9 +659,9 @@ func (s *SQLiteStore) ListContexts() ([]*context.Context, error) {
 	var contexts []*context.Context
 	for rows.Next() {
 		var ctx context.Context
-		var description sql.NullString
+		var description, systemPrompt sql.NullString
 
-		err := rows.Scan(&ctx.ID, &ctx.Name, &description, &ctx.TotalTokens)
+		err := rows.Scan(&ctx.ID, &ctx.Name, &description, &systemPrompt, &ctx.TotalTokens)
 		if err != nil {
 			return nil, err
 		}
@@ -394,19 +669,618 @@ func (s *SQLiteStore) ListContexts() ([]*context.Context, error) {
 		if description.Valid {
 			ctx.Description = description.String
 		}
+		if systemPrompt.Valid {
+			ctx.SystemPrompt = systemPrompt.String
+		}
 
 		// Note: Not loading files for list operation to keep it efficient
 		ctx.Files = make([]*context.File, 0)
 
 		contexts = append(contexts, &ctx)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, ctx := range contexts {
+		if ctx.Includes, err = queryIncludes(s.db, ctx.ID); err != nil {
+			return nil, err
+		}
+	}
 
-	return contexts, rows.Err()
+	return contexts, nil
 }
 
 // DeleteContext deletes a context and its file associations
 func (s *SQLiteStore) DeleteContext(id string) error {
 	// File associations will be deleted automatically due to CASCADE
 	_, err := s.db.Exec("DELETE FROM contexts WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	// Includes have no foreign keys, so remove them in both directions
+	_, err = s.db.Exec("DELETE FROM context_includes WHERE context_id = ? OR include_id = ?", id, id)
+	return err
+}
+
+// Chunk operations
+
+// FileContexts returns the hash of the file stored at path and the IDs of
+// the contexts using it. Files are stored once per path and shared, so
+// saving a different file at path changes all of them. The hash is empty if
+// no file is stored at path.
+func (s *SQLiteStore) FileContexts(path string) (string, []string, error) {
+	var hash string
+	err := s.db.QueryRow("SELECT hash FROM files WHERE path = ?", path).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	rows, err := s.db.Query(`
+	SELECT cf.context_id
+	FROM context_files cf
+	JOIN files f ON f.id = cf.file_id
+	WHERE f.path = ?
+	ORDER BY cf.context_id
+	`, path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return "", nil, err
+		}
+		ids = append(ids, id)
+	}
+	return hash, ids, rows.Err()
+}
+
+// SaveChunks records chunk metadata for a file path, replacing any chunks
+// previously saved for that path. Content is not stored; chunks are cited by
+// range and verified by hash.
+func (s *SQLiteStore) SaveChunks(path string, chunks []*context.Chunk) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM chunks WHERE path = ?", path); err != nil {
+		return err
+	}
+
+	if err := s.saveChunksTx(tx, chunks); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AddChunk records metadata for a single chunk, keeping existing chunks
+func (s *SQLiteStore) AddChunk(chunk *context.Chunk) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.saveChunksTx(tx, []*context.Chunk{chunk}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// saveChunksTx upserts chunk metadata within a transaction
+func (s *SQLiteStore) saveChunksTx(tx *sql.Tx, chunks []*context.Chunk) error {
+	query := `
+	INSERT INTO chunks (path, start_line, end_line, hash, tokens, created_at)
+	VALUES (?, ?, ?, ?, ?, ?)
+	ON CONFLICT(path, start_line, end_line) DO UPDATE SET
+		hash = excluded.hash,
+		tokens = excluded.tokens
+	`
+
+	now := time.Now()
+	for _, c := range chunks {
+		if _, err := tx.Exec(query, c.Path, c.StartLine, c.EndLine, c.Hash, c.Tokens, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListChunks returns the chunk metadata saved for a file path, in line order
+func (s *SQLiteStore) ListChunks(path string) ([]*context.Chunk, error) {
+	query := `
+	SELECT path, start_line, end_line, hash, tokens
+	FROM chunks
+	WHERE path = ?
+	ORDER BY start_line, end_line
+	`
+
+	rows, err := s.db.Query(query, path)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chunks []*context.Chunk
+	for rows.Next() {
+		var c context.Chunk
+		if err := rows.Scan(&c.Path, &c.StartLine, &c.EndLine, &c.Hash, &c.Tokens); err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, &c)
+	}
+
+	return chunks, rows.Err()
+}
+
+// SaveSummary stores a summary keyed by the hash of the content it summarizes
+func (s *SQLiteStore) SaveSummary(summary *context.Summary) error {
+	query := `
+	INSERT INTO summaries (hash, content, tokens, model, created_at)
+	VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT(hash) DO UPDATE SET
+		content = excluded.content,
+		tokens = excluded.tokens,
+		model = excluded.model,
+		created_at = excluded.created_at
+	`
+
+	_, err := s.db.Exec(query, summary.Hash, summary.Content, summary.Tokens, summary.Model, summary.CreatedAt)
+	return err
+}
+
+// GetSummary returns the summary for a content hash, or nil if there is none
+func (s *SQLiteStore) GetSummary(hash string) (*context.Summary, error) {
+	summaries, err := s.GetSummaries([]string{hash})
+	if err != nil {
+		return nil, err
+	}
+	return summaries[hash], nil
+}
+
+// GetSummaries returns the stored summaries for the given content hashes,
+// keyed by hash. Hashes without a summary are left out.
+func (s *SQLiteStore) GetSummaries(hashes []string) (map[string]*context.Summary, error) {
+	summaries := make(map[string]*context.Summary)
+
+	for _, hash := range hashes {
+		if _, ok := summaries[hash]; ok || hash == "" {
+			continue
+		}
+
+		var summary context.Summary
+		var model sql.NullString
+		err := s.db.QueryRow("SELECT hash, content, tokens, model, created_at FROM summaries WHERE hash = ?", hash).
+			Scan(&summary.Hash, &summary.Content, &summary.Tokens, &model, &summary.CreatedAt)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		summary.Model = model.String
+		summaries[hash] = &summary
+	}
+
+	return summaries, nil
+}
+
+// Usage ledger operations
+
+// RecordUsage appends an entry to the usage ledger and sets its ID
+func (s *SQLiteStore) RecordUsage(e *ledger.Entry) error {
+	query := `
+	INSERT INTO usage_ledger (agent_id, provider, model, input_tokens, output_tokens, cost, created_at, chain, tier, escalated)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := s.db.Exec(query, e.AgentID, e.Provider, e.Model, e.InputTokens, e.OutputTokens, e.Cost, e.CreatedAt,
+		e.Chain, e.Tier, e.Escalated)
+	if err != nil {
+		return err
+	}
+
+	e.ID, err = result.LastInsertId()
+	return err
+}
+
+// ListUsage returns the ledger entries recorded at or after since, oldest first
+func (s *SQLiteStore) ListUsage(since time.Time) ([]*ledger.Entry, error) {
+	query := `
+	SELECT id, agent_id, provider, model, input_tokens, output_tokens, cost, created_at, chain, tier, escalated
+	FROM usage_ledger
+	WHERE created_at >= ?
+	ORDER BY created_at, id
+	`
+
+	rows, err := s.db.Query(query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*ledger.Entry
+	for rows.Next() {
+		var e ledger.Entry
+		var agentID, chain, escalated sql.NullString
+		var tier sql.NullInt64
+		err := rows.Scan(&e.ID, &agentID, &e.Provider, &e.Model, &e.InputTokens, &e.OutputTokens, &e.Cost, &e.CreatedAt,
+			&chain, &tier, &escalated)
+		if err != nil {
+			return nil, err
+		}
+		e.AgentID = agentID.String
+		e.Chain = chain.String
+		e.Tier = int(tier.Int64)
+		e.Escalated = escalated.String
+		entries = append(entries, &e)
+	}
+
+	return entries, rows.Err()
+}
+
+// Queue operations
+
+// SaveJob saves or updates a queued job
+func (s *SQLiteStore) SaveJob(j *queue.Job) error {
+	request, err := json.Marshal(j.Request)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	query := `
+	INSERT INTO queue_jobs (id, agent_id, provider, model, priority, tokens, request, status, output, error,
+		input_tokens, output_tokens, created_at, started_at, finished_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(id) DO UPDATE SET
+		priority = excluded.priority,
+		status = excluded.status,
+		output = excluded.output,
+		error = excluded.error,
+		input_tokens = excluded.input_tokens,
+		output_tokens = excluded.output_tokens,
+		started_at = excluded.started_at,
+		finished_at = excluded.finished_at
+	`
+
+	_, err = s.db.Exec(query, j.ID, j.AgentID, j.Provider, j.Model, j.Priority, j.Tokens, string(request), j.Status,
+		j.Output, j.Error, j.Usage.InputTokens, j.Usage.OutputTokens, j.CreatedAt, nullTime(j.StartedAt), nullTime(j.FinishedAt))
+	return err
+}
+
+// CancelJob marks a job cancelled unless it has already finished. It only
+// touches the stored row, so it is safe while the job is still running.
+func (s *SQLiteStore) CancelJob(id string, at time.Time) error {
+	_, err := s.db.Exec(`UPDATE queue_jobs SET status = ?, finished_at = ? WHERE id = ? AND status IN (?, ?)`,
+		queue.StatusCancelled, at, id, queue.StatusQueued, queue.StatusRunning)
+	return err
+}
+
+// ListJobs returns the jobs with any of the given statuses, or all jobs if
+// none are given, oldest first
+func (s *SQLiteStore) ListJobs(statuses ...queue.Status) ([]*queue.Job, error) {
+	query := `
+	SELECT id, agent_id, provider, model, priority, tokens, request, status, output, error,
+		input_tokens, output_tokens, created_at, started_at, finished_at
+	FROM queue_jobs
+	ORDER BY created_at, id
+	`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wanted := make(map[queue.Status]bool)
+	for _, status := range statuses {
+		wanted[status] = true
+	}
+
+	var jobs []*queue.Job
+	for rows.Next() {
+		var j queue.Job
+		var agentID, output, jobErr sql.NullString
+		var request string
+		var startedAt, finishedAt sql.NullTime
+		err := rows.Scan(&j.ID, &agentID, &j.Provider, &j.Model, &j.Priority, &j.Tokens, &request, &j.Status, &output, &jobErr,
+			&j.Usage.InputTokens, &j.Usage.OutputTokens, &j.CreatedAt, &startedAt, &finishedAt)
+		if err != nil {
+			return nil, err
+		}
+		if len(wanted) > 0 && !wanted[j.Status] {
+			continue
+		}
+
+		if err := json.Unmarshal([]byte(request), &j.Request); err != nil {
+			return nil, fmt.Errorf("failed to decode request of job %s: %w", j.ID, err)
+		}
+		j.AgentID = agentID.String
+		j.Output = output.String
+		j.Error = jobErr.String
+		j.StartedAt = startedAt.Time
+		j.FinishedAt = finishedAt.Time
+		jobs = append(jobs, &j)
+	}
+
+	return jobs, rows.Err()
+}
+
+// Comparison operations
+
+// SaveComparison saves or updates a comparison with its candidates and verdict
+func (s *SQLiteStore) SaveComparison(c *judge.Comparison) error {
+	var verdict sql.NullString
+	if c.Verdict != nil {
+		data, err := json.Marshal(c.Verdict)
+		if err != nil {
+			return fmt.Errorf("failed to encode verdict: %w", err)
+		}
+		verdict = sql.NullString{String: string(data), Valid: true}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO comparisons (id, prompt, context_id, verdict, created_at)
+	VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT(id) DO UPDATE SET
+		verdict = excluded.verdict
+	`
+	if _, err := tx.Exec(query, c.ID, c.Prompt, c.ContextID, verdict, c.CreatedAt); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM comparison_candidates WHERE comparison_id = ?", c.ID); err != nil {
+		return err
+	}
+	for i, cand := range c.Candidates {
+		_, err := tx.Exec(`
+		INSERT INTO comparison_candidates (comparison_id, position, agent_id, agent, model, answer, error, input_tokens, output_tokens, cost)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, c.ID, i, cand.AgentID, cand.Agent, cand.Model, cand.Answer, cand.Error, cand.Usage.InputTokens, cand.Usage.OutputTokens, cand.Cost)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListComparisons returns every comparison with its candidates, oldest first
+func (s *SQLiteStore) ListComparisons() ([]*judge.Comparison, error) {
+	rows, err := s.db.Query(`SELECT id, prompt, context_id, verdict, created_at FROM comparisons ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comparisons []*judge.Comparison
+	index := make(map[string]*judge.Comparison)
+	for rows.Next() {
+		var c judge.Comparison
+		var contextID, verdict sql.NullString
+		if err := rows.Scan(&c.ID, &c.Prompt, &contextID, &verdict, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		c.ContextID = contextID.String
+		if verdict.Valid {
+			if err := json.Unmarshal([]byte(verdict.String), &c.Verdict); err != nil {
+				return nil, fmt.Errorf("failed to decode verdict of comparison %s: %w", c.ID, err)
+			}
+		}
+		comparisons = append(comparisons, &c)
+		index[c.ID] = &c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.db.Query(`
+	SELECT comparison_id, agent_id, agent, model, answer, error, input_tokens, output_tokens, cost
+	FROM comparison_candidates
+	ORDER BY comparison_id, position
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer candidates.Close()
+
+	for candidates.Next() {
+		var comparisonID string
+		var cand judge.Candidate
+		var agentID, agentName, model, answer, candErr sql.NullString
+		err := candidates.Scan(&comparisonID, &agentID, &agentName, &model, &answer, &candErr,
+			&cand.Usage.InputTokens, &cand.Usage.OutputTokens, &cand.Cost)
+		if err != nil {
+			return nil, err
+		}
+		cand.AgentID = agentID.String
+		cand.Agent = agentName.String
+		cand.Model = model.String
+		cand.Answer = answer.String
+		cand.Error = candErr.String
+		if c := index[comparisonID]; c != nil {
+			c.Candidates = append(c.Candidates, cand)
+		}
+	}
+
+	return comparisons, candidates.Err()
+}
+
+// Pipeline operations
+
+// SavePipelineRun saves or updates a pipeline run with the prompt and output
+// of each step
+func (s *SQLiteStore) SavePipelineRun(r *pipeline.Run) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO pipeline_runs (id, pipeline, input, context_id, status, created_at, finished_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(id) DO UPDATE SET
+		status = excluded.status,
+		finished_at = excluded.finished_at
+	`
+	if _, err := tx.Exec(query, r.ID, r.Pipeline, r.Input, r.ContextID, r.Status, r.CreatedAt, nullTime(r.FinishedAt)); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM pipeline_steps WHERE run_id = ?", r.ID); err != nil {
+		return err
+	}
+	for i, step := range r.Steps {
+		_, err := tx.Exec(`
+		INSERT INTO pipeline_steps (run_id, position, name, agent_id, agent, model, status, prompt, output, error,
+			input_tokens, output_tokens, cost, started_at, finished_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, r.ID, i, step.Name, step.AgentID, step.Agent, step.Model, step.Status, step.Prompt, step.Output, step.Error,
+			step.Usage.InputTokens, step.Usage.OutputTokens, step.Cost, nullTime(step.StartedAt), nullTime(step.FinishedAt))
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListPipelineRuns returns every pipeline run with its steps, oldest first
+func (s *SQLiteStore) ListPipelineRuns() ([]*pipeline.Run, error) {
+	rows, err := s.db.Query(`
+	SELECT id, pipeline, input, context_id, status, created_at, finished_at
+	FROM pipeline_runs
+	ORDER BY created_at, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*pipeline.Run
+	index := make(map[string]*pipeline.Run)
+	for rows.Next() {
+		var r pipeline.Run
+		var contextID sql.NullString
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&r.ID, &r.Pipeline, &r.Input, &contextID, &r.Status, &r.CreatedAt, &finishedAt); err != nil {
+			return nil, err
+		}
+		r.ContextID = contextID.String
+		r.FinishedAt = finishedAt.Time
+		runs = append(runs, &r)
+		index[r.ID] = &r
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	steps, err := s.db.Query(`
+	SELECT run_id, name, agent_id, agent, model, status, prompt, output, error,
+		input_tokens, output_tokens, cost, started_at, finished_at
+	FROM pipeline_steps
+	ORDER BY run_id, position
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer steps.Close()
+
+	for steps.Next() {
+		var runID string
+		var step pipeline.StepRun
+		var agentID, agentName, model, prompt, output, stepErr sql.NullString
+		var startedAt, finishedAt sql.NullTime
+		err := steps.Scan(&runID, &step.Name, &agentID, &agentName, &model, &step.Status, &prompt, &output, &stepErr,
+			&step.Usage.InputTokens, &step.Usage.OutputTokens, &step.Cost, &startedAt, &finishedAt)
+		if err != nil {
+			return nil, err
+		}
+		step.AgentID = agentID.String
+		step.Agent = agentName.String
+		step.Model = model.String
+		step.Prompt = prompt.String
+		step.Output = output.String
+		step.Error = stepErr.String
+		step.StartedAt = startedAt.Time
+		step.FinishedAt = finishedAt.Time
+		if r := index[runID]; r != nil {
+			r.Steps = append(r.Steps, &step)
+		}
+	}
+
+	return runs, steps.Err()
+}
+
+// SaveCommand records a shell command an agent asked to run, with whether
+// it was approved and its output
+func (s *SQLiteStore) SaveCommand(c *tools.Command) error {
+	query := `
+	INSERT OR REPLACE INTO commands (id, agent_id, agent, command, dir, approved, exit_code, output, error, started_at, finished_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := s.db.Exec(query, c.ID, c.AgentID, c.Agent, c.Command, c.Dir, c.Approved, c.ExitCode, c.Output, c.Error,
+		c.StartedAt, nullTime(c.FinishedAt))
 	return err
 }
+
+// ListCommands returns the shell commands agents asked to run since a time,
+// oldest first
+func (s *SQLiteStore) ListCommands(since time.Time) ([]*tools.Command, error) {
+	rows, err := s.db.Query(`
+	SELECT id, agent_id, agent, command, dir, approved, exit_code, output, error, started_at, finished_at
+	FROM commands
+	WHERE started_at >= ?
+	ORDER BY started_at, id
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var commands []*tools.Command
+	for rows.Next() {
+		var c tools.Command
+		var agentID, agentName, output, cmdErr sql.NullString
+		var finishedAt sql.NullTime
+		err := rows.Scan(&c.ID, &agentID, &agentName, &c.Command, &c.Dir, &c.Approved, &c.ExitCode, &output, &cmdErr,
+			&c.StartedAt, &finishedAt)
+		if err != nil {
+			return nil, err
+		}
+		c.AgentID = agentID.String
+		c.Agent = agentName.String
+		c.Output = output.String
+		c.Error = cmdErr.String
+		c.FinishedAt = finishedAt.Time
+		commands = append(commands, &c)
+	}
+
+	return commands, rows.Err()
+}
+
+// nullTime stores the zero time as NULL
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -1,11 +1,19 @@
 package storage
 
 import (
+	"errors"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/yourusername/aui/internal/agent"
 	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/judge"
+	"github.com/yourusername/aui/internal/ledger"
+	"github.com/yourusername/aui/internal/pipeline"
+	"github.com/yourusername/aui/internal/queue"
+	"github.com/yourusername/aui/internal/tools"
+	"github.com/yourusername/aui/pkg/api"
 )
 
 func TestNewSQLiteStore(t *testing.T) {
@@ -272,4 +280,538 @@ func TestSQLiteStoreMigration(t *testing.T) {
 	if err != nil {
 		t.Errorf("Schema not preserved after reopen: %v", err)
 	}
+
+	// Each migration is recorded once, however often the store is opened
+	var version int
+	err = store2.db.QueryRow("SELECT COUNT(*), MAX(version) FROM schema_migrations").Scan(&count, &version)
+	if err != nil {
+		t.Fatalf("Failed to read schema_migrations: %v", err)
+	}
+	if count != len(migrations) || version != len(migrations) {
+		t.Errorf("Expected %d migrations recorded, got %d (max version %d)", len(migrations), count, version)
+	}
+}
+
+func TestSQLiteStoreContextSystemPrompt(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.NewContext("bug-fix", "Crash on login")
+	ctx.SystemPrompt = "Explain the root cause first."
+	if err := store.SaveContext(ctx); err != nil {
+		t.Fatalf("Failed to save context: %v", err)
+	}
+
+	retrieved, err := store.GetContext(ctx.ID)
+	if err != nil {
+		t.Fatalf("Failed to get context: %v", err)
+	}
+	if retrieved.SystemPrompt != ctx.SystemPrompt {
+		t.Errorf("Expected system prompt %q, got %q", ctx.SystemPrompt, retrieved.SystemPrompt)
+	}
+
+	contexts, err := store.ListContexts()
+	if err != nil {
+		t.Fatalf("Failed to list contexts: %v", err)
+	}
+	if len(contexts) != 1 || contexts[0].SystemPrompt != ctx.SystemPrompt {
+		t.Error("System prompt not returned by ListContexts")
+	}
+}
+
+func TestSQLiteStoreAgentParams(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	a := agent.NewAgent("reviewer", "claude-sonnet-4", "anthropic")
+	a.SystemPrompt = agent.Personas["reviewer"]
+	a.Params, err = agent.ParseParams("temperature=0.2 max_tokens=2048 stop=END reasoning=high ext.top_k=40")
+	if err != nil {
+		t.Fatalf("Failed to parse params: %v", err)
+	}
+	if err := store.SaveAgent(a); err != nil {
+		t.Fatalf("Failed to save agent: %v", err)
+	}
+
+	retrieved, err := store.GetAgent(a.ID)
+	if err != nil {
+		t.Fatalf("Failed to get agent: %v", err)
+	}
+	if retrieved.SystemPrompt != a.SystemPrompt {
+		t.Errorf("Expected system prompt %q, got %q", a.SystemPrompt, retrieved.SystemPrompt)
+	}
+	if got, want := retrieved.Params.String(), a.Params.String(); got != want {
+		t.Errorf("Expected params %q, got %q", want, got)
+	}
+
+	agents, err := store.ListAgents()
+	if err != nil {
+		t.Fatalf("Failed to list agents: %v", err)
+	}
+	if len(agents) != 1 || agents[0].Params.MaxTokens != 2048 {
+		t.Error("Params not returned by ListAgents")
+	}
+}
+
+func TestSQLiteStoreSharedFilePath(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	// Two contexts holding separately created files with the same path
+	ctx1 := context.NewContext("first", "")
+	ctx1.AddFile(context.NewFile("shared.go", "shared.go"))
+	ctx2 := context.NewContext("second", "")
+	ctx2.AddFile(context.NewFile("shared.go", "shared.go"))
+
+	if err := store.SaveContext(ctx1); err != nil {
+		t.Fatalf("Failed to save first context: %v", err)
+	}
+	if err := store.SaveContext(ctx2); err != nil {
+		t.Fatalf("Failed to save second context: %v", err)
+	}
+
+	for _, ctx := range []*context.Context{ctx1, ctx2} {
+		retrieved, err := store.GetContext(ctx.ID)
+		if err != nil {
+			t.Fatalf("Failed to get context: %v", err)
+		}
+		if len(retrieved.Files) != 1 {
+			t.Errorf("Context %s: expected 1 file, got %d", ctx.Name, len(retrieved.Files))
+		}
+	}
+
+	hash, ids, err := store.FileContexts("shared.go")
+	if err != nil {
+		t.Fatalf("Failed to get file contexts: %v", err)
+	}
+	if hash != ctx2.Files[0].Hash || len(ids) != 2 {
+		t.Errorf("FileContexts() = %q, %v, want the shared file used by both contexts", hash, ids)
+	}
+	if hash, ids, err := store.FileContexts("missing.go"); err != nil || hash != "" || ids != nil {
+		t.Errorf("FileContexts() of a missing path = %q, %v, %v", hash, ids, err)
+	}
+}
+
+func TestSQLiteStoreChunks(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	file := context.NewFile("app.log", "app.log")
+	file.SetContent("a\nb\nc\nd\n")
+	chunks := context.ChunkByLines(file, 2)
+
+	if err := store.SaveChunks("app.log", chunks); err != nil {
+		t.Fatalf("Failed to save chunks: %v", err)
+	}
+
+	retrieved, err := store.ListChunks("app.log")
+	if err != nil {
+		t.Fatalf("Failed to list chunks: %v", err)
+	}
+	if len(retrieved) != 2 {
+		t.Fatalf("Expected 2 chunks, got %d", len(retrieved))
+	}
+	if retrieved[1].StartLine != 3 || retrieved[1].EndLine != 4 || retrieved[1].Hash != chunks[1].Hash {
+		t.Errorf("Chunk metadata not preserved: %+v", retrieved[1])
+	}
+	if retrieved[0].Content != "" {
+		t.Error("Chunk content should not be stored")
+	}
+
+	// Adding a single range keeps existing chunks
+	extra, _ := file.Lines(2, 3)
+	if err := store.AddChunk(extra); err != nil {
+		t.Fatalf("Failed to add chunk: %v", err)
+	}
+	retrieved, _ = store.ListChunks("app.log")
+	if len(retrieved) != 3 {
+		t.Errorf("Expected 3 chunks after AddChunk, got %d", len(retrieved))
+	}
+
+	// Saving replaces all chunks for the path
+	if err := store.SaveChunks("app.log", context.ChunkByLines(file, 4)); err != nil {
+		t.Fatalf("Failed to resave chunks: %v", err)
+	}
+	retrieved, _ = store.ListChunks("app.log")
+	if len(retrieved) != 1 {
+		t.Errorf("Expected 1 chunk after resave, got %d", len(retrieved))
+	}
+}
+
+func TestSQLiteStoreContextIncludes(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	overview := context.NewContext("project-overview", "")
+	readme := context.NewFile("README.md", "README.md")
+	readme.SetContent("# Project\n")
+	overview.AddFile(readme)
+	if err := store.SaveContext(overview); err != nil {
+		t.Fatalf("Failed to save context: %v", err)
+	}
+
+	feature := context.NewContext("feature", "")
+	main := context.NewFile("main.go", "main.go")
+	main.SetContent("package main\n")
+	feature.AddFile(main)
+	feature.Include(overview.ID)
+	if err := store.SaveContext(feature); err != nil {
+		t.Fatalf("Failed to save context: %v", err)
+	}
+
+	retrieved, err := store.GetContext(feature.ID)
+	if err != nil {
+		t.Fatalf("Failed to get context: %v", err)
+	}
+	if len(retrieved.Includes) != 1 || retrieved.Includes[0] != overview.ID {
+		t.Errorf("Expected includes [%s], got %v", overview.ID, retrieved.Includes)
+	}
+
+	flat, err := store.GetFlattenedContext(feature.ID)
+	if err != nil {
+		t.Fatalf("Failed to flatten context: %v", err)
+	}
+	if len(flat.Files) != 2 || flat.TotalTokens != readme.Tokens+main.Tokens {
+		t.Errorf("Expected 2 files and %d tokens, got %d and %d", readme.Tokens+main.Tokens, len(flat.Files), flat.TotalTokens)
+	}
+
+	// Including feature in overview would create a cycle
+	overview.Include(feature.ID)
+	if err := store.SaveContext(overview); err == nil {
+		t.Error("Expected cycle error when saving overview")
+	}
+	retrieved, _ = store.GetContext(overview.ID)
+	if len(retrieved.Includes) != 0 {
+		t.Errorf("Cyclic include should not be stored, got %v", retrieved.Includes)
+	}
+
+	// Deleting an included context removes the reference
+	if err := store.DeleteContext(overview.ID); err != nil {
+		t.Fatalf("Failed to delete context: %v", err)
+	}
+	retrieved, _ = store.GetContext(feature.ID)
+	if len(retrieved.Includes) != 0 {
+		t.Errorf("Expected no includes after delete, got %v", retrieved.Includes)
+	}
+}
+
+func TestSQLiteStoreSummaries(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	missing, err := store.GetSummary("nope")
+	if err != nil || missing != nil {
+		t.Errorf("Expected no summary, got %v (%v)", missing, err)
+	}
+
+	summary := context.NewSummary("abc123", "Parses config files.", "claude-3-5-haiku")
+	if err := store.SaveSummary(summary); err != nil {
+		t.Fatalf("Failed to save summary: %v", err)
+	}
+
+	retrieved, err := store.GetSummary("abc123")
+	if err != nil {
+		t.Fatalf("Failed to get summary: %v", err)
+	}
+	if retrieved == nil || retrieved.Content != summary.Content || retrieved.Tokens != summary.Tokens || retrieved.Model != summary.Model {
+		t.Errorf("Summary not preserved: %+v", retrieved)
+	}
+
+	summaries, err := store.GetSummaries([]string{"abc123", "nope", "abc123"})
+	if err != nil {
+		t.Fatalf("Failed to get summaries: %v", err)
+	}
+	if len(summaries) != 1 || summaries["abc123"] == nil {
+		t.Errorf("Expected one summary, got %v", summaries)
+	}
+}
+
+func TestSQLiteStoreUsageLedger(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	old := &ledger.Entry{Provider: "openai", Model: "gpt-4o", InputTokens: 10, CreatedAt: time.Now().Add(-48 * time.Hour)}
+	recent := &ledger.Entry{AgentID: "a1", Provider: "anthropic", Model: "claude-sonnet-4-5", InputTokens: 1000, OutputTokens: 200, Cost: 0.006, CreatedAt: time.Now()}
+	for _, e := range []*ledger.Entry{old, recent} {
+		if err := store.RecordUsage(e); err != nil {
+			t.Fatalf("Failed to record usage: %v", err)
+		}
+	}
+	if recent.ID == 0 || recent.ID == old.ID {
+		t.Errorf("Expected distinct IDs, got %d and %d", old.ID, recent.ID)
+	}
+
+	all, err := store.ListUsage(time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to list usage: %v", err)
+	}
+	if len(all) != 2 || all[0].Model != "gpt-4o" {
+		t.Errorf("Expected both entries oldest first, got %d", len(all))
+	}
+
+	entries, err := store.ListUsage(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to list usage: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 recent entry, got %d", len(entries))
+	}
+	if e := entries[0]; e.AgentID != "a1" || e.OutputTokens != 200 || e.Cost != 0.006 {
+		t.Errorf("Entry not preserved: %+v", e)
+	}
+
+	tier := &ledger.Entry{Provider: "google", Model: "gemini-2.5-flash", Chain: "coding", Tier: 1, Escalated: "refusal", CreatedAt: time.Now()}
+	if err := store.RecordUsage(tier); err != nil {
+		t.Fatalf("Failed to record usage: %v", err)
+	}
+	entries, _ = store.ListUsage(time.Now().Add(-time.Hour))
+	if e := entries[len(entries)-1]; e.Chain != "coding" || e.Tier != 1 || e.Escalated != "refusal" {
+		t.Errorf("Chain tier not preserved: %+v", e)
+	}
+}
+
+func TestSQLiteStoreJobs(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	temperature := 0.5
+	queued := queue.NewJob("a1", "anthropic", &api.Request{
+		Model:       "claude-sonnet-4-5",
+		Messages:    []api.Message{{Role: api.RoleUser, Content: "hi"}},
+		Temperature: &temperature,
+	}, 10)
+	queued.Priority = 2
+	done := queue.NewJob("a2", "openai", &api.Request{Model: "gpt-4o"}, 0)
+	done.Status = queue.StatusDone
+	done.Output = "hello"
+	done.Usage = api.TokenUsage{InputTokens: 5, OutputTokens: 7}
+	done.StartedAt = time.Now()
+	done.FinishedAt = time.Now()
+
+	for _, j := range []*queue.Job{queued, done} {
+		if err := store.SaveJob(j); err != nil {
+			t.Fatalf("Failed to save job: %v", err)
+		}
+	}
+
+	jobs, err := store.ListJobs(queue.StatusQueued, queue.StatusRunning)
+	if err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("Expected 1 waiting job, got %d", len(jobs))
+	}
+	j := jobs[0]
+	if j.ID != queued.ID || j.Priority != 2 || j.Tokens != 10+api.DefaultMaxTokens || !j.StartedAt.IsZero() {
+		t.Errorf("Job not preserved: %+v", j)
+	}
+	if j.Request.Messages[0].Content != "hi" || *j.Request.Temperature != 0.5 {
+		t.Errorf("Request not preserved: %+v", j.Request)
+	}
+
+	all, err := store.ListJobs()
+	if err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(all) != 2 || all[1].Output != "hello" || all[1].Usage.OutputTokens != 7 || all[1].FinishedAt.IsZero() {
+		t.Errorf("Expected both jobs with results, got %+v", all)
+	}
+
+	// Cancelling leaves finished jobs alone
+	for _, id := range []string{queued.ID, done.ID} {
+		if err := store.CancelJob(id, time.Now()); err != nil {
+			t.Fatalf("Failed to cancel job: %v", err)
+		}
+	}
+	all, _ = store.ListJobs()
+	if all[0].Status != queue.StatusCancelled || all[0].FinishedAt.IsZero() || all[1].Status != queue.StatusDone {
+		t.Errorf("Expected only the waiting job cancelled, got %s and %s", all[0].Status, all[1].Status)
+	}
+}
+
+func TestSQLiteStoreComparisons(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	c := judge.NewComparison("fix the race", "ctx1")
+	c.Candidates = []judge.Candidate{
+		{AgentID: "a1", Agent: "Claude", Model: "claude-sonnet-4-5", Answer: "use a mutex", Usage: api.TokenUsage{InputTokens: 10, OutputTokens: 5}, Cost: 0.01},
+		{AgentID: "a2", Agent: "GPT", Model: "gpt-4o", Error: "rate limited"},
+	}
+	if err := store.SaveComparison(c); err != nil {
+		t.Fatalf("Failed to save comparison: %v", err)
+	}
+
+	// Judging saves the comparison again with its verdict
+	c.Verdict = &judge.Verdict{Judge: "Gemini", Rubric: "default", Pick: 0, Scores: []judge.Score{
+		{Candidate: 0, Criteria: map[string]float64{"correctness": 8}, Total: 8},
+	}}
+	if err := store.SaveComparison(c); err != nil {
+		t.Fatalf("Failed to save verdict: %v", err)
+	}
+
+	comparisons, err := store.ListComparisons()
+	if err != nil {
+		t.Fatalf("Failed to list comparisons: %v", err)
+	}
+	if len(comparisons) != 1 {
+		t.Fatalf("Expected 1 comparison, got %d", len(comparisons))
+	}
+	got := comparisons[0]
+	if got.ID != c.ID || got.Prompt != "fix the race" || got.ContextID != "ctx1" {
+		t.Errorf("Comparison not preserved: %+v", got)
+	}
+	if len(got.Candidates) != 2 || got.Candidates[0].Answer != "use a mutex" || got.Candidates[0].Usage.OutputTokens != 5 ||
+		got.Candidates[1].Error != "rate limited" {
+		t.Errorf("Candidates not preserved: %+v", got.Candidates)
+	}
+	if got.Verdict == nil || got.Verdict.Judge != "Gemini" || got.Verdict.ScoreFor(0).Criteria["correctness"] != 8 {
+		t.Errorf("Verdict not preserved: %+v", got.Verdict)
+	}
+}
+
+func TestSQLiteStorePipelineRuns(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	p := &pipeline.Pipeline{Name: "plan-review", Steps: []pipeline.Step{
+		{Name: "plan", Prompt: "Plan {{.Input}}"},
+		{Name: "review", Prompt: "Review {{.Previous}}"},
+	}}
+	r := pipeline.NewRun(p, "add login", "ctx1")
+	r.Start(0, "a1", "Claude", "claude-sonnet-4-5")
+	if err := store.SavePipelineRun(r); err != nil {
+		t.Fatalf("Failed to save run: %v", err)
+	}
+
+	r.Finish(0, "1. form", api.TokenUsage{InputTokens: 10, OutputTokens: 5}, 0.01, nil)
+	r.Start(1, "a2", "GPT", "gpt-4o")
+	r.Finish(1, "", api.TokenUsage{}, 0, errors.New("overloaded"))
+	if err := store.SavePipelineRun(r); err != nil {
+		t.Fatalf("Failed to update run: %v", err)
+	}
+
+	runs, err := store.ListPipelineRuns()
+	if err != nil {
+		t.Fatalf("Failed to list runs: %v", err)
+	}
+	if len(runs) != 1 {
+		t.Fatalf("Expected 1 run, got %d", len(runs))
+	}
+	got := runs[0]
+	if got.ID != r.ID || got.Pipeline != "plan-review" || got.Input != "add login" || got.ContextID != "ctx1" ||
+		got.Status != pipeline.StatusFailed || got.FinishedAt.IsZero() {
+		t.Errorf("Run not preserved: %+v", got)
+	}
+	if len(got.Steps) != 2 {
+		t.Fatalf("Expected 2 steps, got %d", len(got.Steps))
+	}
+	plan, review := got.Steps[0], got.Steps[1]
+	if plan.Prompt != "Plan add login" || plan.Output != "1. form" || plan.Agent != "Claude" || plan.Usage.OutputTokens != 5 ||
+		plan.Status != pipeline.StatusDone || plan.StartedAt.IsZero() {
+		t.Errorf("Step not preserved: %+v", plan)
+	}
+	if review.Status != pipeline.StatusFailed || review.Error != "overloaded" || review.Model != "gpt-4o" {
+		t.Errorf("Failed step not preserved: %+v", review)
+	}
+}
+
+func TestSQLiteStoreCommands(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	store, err := NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	start := time.Now().Add(-time.Minute)
+	declined := &tools.Command{ID: "c1", AgentID: "a1", Agent: "Claude", Command: "rm -rf build", Dir: ".", ExitCode: -1,
+		Error: "declined by the user", StartedAt: start}
+	ran := &tools.Command{ID: "c2", AgentID: "a1", Agent: "Claude", Command: "go test ./...", Dir: "internal", Approved: true,
+		ExitCode: 1, Output: "FAIL", StartedAt: start.Add(time.Second), FinishedAt: start.Add(5 * time.Second)}
+	for _, c := range []*tools.Command{declined, ran} {
+		if err := store.SaveCommand(c); err != nil {
+			t.Fatalf("Failed to save command: %v", err)
+		}
+	}
+
+	commands, err := store.ListCommands(time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to list commands: %v", err)
+	}
+	if len(commands) != 2 {
+		t.Fatalf("Expected 2 commands, got %d", len(commands))
+	}
+	if got := commands[0]; got.Command != "rm -rf build" || got.Approved || got.Error != "declined by the user" || !got.FinishedAt.IsZero() {
+		t.Errorf("Declined command not preserved: %+v", got)
+	}
+	if got := commands[1]; !got.Approved || got.ExitCode != 1 || got.Output != "FAIL" || got.Dir != "internal" || got.FinishedAt.IsZero() {
+		t.Errorf("Command not preserved: %+v", got)
+	}
+
+	if recent, _ := store.ListCommands(start.Add(time.Second)); len(recent) != 1 || recent[0].ID != "c2" {
+		t.Errorf("ListCommands(since) = %+v, want the later command", recent)
+	}
 }
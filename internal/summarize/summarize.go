@@ -0,0 +1,161 @@
+package summarize
+
+import (
+	gocontext "context"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/ledger"
+	"github.com/yourusername/aui/internal/models"
+	"github.com/yourusername/aui/internal/providers"
+	"github.com/yourusername/aui/internal/redact"
+	"github.com/yourusername/aui/internal/storage"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// systemPrompt instructs the summarizing model
+const systemPrompt = `You summarize source files so another engineer or model can work with the
+codebase without reading them in full. Describe the file's purpose, then list
+its exported types, functions and constants with one line each, noting
+important behavior, side effects and dependencies. Do not include code
+beyond signatures. Be concise.`
+
+// Summarizer writes summaries of large files with a cheap agent and caches
+// them in storage by content hash
+type Summarizer struct {
+	Provider  api.Provider
+	Model     string
+	Agent     *agent.Agent    // Supplies persona and generation parameters, if set
+	Catalog   *models.Catalog // Prices usage recorded in the ledger
+	Store     *storage.SQLiteStore
+	Redactor  *redact.Redactor // Scrubs content before it is sent, if set
+	MinTokens int              // Files smaller than this are not summarized
+	MaxTokens int              // Length limit for each summary
+}
+
+// New creates a summarizer using the agent named in the summarize settings
+func New(cfg *config.Config, store *storage.SQLiteStore, redactor *redact.Redactor) (*Summarizer, error) {
+	if cfg.Summarize.Agent == "" {
+		return nil, fmt.Errorf("no summarize agent configured (set summarize.agent)")
+	}
+
+	agents, err := store.ListAgents()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range agents {
+		if a.Name != cfg.Summarize.Agent {
+			continue
+		}
+
+		provider, err := providers.New(a.Provider, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		catalog, err := models.Load(models.OverridePath())
+		if err != nil {
+			return nil, err
+		}
+
+		return &Summarizer{
+			Provider:  provider,
+			Model:     a.Model,
+			Agent:     a,
+			Catalog:   catalog,
+			Store:     store,
+			Redactor:  redactor,
+			MinTokens: cfg.Summarize.MinTokens,
+			MaxTokens: cfg.Summarize.MaxTokens,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("summarize agent not found: %s", cfg.Summarize.Agent)
+}
+
+// Summarize returns the summary of a file, from storage if the file has not
+// changed since it was last summarized. Cached reports whether no request
+// was made.
+func (s *Summarizer) Summarize(ctx gocontext.Context, f *context.File) (summary *context.Summary, cached bool, err error) {
+	if s.Store != nil {
+		summary, err := s.Store.GetSummary(f.Hash)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to load summary: %w", err)
+		}
+		if summary != nil {
+			return summary, true, nil
+		}
+	}
+
+	content, _ := s.Redactor.Redact(f.Path, f.Content)
+
+	req := &api.Request{
+		Model:  s.Model,
+		System: systemPrompt,
+		Messages: []api.Message{{
+			Role:    api.RoleUser,
+			Content: fmt.Sprintf("File: %s\n\n%s", f.Path, content),
+		}},
+	}
+	if s.Agent != nil {
+		s.Agent.ApplyTo(req)
+	}
+	// The summary length limit takes precedence over the agent's own
+	if s.MaxTokens > 0 {
+		req.MaxTokens = s.MaxTokens
+	}
+
+	responses, err := s.Provider.SendMessage(ctx, req)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to summarize %s: %w", f.Path, err)
+	}
+
+	text, usage, err := api.Collect(responses)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to summarize %s: %w", f.Path, err)
+	}
+
+	if s.Store != nil && s.Agent != nil {
+		if err := s.Store.RecordUsage(ledger.NewEntry(s.Agent, usage, s.Catalog)); err != nil {
+			return nil, false, fmt.Errorf("failed to record usage: %w", err)
+		}
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, false, fmt.Errorf("empty summary for %s", f.Path)
+	}
+
+	summary = context.NewSummary(f.Hash, text, s.Model)
+	if s.Store != nil {
+		if err := s.Store.SaveSummary(summary); err != nil {
+			return nil, false, fmt.Errorf("failed to save summary: %w", err)
+		}
+	}
+
+	return summary, false, nil
+}
+
+// SummarizeContext summarizes every file in c of at least MinTokens tokens
+// and returns the summaries keyed by content hash, ready for context.Pack
+func (s *Summarizer) SummarizeContext(ctx gocontext.Context, c *context.Context) (map[string]*context.Summary, error) {
+	summaries := make(map[string]*context.Summary)
+
+	for _, f := range c.Files {
+		if f.Tokens < s.MinTokens || f.Summarized {
+			continue
+		}
+
+		summary, _, err := s.Summarize(ctx, f)
+		if err != nil {
+			return summaries, err
+		}
+		summaries[f.Hash] = summary
+	}
+
+	return summaries, nil
+}
@@ -0,0 +1,212 @@
+package summarize
+
+import (
+	gocontext "context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/models"
+	"github.com/yourusername/aui/internal/redact"
+	"github.com/yourusername/aui/internal/storage"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// fakeProvider answers every request with a fixed reply and records requests
+type fakeProvider struct {
+	reply    string
+	err      error
+	requests []*api.Request
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) ValidateConfig(map[string]string) error { return nil }
+
+func (p *fakeProvider) SendMessage(_ gocontext.Context, req *api.Request) (<-chan api.Response, error) {
+	p.requests = append(p.requests, req)
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	ch := make(chan api.Response, 1)
+	ch <- api.Response{Content: p.reply, Done: true, Usage: api.TokenUsage{InputTokens: 3000, OutputTokens: 100}}
+	close(ch)
+	return ch, nil
+}
+
+// newTestStore opens a store in a temporary directory
+func newTestStore(t *testing.T) *storage.SQLiteStore {
+	t.Helper()
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// largeFile returns a file of roughly the given number of tokens
+func largeFile(path string, tokens int) *context.File {
+	f := context.NewFile(path, filepath.Base(path))
+	f.SetContent(strings.Repeat("abcd", tokens))
+	return f
+}
+
+func TestNew(t *testing.T) {
+	store := newTestStore(t)
+	store.SaveAgent(agent.NewAgent("cheap", "claude-3-5-haiku", "anthropic"))
+
+	cfg := config.NewDefault()
+	if _, err := New(cfg, store, nil); err == nil {
+		t.Error("New() without a configured agent should fail")
+	}
+
+	cfg.Summarize.Agent = "missing"
+	if _, err := New(cfg, store, nil); err == nil {
+		t.Error("New() with an unknown agent should fail")
+	}
+
+	cfg.Summarize.Agent = "cheap"
+	cfg.APIKeys["anthropic"] = "key"
+	s, err := New(cfg, store, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if s.Provider.Name() != "anthropic" || s.Model != "claude-3-5-haiku" || s.MinTokens != 2000 || s.MaxTokens != 500 {
+		t.Errorf("New() = %+v", s)
+	}
+}
+
+func TestSummarizeCachesByHash(t *testing.T) {
+	provider := &fakeProvider{reply: "  Parses config files.\n"}
+	s := &Summarizer{Provider: provider, Model: "cheap-model", Store: newTestStore(t), MaxTokens: 100}
+
+	f := largeFile("config.go", 3000)
+
+	summary, cached, err := s.Summarize(gocontext.Background(), f)
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if cached || summary.Content != "Parses config files." || summary.Hash != f.Hash || summary.Model != "cheap-model" {
+		t.Errorf("Summarize() = %+v, cached %v", summary, cached)
+	}
+	if req := provider.requests[0]; req.Model != "cheap-model" || req.MaxTokens != 100 || !strings.Contains(req.Messages[0].Content, "File: config.go") {
+		t.Errorf("request = %+v", req)
+	}
+
+	// Unchanged content is served from storage
+	_, cached, err = s.Summarize(gocontext.Background(), f)
+	if err != nil || !cached || len(provider.requests) != 1 {
+		t.Errorf("second Summarize() cached = %v, err = %v, requests = %d", cached, err, len(provider.requests))
+	}
+
+	// Changed content is summarized again
+	f.SetContent(f.Content + "more")
+	_, cached, _ = s.Summarize(gocontext.Background(), f)
+	if cached || len(provider.requests) != 2 {
+		t.Errorf("changed file should be summarized again, cached = %v", cached)
+	}
+}
+
+func TestSummarizeUsesAgentParams(t *testing.T) {
+	a := agent.NewAgent("Haiku", "claude-3-5-haiku", "anthropic")
+	a.SystemPrompt = "Write for newcomers."
+	a.Params, _ = agent.ParseParams("temperature=0 max_tokens=4000")
+
+	provider := &fakeProvider{reply: "Summary."}
+	s := &Summarizer{Provider: provider, Model: a.Model, Agent: a, MaxTokens: 300}
+
+	if _, _, err := s.Summarize(gocontext.Background(), largeFile("main.go", 3000)); err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+
+	req := provider.requests[0]
+	if req.Temperature == nil || *req.Temperature != 0 {
+		t.Errorf("Temperature = %v, want 0", req.Temperature)
+	}
+	if req.MaxTokens != 300 {
+		t.Errorf("MaxTokens = %d, want the summary limit 300", req.MaxTokens)
+	}
+	if !strings.HasPrefix(req.System, "Write for newcomers.") || !strings.Contains(req.System, "You summarize source files") {
+		t.Errorf("System = %q, want agent prompt followed by summary instructions", req.System)
+	}
+}
+
+func TestSummarizeRecordsUsage(t *testing.T) {
+	store := newTestStore(t)
+	a := agent.NewAgent("Haiku", "claude-haiku-4-5", "anthropic")
+	s := &Summarizer{Provider: &fakeProvider{reply: "Summary."}, Model: a.Model, Agent: a, Catalog: models.Default(), Store: store}
+
+	if _, _, err := s.Summarize(gocontext.Background(), largeFile("main.go", 3000)); err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+
+	entries, err := store.ListUsage(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("len(entries) = %d, want 1", len(entries))
+	}
+	if e := entries[0]; e.AgentID != a.ID || e.InputTokens != 3000 || e.OutputTokens != 100 || e.Cost <= 0 {
+		t.Errorf("entry = %+v", e)
+	}
+}
+
+func TestSummarizeRedactsContent(t *testing.T) {
+	redactor, err := redact.New(nil, []string{"hunter2hunter2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &fakeProvider{reply: "Holds credentials."}
+	s := &Summarizer{Provider: provider, Redactor: redactor}
+
+	f := context.NewFile("creds.go", "creds.go")
+	f.SetContent(`const pass = "hunter2hunter2"`)
+
+	if _, _, err := s.Summarize(gocontext.Background(), f); err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if strings.Contains(provider.requests[0].Messages[0].Content, "hunter2hunter2") {
+		t.Error("Secret was sent to the provider")
+	}
+}
+
+func TestSummarizeErrors(t *testing.T) {
+	f := largeFile("a.go", 10)
+
+	s := &Summarizer{Provider: &fakeProvider{err: errors.New("unavailable")}}
+	if _, _, err := s.Summarize(gocontext.Background(), f); err == nil {
+		t.Error("Summarize() should return provider errors")
+	}
+
+	s = &Summarizer{Provider: &fakeProvider{reply: "  "}}
+	if _, _, err := s.Summarize(gocontext.Background(), f); err == nil {
+		t.Error("Summarize() should reject an empty summary")
+	}
+}
+
+func TestSummarizeContext(t *testing.T) {
+	provider := &fakeProvider{reply: "Summary."}
+	s := &Summarizer{Provider: provider, MinTokens: 1000}
+
+	ctx := context.NewContext("big", "")
+	big := largeFile("big.go", 5000)
+	ctx.AddFile(big)
+	ctx.AddFile(largeFile("small.go", 100))
+
+	summaries, err := s.SummarizeContext(gocontext.Background(), ctx)
+	if err != nil {
+		t.Fatalf("SummarizeContext() error = %v", err)
+	}
+	if len(summaries) != 1 || summaries[big.Hash] == nil || len(provider.requests) != 1 {
+		t.Errorf("SummarizeContext() = %v with %d requests, want only big.go", summaries, len(provider.requests))
+	}
+}
@@ -0,0 +1,224 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/aui/internal/context"
+	"gopkg.in/yaml.v3"
+)
+
+// Template describes how to build a context for a recurring task
+type Template struct {
+	Name         string   `yaml:"name"`
+	Description  string   `yaml:"description,omitempty"`
+	Include      []string `yaml:"include,omitempty"`       // Globs of project files to add
+	Exclude      []string `yaml:"exclude,omitempty"`       // Globs of project files to skip
+	Files        []string `yaml:"files,omitempty"`         // Paths or line ranges, e.g. an error log
+	GitDiff      string   `yaml:"git_diff,omitempty"`      // Revision to diff the working tree against, e.g. HEAD
+	SystemPrompt string   `yaml:"system_prompt,omitempty"` // Copied to the new context
+	MaxFileSize  int64    `yaml:"max_file_size,omitempty"`
+
+	Source string `yaml:"-"` // File the template was loaded from, or "builtin"
+}
+
+// Builtin are the templates available without any configuration. Templates
+// loaded from disk with the same name replace them.
+var Builtin = []*Template{
+	{
+		Name:         "bug-fix",
+		Description:  "Uncommitted changes for fixing a bug",
+		GitDiff:      "HEAD",
+		SystemPrompt: "You are helping fix a bug. The diff shows the work in progress. Explain the root cause before proposing a fix.",
+		Source:       "builtin",
+	},
+	{
+		Name:         "review",
+		Description:  "Uncommitted changes for code review",
+		GitDiff:      "HEAD",
+		SystemPrompt: "Review the diff for bugs, missing tests and unclear code. Be specific and cite file and line.",
+		Source:       "builtin",
+	},
+}
+
+// Dirs returns the template directories for a project, global first so that
+// project templates take precedence: ~/.config/aui/templates and
+// <root>/.aui/templates
+func Dirs(root string) []string {
+	var dirs []string
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".config", "aui", "templates"))
+	}
+	if root != "" {
+		dirs = append(dirs, filepath.Join(root, ".aui", "templates"))
+	}
+	return dirs
+}
+
+// LoadFile reads a single YAML template. The name defaults to the file name
+// without its extension.
+func LoadFile(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+
+	var t Template
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", path, err)
+	}
+
+	if t.Name == "" {
+		t.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	t.Source = path
+
+	return &t, nil
+}
+
+// LoadAll returns the built-in templates plus every *.yaml or *.yml file in
+// dirs, sorted by name. Later directories override earlier ones by name, and
+// directories that do not exist are skipped.
+func LoadAll(dirs ...string) ([]*Template, error) {
+	byName := make(map[string]*Template)
+	for _, t := range Builtin {
+		byName[t.Name] = t
+	}
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template directory: %w", err)
+		}
+
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+				continue
+			}
+
+			t, err := LoadFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			byName[t.Name] = t
+		}
+	}
+
+	templates := make([]*Template, 0, len(byName))
+	for _, t := range byName {
+		templates = append(templates, t)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+
+	return templates, nil
+}
+
+// Find returns the template with the given name, or nil
+func Find(templates []*Template, name string) *Template {
+	for _, t := range templates {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Names returns the names of the templates
+func Names(templates []*Template) []string {
+	names := make([]string, len(templates))
+	for i, t := range templates {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// Instantiate builds a new context named name from the template, reading
+// files below root. Listed files that do not exist, such as an error log that
+// has not been written yet, are skipped and returned as missing.
+func (t *Template) Instantiate(root, name string) (*context.Context, []string, error) {
+	if name == "" {
+		name = t.Name
+	}
+
+	ctx := context.NewContext(name, t.Description)
+	ctx.SystemPrompt = t.SystemPrompt
+
+	if len(t.Include) > 0 {
+		files, err := context.ScanDirectory(root, context.ScanOptions{
+			Include:     t.Include,
+			Exclude:     t.Exclude,
+			MaxFileSize: t.MaxFileSize,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, f := range files {
+			ctx.AddFile(f)
+		}
+	}
+
+	var missing []string
+	for _, ref := range t.Files {
+		f, _, err := context.LoadRange(root, ref)
+		if errors.Is(err, os.ErrNotExist) {
+			missing = append(missing, ref)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		ctx.AddFile(f)
+	}
+
+	if t.GitDiff != "" {
+		diff, err := GitDiff(root, t.GitDiff, ctx.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if diff != nil {
+			ctx.AddFile(diff)
+		}
+	}
+
+	return ctx, missing, nil
+}
+
+// GitDiff returns the diff of the working tree in root against rev as a
+// synthetic file for the context with the given ID, or nil if there are no
+// changes. Its path contains '#' like other generated files, so it is never
+// mistaken for a file on disk, and the context ID, since stored files are
+// shared by path and each context keeps the diff it was created with.
+func GitDiff(root, rev, contextID string) (*context.File, error) {
+	cmd := exec.Command("git", "diff", rev, "--")
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("failed to run git diff %s: %s", rev, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("failed to run git diff %s: %w", rev, err)
+	}
+
+	if len(out) == 0 {
+		return nil, nil
+	}
+
+	file := context.NewFile("git#diff "+rev+" "+contextID, "diff")
+	file.SetContent(string(out))
+	file.Language = "diff"
+	file.ModifiedAt = time.Now()
+	return file, nil
+}
@@ -0,0 +1,247 @@
+package templates
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/storage"
+)
+
+// writeFiles creates files below root from a map of relative path to content
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"perf.yaml": `description: Slow request investigation
+include: ["**/*.go"]
+exclude: ["*_test.go"]
+files: ["logs/error.log"]
+git_diff: main
+system_prompt: Find the bottleneck.
+`,
+	})
+
+	tmpl, err := LoadFile(filepath.Join(dir, "perf.yaml"))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	if tmpl.Name != "perf" {
+		t.Errorf("Name = %q, want %q (from file name)", tmpl.Name, "perf")
+	}
+	if tmpl.GitDiff != "main" || tmpl.SystemPrompt != "Find the bottleneck." {
+		t.Errorf("LoadFile() = %+v", tmpl)
+	}
+	if len(tmpl.Include) != 1 || len(tmpl.Exclude) != 1 || len(tmpl.Files) != 1 {
+		t.Errorf("globs and files not loaded: %+v", tmpl)
+	}
+}
+
+func TestLoadFileInvalid(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"bad.yaml": "include: [unclosed\n"})
+
+	if _, err := LoadFile(filepath.Join(dir, "bad.yaml")); err == nil {
+		t.Error("LoadFile() of invalid YAML should fail")
+	}
+}
+
+func TestLoadAllPrecedence(t *testing.T) {
+	global := t.TempDir()
+	project := t.TempDir()
+
+	writeFiles(t, global, map[string]string{
+		"bug-fix.yaml": "description: global bug fix\n",
+		"docs.yml":     "name: docs\ndescription: global docs\n",
+		"notes.txt":    "not a template\n",
+	})
+	writeFiles(t, project, map[string]string{
+		"docs.yaml": "description: project docs\n",
+	})
+
+	templates, err := LoadAll(global, project, filepath.Join(project, "missing"))
+	if err != nil {
+		t.Fatalf("LoadAll() error = %v", err)
+	}
+
+	names := strings.Join(Names(templates), ",")
+	if names != "bug-fix,docs,review" {
+		t.Errorf("Names() = %s, want bug-fix,docs,review", names)
+	}
+
+	if got := Find(templates, "bug-fix").Description; got != "global bug fix" {
+		t.Errorf("bug-fix description = %q, want the global override", got)
+	}
+	if got := Find(templates, "docs").Description; got != "project docs" {
+		t.Errorf("docs description = %q, want the project override", got)
+	}
+	if got := Find(templates, "review").Source; got != "builtin" {
+		t.Errorf("review source = %q, want builtin", got)
+	}
+	if Find(templates, "nope") != nil {
+		t.Error("Find() of unknown template should return nil")
+	}
+}
+
+func TestDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dirs := Dirs("/work/project")
+	want := []string{
+		filepath.Join(home, ".config", "aui", "templates"),
+		filepath.Join("/work/project", ".aui", "templates"),
+	}
+	if strings.Join(dirs, ",") != strings.Join(want, ",") {
+		t.Errorf("Dirs() = %v, want %v", dirs, want)
+	}
+}
+
+func TestInstantiate(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"main.go":        "package main\n",
+		"main_test.go":   "package main\n",
+		"api/handler.go": "package api\n",
+		"README.md":      "# Project\n",
+		"logs/error.log": "line 1\npanic: boom\nline 3\n",
+	})
+
+	tmpl := &Template{
+		Name:         "bug-fix",
+		Description:  "Fix a crash",
+		Include:      []string{"**/*.go"},
+		Exclude:      []string{"*_test.go"},
+		Files:        []string{"logs/error.log:2-2", "logs/missing.log"},
+		SystemPrompt: "Find the cause.",
+	}
+
+	ctx, missing, err := tmpl.Instantiate(root, "crash-123")
+	if err != nil {
+		t.Fatalf("Instantiate() error = %v", err)
+	}
+
+	if ctx.Name != "crash-123" || ctx.Description != "Fix a crash" || ctx.SystemPrompt != "Find the cause." {
+		t.Errorf("Instantiate() = %q %q %q", ctx.Name, ctx.Description, ctx.SystemPrompt)
+	}
+
+	var paths []string
+	for _, f := range ctx.Files {
+		paths = append(paths, f.Path)
+	}
+	if got := strings.Join(paths, ","); got != "api/handler.go,main.go,logs/error.log:2-2" {
+		t.Errorf("file paths = %s", got)
+	}
+	if got := ctx.GetFile("logs/error.log:2-2").Content; got != "panic: boom\n" {
+		t.Errorf("error log range content = %q", got)
+	}
+
+	if len(missing) != 1 || missing[0] != "logs/missing.log" {
+		t.Errorf("missing = %v, want [logs/missing.log]", missing)
+	}
+
+	ctx, _, err = tmpl.Instantiate(root, "")
+	if err != nil {
+		t.Fatalf("Instantiate() error = %v", err)
+	}
+	if ctx.Name != "bug-fix" {
+		t.Errorf("default Name = %q, want the template name", ctx.Name)
+	}
+}
+
+func TestInstantiateGitDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	root := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	writeFiles(t, root, map[string]string{"main.go": "package main\n"})
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	tmpl := &Template{Name: "review", GitDiff: "HEAD"}
+
+	// No changes, no diff file
+	ctx, _, err := tmpl.Instantiate(root, "")
+	if err != nil {
+		t.Fatalf("Instantiate() error = %v", err)
+	}
+	if len(ctx.Files) != 0 {
+		t.Errorf("clean tree added %d files, want 0", len(ctx.Files))
+	}
+
+	writeFiles(t, root, map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+
+	ctx, _, err = tmpl.Instantiate(root, "")
+	if err != nil {
+		t.Fatalf("Instantiate() error = %v", err)
+	}
+	diff := ctx.GetFile("git#diff HEAD " + ctx.ID)
+	if diff == nil {
+		t.Fatal("diff file not added")
+	}
+	if diff.Language != "diff" || !strings.Contains(diff.Content, "+func main() {}") {
+		t.Errorf("diff = %q (%s)", diff.Content, diff.Language)
+	}
+
+	// A later context keeps its own diff in the store
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if err := store.SaveContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, root, map[string]string{"main.go": "package main\n\nfunc run() {}\n"})
+	later, _, err := tmpl.Instantiate(root, "")
+	if err != nil {
+		t.Fatalf("Instantiate() error = %v", err)
+	}
+	if err := store.SaveContext(later); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []struct {
+		ctx  *context.Context
+		line string
+	}{{ctx, "+func main() {}"}, {later, "+func run() {}"}} {
+		saved, err := store.GetContext(want.ctx.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(saved.Files) != 1 || !strings.Contains(saved.Files[0].Content, want.line) {
+			t.Errorf("saved diff of %s = %+v, want its own diff with %q", want.ctx.ID, saved.Files, want.line)
+		}
+	}
+
+	tmpl.GitDiff = "no-such-revision"
+	if _, _, err := tmpl.Instantiate(root, ""); err == nil {
+		t.Error("Instantiate() with an unknown revision should fail")
+	}
+}
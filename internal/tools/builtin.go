@@ -0,0 +1,341 @@
+package tools
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/redact"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+const (
+	// maxOutput caps the characters a tool returns, keeping results well
+	// inside a model's context window
+	maxOutput = 32 * 1024
+
+	// maxMatches caps the lines grep returns
+	maxMatches = 100
+
+	// maxGrepFileSize skips files too large to be source code
+	maxGrepFileSize = 1 << 20
+)
+
+// Builtin returns the read-only tools for a project: reading, listing and
+// searching files below root, and fetching files from ctx if it is not nil.
+// File contents are redacted by path with redactor, which may be nil.
+func Builtin(root string, ctx *context.Context, redactor *redact.Redactor) []Tool {
+	tools := []Tool{ReadFile{Root: root, Redactor: redactor}, ListDir{Root: root}, Grep{Root: root, Redactor: redactor}}
+	if ctx != nil {
+		tools = append(tools, ContextFile{Context: ctx, Redactor: redactor})
+	}
+	return tools
+}
+
+// schema returns the JSON schema of an object with properties
+func schema(required []string, properties map[string]interface{}) map[string]interface{} {
+	if required == nil {
+		required = []string{}
+	}
+	return map[string]interface{}{"type": "object", "properties": properties, "required": required}
+}
+
+// property returns the JSON schema of a property of a type
+func property(kind, description string) map[string]interface{} {
+	return map[string]interface{}{"type": kind, "description": description}
+}
+
+// decode unmarshals the input of a call
+func decode(input json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(input, v); err != nil {
+		return fmt.Errorf("invalid input: %w", err)
+	}
+	return nil
+}
+
+// Resolve returns the absolute path of a slash-separated path relative to
+// root, refusing paths that lead outside root, including through symlinks
+func Resolve(root, path string) (string, error) {
+	if filepath.IsAbs(path) {
+		return "", fmt.Errorf("path must be relative to the project root: %s", path)
+	}
+
+	full := filepath.Join(root, filepath.FromSlash(path))
+	if !within(root, full) {
+		return "", fmt.Errorf("path is outside the project root: %s", path)
+	}
+
+	// A symlink inside root may point anywhere
+	real, err := filepath.EvalSymlinks(full)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("no such file or directory: %s", path)
+		}
+		return "", err
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	if !within(realRoot, real) {
+		return "", fmt.Errorf("path is outside the project root: %s", path)
+	}
+	return full, nil
+}
+
+// within reports whether path is root or below it
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// truncate cuts text to maxOutput, saying so
+func truncate(text string) string {
+	if len(text) <= maxOutput {
+		return text
+	}
+	cut := strings.LastIndexByte(text[:maxOutput], '\n')
+	if cut < 0 {
+		cut = maxOutput
+	}
+	return text[:cut] + "\n[output truncated]"
+}
+
+// ReadFile reads a file, or a range of its lines, below the project root
+type ReadFile struct {
+	Root     string
+	Redactor *redact.Redactor // Scrubs secrets from the file by its path; may be nil
+}
+
+// Spec describes read_file
+func (ReadFile) Spec() api.Tool {
+	return api.Tool{
+		Name:        "read_file",
+		Description: "Read a file of the project. Use start_line and end_line to read part of a large file.",
+		Parameters: schema([]string{"path"}, map[string]interface{}{
+			"path":       property("string", "Path relative to the project root"),
+			"start_line": property("integer", "First line to read, counting from 1"),
+			"end_line":   property("integer", "Last line to read"),
+		}),
+	}
+}
+
+// Run reads the file
+func (t ReadFile) Run(_ gocontext.Context, input json.RawMessage) (string, error) {
+	var in struct {
+		Path      string `json:"path"`
+		StartLine int    `json:"start_line"`
+		EndLine   int    `json:"end_line"`
+	}
+	if err := decode(input, &in); err != nil {
+		return "", err
+	}
+
+	full, err := Resolve(t.Root, in.Path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(full)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory (use list_dir)", in.Path)
+	}
+	data, err := os.ReadFile(full)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if strings.IndexByte(string(data), 0) >= 0 {
+		return "", fmt.Errorf("%s is a binary file", in.Path)
+	}
+
+	// The whole file is redacted so rules spanning lines still match
+	content, _ := t.Redactor.Redact(in.Path, string(data))
+	if in.StartLine > 0 || in.EndLine > 0 {
+		lines := strings.SplitAfter(strings.TrimSuffix(content, "\n"), "\n")
+		start, end := max(in.StartLine, 1), in.EndLine
+		if end <= 0 || end > len(lines) {
+			end = len(lines)
+		}
+		if start > end {
+			return "", fmt.Errorf("%s has %d lines", in.Path, len(lines))
+		}
+		content = strings.Join(lines[start-1:end], "")
+	}
+	return truncate(content), nil
+}
+
+// ListDir lists a directory below the project root
+type ListDir struct {
+	Root string
+}
+
+// Spec describes list_dir
+func (ListDir) Spec() api.Tool {
+	return api.Tool{
+		Name:        "list_dir",
+		Description: "List the files and directories in a directory of the project. Directories end with a slash.",
+		Parameters: schema(nil, map[string]interface{}{
+			"path": property("string", "Directory relative to the project root; defaults to the root"),
+		}),
+	}
+}
+
+// Run lists the directory
+func (t ListDir) Run(_ gocontext.Context, input json.RawMessage) (string, error) {
+	var in struct {
+		Path string `json:"path"`
+	}
+	if err := decode(input, &in); err != nil {
+		return "", err
+	}
+	if in.Path == "" {
+		in.Path = "."
+	}
+
+	full, err := Resolve(t.Root, in.Path)
+	if err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(full)
+	if err != nil {
+		return "", fmt.Errorf("failed to list directory: %w", err)
+	}
+
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString(e.Name())
+		if e.IsDir() {
+			b.WriteString("/")
+		}
+		b.WriteString("\n")
+	}
+	if b.Len() == 0 {
+		return "(empty directory)", nil
+	}
+	return truncate(b.String()), nil
+}
+
+// Grep searches the text files below a directory of the project for lines
+// matching a regular expression, skipping VCS and dependency directories
+type Grep struct {
+	Root     string
+	Redactor *redact.Redactor // Scrubs secrets from each file by its path before searching; may be nil
+}
+
+// Spec describes grep
+func (Grep) Spec() api.Tool {
+	return api.Tool{
+		Name:        "grep",
+		Description: fmt.Sprintf("Search the project's text files for lines matching a regular expression (RE2 syntax). Returns up to %d matches as path:line: text.", maxMatches),
+		Parameters: schema([]string{"pattern"}, map[string]interface{}{
+			"pattern": property("string", "Regular expression to search for"),
+			"path":    property("string", "Directory to search, relative to the project root; defaults to the root"),
+			"include": property("string", "Glob of files to search, e.g. *.go or internal/**/*.go"),
+		}),
+	}
+}
+
+// Run searches the files
+func (t Grep) Run(ctx gocontext.Context, input json.RawMessage) (string, error) {
+	var in struct {
+		Pattern string `json:"pattern"`
+		Path    string `json:"path"`
+		Include string `json:"include"`
+	}
+	if err := decode(input, &in); err != nil {
+		return "", err
+	}
+	re, err := regexp.Compile(in.Pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern: %w", err)
+	}
+	if in.Path == "" {
+		in.Path = "."
+	}
+
+	dir, err := Resolve(t.Root, in.Path)
+	if err != nil {
+		return "", err
+	}
+	opts := context.ScanOptions{MaxFileSize: maxGrepFileSize}
+	if in.Include != "" {
+		opts.Include = []string{in.Include}
+	}
+	files, err := context.ScanDirectory(dir, opts)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	matches := 0
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		path := filepath.ToSlash(filepath.Join(in.Path, f.Path))
+		content, _ := t.Redactor.Redact(path, f.Content)
+		for i, line := range strings.Split(content, "\n") {
+			if !re.MatchString(line) {
+				continue
+			}
+			if matches == maxMatches {
+				b.WriteString("[more matches not shown; narrow the pattern or path]\n")
+				return truncate(b.String()), nil
+			}
+			if len(line) > 200 {
+				line = line[:200] + "…"
+			}
+			fmt.Fprintf(&b, "%s:%d: %s\n", path, i+1, line)
+			matches++
+		}
+	}
+	if matches == 0 {
+		return "no matches", nil
+	}
+	return truncate(b.String()), nil
+}
+
+// ContextFile fetches a file from the context attached to the conversation,
+// in full even if the prompt only carries its summary
+type ContextFile struct {
+	Context  *context.Context
+	Redactor *redact.Redactor // Scrubs secrets from the file by its path; may be nil
+}
+
+// Spec describes context_file
+func (t ContextFile) Spec() api.Tool {
+	return api.Tool{
+		Name:        "context_file",
+		Description: fmt.Sprintf("Fetch the full content of a file in the attached context %q, e.g. one the prompt only summarizes.", t.Context.Name),
+		Parameters: schema([]string{"path"}, map[string]interface{}{
+			"path": property("string", "Path of the file as listed in the context"),
+		}),
+	}
+}
+
+// Run returns the file's content
+func (t ContextFile) Run(_ gocontext.Context, input json.RawMessage) (string, error) {
+	var in struct {
+		Path string `json:"path"`
+	}
+	if err := decode(input, &in); err != nil {
+		return "", err
+	}
+
+	f := t.Context.GetFile(in.Path)
+	if f == nil {
+		paths := make([]string, len(t.Context.Files))
+		for i, f := range t.Context.Files {
+			paths[i] = f.Path
+		}
+		return "", fmt.Errorf("%s is not in context %s, which has: %s", in.Path, t.Context.Name, strings.Join(paths, ", "))
+	}
+	content, _ := t.Redactor.Redact(f.Path, f.Content)
+	return truncate(content), nil
+}
@@ -0,0 +1,128 @@
+package tools
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/aui/internal/context"
+)
+
+// projectDir creates a small project and returns its root
+func projectDir(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"main.go":           "package main\n\nfunc main() {\n\trun() // TODO: flags\n}\n",
+		"internal/run.go":   "package internal\n\n// TODO: retries\nfunc Run() {}\n",
+		".git/config":       "TODO in git\n",
+		"docs/notes.md":     "nothing to see\n",
+		"internal/data.bin": "TODO\x00",
+	}
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// run calls a tool with input
+func run(t *testing.T, tool Tool, input string) (string, error) {
+	t.Helper()
+	return tool.Run(gocontext.Background(), json.RawMessage(input))
+}
+
+func TestResolve(t *testing.T) {
+	root := projectDir(t)
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Resolve(root, "internal/run.go"); err != nil {
+		t.Errorf("Resolve() error = %v", err)
+	}
+	for _, path := range []string{"../etc/passwd", "internal/../../x", "/etc/passwd", "escape", "missing.go"} {
+		if _, err := Resolve(root, path); err == nil {
+			t.Errorf("Resolve(%q) should fail", path)
+		}
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	tool := ReadFile{Root: projectDir(t)}
+
+	got, err := run(t, tool, `{"path":"main.go"}`)
+	if err != nil || !strings.HasPrefix(got, "package main") {
+		t.Errorf("read_file = %q, %v", got, err)
+	}
+	got, err = run(t, tool, `{"path":"main.go","start_line":3,"end_line":4}`)
+	if err != nil || got != "func main() {\n\trun() // TODO: flags\n" {
+		t.Errorf("read_file lines 3-4 = %q, %v", got, err)
+	}
+	for _, input := range []string{`{"path":"internal"}`, `{"path":"internal/data.bin"}`, `{"path":"../x"}`, `{"path":"main.go","start_line":9}`} {
+		if _, err := run(t, tool, input); err == nil {
+			t.Errorf("read_file %s should fail", input)
+		}
+	}
+}
+
+func TestListDir(t *testing.T) {
+	tool := ListDir{Root: projectDir(t)}
+
+	got, err := run(t, tool, `{}`)
+	if err != nil || got != ".git/\ndocs/\ninternal/\nmain.go\n" {
+		t.Errorf("list_dir = %q, %v", got, err)
+	}
+	if got, _ := run(t, tool, `{"path":"docs"}`); got != "notes.md\n" {
+		t.Errorf("list_dir docs = %q", got)
+	}
+}
+
+func TestGrep(t *testing.T) {
+	tool := Grep{Root: projectDir(t)}
+
+	got, err := run(t, tool, `{"pattern":"TODO"}`)
+	want := "internal/run.go:3: // TODO: retries\nmain.go:4: \trun() // TODO: flags\n"
+	if err != nil || got != want {
+		t.Errorf("grep = %q, %v; want %q", got, err, want)
+	}
+	if got, _ := run(t, tool, `{"pattern":"TODO","path":"internal"}`); got != "internal/run.go:3: // TODO: retries\n" {
+		t.Errorf("grep in internal = %q", got)
+	}
+	if got, _ := run(t, tool, `{"pattern":"TODO","include":"main.go"}`); !strings.HasPrefix(got, "main.go:4:") {
+		t.Errorf("grep main.go = %q", got)
+	}
+	if got, _ := run(t, tool, `{"pattern":"nope"}`); got != "no matches" {
+		t.Errorf("grep = %q, want no matches", got)
+	}
+	if _, err := run(t, tool, `{"pattern":"("}`); err == nil {
+		t.Error("grep with an invalid pattern should fail")
+	}
+}
+
+func TestContextFile(t *testing.T) {
+	ctx := context.NewContext("auth", "")
+	f := context.NewFile("auth/login.go", "login.go")
+	f.SetContent("package auth\n")
+	ctx.AddFile(f)
+	tool := ContextFile{Context: ctx}
+
+	if got, err := run(t, tool, `{"path":"auth/login.go"}`); err != nil || got != "package auth\n" {
+		t.Errorf("context_file = %q, %v", got, err)
+	}
+	if _, err := run(t, tool, `{"path":"main.go"}`); err == nil || !strings.Contains(err.Error(), "auth/login.go") {
+		t.Errorf("context_file of a missing file error = %v, want the context's files listed", err)
+	}
+	if tools := Builtin("/tmp", ctx, nil); len(tools) != 4 || Builtin("/tmp", nil, nil)[2].Spec().Name != "grep" {
+		t.Errorf("Builtin() = %d tools", len(tools))
+	}
+}
@@ -0,0 +1,211 @@
+package tools
+
+import (
+	"bytes"
+	gocontext "context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// ShellEnv are the environment variables commands see; everything else,
+// API keys included, is scrubbed
+var ShellEnv = []string{
+	"PATH", "HOME", "USER", "LANG", "LC_ALL", "TERM", "TMPDIR",
+	"GOPATH", "GOROOT", "GOCACHE", "GOMODCACHE", "GOFLAGS", "GOPROXY",
+}
+
+const (
+	// DefaultShellTimeout stops commands that run longer
+	DefaultShellTimeout = 2 * time.Minute
+
+	// DefaultShellOutput caps the output kept from a command
+	DefaultShellOutput = 32 * 1024
+)
+
+// Command is a shell command an agent asked to run, kept for audit whether
+// or not the user approved it
+type Command struct {
+	ID         string
+	AgentID    string
+	Agent      string // Agent name when it asked
+	Command    string
+	Dir        string // Working directory relative to the project root
+	Approved   bool
+	ExitCode   int    // -1 if the command did not run to completion
+	Output     string // Combined stdout and stderr, capped
+	Error      string // Why the command was not run or did not finish
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Shell runs commands an agent proposes in a directory of the project,
+// each only once Approve says the user agreed. Commands run with a scrubbed
+// environment, a timeout and capped output.
+type Shell struct {
+	Root      string
+	Timeout   time.Duration // 0 uses DefaultShellTimeout
+	MaxOutput int           // Bytes of output kept; 0 uses DefaultShellOutput
+	Env       []string      // Variables passed through besides ShellEnv
+
+	Approve func(c *Command) bool // Nil declines every command
+	Log     func(c *Command)      // Called with every command once it is over; may be nil
+}
+
+// Spec describes run_command
+func (*Shell) Spec() api.Tool {
+	return api.Tool{
+		Name:        "run_command",
+		Description: "Run a shell command, such as the tests or a build, in a directory of the project. The user must approve every command; prefer the read-only tools for looking at files. Returns the exit code and output.",
+		Parameters: schema([]string{"command"}, map[string]interface{}{
+			"command": property("string", "Command line, run with sh -c"),
+			"dir":     property("string", "Working directory relative to the project root; defaults to the root"),
+		}),
+	}
+}
+
+// ParseCommand returns the command line and working directory of a
+// run_command call's input
+func ParseCommand(input json.RawMessage) (command, dir string, err error) {
+	var in struct {
+		Command string `json:"command"`
+		Dir     string `json:"dir"`
+	}
+	if err := decode(input, &in); err != nil {
+		return "", "", err
+	}
+	if strings.TrimSpace(in.Command) == "" {
+		return "", "", fmt.Errorf("command is required")
+	}
+	if in.Dir == "" {
+		in.Dir = "."
+	}
+	return in.Command, filepath.ToSlash(filepath.Clean(in.Dir)), nil
+}
+
+// Run asks for approval, then runs the command
+func (s *Shell) Run(ctx gocontext.Context, input json.RawMessage) (string, error) {
+	command, dir, err := ParseCommand(input)
+	if err != nil {
+		return "", err
+	}
+	c := &Command{ID: generateID(), Command: command, Dir: dir, ExitCode: -1, StartedAt: time.Now()}
+	defer func() {
+		c.FinishedAt = time.Now()
+		if s.Log != nil {
+			s.Log(c)
+		}
+	}()
+
+	full, err := Resolve(s.Root, dir)
+	if err != nil {
+		c.Error = err.Error()
+		return "", err
+	}
+	if info, err := os.Stat(full); err != nil || !info.IsDir() {
+		c.Error = fmt.Sprintf("not a directory: %s", dir)
+		return "", errors.New(c.Error)
+	}
+
+	c.Approved = s.Approve != nil && s.Approve(c)
+	if !c.Approved {
+		c.Error = "declined by the user"
+		return "", fmt.Errorf("the user declined to run %q", command)
+	}
+
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = DefaultShellTimeout
+	}
+	runCtx, cancel := gocontext.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	limit := s.MaxOutput
+	if limit <= 0 {
+		limit = DefaultShellOutput
+	}
+	output := &cappedBuffer{limit: limit}
+
+	cmd := exec.CommandContext(runCtx, "sh", "-c", command)
+	cmd.Dir = full
+	cmd.Env = scrubEnv(append(append([]string{}, ShellEnv...), s.Env...))
+	cmd.Stdout, cmd.Stderr = output, output
+	// Children that keep the output open must not hold up the result
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	c.Output = output.String()
+	var exitErr *exec.ExitError
+	switch {
+	case runCtx.Err() == gocontext.DeadlineExceeded:
+		c.Error = fmt.Sprintf("timed out after %s", timeout)
+	case ctx.Err() != nil:
+		c.Error = ctx.Err().Error()
+		return "", ctx.Err()
+	case errors.As(err, &exitErr):
+		c.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		c.Error = err.Error()
+		return "", fmt.Errorf("failed to run command: %w", err)
+	default:
+		c.ExitCode = 0
+	}
+
+	status := fmt.Sprintf("exit code %d", c.ExitCode)
+	if c.Error != "" {
+		status = c.Error
+	}
+	return status + "\n" + c.Output, nil
+}
+
+// scrubEnv returns the variables of the environment with the given names
+func scrubEnv(names []string) []string {
+	var env []string
+	for _, name := range names {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
+
+// cappedBuffer keeps the first limit bytes written to it and counts the rest
+type cappedBuffer struct {
+	buf     bytes.Buffer
+	limit   int
+	dropped int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := b.limit - b.buf.Len(); room < n {
+		b.dropped += n - room
+		p = p[:room]
+	}
+	b.buf.Write(p)
+	return n, nil
+}
+
+// String returns the output kept, saying how much was dropped
+func (b *cappedBuffer) String() string {
+	if b.dropped == 0 {
+		return b.buf.String()
+	}
+	return fmt.Sprintf("%s\n[%d more bytes of output dropped]", b.buf.String(), b.dropped)
+}
+
+// generateID generates a random ID for a command
+func generateID() string {
+	bytes := make([]byte, 8)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
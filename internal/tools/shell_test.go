@@ -0,0 +1,100 @@
+package tools
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testShell returns a shell in a new project that approves every command
+// and records what it logs
+func testShell(t *testing.T) (*Shell, *[]*Command) {
+	t.Helper()
+	var logged []*Command
+	s := &Shell{
+		Root:    projectDir(t),
+		Approve: func(*Command) bool { return true },
+		Log:     func(c *Command) { logged = append(logged, c) },
+	}
+	return s, &logged
+}
+
+func TestShellRun(t *testing.T) {
+	s, logged := testShell(t)
+
+	got, err := run(t, s, `{"command":"pwd; echo oops >&2; exit 3","dir":"internal"}`)
+	if err != nil {
+		t.Fatalf("run_command error = %v", err)
+	}
+	if !strings.HasPrefix(got, "exit code 3\n") || !strings.Contains(got, "/internal\n") || !strings.Contains(got, "oops") {
+		t.Errorf("run_command = %q", got)
+	}
+
+	if len(*logged) != 1 {
+		t.Fatalf("logged %d commands, want 1", len(*logged))
+	}
+	if c := (*logged)[0]; !c.Approved || c.ExitCode != 3 || c.Dir != "internal" || c.FinishedAt.IsZero() {
+		t.Errorf("logged command = %+v", c)
+	}
+}
+
+func TestShellDeclined(t *testing.T) {
+	s, logged := testShell(t)
+	var asked *Command
+	s.Approve = func(c *Command) bool {
+		asked = c
+		return false
+	}
+
+	if _, err := run(t, s, `{"command":"touch ran"}`); err == nil || !strings.Contains(err.Error(), "declined") {
+		t.Errorf("run_command error = %v, want the command declined", err)
+	}
+	if asked == nil || asked.Command != "touch ran" || asked.Dir != "." {
+		t.Errorf("Approve() asked about %+v", asked)
+	}
+	if _, err := (ReadFile{Root: s.Root}).Run(gocontext.Background(), json.RawMessage(`{"path":"ran"}`)); err == nil {
+		t.Error("a declined command should not run")
+	}
+	if c := (*logged)[0]; c.Approved || c.Error == "" {
+		t.Errorf("logged command = %+v, want it declined", c)
+	}
+
+	// Without an approver nothing runs
+	s.Approve = nil
+	if _, err := run(t, s, `{"command":"true"}`); err == nil {
+		t.Error("run_command without an approver should be declined")
+	}
+}
+
+func TestShellLimits(t *testing.T) {
+	s, _ := testShell(t)
+	t.Setenv("AUI_TEST_SECRET", "sk-123")
+	t.Setenv("AUI_TEST_ALLOWED", "yes")
+	s.Env = []string{"AUI_TEST_ALLOWED"}
+
+	got, _ := run(t, s, `{"command":"echo secret=$AUI_TEST_SECRET allowed=$AUI_TEST_ALLOWED"}`)
+	if !strings.Contains(got, "secret= allowed=yes") {
+		t.Errorf("run_command = %q, want the environment scrubbed", got)
+	}
+
+	s.MaxOutput = 10
+	got, _ = run(t, s, `{"command":"echo 0123456789abcdef"}`)
+	if !strings.Contains(got, "0123456789\n[7 more bytes of output dropped]") {
+		t.Errorf("run_command = %q, want the output capped", got)
+	}
+
+	s.Timeout = 50 * time.Millisecond
+	start := time.Now()
+	got, _ = run(t, s, `{"command":"sleep 5"}`)
+	if !strings.HasPrefix(got, "timed out") || time.Since(start) > 3*time.Second {
+		t.Errorf("run_command = %q after %s, want a timeout", got, time.Since(start))
+	}
+
+	for _, input := range []string{`{"command":"ls","dir":".."}`, `{"command":"ls","dir":"main.go"}`, `{"command":" "}`} {
+		if _, err := run(t, s, input); err == nil {
+			t.Errorf("run_command %s should fail", input)
+		}
+	}
+}
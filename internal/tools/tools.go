@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"bytes"
+	gocontext "context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/yourusername/aui/internal/redact"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// DefaultMaxTurns is how many times a model may ask for tools in one reply
+// unless configured otherwise
+const DefaultMaxTurns = 8
+
+// Tool is a function agents can call to look things up
+type Tool interface {
+	Spec() api.Tool
+	Run(ctx gocontext.Context, input json.RawMessage) (string, error)
+}
+
+// Toolbox is the set of tools offered to a model
+type Toolbox struct {
+	Tools    []Tool
+	MaxTurns int              // Rounds of tool calls allowed per reply; 0 uses DefaultMaxTurns
+	Redactor *redact.Redactor // Scrubs secrets from results and errors before they are sent; may be nil
+}
+
+// Specs returns the descriptions of the tools sent with a request
+func (b *Toolbox) Specs() []api.Tool {
+	specs := make([]api.Tool, len(b.Tools))
+	for i, t := range b.Tools {
+		specs[i] = t.Spec()
+	}
+	return specs
+}
+
+// Find returns the tool with a name, or nil
+func (b *Toolbox) Find(name string) Tool {
+	for _, t := range b.Tools {
+		if t.Spec().Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Turns returns the rounds of tool calls allowed per reply
+func (b *Toolbox) Turns() int {
+	if b.MaxTurns > 0 {
+		return b.MaxTurns
+	}
+	return DefaultMaxTurns
+}
+
+// Call runs a tool call. Failures, including unknown tools, become error
+// results so the model can read them and try something else. Results and
+// errors are scrubbed of secrets the redactor finds anywhere; rules that
+// depend on a file's path are applied by the tools that read files.
+func (b *Toolbox) Call(ctx gocontext.Context, call api.ToolCall) api.ToolResult {
+	result := api.ToolResult{CallID: call.ID, Name: call.Name}
+
+	t := b.Find(call.Name)
+	if t == nil {
+		result.Content, result.IsError = fmt.Sprintf("unknown tool: %s", call.Name), true
+		return result
+	}
+
+	content, err := t.Run(ctx, call.Arguments())
+	if err != nil {
+		content, result.IsError = err.Error(), true
+	}
+	result.Content, _ = b.Redactor.Redact(call.Name, content)
+	return result
+}
+
+// Answer runs the calls of a reply in turn and appends the reply and their
+// results to req, ready to be sent again
+func (b *Toolbox) Answer(ctx gocontext.Context, req *api.Request, reply string, calls []api.ToolCall) []api.ToolResult {
+	results := make([]api.ToolResult, len(calls))
+	for i, call := range calls {
+		results[i] = b.Call(ctx, call)
+	}
+	req.AppendToolTurn(reply, calls, results)
+	return results
+}
+
+// Loop offers the tools with req and sends it, answering tool calls and
+// sending it again until the model replies without any; req is extended
+// with each exchange. It returns the last reply and the usage of every
+// request.
+func (b *Toolbox) Loop(ctx gocontext.Context, req *api.Request, send func(*api.Request) <-chan api.Response) (string, api.TokenUsage, error) {
+	req.Tools = b.Specs()
+
+	var total api.TokenUsage
+	for turn := 0; ; turn++ {
+		reply, calls, usage, err := api.CollectCalls(send(req))
+		total = total.Add(usage)
+		if err != nil || len(calls) == 0 {
+			return reply, total, err
+		}
+		if turn == b.Turns() {
+			return reply, total, fmt.Errorf("gave up after %d rounds of tool calls", turn)
+		}
+		b.Answer(ctx, req, reply, calls)
+	}
+}
+
+// Describe returns a call as a line for display, e.g.
+// `read_file {"path":"main.go"}`
+func Describe(call api.ToolCall) string {
+	var input bytes.Buffer
+	if err := json.Compact(&input, call.Arguments()); err != nil || input.String() == "{}" {
+		return call.Name
+	}
+	text := input.String()
+	if len(text) > 80 {
+		text = text[:77] + "..."
+	}
+	return call.Name + " " + text
+}
@@ -0,0 +1,151 @@
+package tools
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/aui/internal/redact"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// echoTool returns its input
+type echoTool struct{}
+
+func (echoTool) Spec() api.Tool {
+	return api.Tool{Name: "echo", Parameters: schema(nil, nil)}
+}
+
+func (echoTool) Run(_ gocontext.Context, input json.RawMessage) (string, error) {
+	return string(input), nil
+}
+
+// reply returns a channel carrying a finished reply
+func reply(text string, calls ...api.ToolCall) <-chan api.Response {
+	ch := make(chan api.Response, 1)
+	ch <- api.Response{Content: text, Done: true, Usage: api.TokenUsage{InputTokens: 10, OutputTokens: 1}, ToolCalls: calls}
+	close(ch)
+	return ch
+}
+
+func TestCall(t *testing.T) {
+	redactor, err := redact.New(nil, []string{"sk-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	box := &Toolbox{Tools: []Tool{echoTool{}}, Redactor: redactor}
+
+	result := box.Call(gocontext.Background(), api.ToolCall{ID: "1", Name: "echo", Input: json.RawMessage(`{"key":"sk-secret"}`)})
+	if result.IsError || result.CallID != "1" || result.Name != "echo" || strings.Contains(result.Content, "sk-secret") {
+		t.Errorf("Call() = %+v, want the result with the secret redacted", result)
+	}
+
+	result = box.Call(gocontext.Background(), api.ToolCall{ID: "2", Name: "rm"})
+	if !result.IsError || !strings.Contains(result.Content, "unknown tool") {
+		t.Errorf("Call() of an unknown tool = %+v", result)
+	}
+}
+
+// failTool fails with its input in the error
+type failTool struct{}
+
+func (failTool) Spec() api.Tool {
+	return api.Tool{Name: "fail", Parameters: schema(nil, nil)}
+}
+
+func (failTool) Run(_ gocontext.Context, input json.RawMessage) (string, error) {
+	return "", errors.New("bad input " + string(input))
+}
+
+func TestCallRedactsFiles(t *testing.T) {
+	root := projectDir(t)
+	if err := os.WriteFile(filepath.Join(root, ".env"), []byte("DB_PASSWORD=hunter2secretvalue\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	redactor, err := redact.New(nil, []string{"sk-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	box := &Toolbox{Tools: append(Builtin(root, nil, redactor), failTool{}), Redactor: redactor}
+
+	calls := []api.ToolCall{
+		{ID: "1", Name: "read_file", Input: json.RawMessage(`{"path":".env"}`)},
+		{ID: "2", Name: "grep", Input: json.RawMessage(`{"pattern":"DB_PASSWORD"}`)},
+		{ID: "3", Name: "fail", Input: json.RawMessage(`{"key":"sk-secret"}`)},
+	}
+	for _, call := range calls {
+		result := box.Call(gocontext.Background(), call)
+		if strings.Contains(result.Content, "hunter2secretvalue") || strings.Contains(result.Content, "sk-secret") {
+			t.Errorf("Call(%s) = %q, want the secret redacted", call.Name, result.Content)
+		}
+	}
+	if result := box.Call(gocontext.Background(), calls[1]); !strings.Contains(result.Content, ".env:1: DB_PASSWORD=[REDACTED") {
+		t.Errorf("Call(grep) = %q, want the match with its value redacted", result.Content)
+	}
+	grep := api.ToolCall{ID: "4", Name: "grep", Input: json.RawMessage(`{"pattern":"hunter2"}`)}
+	if result := box.Call(gocontext.Background(), grep); result.Content != "no matches" {
+		t.Errorf("Call(grep) for the secret = %q, want no matches", result.Content)
+	}
+}
+
+func TestLoop(t *testing.T) {
+	box := &Toolbox{Tools: []Tool{echoTool{}}}
+	req := &api.Request{Messages: []api.Message{{Role: api.RoleUser, Content: "hi"}}}
+
+	sent := 0
+	answer, usage, err := box.Loop(gocontext.Background(), req, func(r *api.Request) <-chan api.Response {
+		sent++
+		if len(r.Tools) != 1 || r.Tools[0].Name != "echo" {
+			t.Errorf("request tools = %+v", r.Tools)
+		}
+		if sent == 1 {
+			return reply("checking", api.ToolCall{ID: "c1", Name: "echo", Input: json.RawMessage(`{"n":1}`)})
+		}
+		if last := r.Messages[len(r.Messages)-1]; len(last.ToolResults) != 1 || last.ToolResults[0].Content != `{"n":1}` {
+			t.Errorf("last message = %+v, want the tool result", last)
+		}
+		return reply("done")
+	})
+	if err != nil || answer != "done" || sent != 2 {
+		t.Fatalf("Loop() = %q, %v after %d requests", answer, err, sent)
+	}
+	if usage.InputTokens != 20 {
+		t.Errorf("usage = %+v, want both requests counted", usage)
+	}
+	if len(req.Messages) != 3 {
+		t.Errorf("messages = %+v, want the tool turn appended", req.Messages)
+	}
+}
+
+func TestLoopMaxTurns(t *testing.T) {
+	box := &Toolbox{Tools: []Tool{echoTool{}}, MaxTurns: 2}
+
+	sent := 0
+	_, _, err := box.Loop(gocontext.Background(), &api.Request{}, func(*api.Request) <-chan api.Response {
+		sent++
+		return reply("", api.ToolCall{ID: "c", Name: "echo"})
+	})
+	if err == nil || sent != 3 {
+		t.Errorf("Loop() = %v after %d requests, want an error after 3", err, sent)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		call api.ToolCall
+		want string
+	}{
+		{api.ToolCall{Name: "list_dir"}, "list_dir"},
+		{api.ToolCall{Name: "read_file", Input: json.RawMessage(`{ "path": "main.go" }`)}, `read_file {"path":"main.go"}`},
+		{api.ToolCall{Name: "grep", Input: json.RawMessage(`{"pattern":"` + strings.Repeat("x", 100) + `"}`)}, `grep {"pattern":"` + strings.Repeat("x", 65) + "..."},
+	}
+	for _, tt := range tests {
+		if got := Describe(tt.call); got != tt.want {
+			t.Errorf("Describe(%s) = %q, want %q", tt.call.Input, got, tt.want)
+		}
+	}
+}
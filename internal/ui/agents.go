@@ -0,0 +1,334 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/models"
+	"github.com/yourusername/aui/internal/providers"
+)
+
+// updateAgents handles keys for the Agents tab
+func (a App) updateAgents(msg tea.KeyMsg) (App, tea.Cmd) {
+	switch msg.String() {
+	case "j", "down":
+		if a.SelectedAgent < len(a.Agents)-1 {
+			a.SelectedAgent++
+		}
+	case "k", "up":
+		if a.SelectedAgent > 0 {
+			a.SelectedAgent--
+		}
+	case "a":
+		a.OpenForm(a.agentForm(nil))
+	case "e":
+		if ag := a.CurrentAgent(); ag != nil {
+			a.OpenForm(a.agentForm(ag))
+		}
+	case "s":
+		if ag := a.CurrentAgent(); ag != nil {
+			label := fmt.Sprintf("System prompt for %s (text or @%s)", ag.Name, strings.Join(agent.PersonaNames(), ", @"))
+			a.OpenPrompt(label, func(a App, value string) App {
+				if err := a.SetAgentSystemPrompt(value); err != nil {
+					a.Status = fmt.Sprintf("Error: %v", err)
+				}
+				return a
+			})
+			a.Prompt.Value = ag.SystemPrompt
+		}
+	case "d":
+		if ag := a.CurrentAgent(); ag != nil {
+			a.OpenPrompt(fmt.Sprintf("Delete agent %s? (y/n)", ag.Name), func(a App, value string) App {
+				if answer := strings.ToLower(strings.TrimSpace(value)); answer != "y" && answer != "yes" {
+					a.Status = "Delete cancelled"
+					return a
+				}
+				if err := a.DeleteAgent(ag.ID); err != nil {
+					a.Status = fmt.Sprintf("Error: %v", err)
+				} else {
+					a.Status = fmt.Sprintf("Deleted agent %s", ag.Name)
+				}
+				return a
+			})
+		}
+	}
+	return a, nil
+}
+
+// agentForm builds the form for creating an agent, or editing ag if not nil
+func (a *App) agentForm(ag *agent.Agent) *Form {
+	title := "New agent"
+	name, provider, model, params, system := "", providers.Names[0], "", "", ""
+	if ag != nil {
+		title = "Edit agent " + ag.Name
+		name, provider, model, params, system = ag.Name, ag.Provider, ag.Model, ag.Params.String(), ag.SystemPrompt
+	}
+
+	options := modelOptions(a.Catalog, provider, model)
+	if model == "" && len(options) > 0 {
+		model = options[0]
+	}
+
+	return &Form{
+		Title: title,
+		Fields: []Field{
+			{Label: "Name", Value: name},
+			{Label: "Provider", Value: provider, Options: providers.Names},
+			{Label: "Model", Value: model, Options: options},
+			{Label: "Parameters", Value: params},
+			{Label: "System prompt", Value: system},
+		},
+		OnChange: func(f *Form, field int) {
+			if f.Fields[field].Label != "Provider" {
+				return
+			}
+			// A new provider offers its own models
+			m := f.Field("Model")
+			m.Options = modelOptions(a.Catalog, f.Value("Provider"), "")
+			m.Value = ""
+			if len(m.Options) > 0 {
+				m.Value = m.Options[0]
+			}
+		},
+		Submit: func(a App, f *Form) (App, error) {
+			err := a.SaveAgentForm(ag, f)
+			return a, err
+		},
+	}
+}
+
+// modelOptions lists a provider's models from the catalog, or the models the
+// provider suggests if the catalog has none, with current first if it is
+// not one of them
+func modelOptions(catalog *models.Catalog, provider, current string) []string {
+	var options []string
+	if catalog != nil {
+		options = catalog.IDs(provider)
+	}
+	if len(options) == 0 {
+		options = providers.Models(provider)
+	}
+	if current != "" && indexOf(options, current) < 0 {
+		options = append([]string{current}, options...)
+	}
+	return options
+}
+
+// SaveAgentForm validates a submitted agent form and creates a new agent, or
+// updates ag if it is not nil
+func (a *App) SaveAgentForm(ag *agent.Agent, f *Form) error {
+	name := strings.TrimSpace(f.Value("Name"))
+	provider := f.Value("Provider")
+	model := strings.TrimSpace(f.Value("Model"))
+
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+	for _, other := range a.Agents {
+		if other.Name == name && other != ag {
+			return fmt.Errorf("an agent named %s already exists", name)
+		}
+	}
+	if model == "" {
+		return fmt.Errorf("model is required")
+	}
+	if a.Config != nil {
+		if _, err := providers.New(provider, a.Config); err != nil {
+			return fmt.Errorf("provider %s is not usable: %w", provider, err)
+		}
+	}
+
+	params, err := agent.ParseParams(f.Value("Parameters"))
+	if err != nil {
+		return err
+	}
+	system, err := resolveSystemPrompt(f.Value("System prompt"))
+	if err != nil {
+		return err
+	}
+	if a.Catalog != nil {
+		candidate := agent.NewAgent(name, model, provider)
+		candidate.Params = params
+		if err := a.Catalog.Validate(candidate); err != nil {
+			return err
+		}
+	}
+
+	if ag == nil {
+		ag = a.AddAgent(name, model, provider)
+		a.SelectedAgent = len(a.Agents) - 1
+		a.Status = fmt.Sprintf("Added agent %s", name)
+	} else {
+		ag.Name, ag.Provider, ag.Model = name, provider, model
+		a.Status = fmt.Sprintf("Updated agent %s", name)
+	}
+	ag.Params = params
+	ag.SystemPrompt = system
+
+	return a.saveAgent(ag)
+}
+
+// DeleteAgent removes an agent from the application and from storage
+func (a *App) DeleteAgent(id string) error {
+	for i, ag := range a.Agents {
+		if ag.ID != id {
+			continue
+		}
+
+		if a.Store != nil {
+			if err := a.Store.DeleteAgent(id); err != nil {
+				return fmt.Errorf("failed to delete agent: %w", err)
+			}
+		}
+
+		a.Agents = append(a.Agents[:i:i], a.Agents[i+1:]...)
+		if a.SelectedAgent >= len(a.Agents) && a.SelectedAgent > 0 {
+			a.SelectedAgent = len(a.Agents) - 1
+		}
+		return nil
+	}
+
+	return fmt.Errorf("agent not found: %s", id)
+}
+
+// CurrentAgent returns the selected agent, or nil if there are none
+func (a *App) CurrentAgent() *agent.Agent {
+	if a.SelectedAgent < 0 || a.SelectedAgent >= len(a.Agents) {
+		return nil
+	}
+	return a.Agents[a.SelectedAgent]
+}
+
+// SetAgentSystemPrompt sets the selected agent's system prompt. A value of
+// the form "@name" uses the named persona.
+func (a *App) SetAgentSystemPrompt(value string) error {
+	ag := a.CurrentAgent()
+	if ag == nil {
+		return fmt.Errorf("no agent selected")
+	}
+
+	system, err := resolveSystemPrompt(value)
+	if err != nil {
+		return err
+	}
+	ag.SystemPrompt = system
+
+	if err := a.saveAgent(ag); err != nil {
+		return err
+	}
+	a.Status = fmt.Sprintf("Updated system prompt for %s", ag.Name)
+	return nil
+}
+
+// resolveSystemPrompt trims a system prompt and expands "@name" to the
+// named persona
+func resolveSystemPrompt(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if name, ok := strings.CutPrefix(value, "@"); ok {
+		persona, found := agent.Personas[name]
+		if !found {
+			return "", fmt.Errorf("unknown persona: %s", name)
+		}
+		return persona, nil
+	}
+	return value, nil
+}
+
+// saveAgent persists an agent if storage is available
+func (a *App) saveAgent(ag *agent.Agent) error {
+	if a.Store == nil {
+		return nil
+	}
+	if err := a.Store.SaveAgent(ag); err != nil {
+		return fmt.Errorf("failed to save agent: %w", err)
+	}
+	return nil
+}
+
+// viewAgents renders the Agents tab
+func (a App) viewAgents() string {
+	view := "Agents:\n"
+	if len(a.Agents) == 0 {
+		return view + "  No agents configured. Press 'a' to add an agent.\n"
+	}
+
+	for i, ag := range a.Agents {
+		bullet := "•"
+		if i == a.SelectedAgent {
+			bullet = ">"
+		}
+		status := string(ag.Status)
+		if ag.Status == agent.StatusWorking {
+			status = spinnerFrames[a.Spinner%len(spinnerFrames)] + " " + status
+		}
+		view += fmt.Sprintf("  %s %s (%s) - %s\n", bullet, ag.Name, ag.Model, status)
+		if ag.Status == agent.StatusWorking && ag.CurrentTask != "" {
+			view += fmt.Sprintf("      task: %s\n", truncate(ag.CurrentTask, 60))
+		}
+		if position := a.queuePosition(ag); position > 0 {
+			view += fmt.Sprintf("      queued: position %d\n", position)
+		}
+		if ag.Status == agent.StatusError && ag.LastError != "" {
+			view += fmt.Sprintf("      error: %s\n", truncate(ag.LastError, 60))
+		}
+		if a.Catalog != nil {
+			if m := a.Catalog.Get(ag.Model); m != nil {
+				view += fmt.Sprintf("      model: %s context, %s output, $%.2f/$%.2f per M tokens\n",
+					formatTokens(m.ContextWindow), formatTokens(m.MaxOutput), m.InputPrice, m.OutputPrice)
+			}
+		}
+		if params := ag.Params.String(); params != "" {
+			view += fmt.Sprintf("      params: %s\n", params)
+		}
+		if ag.SystemPrompt != "" {
+			view += fmt.Sprintf("      system: %s\n", truncate(ag.SystemPrompt, 60))
+		}
+	}
+	view += a.viewQueue()
+	view += "\n  [j/k: select] [a: add] [e: edit] [s: system prompt] [d: delete]\n"
+
+	return view
+}
+
+// viewQueue renders the depth and wait times of each provider's queue
+func (a App) viewQueue() string {
+	if a.Orchestrator == nil {
+		return ""
+	}
+	stats := a.Orchestrator.Queue.Stats(time.Now())
+	if len(stats) == 0 {
+		return ""
+	}
+
+	view := "\nQueue:\n"
+	for _, s := range stats {
+		view += fmt.Sprintf("  %s: %d queued, %d running, longest wait %s, average wait %s\n",
+			s.Provider, s.Queued, s.Running, s.LongestWait.Round(time.Second), s.AverageWait.Round(time.Second))
+	}
+	return view
+}
+
+// formatTokens abbreviates a token count, e.g. 200000 as "200k"
+func formatTokens(n int) string {
+	switch {
+	case n >= 1000000:
+		return fmt.Sprintf("%.1fM", float64(n)/1000000)
+	case n >= 1000:
+		return fmt.Sprintf("%dk", n/1000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+// truncate shortens s to at most n runes on a single line
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
+}
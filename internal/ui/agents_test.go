@@ -0,0 +1,220 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/config"
+)
+
+// typeText sends each rune of s to the app as a key press
+func typeText(app App, s string) App {
+	for _, r := range s {
+		app = pressKey(app, string(r))
+	}
+	return app
+}
+
+func TestAgentSelection(t *testing.T) {
+	app := InitialApp()
+
+	app = pressKey(app, "j")
+	if app.CurrentAgent().Name != "Gemini" {
+		t.Errorf("CurrentAgent() = %s, want Gemini", app.CurrentAgent().Name)
+	}
+	app = pressKey(app, "j")
+	if app.SelectedAgent != 1 {
+		t.Errorf("SelectedAgent = %d, want 1 at end of list", app.SelectedAgent)
+	}
+	app = pressKey(app, "k")
+	if app.CurrentAgent().Name != "Claude" {
+		t.Errorf("CurrentAgent() = %s, want Claude", app.CurrentAgent().Name)
+	}
+}
+
+// pressKeyType sends a special key to the app
+func pressKeyType(app App, key tea.KeyType) App {
+	model, _ := app.Update(tea.KeyMsg{Type: key})
+	return model.(App)
+}
+
+func TestAddAgentForm(t *testing.T) {
+	app := InitialApp()
+
+	app = pressKey(app, "a")
+	if app.Form == nil {
+		t.Fatal("'a' should open the agent form")
+	}
+	if app.Form.Value("Provider") != "anthropic" || app.Form.Value("Model") != app.Catalog.IDs("anthropic")[0] {
+		t.Errorf("form defaults = %s/%s", app.Form.Value("Provider"), app.Form.Value("Model"))
+	}
+
+	app = typeText(app, "Reviewer")
+	app = pressKeyType(app, tea.KeyDown)
+	app = pressKeyType(app, tea.KeyRight)
+	if app.Form.Value("Provider") != "openai" || app.Form.Value("Model") != app.Catalog.IDs("openai")[0] {
+		t.Errorf("changing provider should offer its models, got %s/%s", app.Form.Value("Provider"), app.Form.Value("Model"))
+	}
+	app = pressKeyType(app, tea.KeyDown)
+	app = pressKeyType(app, tea.KeyRight)
+	model := app.Form.Value("Model")
+	app = pressKeyType(app, tea.KeyDown)
+	app = typeText(app, "temperature=0.2 max_tokens=1024")
+	app = pressKeyType(app, tea.KeyDown)
+	app = typeText(app, "@reviewer")
+	app = pressKey(app, "enter")
+
+	if app.Form != nil {
+		t.Fatalf("form should close after saving, error %q", app.Form.Error)
+	}
+	if len(app.Agents) != 3 {
+		t.Fatalf("len(Agents) = %d, want 3", len(app.Agents))
+	}
+	ag := app.CurrentAgent()
+	if ag.Name != "Reviewer" || ag.Provider != "openai" || ag.Model != model {
+		t.Errorf("agent = %s %s/%s, want Reviewer openai/%s", ag.Name, ag.Provider, ag.Model, model)
+	}
+	if ag.Params.MaxTokens != 1024 || ag.SystemPrompt != agent.Personas["reviewer"] {
+		t.Errorf("agent params = %q, system prompt = %q", ag.Params.String(), ag.SystemPrompt)
+	}
+	if !strings.Contains(app.View(), "params: temperature=0.2 max_tokens=1024") {
+		t.Error("View() should show agent parameters")
+	}
+	if !strings.Contains(app.View(), "model: 128k context, 16k output, $2.50/$10.00 per M tokens") {
+		t.Error("View() should show catalog details of the model")
+	}
+}
+
+func TestAgentFormValidation(t *testing.T) {
+	app := InitialApp()
+	app.Config = config.NewDefault()
+	app.Config.APIKeys = map[string]string{"anthropic": "key"}
+
+	tests := []struct {
+		name   string
+		values map[string]string
+		want   string
+	}{
+		{"missing name", map[string]string{"Name": " "}, "name is required"},
+		{"duplicate name", map[string]string{"Name": "Gemini"}, "already exists"},
+		{"missing API key", map[string]string{"Name": "New", "Provider": "google", "Model": "gemini-2.5-pro"}, "not usable"},
+		{"bad params", map[string]string{"Name": "New", "Parameters": "temperature=5"}, "invalid temperature"},
+		{"unknown persona", map[string]string{"Name": "New", "System prompt": "@pirate"}, "unknown persona"},
+		{"too many output tokens", map[string]string{"Name": "New", "Model": "claude-3-5-haiku-latest", "Parameters": "max_tokens=10000"}, "exceeds"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := app.agentForm(nil)
+			for label, value := range tt.values {
+				f.Field(label).Value = value
+			}
+			err := app.SaveAgentForm(nil, f)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("SaveAgentForm() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	// Errors keep the form open
+	app = pressKey(app, "a")
+	app = pressKey(app, "enter")
+	if app.Form == nil || app.Form.Error != "name is required" {
+		t.Errorf("form should stay open with an error, got %+v", app.Form)
+	}
+	if len(app.Agents) != 2 {
+		t.Errorf("invalid form should not add an agent")
+	}
+}
+
+func TestEditAgentForm(t *testing.T) {
+	app := InitialApp()
+	app = pressKey(app, "j")
+	app = pressKey(app, "e")
+
+	if app.Form == nil || app.Form.Value("Name") != "Gemini" || app.Form.Value("Model") != "gemini-1.5-pro" {
+		t.Fatalf("'e' should open the form prefilled with the agent, got %+v", app.Form)
+	}
+	app = pressKeyType(app, tea.KeyBackspace)
+	app = typeText(app, "y")
+	app = pressKeyType(app, tea.KeyUp)
+	app = pressKeyType(app, tea.KeyUp)
+	app = typeText(app, "max_tokens=500")
+	app = pressKey(app, "enter")
+
+	if len(app.Agents) != 2 {
+		t.Fatalf("editing should not add agents, got %d", len(app.Agents))
+	}
+	if ag := app.Agents[1]; ag.Name != "Geminy" || ag.Params.MaxTokens != 500 {
+		t.Errorf("agent = %s %q, want Geminy max_tokens=500", ag.Name, ag.Params.String())
+	}
+
+	// Escape discards changes
+	app = pressKey(app, "e")
+	app = typeText(app, "zzz")
+	app = pressKey(app, "esc")
+	if app.Form != nil || app.Agents[1].Name != "Geminy" {
+		t.Errorf("esc should close the form without saving")
+	}
+
+	// Models missing from the catalog are offered but must be replaced
+	app = pressKey(app, "k")
+	app = pressKey(app, "e")
+	if app.Form.Field("Model").Options[0] != "claude-3.5-sonnet" {
+		t.Error("the current model should be offered even if the catalog does not list it")
+	}
+	app = pressKey(app, "enter")
+	if app.Form == nil || !strings.Contains(app.Form.Error, "unknown model claude-3.5-sonnet") {
+		t.Errorf("saving an unknown model should fail, got %+v", app.Form)
+	}
+}
+
+func TestDeleteAgent(t *testing.T) {
+	app := InitialApp()
+	app = pressKey(app, "j")
+
+	app = pressKey(app, "d")
+	app = typeText(app, "n")
+	app = pressKey(app, "enter")
+	if len(app.Agents) != 2 {
+		t.Fatalf("answering no should keep the agent")
+	}
+
+	app = pressKey(app, "d")
+	app = typeText(app, "y")
+	app = pressKey(app, "enter")
+	if len(app.Agents) != 1 || app.Agents[0].Name != "Claude" {
+		t.Fatalf("Agents = %v, want only Claude", app.Agents)
+	}
+	if app.SelectedAgent != 0 {
+		t.Errorf("SelectedAgent = %d, want 0 after deleting the last agent", app.SelectedAgent)
+	}
+
+	if err := app.DeleteAgent("missing"); err == nil {
+		t.Error("DeleteAgent() of an unknown agent should fail")
+	}
+}
+
+func TestSetAgentSystemPrompt(t *testing.T) {
+	app := InitialApp()
+
+	if err := app.SetAgentSystemPrompt("@reviewer"); err != nil {
+		t.Fatalf("SetAgentSystemPrompt() error = %v", err)
+	}
+	if app.Agents[0].SystemPrompt != agent.Personas["reviewer"] {
+		t.Errorf("SystemPrompt = %q, want reviewer persona", app.Agents[0].SystemPrompt)
+	}
+	if !strings.Contains(app.View(), "system: You are a meticulous code reviewer") {
+		t.Error("View() should show the system prompt")
+	}
+
+	if err := app.SetAgentSystemPrompt("  Answer in French.  "); err != nil || app.Agents[0].SystemPrompt != "Answer in French." {
+		t.Errorf("SystemPrompt = %q, err = %v", app.Agents[0].SystemPrompt, err)
+	}
+
+	if err := app.SetAgentSystemPrompt("@pirate"); err == nil {
+		t.Error("SetAgentSystemPrompt() should reject unknown personas")
+	}
+}
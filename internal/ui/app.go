@@ -2,12 +2,21 @@ package ui
 
 import (
 	"fmt"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/yourusername/aui/internal/agent"
 	"github.com/yourusername/aui/internal/config"
 	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/models"
+	"github.com/yourusername/aui/internal/orchestrator"
+	"github.com/yourusername/aui/internal/providers"
+	"github.com/yourusername/aui/internal/queue"
+	"github.com/yourusername/aui/internal/redact"
 	"github.com/yourusername/aui/internal/storage"
+	"github.com/yourusername/aui/internal/summarize"
+	"github.com/yourusername/aui/internal/tools"
+	"github.com/yourusername/aui/pkg/api"
 )
 
 // App represents the main TUI application state
@@ -22,6 +31,33 @@ type App struct {
 	Height    int
 	Ready     bool
 	Quitting  bool
+
+	Root            string // Project root that file paths are relative to
+	SelectedAgent   int
+	SelectedContext int
+	SelectedFile    int
+	Expansion       *Expansion
+	Proposal        *Proposal
+	Prompt          *Prompt
+	Confirm         *Confirm
+	Form            *Form
+	Approval        *Approval
+	Patches         *Patches
+	Redactor        *redact.Redactor
+	RedactionErr    error // Why the redaction rules could not be loaded; nothing is sent while set
+	Summarizer      *summarize.Summarizer
+	Catalog         *models.Catalog
+	Chat            *Chat
+	Compare         *Compare
+	Pipelines       *Pipelines
+	Orchestrator    *orchestrator.Orchestrator                  // Queues provider requests; nil sends them directly
+	MCPTools        []tools.Tool                                // Tools of the MCP servers with expose_tools set, offered in chat
+	NewProvider     func(ag *agent.Agent) (api.Provider, error) // Overrides the provider registry, for tests
+	Spinner         int                                         // Spinner frame shown for working agents
+	Spinning        bool
+	Status          string // Last status or error message shown in the footer
+
+	inflight map[string]*inflight // Requests being answered, by agent ID
 }
 
 // InitialApp creates the initial application state (for testing)
@@ -38,6 +74,7 @@ func InitialApp() App {
 		Tabs:      []string{"Agents", "Contexts", "Files"},
 		Agents:    []*agent.Agent{claude, gemini},
 		Contexts:  []*context.Context{exampleCtx},
+		Catalog:   models.Default(),
 		Ready:     true,
 		Quitting:  false,
 	}
@@ -47,13 +84,46 @@ func InitialApp() App {
 func InitialAppWithDependencies(cfg *config.Config, store *storage.SQLiteStore) App {
 	app := App{
 		ActiveTab: 0,
-		Tabs:      []string{"Agents", "Contexts", "Files", "Config"},
+		Tabs:      []string{"Agents", "Contexts", "Files", "Chat", "Compare", "Pipelines", "Config"},
 		Config:    cfg,
 		Store:     store,
 		Ready:     true,
 		Quitting:  false,
 	}
 
+	// Secrets are scrubbed from anything sent to providers
+	redactor, err := redact.FromConfig(cfg)
+	if err != nil {
+		app.RedactionErr = err
+	}
+	app.Redactor = redactor
+
+	catalog, err := models.Load(models.OverridePath())
+	if err != nil {
+		app.Status = fmt.Sprintf("Using built-in model catalog: %v", err)
+		catalog = models.Default()
+	}
+	app.Catalog = catalog
+
+	// Batch jobs left waiting by the last run are sent again
+	app.Orchestrator = orchestrator.New(cfg, store, catalog)
+	resumed, err := app.Orchestrator.Resume(func(job *queue.Job) (api.Provider, error) {
+		return providers.New(job.Provider, cfg)
+	})
+	if err != nil {
+		app.Status = fmt.Sprintf("Failed to resume queued jobs: %v", err)
+	} else if resumed > 0 {
+		app.Status = fmt.Sprintf("Resumed %d queued jobs", resumed)
+	}
+
+	if cfg.Summarize.Agent != "" && app.RedactionErr == nil {
+		summarizer, err := summarize.New(cfg, store, redactor)
+		if err != nil {
+			app.Status = fmt.Sprintf("Summarization disabled: %v", err)
+		}
+		app.Summarizer = summarizer
+	}
+
 	// Load agents from storage
 	agents, err := store.ListAgents()
 	if err != nil {
@@ -72,6 +142,11 @@ func InitialAppWithDependencies(cfg *config.Config, store *storage.SQLiteStore)
 		app.Contexts = contexts
 	}
 
+	// Broken redaction rules must not go unnoticed, since nothing is sent
+	// until they are fixed
+	if app.RedactionErr != nil {
+		app.Status = fmt.Sprintf("Sending is disabled: the redaction config is invalid: %v", app.RedactionErr)
+	}
 	return app
 }
 
@@ -88,25 +163,106 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.Height = msg.Height
 		return a, nil
 
+	case summaryMsg:
+		return a.handleSummary(msg), nil
+
+	case chatChunkMsg:
+		return a.handleChatChunk(msg)
+
+	case chatToolsMsg:
+		return a.handleChatTools(msg)
+
+	case compareMsg:
+		return a.handleCompare(msg)
+
+	case verdictMsg:
+		return a.handleVerdict(msg)
+
+	case pipelineStepMsg:
+		return a.handlePipelineStep(msg)
+
+	case spinnerTickMsg:
+		return a.handleSpinnerTick()
+
 	case tea.KeyMsg:
+		if a.Approval != nil && msg.Type != tea.KeyCtrlC {
+			return a.updateApproval(msg)
+		}
+		if a.Confirm != nil && msg.Type != tea.KeyCtrlC {
+			return a.updateConfirm(msg)
+		}
+		if a.Patches != nil && msg.Type != tea.KeyCtrlC {
+			return a.updatePatches(msg)
+		}
+		if a.Prompt != nil && msg.Type != tea.KeyCtrlC {
+			return a.updatePrompt(msg)
+		}
+		if a.Form != nil && msg.Type != tea.KeyCtrlC {
+			return a.updateForm(msg)
+		}
+		if a.Tabs[a.ActiveTab] == "Chat" && a.Chat != nil && a.Chat.Editing && msg.Type != tea.KeyCtrlC {
+			return a.updateChatInput(msg)
+		}
+		if a.Tabs[a.ActiveTab] == "Compare" && a.Compare != nil && a.Compare.Editing && msg.Type != tea.KeyCtrlC {
+			return a.updateCompareInput(msg)
+		}
+		if a.Tabs[a.ActiveTab] == "Pipelines" && a.Pipelines != nil && a.Pipelines.Editing && msg.Type != tea.KeyCtrlC {
+			return a.updatePipelineInput(msg)
+		}
+
 		switch msg.String() {
 		case "ctrl+c", "q":
 			a.Quitting = true
+			a.interruptReplies()
 			return a, tea.Quit
 
 		case "tab", "l":
 			a.ActiveTab = (a.ActiveTab + 1) % len(a.Tabs)
+			a.onTabChange()
 			return a, nil
 
 		case "shift+tab", "h":
 			a.ActiveTab = (a.ActiveTab - 1 + len(a.Tabs)) % len(a.Tabs)
+			a.onTabChange()
 			return a, nil
 		}
+
+		switch a.Tabs[a.ActiveTab] {
+		case "Agents":
+			return a.updateAgents(msg)
+		case "Contexts":
+			return a.updateContexts(msg)
+		case "Files":
+			return a.updateFiles(msg)
+		case "Chat":
+			return a.updateChat(msg)
+		case "Compare":
+			return a.updateCompare(msg)
+		case "Pipelines":
+			return a.updatePipelines(msg)
+		}
 	}
 
 	return a, nil
 }
 
+// onTabChange refreshes state needed by the newly active tab
+func (a *App) onTabChange() {
+	a.Status = ""
+	if a.Tabs[a.ActiveTab] == "Files" {
+		a.loadSelectedContext()
+		a.SelectedFile = 0
+		a.Expansion = nil
+		a.Proposal = nil
+	}
+	if a.Tabs[a.ActiveTab] == "Compare" {
+		a.loadComparisons()
+	}
+	if a.Tabs[a.ActiveTab] == "Pipelines" {
+		a.loadPipelines()
+	}
+}
+
 // View renders the application UI
 func (a App) View() string {
 	if a.Quitting {
@@ -127,31 +283,46 @@ func (a App) View() string {
 	view += "\n\n"
 
 	// Render content based on active tab
-	switch a.ActiveTab {
-	case 0: // Agents
-		view += "Agents:\n"
-		if len(a.Agents) == 0 {
-			view += "  No agents configured. Press 'a' to add an agent.\n"
-		} else {
-			for _, ag := range a.Agents {
-				view += fmt.Sprintf("  • %s (%s) - %s\n", ag.Name, ag.Model, ag.Status)
-			}
-		}
+	switch a.Tabs[a.ActiveTab] {
+	case "Agents":
+		view += a.viewAgents()
 
-	case 1: // Contexts
+	case "Contexts":
 		view += "Contexts:\n"
 		if len(a.Contexts) == 0 {
 			view += "  No contexts saved. Press 'c' to create a context.\n"
+			view += "\n  [n: new from template]\n"
 		} else {
-			for _, ctx := range a.Contexts {
-				view += fmt.Sprintf("  • %s - %s\n", ctx.Name, ctx.Description)
+			for i, ctx := range a.Contexts {
+				bullet := "•"
+				if i == a.SelectedContext {
+					bullet = ">"
+				}
+				view += fmt.Sprintf("  %s %s - %s\n", bullet, ctx.Name, ctx.Description)
+				if len(ctx.Includes) > 0 {
+					view += fmt.Sprintf("      includes: %s\n", strings.Join(a.includeNames(ctx), ", "))
+				}
 			}
+			view += "\n  [j/k: select] [n: new from template] [i: include context] [r: scan for secrets]\n"
 		}
 
-	case 2: // Files
-		view += "Files:\n  (File browser coming soon)\n"
+	case "Files":
+		view += a.viewFiles()
+
+	case "Chat":
+		if a.Patches != nil {
+			view += a.viewPatches()
+		} else {
+			view += a.viewChat()
+		}
 
-	case 3: // Config
+	case "Compare":
+		view += a.viewCompare()
+
+	case "Pipelines":
+		view += a.viewPipelines()
+
+	case "Config":
 		view += "Configuration:\n"
 		if a.Config != nil {
 			view += fmt.Sprintf("  Database: %s\n", a.Config.Database.Path)
@@ -170,13 +341,30 @@ func (a App) View() string {
 		}
 	}
 
+	if a.Prompt != nil {
+		view += a.viewPrompt()
+	}
+	if a.Form != nil {
+		view += a.viewForm()
+	}
+	if a.Approval != nil {
+		view += a.viewApproval()
+	}
+	if a.Confirm != nil {
+		view += a.viewConfirm()
+	}
+
+	if a.Status != "" {
+		view += "\n" + a.Status + "\n"
+	}
+
 	view += "\n[tab/l: next tab] [shift+tab/h: prev tab] [q: quit]"
 
 	return view
 }
 
-// AddAgent adds a new agent to the application
-func (a *App) AddAgent(name, model, provider string) {
+// AddAgent adds a new agent to the application and returns it
+func (a *App) AddAgent(name, model, provider string) *agent.Agent {
 	newAgent := agent.NewAgent(name, model, provider)
 	a.Agents = append(a.Agents, newAgent)
 
@@ -184,6 +372,8 @@ func (a *App) AddAgent(name, model, provider string) {
 	if a.Store != nil {
 		a.Store.SaveAgent(newAgent)
 	}
+
+	return newAgent
 }
 
 // AddContext adds a new context to the application
@@ -274,9 +274,9 @@ func TestAppViewContentChangesWithTab(t *testing.T) {
 		tabIndex int
 		expected string
 	}{
-		{0, "Claude"},       // Agents tab shows agents
-		{1, "bug-fix-auth"}, // Contexts tab shows contexts
-		{2, "File browser"}, // Files tab shows placeholder
+		{0, "Claude"},           // Agents tab shows agents
+		{1, "bug-fix-auth"},     // Contexts tab shows contexts
+		{2, "has no files yet"}, // Files tab shows the selected context's files
 	}
 
 	for _, tt := range tests {
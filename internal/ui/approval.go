@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/aui/internal/tools"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// Approval asks the user whether to run the shell commands an agent
+// proposed in a reply. While it is open it receives all keys except ctrl+c.
+type Approval struct {
+	Agent    string
+	Commands []*tools.Command // Only Command and Dir are set
+	Current  int              // Index of the command being asked about
+
+	approved map[string]bool // By approvalKey
+	msg      chatChunkMsg
+	calls    []api.ToolCall
+}
+
+// approvalKey identifies a command by its directory and command line
+func approvalKey(dir, command string) string {
+	return dir + "\x00" + command
+}
+
+// answerChatTools runs the tool calls of a reply in the background, first
+// asking the user about any shell commands among them
+func (a App) answerChatTools(msg chatChunkMsg, calls []api.ToolCall) (App, tea.Cmd) {
+	shell, _ := msg.Tools.Find("run_command").(*tools.Shell)
+
+	var commands []*tools.Command
+	if shell != nil {
+		for _, call := range calls {
+			if call.Name != "run_command" {
+				continue
+			}
+			// Malformed calls fail when run, without asking
+			if command, dir, err := tools.ParseCommand(call.Arguments()); err == nil {
+				commands = append(commands, &tools.Command{Command: command, Dir: dir})
+			}
+		}
+	}
+	if len(commands) == 0 {
+		return a, answerTools(msg, calls)
+	}
+
+	a.Approval = &Approval{
+		Agent:    msg.Agent.Name,
+		Commands: commands,
+		approved: make(map[string]bool),
+		msg:      msg,
+		calls:    calls,
+	}
+	a.Status = fmt.Sprintf("%s wants to run a command", msg.Agent.Name)
+	return a, nil
+}
+
+// updateApproval handles keys while an approval is open
+func (a App) updateApproval(msg tea.KeyMsg) (App, tea.Cmd) {
+	p := a.Approval
+
+	switch msg.String() {
+	case "y":
+		c := p.Commands[p.Current]
+		p.approved[approvalKey(c.Dir, c.Command)] = true
+		p.Current++
+	case "n":
+		p.Current++
+	case "esc":
+		p.Current = len(p.Commands)
+	default:
+		return a, nil
+	}
+	if p.Current < len(p.Commands) {
+		return a, nil
+	}
+
+	// Commands run as the tool calls are answered, and only if approved
+	a.Approval = nil
+	shell := p.msg.Tools.Find("run_command").(*tools.Shell)
+	shell.Approve = func(c *tools.Command) bool {
+		return p.approved[approvalKey(c.Dir, c.Command)]
+	}
+	a.Status = fmt.Sprintf("You approved %d of %d commands from %s", len(p.approved), len(p.Commands), p.Agent)
+	return a, answerTools(p.msg, p.calls)
+}
+
+// viewApproval renders the open approval
+func (a App) viewApproval() string {
+	p := a.Approval
+	c := p.Commands[p.Current]
+
+	view := fmt.Sprintf("\n⚠ %s wants to run a command (%d/%d):\n", p.Agent, p.Current+1, len(p.Commands))
+	view += "    $ " + c.Command + "\n"
+	view += "    in " + c.Dir + "\n"
+	view += "  [y: run] [n: decline] [esc: decline all]\n"
+	return view
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/internal/storage"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+func TestApproveCommands(t *testing.T) {
+	provider := &toolCallProvider{calls: []api.ToolCall{
+		{ID: "1", Name: "run_command", Input: []byte(`{"command":"echo ok > approved.txt"}`)},
+		{ID: "2", Name: "run_command", Input: []byte(`{"command":"touch declined.txt","dir":"."}`)},
+	}}
+	app := chatTestApp(provider)
+	app.Config = config.NewDefault()
+	app.Config.Tools.Shell.Enabled = true
+	app.Root = t.TempDir()
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	app.Store = store
+	for _, ctx := range app.Contexts {
+		if err := store.SaveContext(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	app, cmd := sendChat(t, app, "Run the tests")
+	app = runPipelineSteps(app, cmd)
+	if app.Approval == nil {
+		t.Fatalf("the commands should wait for approval, status %q", app.Status)
+	}
+	if view := app.View(); !strings.Contains(view, "Claude wants to run a command (1/2)") || !strings.Contains(view, "$ echo ok > approved.txt") {
+		t.Errorf("View() should ask about the first command:\n%s", view)
+	}
+	if app.Agents[0].Status != agent.StatusWorking {
+		t.Error("the agent should keep working while waiting")
+	}
+
+	app = pressKey(app, "q")
+	if app.Quitting || app.Approval.Current != 0 {
+		t.Error("other keys should be ignored while asking")
+	}
+	app = pressKey(app, "y")
+	if view := app.View(); !strings.Contains(view, "(2/2)") || !strings.Contains(view, "$ touch declined.txt") {
+		t.Errorf("View() should ask about the second command:\n%s", view)
+	}
+	model, cmd := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	app = model.(App)
+	if app.Approval != nil || cmd == nil {
+		t.Fatal("answering the last command should run the tools")
+	}
+	if app.Status != "You approved 1 of 2 commands from Claude" {
+		t.Errorf("Status = %q, want the user's answers", app.Status)
+	}
+	app = runPipelineSteps(app, cmd)
+
+	if _, err := os.Stat(filepath.Join(app.Root, "approved.txt")); err != nil {
+		t.Error("the approved command should have run")
+	}
+	if _, err := os.Stat(filepath.Join(app.Root, "declined.txt")); err == nil {
+		t.Error("the declined command should not have run")
+	}
+
+	results := provider.requests[1].Messages[len(provider.requests[1].Messages)-1].ToolResults
+	if len(results) != 2 || results[0].IsError || !strings.HasPrefix(results[0].Content, "exit code 0") || !results[1].IsError {
+		t.Errorf("tool results = %+v", results)
+	}
+	if reply := app.lastReply(); !strings.HasSuffix(reply.Content, "The token is set.") || len(reply.ToolCalls) != 2 || app.Agents[0].Status != agent.StatusReady {
+		t.Errorf("reply = %q, agent %s", reply.Content, app.Agents[0].Status)
+	}
+
+	commands, err := store.ListCommands(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commands) != 2 || !commands[0].Approved || commands[0].Agent != "Claude" || commands[1].Approved {
+		t.Errorf("audit log = %+v", commands)
+	}
+}
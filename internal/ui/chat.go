@@ -0,0 +1,793 @@
+package ui
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/conversation"
+	"github.com/yourusername/aui/internal/ledger"
+	"github.com/yourusername/aui/internal/providers"
+	"github.com/yourusername/aui/internal/queue"
+	"github.com/yourusername/aui/internal/redact"
+	"github.com/yourusername/aui/internal/render"
+	"github.com/yourusername/aui/internal/tools"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// spinnerFrames are shown in turn while an agent is working
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Chat is the state of the Chat tab
+type Chat struct {
+	Agent        int // Index into App.Agents
+	Context      int // Index into App.Contexts, or -1 for no context
+	Conversation *conversation.Conversation
+	Input        string
+	Editing      bool // The input has focus and receives all keys
+}
+
+// inflight is a request an agent is waiting on
+type inflight struct {
+	cancel gocontext.CancelFunc
+	job    *queue.Job // Nil when the request bypasses the queue
+}
+
+// queuePosition returns the place of an agent's request in the queue, or 0
+// if it is not waiting there
+func (a *App) queuePosition(ag *agent.Agent) int {
+	pending := a.inflight[ag.ID]
+	if pending == nil || pending.job == nil {
+		return 0
+	}
+	return a.Orchestrator.Queue.Position(pending.job)
+}
+
+// chatChunkMsg delivers the next piece of a streamed reply
+type chatChunkMsg struct {
+	Stream   <-chan api.Response
+	Ctx      gocontext.Context // Cancelled when the user cancels the request
+	Agent    *agent.Agent
+	Reply    *conversation.Message
+	Response api.Response
+
+	// The request is sent again with the results when the agent calls tools
+	Request  *api.Request
+	Provider api.Provider
+	Tools    *tools.Toolbox // Nil when no tools are offered
+	Turn     int            // Rounds of tool calls answered so far
+	Text     string         // Content streamed since the last round
+}
+
+// chatToolsMsg reports that the tool calls of a reply were answered and the
+// request can be sent again
+type chatToolsMsg struct {
+	chatChunkMsg
+}
+
+// spinnerTickMsg advances the spinner of working agents
+type spinnerTickMsg struct{}
+
+// waitForChunk returns a command that reads the next response of a stream
+func waitForChunk(msg chatChunkMsg) tea.Cmd {
+	return func() tea.Msg {
+		r, ok := <-msg.Stream
+		if !ok {
+			// A stream that closes without a last response was cut short
+			r = api.Response{Done: true}
+			if err := msg.Ctx.Err(); err != nil {
+				r = api.Response{Error: err}
+			}
+		}
+		msg.Response = r
+		return msg
+	}
+}
+
+// spinnerTick returns a command that advances the spinner after a delay
+func spinnerTick() tea.Cmd {
+	return tea.Tick(100*time.Millisecond, func(time.Time) tea.Msg {
+		return spinnerTickMsg{}
+	})
+}
+
+// chat returns the Chat tab state, creating it on first use
+func (a *App) chat() *Chat {
+	if a.Chat == nil {
+		a.Chat = &Chat{Context: a.SelectedContext}
+		if a.Chat.Context >= len(a.Contexts) {
+			a.Chat.Context = -1
+		}
+	}
+	return a.Chat
+}
+
+// ChatAgent returns the agent chatted with, or nil if there are none
+func (a *App) ChatAgent() *agent.Agent {
+	c := a.chat()
+	if c.Agent < 0 || c.Agent >= len(a.Agents) {
+		return nil
+	}
+	return a.Agents[c.Agent]
+}
+
+// ChatContext returns the context attached to the chat, or nil
+func (a *App) ChatContext() *context.Context {
+	c := a.chat()
+	if c.Context < 0 || c.Context >= len(a.Contexts) {
+		return nil
+	}
+	return a.Contexts[c.Context]
+}
+
+// updateChat handles keys for the Chat tab
+func (a App) updateChat(msg tea.KeyMsg) (App, tea.Cmd) {
+	c := a.chat()
+
+	if c.Editing {
+		return a.updateChatInput(msg)
+	}
+
+	switch msg.String() {
+	case "g":
+		if len(a.Agents) > 0 {
+			c.Agent = (c.Agent + 1) % len(a.Agents)
+		}
+	case "c":
+		// Cycle through the contexts and then no context
+		c.Context++
+		if c.Context >= len(a.Contexts) {
+			c.Context = -1
+		}
+	case "n":
+		c.Conversation = nil
+		a.Status = "Started a new conversation"
+	case "x":
+		if err := a.CancelChat(); err != nil {
+			a.Status = fmt.Sprintf("Error: %v", err)
+		}
+	case "r", "R":
+		resend := a.RetryChat
+		if msg.String() == "R" {
+			resend = a.RegenerateChat
+		}
+		cmd, err := resend()
+		if err != nil {
+			a.Status = fmt.Sprintf("Error: %v", err)
+		}
+		return a, cmd
+	case "p":
+		if err := a.ReviewPatches(); err != nil {
+			a.Status = fmt.Sprintf("Error: %v", err)
+		}
+	case "[":
+		a.SwitchVersion(-1)
+	case "]":
+		a.SwitchVersion(1)
+	case "i", "enter":
+		c.Editing = true
+	}
+	return a, nil
+}
+
+// updateChatInput handles keys while the chat input has focus
+func (a App) updateChatInput(msg tea.KeyMsg) (App, tea.Cmd) {
+	c := a.Chat
+
+	switch msg.Type {
+	case tea.KeyEnter:
+		if msg.Alt {
+			c.Input += "\n"
+			break
+		}
+		prompt := strings.TrimSpace(c.Input)
+		if prompt == "" {
+			break
+		}
+		cmd, err := a.SendChat(prompt)
+		if err != nil {
+			a.Status = fmt.Sprintf("Error: %v", err)
+			break
+		}
+		c.Input = ""
+		return a, cmd
+	case tea.KeyEsc:
+		c.Editing = false
+	case tea.KeyBackspace:
+		if len(c.Input) > 0 {
+			runes := []rune(c.Input)
+			c.Input = string(runes[:len(runes)-1])
+		}
+	case tea.KeySpace:
+		c.Input += " "
+	case tea.KeyRunes:
+		c.Input += string(msg.Runes)
+	}
+	return a, nil
+}
+
+// providerFor returns the API client for an agent
+func (a *App) providerFor(ag *agent.Agent) (api.Provider, error) {
+	if a.NewProvider != nil {
+		return a.NewProvider(ag)
+	}
+	if a.Config == nil {
+		return nil, fmt.Errorf("no configuration loaded")
+	}
+	return providers.New(ag.Provider, a.Config)
+}
+
+// chatBudget returns the token budget for the attached context: the
+// configured budget, reduced to what fits in the model's context window
+// next to the conversation so far
+func (a *App) chatBudget(ag *agent.Agent, history int) int {
+	budget := 100000
+	if a.Config != nil {
+		budget = a.Config.Context.TokenBudget
+	}
+	if a.Catalog != nil {
+		if m := a.Catalog.Get(ag.Model); m != nil {
+			budget = min(budget, m.InputBudget(ag.Params.MaxTokens)-history)
+		}
+	}
+	return max(budget, 0)
+}
+
+// chatAgentProvider returns the chat agent and its API client, if the
+// agent is free to answer
+func (a *App) chatAgentProvider() (*agent.Agent, api.Provider, error) {
+	ag := a.ChatAgent()
+	if ag == nil {
+		return nil, nil, fmt.Errorf("no agent to chat with (add one in the Agents tab)")
+	}
+	if ag.Status == agent.StatusWorking {
+		return nil, nil, fmt.Errorf("%s is still working", ag.Name)
+	}
+
+	provider, err := a.providerFor(ag)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ag, provider, nil
+}
+
+// SendChat adds prompt to the conversation and starts streaming the reply
+// of the chat agent
+func (a *App) SendChat(prompt string) (tea.Cmd, error) {
+	c := a.chat()
+
+	ag, provider, err := a.chatAgentProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Conversation == nil {
+		contextID := ""
+		if ctx := a.ChatContext(); ctx != nil {
+			contextID = ctx.ID
+		}
+		c.Conversation = conversation.New(contextID)
+	}
+
+	// A prompt that is not sent is taken back out
+	question := c.Conversation.Append(api.RoleUser, prompt)
+	undo := func() { c.Conversation.Remove(question.ID) }
+	cmd, err := a.streamReply(ag, provider, question, undo)
+	if err != nil {
+		undo()
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// RetryChat asks the chat agent again for the last reply after it failed or
+// was cancelled. The failed reply is kept as an earlier version.
+func (a *App) RetryChat() (tea.Cmd, error) {
+	return a.resendChat(true)
+}
+
+// RegenerateChat asks the chat agent for a new version of the last reply,
+// keeping the previous versions as siblings
+func (a *App) RegenerateChat() (tea.Cmd, error) {
+	return a.resendChat(false)
+}
+
+// resendChat streams a new version of the last reply of the thread, which
+// must have failed or been cancelled if failed is set, and have finished
+// otherwise
+func (a *App) resendChat(failed bool) (tea.Cmd, error) {
+	reply := a.lastReply()
+	if reply == nil {
+		return nil, fmt.Errorf("no reply to ask again for")
+	}
+
+	switch {
+	case reply.Status == conversation.StatusStreaming:
+		return nil, fmt.Errorf("the reply is still streaming")
+	case failed && reply.Status == conversation.StatusDone:
+		return nil, fmt.Errorf("the last reply did not fail (press R to regenerate it)")
+	case !failed && reply.Status != conversation.StatusDone:
+		return nil, fmt.Errorf("the last reply did not finish (press r to retry it)")
+	}
+
+	ag, provider, err := a.chatAgentProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	conv := a.Chat.Conversation
+	previous := conv.Head
+	question := conv.Get(reply.ParentID)
+	conv.Head = question.ID
+
+	undo := func() { conv.Head = previous }
+	cmd, err := a.streamReply(ag, provider, question, undo)
+	if err != nil {
+		undo()
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// lastReply returns the last assistant message of the current thread, or nil
+func (a *App) lastReply() *conversation.Message {
+	if a.Chat == nil || a.Chat.Conversation == nil {
+		return nil
+	}
+	thread := a.Chat.Conversation.Thread()
+	for i := len(thread) - 1; i >= 0; i-- {
+		if thread[i].Role == api.RoleAssistant {
+			return thread[i]
+		}
+	}
+	return nil
+}
+
+// CancelChat cancels the reply the chat agent is streaming
+func (a *App) CancelChat() error {
+	ag := a.ChatAgent()
+	if ag == nil || a.inflight[ag.ID] == nil {
+		return fmt.Errorf("nothing to cancel")
+	}
+	a.inflight[ag.ID].cancel()
+	a.Status = fmt.Sprintf("Cancelling %s...", ag.Name)
+	return nil
+}
+
+// interruptReplies cancels every reply being waited on as aui quits, and
+// marks their jobs cancelled so they are not sent again on the next start
+func (a *App) interruptReplies() {
+	for _, pending := range a.inflight {
+		pending.cancel()
+		if pending.job != nil && a.Orchestrator != nil {
+			a.Orchestrator.Interrupt(pending.job)
+		}
+	}
+}
+
+// SwitchVersion shows another version of the last reply, delta versions
+// later or earlier
+func (a *App) SwitchVersion(delta int) {
+	reply := a.lastReply()
+	if reply == nil {
+		return
+	}
+
+	conv := a.Chat.Conversation
+	versions := conv.Siblings(reply.ID)
+	i := 0
+	for i < len(versions) && versions[i] != reply {
+		i++
+	}
+	conv.Select(versions[(i+delta+len(versions))%len(versions)].ID)
+}
+
+// streamReply sends the thread ending with question to an agent and starts
+// streaming its reply as a new child of question. The attached context is
+// flattened, packed to the budget and redacted before it is sent. If
+// anything was redacted, the user is asked first, and undo runs if they
+// decline.
+func (a *App) streamReply(ag *agent.Agent, provider api.Provider, question *conversation.Message, undo func()) (tea.Cmd, error) {
+	if err := a.checkRedaction(); err != nil {
+		return nil, err
+	}
+	req, box, report, err := a.prepareReply(ag, question)
+	if err != nil {
+		return nil, err
+	}
+	if !report.HasFindings() {
+		return a.startReply(ag, provider, question, req, box), nil
+	}
+
+	a.Confirm = &Confirm{
+		Question: fmt.Sprintf("%d secrets will be redacted before sending (%s). Send to %s?", len(report.Findings), report.Summary(), ag.Name),
+		Yes: func(a *App) tea.Cmd {
+			cmd := a.startReply(ag, provider, question, req, box)
+			a.Status = fmt.Sprintf("⚠ %d secrets were redacted before sending", len(report.Findings))
+			return cmd
+		},
+		No: func(a *App) {
+			undo()
+			a.Status = "Not sent"
+		},
+	}
+	return nil, nil
+}
+
+// checkRedaction refuses to send anything while the redaction rules are
+// broken, rather than sending unredacted content
+func (a *App) checkRedaction() error {
+	if a.RedactionErr != nil {
+		return fmt.Errorf("not sending: the redaction config is invalid: %w", a.RedactionErr)
+	}
+	return nil
+}
+
+// prepareReply builds the request for a reply to question, with the report
+// of what was redacted from it
+func (a *App) prepareReply(ag *agent.Agent, question *conversation.Message) (*api.Request, *tools.Toolbox, *redact.Report, error) {
+	conv := a.Chat.Conversation
+	ctx := a.ChatContext()
+
+	history := 0
+	for _, m := range conv.Thread() {
+		history += context.EstimateTokens(m.Content)
+	}
+
+	report := &redact.Report{}
+	var full *context.Context // Before packing, for the context_file tool
+	if ctx != nil {
+		var err error
+		if a.Store != nil {
+			if ctx, err = a.Store.GetContext(ctx.ID); err != nil {
+				return nil, nil, nil, fmt.Errorf("failed to load context: %w", err)
+			}
+		}
+		full = ctx
+		prepared, err := render.Prepare(a.Store, a.Redactor, ctx, a.chatBudget(ag, history))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to prepare context: %w", err)
+		}
+		ctx = prepared.Context
+		report.Findings = append(report.Findings, prepared.Redactions.Findings...)
+	}
+
+	req, err := conv.Request(ctx, render.Options{Format: a.chatFormat(ag), LineNumbers: a.Config != nil && a.Config.Context.LineNumbers})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Prompts are redacted too, since they may quote secrets
+	for i := range req.Messages {
+		if req.Messages[i].Role == api.RoleUser {
+			req.Messages[i].Content = a.Redactor.RedactPrompt(req.Messages[i].Content, report)
+		}
+	}
+	ag.ApplyTo(req)
+
+	// The agent may fetch more of the project or the full context on demand
+	box := a.chatTools(ag, full)
+	if box != nil {
+		req.Tools = box.Specs()
+	}
+	return req, box, report, nil
+}
+
+// startReply sends a prepared request and starts streaming the reply
+func (a *App) startReply(ag *agent.Agent, provider api.Provider, question *conversation.Message, req *api.Request, box *tools.Toolbox) tea.Cmd {
+	conv := a.Chat.Conversation
+
+	// The request is sent in the background, since it may wait in the queue
+	// or to be retried
+	reqCtx, cancel := gocontext.WithCancel(gocontext.Background())
+	pending := &inflight{cancel: cancel}
+	var stream <-chan api.Response
+	stream, pending.job = a.submitChat(reqCtx, ag, provider, req)
+
+	reply := conv.AppendTo(question.ID, api.RoleAssistant, "")
+	reply.AgentID = ag.ID
+	reply.Model = ag.Model
+	reply.Status = conversation.StatusStreaming
+	ag.AssignTask("chat: " + truncate(question.Content, 40))
+
+	if a.inflight == nil {
+		a.inflight = make(map[string]*inflight)
+	}
+	a.inflight[ag.ID] = pending
+	a.Status = ""
+
+	msg := chatChunkMsg{Stream: stream, Ctx: reqCtx, Agent: ag, Reply: reply, Request: req, Provider: provider, Tools: box}
+	return tea.Batch(waitForChunk(msg), a.startSpinner())
+}
+
+// chatTools returns the tools offered to the chat agent, with ctx as the
+// attached context, or nil if tools are disabled. Shell commands the agent
+// runs are logged to storage.
+func (a *App) chatTools(ag *agent.Agent, ctx *context.Context) *tools.Toolbox {
+	if a.Config == nil || !a.Config.Tools.Enabled || a.Root == "" {
+		return nil
+	}
+	box := &tools.Toolbox{
+		Tools:    append(tools.Builtin(a.Root, ctx, a.Redactor), a.MCPTools...),
+		MaxTurns: a.Config.Tools.MaxTurns,
+		Redactor: a.Redactor,
+	}
+
+	if cfg := a.Config.Tools.Shell; cfg.Enabled {
+		store, agentID, agentName := a.Store, ag.ID, ag.Name
+		box.Tools = append(box.Tools, &tools.Shell{
+			Root:      a.Root,
+			Timeout:   cfg.Timeout,
+			MaxOutput: cfg.MaxOutput,
+			Env:       cfg.Env,
+			Log: func(c *tools.Command) {
+				c.AgentID, c.Agent = agentID, agentName
+				if store != nil {
+					store.SaveCommand(c)
+				}
+			},
+		})
+	}
+	return box
+}
+
+// submitChat sends a chat request through the queue, or directly when there
+// is no orchestrator
+func (a *App) submitChat(ctx gocontext.Context, ag *agent.Agent, provider api.Provider, req *api.Request) (<-chan api.Response, *queue.Job) {
+	if a.Orchestrator == nil {
+		return api.Send(ctx, provider, req), nil
+	}
+	tokens := context.EstimateTokens(req.System)
+	for _, m := range req.Messages {
+		tokens += context.EstimateTokens(m.Content)
+		for _, r := range m.ToolResults {
+			tokens += context.EstimateTokens(r.Content)
+		}
+	}
+	job := queue.NewJob(ag.ID, ag.Provider, req, tokens)
+	job.Priority = queue.PriorityInteractive
+	return a.Orchestrator.Submit(ctx, job, provider), job
+}
+
+// answerTools returns a command that runs the tool calls of a reply in the
+// background and appends the results to its request
+func answerTools(msg chatChunkMsg, calls []api.ToolCall) tea.Cmd {
+	return func() tea.Msg {
+		msg.Tools.Answer(msg.Ctx, msg.Request, msg.Text, calls)
+		return chatToolsMsg{msg}
+	}
+}
+
+// handleChatTools sends a request again once its tool calls are answered
+func (a App) handleChatTools(msg chatToolsMsg) (App, tea.Cmd) {
+	next := msg.chatChunkMsg
+	if err := next.Ctx.Err(); err != nil {
+		next.Response = api.Response{Error: err}
+		return a.handleChatChunk(next)
+	}
+
+	pending := a.inflight[next.Agent.ID]
+	if pending == nil {
+		return a, nil
+	}
+	next.Stream, pending.job = a.submitChat(next.Ctx, next.Agent, next.Provider, next.Request)
+	next.Turn++
+	next.Text = ""
+	if next.Reply.Content != "" && !strings.HasSuffix(next.Reply.Content, "\n\n") {
+		next.Reply.Content = strings.TrimRight(next.Reply.Content, "\n") + "\n\n"
+	}
+	return a, waitForChunk(next)
+}
+
+// chatFormat picks the prompt format for an agent
+func (a *App) chatFormat(ag *agent.Agent) render.Format {
+	if a.Config != nil && a.Config.Context.Format != "" {
+		if f, err := render.ParseFormat(a.Config.Context.Format); err == nil {
+			return f
+		}
+	}
+	return render.DefaultFormat(ag.Provider)
+}
+
+// startSpinner starts the spinner unless it is already running
+func (a *App) startSpinner() tea.Cmd {
+	if a.Spinning {
+		return nil
+	}
+	a.Spinning = true
+	return spinnerTick()
+}
+
+// handleSpinnerTick advances the spinner while any agent is working
+func (a App) handleSpinnerTick() (App, tea.Cmd) {
+	for _, ag := range a.Agents {
+		if ag.Status == agent.StatusWorking {
+			a.Spinner++
+			return a, spinnerTick()
+		}
+	}
+	a.Spinning = false
+	return a, nil
+}
+
+// handleChatChunk appends streamed text to a reply and, when the stream
+// ends, records its usage and frees the agent
+func (a App) handleChatChunk(msg chatChunkMsg) (App, tea.Cmd) {
+	r := msg.Response
+	msg.Reply.Content += r.Content
+	msg.Text += r.Content
+
+	if r.Wait > 0 {
+		msg.Agent.WaitForRetry(r.Wait, r.WaitReason)
+		a.saveAgent(msg.Agent)
+		return a, waitForChunk(msg)
+	}
+	msg.Agent.Resume()
+
+	switch {
+	case errors.Is(r.Error, gocontext.Canceled):
+		msg.Reply.Status = conversation.StatusCancelled
+		msg.Agent.Cancel()
+		a.Status = fmt.Sprintf("Cancelled the reply from %s", msg.Agent.Name)
+	case r.Error != nil:
+		msg.Reply.Status = conversation.StatusFailed
+		msg.Reply.Error = r.Error.Error()
+		msg.Agent.SetError(r.Error.Error())
+		a.Status = fmt.Sprintf("%s failed: %v (press r to retry)", msg.Agent.Name, r.Error)
+	case r.Done:
+		// Each round of tool calls is a request of its own
+		entry := ledger.NewEntry(msg.Agent, r.Usage, a.Catalog)
+		msg.Reply.Usage = msg.Reply.Usage.Add(r.Usage)
+		msg.Reply.Cost += entry.Cost
+		if a.Store != nil {
+			if err := a.Store.RecordUsage(entry); err != nil {
+				a.Status = fmt.Sprintf("Failed to record usage: %v", err)
+			}
+		}
+
+		if len(r.ToolCalls) > 0 && msg.Tools != nil && msg.Turn < msg.Tools.Turns() {
+			for _, call := range r.ToolCalls {
+				msg.Reply.ToolCalls = append(msg.Reply.ToolCalls, tools.Describe(call))
+			}
+			a.Status = fmt.Sprintf("%s is calling %s", msg.Agent.Name, tools.Describe(r.ToolCalls[0]))
+			return a.answerChatTools(msg, r.ToolCalls)
+		}
+		if len(r.ToolCalls) > 0 {
+			err := fmt.Errorf("gave up after %d rounds of tool calls", msg.Turn)
+			msg.Reply.Status = conversation.StatusFailed
+			msg.Reply.Error = err.Error()
+			msg.Agent.SetError(err.Error())
+			a.Status = fmt.Sprintf("%s failed: %v (press r to retry)", msg.Agent.Name, err)
+			break
+		}
+		msg.Reply.Status = conversation.StatusDone
+		msg.Agent.CompleteTask()
+	default:
+		return a, waitForChunk(msg)
+	}
+
+	if pending := a.inflight[msg.Agent.ID]; pending != nil {
+		pending.cancel()
+		delete(a.inflight, msg.Agent.ID)
+	}
+	a.saveAgent(msg.Agent)
+	return a, nil
+}
+
+// viewChat renders the Chat tab
+func (a App) viewChat() string {
+	c := a.Chat
+	if c == nil {
+		c = &Chat{Context: a.SelectedContext}
+	}
+
+	view := "Chat:\n"
+	ag := a.ChatAgent()
+	if ag == nil {
+		return view + "  No agents configured. Add one in the Agents tab.\n"
+	}
+
+	contextName := "no context"
+	if ctx := a.ChatContext(); ctx != nil {
+		contextName = "context " + ctx.Name
+	}
+	view += fmt.Sprintf("  %s (%s) with %s\n", ag.Name, ag.Model, contextName)
+
+	var transcript []string
+	if c.Conversation != nil {
+		for _, m := range c.Conversation.Thread() {
+			transcript = append(transcript, a.viewChatMessage(m)...)
+		}
+	}
+	if ag.Status == agent.StatusWorking {
+		working := "  " + spinnerFrames[a.Spinner%len(spinnerFrames)] + " " + ag.Name + " is working..."
+		if position := a.queuePosition(ag); position > 0 {
+			working += fmt.Sprintf(" queued (position %d)", position)
+		} else if ag.Waiting() {
+			working += " " + ag.CurrentTask
+		}
+		transcript = append(transcript, working)
+	}
+
+	// Keep the end of a long transcript in view
+	if limit := a.Height - 14; a.Height > 0 && limit > 0 && len(transcript) > limit {
+		transcript = transcript[len(transcript)-limit:]
+	}
+	if len(transcript) > 0 {
+		view += "\n" + strings.Join(transcript, "\n") + "\n"
+	}
+
+	view += "\n"
+	if c.Editing {
+		lines := strings.Split(c.Input+"█", "\n")
+		view += "  > " + strings.Join(lines, "\n    ") + "\n"
+		view += "  [enter: send] [alt+enter: new line] [esc: stop typing]\n"
+	} else {
+		view += "  [i: type] [g: next agent] [c: next context] [n: new conversation]\n"
+		view += "  [x: cancel] [r: retry] [R: regenerate] [[/]: previous/next version] [p: apply code]\n"
+	}
+
+	view += a.viewChatFooter()
+	return view
+}
+
+// viewChatMessage renders one message of the transcript
+func (a App) viewChatMessage(m *conversation.Message) []string {
+	author := "You"
+	if m.Role == api.RoleAssistant {
+		author = "Assistant"
+		for _, ag := range a.Agents {
+			if ag.ID == m.AgentID {
+				author = ag.Name
+			}
+		}
+	}
+
+	if versions := a.Chat.Conversation.Siblings(m.ID); m.Role == api.RoleAssistant && len(versions) > 1 {
+		for i, v := range versions {
+			if v == m {
+				author += fmt.Sprintf(" (version %d/%d)", i+1, len(versions))
+			}
+		}
+	}
+
+	lines := []string{author + ":"}
+	for _, call := range m.ToolCalls {
+		lines = append(lines, "  ⚙ "+call)
+	}
+	for _, line := range strings.Split(formatMarkdown(m.Content), "\n") {
+		lines = append(lines, "  "+line)
+	}
+
+	switch m.Status {
+	case conversation.StatusCancelled:
+		lines = append(lines, "  [cancelled]")
+	case conversation.StatusFailed:
+		lines = append(lines, "  [failed: "+m.Error+"]")
+	}
+	return lines
+}
+
+// viewChatFooter shows the tokens and cost of the conversation so far. While
+// a reply streams its output tokens are estimated from the text received.
+func (a App) viewChatFooter() string {
+	if a.Chat == nil || a.Chat.Conversation == nil {
+		return ""
+	}
+
+	usage, cost := a.Chat.Conversation.Usage()
+	estimate := ""
+	if ag := a.ChatAgent(); ag != nil && ag.Status == agent.StatusWorking {
+		if thread := a.Chat.Conversation.Thread(); len(thread) > 0 {
+			usage.OutputTokens += context.EstimateTokens(thread[len(thread)-1].Content)
+			estimate = "~"
+		}
+	}
+
+	return fmt.Sprintf("  tokens: %d in / %s%d out · cost: $%.4f\n", usage.InputTokens, estimate, usage.OutputTokens, cost)
+}
@@ -0,0 +1,548 @@
+package ui
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/orchestrator"
+	"github.com/yourusername/aui/internal/queue"
+	"github.com/yourusername/aui/internal/redact"
+	"github.com/yourusername/aui/internal/storage"
+	"github.com/yourusername/aui/internal/tools"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// streamProvider streams fixed chunks and records requests
+type streamProvider struct {
+	chunks   []string
+	usage    api.TokenUsage
+	err      error // Returned by SendMessage
+	fail     error // Sent as the last response instead of Done
+	block    bool  // Keeps the stream open until the request is cancelled
+	requests []*api.Request
+}
+
+func (p *streamProvider) Name() string { return "fake" }
+
+func (p *streamProvider) ValidateConfig(map[string]string) error { return nil }
+
+func (p *streamProvider) SendMessage(ctx gocontext.Context, req *api.Request) (<-chan api.Response, error) {
+	p.requests = append(p.requests, req)
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	ch := make(chan api.Response, len(p.chunks)+1)
+	for _, c := range p.chunks {
+		ch <- api.Response{Content: c}
+	}
+	if p.block {
+		go func() {
+			<-ctx.Done()
+			close(ch)
+		}()
+		return ch, nil
+	}
+	if p.fail != nil {
+		ch <- api.Response{Error: p.fail}
+	} else {
+		ch <- api.Response{Done: true, Usage: p.usage}
+	}
+	close(ch)
+	return ch, nil
+}
+
+// chatTestApp returns an app on the Chat tab whose agents use provider
+func chatTestApp(provider api.Provider) App {
+	app := InitialApp()
+	app.Tabs = append(app.Tabs, "Chat")
+	app.ActiveTab = len(app.Tabs) - 1
+	app.Agents[0].Model = "claude-sonnet-4-5"
+	app.NewProvider = func(*agent.Agent) (api.Provider, error) { return provider, nil }
+	return app
+}
+
+// sendChat types a prompt into the chat input, sends it and returns the
+// app and the command that streams the reply
+func sendChat(t *testing.T, app App, prompt string) (App, tea.Cmd) {
+	t.Helper()
+	if app.Chat == nil || !app.Chat.Editing {
+		app = pressKey(app, "i")
+	}
+	app = typeText(app, prompt)
+	model, cmd := app.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	return model.(App), cmd
+}
+
+// confirmSend answers yes to sending a request with redacted secrets
+func confirmSend(t *testing.T, app App) (App, tea.Cmd) {
+	t.Helper()
+	if app.Confirm == nil {
+		t.Fatalf("sending redacted secrets should ask first, status %q", app.Status)
+	}
+	model, cmd := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	return model.(App), cmd
+}
+
+// nextChunk runs a streaming command and applies its message. The spinner
+// command batched with the first chunk is skipped.
+func nextChunk(app App, cmd tea.Cmd) (App, tea.Cmd) {
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		msg = batch[0]()
+	}
+	model, next := app.Update(msg)
+	return model.(App), next
+}
+
+func TestChatStreaming(t *testing.T) {
+	provider := &streamProvider{chunks: []string{"Use ", "**refresh** tokens."}, usage: api.TokenUsage{InputTokens: 1000, OutputTokens: 20}}
+	app := chatTestApp(provider)
+
+	redactor, err := redact.New(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Redactor = redactor
+
+	f := context.NewFile("auth/.env", ".env")
+	f.SetContent("API_TOKEN=abc123\n")
+	app.Contexts[0].AddFile(f)
+
+	app, cmd := sendChat(t, app, "Why does login fail?")
+	if cmd != nil || len(provider.requests) != 0 {
+		t.Fatal("a request with redacted secrets should not be sent before confirming")
+	}
+	if view := app.View(); !strings.Contains(view, "1 secrets will be redacted before sending (1 env_value)") {
+		t.Errorf("View() should ask about the redaction:\n%s", view)
+	}
+	app, cmd = confirmSend(t, app)
+	if cmd == nil {
+		t.Fatalf("sending should start streaming, status %q", app.Status)
+	}
+	if app.Chat.Input != "" {
+		t.Errorf("Input = %q, want cleared after sending", app.Chat.Input)
+	}
+
+	app, cmd = nextChunk(app, cmd)
+	ag := app.Agents[0]
+	if ag.Status != agent.StatusWorking {
+		t.Errorf("agent status = %s while streaming, want working", ag.Status)
+	}
+	view := app.View()
+	if !strings.Contains(view, "Claude is working") || !strings.Contains(view, "~") {
+		t.Error("View() should show a spinner and an output estimate while streaming")
+	}
+
+	for cmd != nil {
+		app, cmd = nextChunk(app, cmd)
+	}
+
+	if ag.Status != agent.StatusReady {
+		t.Errorf("agent status = %s after streaming, want ready", ag.Status)
+	}
+	view = app.View()
+	for _, want := range []string{"You:", "Why does login fail?", "Claude:", "Use refresh tokens.", "tokens: 1000 in / 20 out · cost: $0.0033"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() should contain %q", want)
+		}
+	}
+
+	req := provider.requests[0]
+	if req.Model != "claude-sonnet-4-5" || !strings.Contains(req.Messages[0].Content, "auth/.env") {
+		t.Errorf("request = %+v, want agent model and context files", req)
+	}
+	if strings.Contains(req.Messages[0].Content, "abc123") {
+		t.Error("secret was sent to the provider")
+	}
+	if !strings.Contains(app.Status, "1 secrets were redacted") {
+		t.Errorf("Status = %q, want a redaction warning", app.Status)
+	}
+
+	// Follow-up turns send the history with the context still attached
+	app, _ = sendChat(t, app, "How do I fix it?")
+	app, cmd = confirmSend(t, app)
+	for cmd != nil {
+		app, cmd = nextChunk(app, cmd)
+	}
+	req = provider.requests[1]
+	if len(req.Messages) != 3 || !strings.Contains(req.Messages[0].Content, "auth/.env") || req.Messages[1].Content != "Use **refresh** tokens." {
+		t.Errorf("follow-up request = %+v", req.Messages)
+	}
+	if !strings.Contains(app.View(), "tokens: 2000 in / 40 out") {
+		t.Error("footer should total the conversation")
+	}
+}
+
+func TestChatRedactionDeclined(t *testing.T) {
+	provider := &streamProvider{chunks: []string{"ok"}}
+	app := chatTestApp(provider)
+	redactor, err := redact.New(nil, []string{"abc123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Redactor = redactor
+
+	app, _ = sendChat(t, app, "Is abc123 the token?")
+	if app.Confirm == nil {
+		t.Fatal("a prompt with a secret should ask before sending")
+	}
+	app = pressKey(app, "n")
+	if app.Confirm != nil || len(provider.requests) != 0 || app.Status != "Not sent" {
+		t.Errorf("declining should send nothing, status %q", app.Status)
+	}
+	if app.Chat.Conversation != nil && len(app.Chat.Conversation.Thread()) != 0 {
+		t.Errorf("declined prompt should be taken back out, thread has %d messages", len(app.Chat.Conversation.Thread()))
+	}
+
+	// Broken redaction rules refuse sending instead of sending in the clear
+	app.Redactor, app.RedactionErr = nil, errors.New("invalid redaction pattern x")
+	app, cmd := sendChat(t, app, "Hello")
+	if cmd != nil || app.Confirm != nil || len(provider.requests) != 0 || !strings.Contains(app.Status, "redaction config is invalid") {
+		t.Errorf("sending with broken redaction should be refused, status %q", app.Status)
+	}
+}
+
+func TestChatErrors(t *testing.T) {
+	app := InitialApp()
+	app.Tabs = append(app.Tabs, "Chat")
+	app.ActiveTab = len(app.Tabs) - 1
+
+	app, _ = sendChat(t, app, "hi")
+	if !strings.Contains(app.Status, "no configuration loaded") {
+		t.Errorf("Status = %q, want a configuration error", app.Status)
+	}
+	if app.Chat.Input != "hi" {
+		t.Error("a prompt that was not sent should stay in the input")
+	}
+
+	provider := &streamProvider{err: errors.New("connection refused")}
+	app.NewProvider = func(*agent.Agent) (api.Provider, error) { return provider, nil }
+	model, cmd := app.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	app = model.(App)
+	for cmd != nil {
+		app, cmd = nextChunk(app, cmd)
+	}
+	if app.Agents[0].Status != agent.StatusError || !strings.Contains(app.View(), "[failed: connection refused]") {
+		t.Errorf("failed send: status %s, view %q", app.Agents[0].Status, app.View())
+	}
+
+	provider.err = nil
+	provider.chunks = []string{"Partial"}
+	provider.fail = errors.New("stream reset")
+	app, cmd = sendChat(t, app, "again")
+	for cmd != nil {
+		app, cmd = nextChunk(app, cmd)
+	}
+	if app.Agents[0].Status != agent.StatusError || !strings.Contains(app.Status, "stream reset") {
+		t.Errorf("stream error: status %s, %q", app.Agents[0].Status, app.Status)
+	}
+	if !strings.Contains(app.View(), "Partial") {
+		t.Error("text received before an error should be kept")
+	}
+}
+
+func TestChatRateLimitWait(t *testing.T) {
+	app := chatTestApp(&streamProvider{chunks: []string{"Hello"}})
+	app, cmd := sendChat(t, app, "hi")
+	ag := app.Agents[0]
+
+	model, _ := app.Update(chatChunkMsg{Agent: ag, Reply: app.Chat.Conversation.Thread()[1], Response: api.Response{Wait: 12 * time.Second, WaitReason: "rate limit"}})
+	app = model.(App)
+	if ag.CurrentTask != "retrying after rate limit (12s)" || !strings.Contains(app.View(), "retrying after rate limit (12s)") {
+		t.Errorf("CurrentTask = %q, want the rate limit wait shown", ag.CurrentTask)
+	}
+
+	for cmd != nil {
+		app, cmd = nextChunk(app, cmd)
+	}
+	if ag.Status != agent.StatusReady || ag.Waiting() {
+		t.Errorf("agent = %s waiting %v after the reply, want ready", ag.Status, ag.Waiting())
+	}
+}
+
+func TestChatSelection(t *testing.T) {
+	app := chatTestApp(&streamProvider{})
+
+	if !strings.Contains(app.View(), "Claude (claude-sonnet-4-5) with context bug-fix-auth") {
+		t.Errorf("View() should show the agent and context, got %q", app.View())
+	}
+
+	app = pressKey(app, "g")
+	app = pressKey(app, "c")
+	if app.ChatAgent().Name != "Gemini" || app.ChatContext() != nil {
+		t.Errorf("g/c should select the next agent and context")
+	}
+	if !strings.Contains(app.View(), "Gemini (gemini-1.5-pro) with no context") {
+		t.Error("View() should show the new selection")
+	}
+
+	// Global keys are typed while the input has focus
+	app = pressKey(app, "i")
+	app = typeText(app, "hlq")
+	model, _ := app.Update(tea.KeyMsg{Type: tea.KeyEnter, Alt: true})
+	app = model.(App)
+	app = typeText(app, "x")
+	if app.Chat.Input != "hlq\nx" || app.Quitting {
+		t.Errorf("Input = %q, quitting %v", app.Chat.Input, app.Quitting)
+	}
+	app = pressKey(app, "esc")
+	app = pressKey(app, "n")
+	if app.Chat.Editing || app.Chat.Conversation != nil {
+		t.Error("esc should leave the input and n start a new conversation")
+	}
+}
+
+func TestChatCancel(t *testing.T) {
+	provider := &streamProvider{chunks: []string{"Thinking"}, block: true}
+	app := chatTestApp(provider)
+
+	app, cmd := sendChat(t, app, "Explain everything")
+	app, cmd = nextChunk(app, cmd)
+	app = pressKey(app, "esc")
+
+	if err := app.CancelChat(); err != nil {
+		t.Fatalf("CancelChat() error = %v", err)
+	}
+	for cmd != nil {
+		app, cmd = nextChunk(app, cmd)
+	}
+
+	ag := app.Agents[0]
+	if ag.Status != agent.StatusCancelled || ag.LastError != "" {
+		t.Errorf("agent = %s %q, want cancelled without an error", ag.Status, ag.LastError)
+	}
+	view := app.View()
+	if !strings.Contains(view, "Thinking") || !strings.Contains(view, "[cancelled]") {
+		t.Errorf("View() should keep the partial reply and mark it cancelled")
+	}
+	if err := app.CancelChat(); err == nil {
+		t.Error("CancelChat() with nothing streaming should fail")
+	}
+}
+
+func TestChatRetryAndRegenerate(t *testing.T) {
+	provider := &streamProvider{chunks: []string{"Par"}, fail: errors.New("overloaded")}
+	app := chatTestApp(provider)
+
+	app, cmd := sendChat(t, app, "hi")
+	for cmd != nil {
+		app, cmd = nextChunk(app, cmd)
+	}
+	app = pressKey(app, "esc")
+	if !strings.Contains(app.View(), "[failed: overloaded]") {
+		t.Error("View() should mark the failed reply")
+	}
+
+	if _, err := app.RegenerateChat(); err == nil {
+		t.Error("RegenerateChat() should refuse a failed reply")
+	}
+
+	provider.chunks, provider.fail = []string{"Hello"}, nil
+	model, cmd := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	app = model.(App)
+	for cmd != nil {
+		app, cmd = nextChunk(app, cmd)
+	}
+	if app.Agents[0].Status != agent.StatusReady {
+		t.Errorf("agent status = %s after retry, want ready", app.Agents[0].Status)
+	}
+	if len(provider.requests[1].Messages) != 1 {
+		t.Errorf("retry request = %+v, want only the question", provider.requests[1].Messages)
+	}
+
+	if _, err := app.RetryChat(); err == nil {
+		t.Error("RetryChat() should refuse a finished reply")
+	}
+
+	provider.chunks = []string{"Hi there"}
+	model, cmd = app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("R")})
+	app = model.(App)
+	for cmd != nil {
+		app, cmd = nextChunk(app, cmd)
+	}
+
+	conv := app.Chat.Conversation
+	thread := conv.Thread()
+	if len(thread) != 2 || thread[1].Content != "Hi there" || len(conv.Siblings(thread[1].ID)) != 3 {
+		t.Fatalf("thread = %v, want the newest of 3 versions", thread)
+	}
+	if !strings.Contains(app.View(), "(version 3/3)") {
+		t.Error("View() should show which version is shown")
+	}
+
+	app = pressKey(app, "[")
+	if reply := conv.Thread()[1]; reply.Content != "Hello" {
+		t.Errorf("[ shows %q, want the previous version", reply.Content)
+	}
+	app = pressKey(app, "]")
+	app = pressKey(app, "]")
+	if reply := conv.Thread()[1]; reply.Content != "Par" {
+		t.Errorf("] shows %q, want to wrap around to the first version", reply.Content)
+	}
+}
+
+func TestChatQueued(t *testing.T) {
+	provider := &streamProvider{chunks: []string{"Hello"}}
+	app := chatTestApp(provider)
+	cfg := config.NewDefault()
+	cfg.Queue.Concurrency = map[string]int{"anthropic": 1}
+	app.Orchestrator = orchestrator.New(cfg, nil, app.Catalog)
+
+	// Another request holds the only anthropic slot
+	blockerCtx, release := gocontext.WithCancel(gocontext.Background())
+	blocker := queue.NewJob("other", "anthropic", &api.Request{Model: "claude-sonnet-4-5"}, 10)
+	blocked := app.Orchestrator.Submit(blockerCtx, blocker, &streamProvider{block: true})
+	for app.Orchestrator.Queue.Position(blocker) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	app, cmd := sendChat(t, app, "hi")
+	if !strings.Contains(app.View(), "queued (position 1)") {
+		t.Errorf("Chat view should show the queue position, got %q", app.View())
+	}
+	app = pressKey(app, "esc")
+	app.ActiveTab = 0
+	view := app.View()
+	if !strings.Contains(view, "queued: position 1") || !strings.Contains(view, "anthropic: 1 queued, 1 running") {
+		t.Errorf("Agents view should show the queue, got %q", view)
+	}
+
+	release()
+	api.Collect(blocked)
+	for cmd != nil {
+		app, cmd = nextChunk(app, cmd)
+	}
+	if reply := app.Chat.Conversation.Thread()[1]; reply.Content != "Hello" || app.Agents[0].Status != agent.StatusReady {
+		t.Errorf("reply = %q, agent %s; want the reply once the slot frees", reply.Content, app.Agents[0].Status)
+	}
+}
+
+func TestChatQuitInterruptsJob(t *testing.T) {
+	app := chatTestApp(&streamProvider{chunks: []string{"Hel"}, block: true})
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	app.Store = store
+	for _, ctx := range app.Contexts {
+		if err := store.SaveContext(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	app.Orchestrator = orchestrator.New(config.NewDefault(), store, app.Catalog)
+
+	app, _ = sendChat(t, app, "hi")
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		if running, _ := store.ListJobs(queue.StatusRunning); len(running) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the chat job should be running, status %q", app.Status)
+		}
+	}
+	app = pressKey(app, "esc")
+	app = pressKey(app, "q")
+
+	if cancelled, _ := store.ListJobs(queue.StatusCancelled); len(cancelled) != 1 {
+		t.Fatalf("cancelled jobs = %d after quitting, want the chat job", len(cancelled))
+	}
+	resumed, err := orchestrator.New(config.NewDefault(), store, app.Catalog).Resume(func(*queue.Job) (api.Provider, error) {
+		t.Error("an interrupted chat job should not be sent again")
+		return &streamProvider{}, nil
+	})
+	if err != nil || resumed != 0 {
+		t.Errorf("Resume() = %d, %v; want nothing resumed", resumed, err)
+	}
+}
+
+// toolCallProvider makes tool calls, then answers
+type toolCallProvider struct {
+	calls    []api.ToolCall
+	requests []*api.Request
+}
+
+func (p *toolCallProvider) Name() string { return "fake" }
+
+func (p *toolCallProvider) ValidateConfig(map[string]string) error { return nil }
+
+func (p *toolCallProvider) SendMessage(ctx gocontext.Context, req *api.Request) (<-chan api.Response, error) {
+	copied := *req
+	p.requests = append(p.requests, &copied)
+
+	ch := make(chan api.Response, 2)
+	if len(p.requests) == 1 {
+		ch <- api.Response{Content: "Let me check."}
+		ch <- api.Response{Done: true, Usage: api.TokenUsage{InputTokens: 100}, ToolCalls: p.calls}
+	} else {
+		ch <- api.Response{Content: "The token is set.", Done: true, Usage: api.TokenUsage{InputTokens: 150}}
+	}
+	close(ch)
+	return ch, nil
+}
+
+// serverTool stands in for a tool of an MCP server
+type serverTool struct{}
+
+func (serverTool) Spec() api.Tool {
+	return api.Tool{Name: "docs__search", Parameters: map[string]interface{}{"type": "object"}}
+}
+
+func (serverTool) Run(gocontext.Context, json.RawMessage) (string, error) {
+	return "", nil
+}
+
+func TestChatTools(t *testing.T) {
+	provider := &toolCallProvider{calls: []api.ToolCall{
+		{ID: "1", Name: "context_file", Input: []byte(`{"path":"auth/.env"}`)},
+	}}
+	app := chatTestApp(provider)
+	app.Config = config.NewDefault()
+	app.Root = t.TempDir()
+	redactor, err := redact.New(nil, []string{"abc123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Redactor = redactor
+	app.MCPTools = []tools.Tool{serverTool{}}
+
+	f := context.NewFile("auth/.env", ".env")
+	f.SetContent("API_TOKEN=abc123\n")
+	app.Contexts[0].AddFile(f)
+
+	app, _ = sendChat(t, app, "Is the token set?")
+	app, cmd := confirmSend(t, app)
+	app = runPipelineSteps(app, cmd)
+
+	if len(provider.requests) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(provider.requests))
+	}
+	if tools := provider.requests[0].Tools; len(tools) != 5 || tools[3].Name != "context_file" || tools[4].Name != "docs__search" {
+		t.Errorf("tools offered = %+v, want no shell tool unless enabled", tools)
+	}
+	last := provider.requests[1].Messages[len(provider.requests[1].Messages)-1]
+	if len(last.ToolResults) != 1 || last.ToolResults[0].IsError || strings.Contains(last.ToolResults[0].Content, "abc123") {
+		t.Errorf("tool results = %+v, want the file with the secret redacted", last.ToolResults)
+	}
+
+	reply := app.lastReply()
+	if reply.Content != "Let me check.\n\nThe token is set." || reply.Usage.InputTokens != 250 {
+		t.Errorf("reply = %q with %+v", reply.Content, reply.Usage)
+	}
+	if app.Agents[0].Status != agent.StatusReady {
+		t.Errorf("agent status = %s, want ready", app.Agents[0].Status)
+	}
+	if view := app.View(); !strings.Contains(view, `⚙ context_file {"path":"auth/.env"}`) {
+		t.Errorf("View() should list the tool call:\n%s", view)
+	}
+}
@@ -0,0 +1,527 @@
+package ui
+
+import (
+	gocontext "context"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/judge"
+	"github.com/yourusername/aui/internal/orchestrator"
+	"github.com/yourusername/aui/internal/render"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// compareAnswerLines is how many lines of each answer the Compare tab shows
+const compareAnswerLines = 12
+
+// Compare is the state of the Compare tab, which sends one prompt to every
+// agent and has a judge score their responses
+type Compare struct {
+	Context     int // Index into App.Contexts, or -1 for no context
+	Rubric      int // Index into the configured rubrics
+	Input       string
+	Editing     bool                // The input has focus and receives all keys
+	Comparisons []*judge.Comparison // Oldest first
+	Selected    int                 // Index into Comparisons
+	Judging     *agent.Agent        // The judge while it scores
+}
+
+// compareMsg delivers the responses of a fan-out
+type compareMsg struct {
+	Comparison *judge.Comparison
+	Agents     []*agent.Agent
+}
+
+// verdictMsg delivers a judge's verdict on a comparison
+type verdictMsg struct {
+	Comparison *judge.Comparison
+	Verdict    *judge.Verdict
+	Err        error
+}
+
+// compare returns the Compare tab state, creating it on first use
+func (a *App) compare() *Compare {
+	if a.Compare == nil {
+		a.Compare = &Compare{Context: a.SelectedContext}
+		if a.Compare.Context >= len(a.Contexts) {
+			a.Compare.Context = -1
+		}
+		// Start with the rubric the judge uses by default
+		for i, r := range a.rubrics() {
+			if a.Config != nil && r.Name == a.Config.Judge.Rubric {
+				a.Compare.Rubric = i
+			}
+		}
+	}
+	return a.Compare
+}
+
+// loadComparisons reads earlier comparisons from storage the first time the
+// Compare tab is shown
+func (a *App) loadComparisons() {
+	c := a.compare()
+	if c.Comparisons != nil || a.Store == nil {
+		return
+	}
+	comparisons, err := a.Store.ListComparisons()
+	if err != nil {
+		a.Status = fmt.Sprintf("Failed to load comparisons: %v", err)
+		return
+	}
+	c.Comparisons = comparisons
+	c.Selected = len(comparisons) - 1
+}
+
+// CompareContext returns the context sent with compared prompts, or nil
+func (a *App) CompareContext() *context.Context {
+	c := a.compare()
+	if c.Context < 0 || c.Context >= len(a.Contexts) {
+		return nil
+	}
+	return a.Contexts[c.Context]
+}
+
+// SelectedComparison returns the comparison shown, or nil
+func (a *App) SelectedComparison() *judge.Comparison {
+	c := a.compare()
+	if c.Selected < 0 || c.Selected >= len(c.Comparisons) {
+		return nil
+	}
+	return c.Comparisons[c.Selected]
+}
+
+// rubrics returns the configured rubrics
+func (a *App) rubrics() []judge.Rubric {
+	if a.Config == nil {
+		return judge.Rubrics(config.NewDefault())
+	}
+	return judge.Rubrics(a.Config)
+}
+
+// CompareRubric returns the rubric the judge scores with
+func (a *App) CompareRubric() judge.Rubric {
+	rubrics := a.rubrics()
+	c := a.compare()
+	if c.Rubric < 0 || c.Rubric >= len(rubrics) {
+		return judge.Rubric{}
+	}
+	return rubrics[c.Rubric]
+}
+
+// orchestrator returns the orchestrator, creating one without a store for
+// apps built without dependencies
+func (a *App) orchestrator() *orchestrator.Orchestrator {
+	if a.Orchestrator == nil {
+		cfg := a.Config
+		if cfg == nil {
+			cfg = config.NewDefault()
+		}
+		a.Orchestrator = orchestrator.New(cfg, a.Store, a.Catalog)
+	}
+	return a.Orchestrator
+}
+
+// updateCompare handles keys for the Compare tab
+func (a App) updateCompare(msg tea.KeyMsg) (App, tea.Cmd) {
+	c := a.compare()
+
+	if c.Editing {
+		return a.updateCompareInput(msg)
+	}
+
+	switch msg.String() {
+	case "c":
+		// Cycle through the contexts and then no context
+		c.Context++
+		if c.Context >= len(a.Contexts) {
+			c.Context = -1
+		}
+	case "u":
+		if rubrics := a.rubrics(); len(rubrics) > 0 {
+			c.Rubric = (c.Rubric + 1) % len(rubrics)
+		}
+	case "s":
+		cmd, err := a.JudgeComparison()
+		if err != nil {
+			a.Status = fmt.Sprintf("Error: %v", err)
+		}
+		return a, cmd
+	case "[":
+		if c.Selected > 0 {
+			c.Selected--
+		}
+	case "]":
+		if c.Selected < len(c.Comparisons)-1 {
+			c.Selected++
+		}
+	case "i", "enter":
+		c.Editing = true
+	}
+	return a, nil
+}
+
+// updateCompareInput handles keys while the prompt input has focus
+func (a App) updateCompareInput(msg tea.KeyMsg) (App, tea.Cmd) {
+	c := a.Compare
+
+	switch msg.Type {
+	case tea.KeyEnter:
+		if msg.Alt {
+			c.Input += "\n"
+			break
+		}
+		prompt := strings.TrimSpace(c.Input)
+		if prompt == "" {
+			break
+		}
+		cmd, err := a.SendCompare(prompt)
+		if err != nil {
+			a.Status = fmt.Sprintf("Error: %v", err)
+			break
+		}
+		c.Input = ""
+		c.Editing = false
+		return a, cmd
+	case tea.KeyEsc:
+		c.Editing = false
+	case tea.KeyBackspace:
+		if len(c.Input) > 0 {
+			runes := []rune(c.Input)
+			c.Input = string(runes[:len(runes)-1])
+		}
+	case tea.KeySpace:
+		c.Input += " "
+	case tea.KeyRunes:
+		c.Input += string(msg.Runes)
+	}
+	return a, nil
+}
+
+// SendCompare sends prompt to every agent that is not working and returns
+// the command that waits for all of their responses. The context is packed
+// to the smallest budget among the agents so that each can take it.
+func (a *App) SendCompare(prompt string) (tea.Cmd, error) {
+	var agents []*agent.Agent
+	for _, ag := range a.Agents {
+		if ag.Status != agent.StatusWorking {
+			agents = append(agents, ag)
+		}
+	}
+	if len(agents) < 2 {
+		return nil, fmt.Errorf("need at least two free agents to compare")
+	}
+
+	ctx := a.CompareContext()
+	contextID := ""
+	if ctx != nil {
+		contextID = ctx.ID
+	}
+	request, redactions, err := a.sharedRequest(agents, ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := request(prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := judge.NewComparison(prompt, contextID)
+	for _, ag := range agents {
+		ag.AssignTask("compare: " + truncate(prompt, 40))
+	}
+
+	a.Status = ""
+	if redactions > 0 {
+		a.Status = fmt.Sprintf("⚠ %d secrets were redacted before sending", redactions)
+	}
+
+	// The requests are made from copies, since the agents change on the UI
+	// goroutine while they work
+	snapshots := make([]*agent.Agent, len(agents))
+	for i, ag := range agents {
+		snapshot := *ag
+		snapshots[i] = &snapshot
+	}
+
+	o := a.orchestrator()
+	providerFor := a.providerFor
+	fanOut := func() tea.Msg {
+		comparison.Candidates = o.FanOut(gocontext.Background(), snapshots, req, providerFor)
+		return compareMsg{Comparison: comparison, Agents: agents}
+	}
+	return tea.Batch(fanOut, a.startSpinner()), nil
+}
+
+// sharedRequest returns a function that builds the request for a prompt with
+// a context attached, and how many secrets were redacted from the context.
+// The context is prepared once, packed to the smallest budget among agents
+// so that each of them can take it. Prompts are redacted too, since they may
+// quote secrets.
+func (a *App) sharedRequest(agents []*agent.Agent, ctx *context.Context) (func(prompt string) (*api.Request, error), int, error) {
+	if err := a.checkRedaction(); err != nil {
+		return nil, 0, err
+	}
+	if ctx == nil {
+		ctx = context.NewContext("prompt", "")
+	} else if a.Store != nil {
+		var err error
+		if ctx, err = a.Store.GetContext(ctx.ID); err != nil {
+			return nil, 0, fmt.Errorf("failed to load context: %w", err)
+		}
+	}
+
+	budget := a.chatBudget(agents[0], 0)
+	for _, ag := range agents[1:] {
+		budget = min(budget, a.chatBudget(ag, 0))
+	}
+	prepared, err := render.Prepare(a.Store, a.Redactor, ctx, budget)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to prepare context: %w", err)
+	}
+
+	opts := render.Options{Format: a.chatFormat(agents[0]), LineNumbers: a.Config != nil && a.Config.Context.LineNumbers}
+	redactor := a.Redactor
+	return func(prompt string) (*api.Request, error) {
+		prompt, _ = redactor.Redact("prompt", prompt)
+		return render.Request(prepared.Context, prompt, opts)
+	}, len(prepared.Redactions.Findings), nil
+}
+
+// handleCompare frees the agents of a fan-out and shows their responses
+func (a App) handleCompare(msg compareMsg) (App, tea.Cmd) {
+	c := a.compare()
+
+	failed := 0
+	for i, ag := range msg.Agents {
+		if err := msg.Comparison.Candidates[i].Error; err != "" {
+			ag.SetError(err)
+			failed++
+		} else {
+			ag.CompleteTask()
+		}
+		a.saveAgent(ag)
+	}
+
+	c.Comparisons = append(c.Comparisons, msg.Comparison)
+	c.Selected = len(c.Comparisons) - 1
+
+	a.Status = fmt.Sprintf("%d agents answered", len(msg.Agents)-failed)
+	if failed > 0 {
+		a.Status += fmt.Sprintf(", %d failed", failed)
+	}
+	if a.Store != nil {
+		if err := a.Store.SaveComparison(msg.Comparison); err != nil {
+			a.Status = fmt.Sprintf("Failed to save comparison: %v", err)
+		}
+	}
+	return a, nil
+}
+
+// JudgeComparison asks the configured judge to score the shown comparison
+// with the selected rubric
+func (a *App) JudgeComparison() (tea.Cmd, error) {
+	c := a.compare()
+	comparison := a.SelectedComparison()
+	switch {
+	case comparison == nil:
+		return nil, fmt.Errorf("nothing to judge (type a prompt to compare first)")
+	case c.Judging != nil:
+		return nil, fmt.Errorf("%s is still judging", c.Judging.Name)
+	case a.Config == nil || a.Config.Judge.Agent == "":
+		return nil, fmt.Errorf("no judge configured (set judge.agent in the config)")
+	}
+
+	judgeAgent, err := orchestrator.ResolveAgent(a.Config.Judge.Agent, a.Agents, a.Catalog)
+	if err != nil {
+		return nil, fmt.Errorf("invalid judge: %w", err)
+	}
+	if judgeAgent.Status == agent.StatusWorking {
+		return nil, fmt.Errorf("%s is still working", judgeAgent.Name)
+	}
+	provider, err := a.providerFor(judgeAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	rubric := a.CompareRubric()
+	c.Judging = judgeAgent
+	judgeAgent.AssignTask("judge: " + truncate(comparison.Prompt, 40))
+	a.Status = ""
+
+	o := a.orchestrator()
+	snapshot := *judgeAgent
+	score := func() tea.Msg {
+		verdict, err := o.Judge(gocontext.Background(), &snapshot, rubric, comparison, provider)
+		return verdictMsg{Comparison: comparison, Verdict: verdict, Err: err}
+	}
+	return tea.Batch(score, a.startSpinner()), nil
+}
+
+// handleVerdict keeps a judge's verdict with its comparison
+func (a App) handleVerdict(msg verdictMsg) (App, tea.Cmd) {
+	c := a.compare()
+	judgeAgent := c.Judging
+	c.Judging = nil
+
+	// A judge named by model ID is not a saved agent
+	if msg.Err != nil {
+		judgeAgent.SetError(msg.Err.Error())
+		a.Status = fmt.Sprintf("Judging failed: %v", msg.Err)
+	} else {
+		judgeAgent.CompleteTask()
+	}
+	if judgeAgent.ID != "" {
+		a.saveAgent(judgeAgent)
+	}
+	if msg.Err != nil {
+		return a, nil
+	}
+
+	msg.Comparison.Verdict = msg.Verdict
+	pick := msg.Comparison.Candidates[msg.Verdict.Pick]
+	a.Status = fmt.Sprintf("%s picked %s", judgeAgent.Name, pick.Agent)
+	if a.Store != nil {
+		if err := a.Store.SaveComparison(msg.Comparison); err != nil {
+			a.Status = fmt.Sprintf("Failed to save verdict: %v", err)
+		}
+	}
+	return a, nil
+}
+
+// viewCompare renders the Compare tab
+func (a App) viewCompare() string {
+	c := a.Compare
+	if c == nil {
+		c = &Compare{Context: a.SelectedContext}
+	}
+
+	view := "Compare:\n"
+	contextName := "no context"
+	if c.Context >= 0 && c.Context < len(a.Contexts) {
+		contextName = "context " + a.Contexts[c.Context].Name
+	}
+	judgeName := "none (set judge.agent in the config)"
+	if a.Config != nil && a.Config.Judge.Agent != "" {
+		judgeName = a.Config.Judge.Agent
+	}
+	rubric := a.CompareRubric()
+	view += fmt.Sprintf("  %d agents with %s · judge %s · rubric %s\n", len(a.Agents), contextName, judgeName, rubric.Name)
+
+	working := 0
+	for _, ag := range a.Agents {
+		if ag.Status == agent.StatusWorking && strings.HasPrefix(ag.CurrentTask, "compare: ") {
+			working++
+		}
+	}
+	spinner := spinnerFrames[a.Spinner%len(spinnerFrames)]
+	if working > 0 {
+		view += fmt.Sprintf("\n  %s waiting for %d agents...\n", spinner, working)
+	}
+	if c.Judging != nil {
+		view += fmt.Sprintf("\n  %s %s is judging...\n", spinner, c.Judging.Name)
+	}
+
+	if c.Selected >= 0 && c.Selected < len(c.Comparisons) {
+		view += a.viewComparison(c.Comparisons[c.Selected], c.Selected+1, len(c.Comparisons))
+	} else if working == 0 {
+		view += "\n  No comparisons yet. Press 'i' to send a prompt to every agent.\n"
+	}
+
+	view += "\n"
+	if c.Editing {
+		lines := strings.Split(c.Input+"█", "\n")
+		view += "  > " + strings.Join(lines, "\n    ") + "\n"
+		view += "  [enter: send to all agents] [alt+enter: new line] [esc: stop typing]\n"
+	} else {
+		view += "  [i: type] [c: next context] [u: next rubric] [s: score with judge] [[/]: previous/next comparison]\n"
+	}
+	return view
+}
+
+// viewComparison renders the responses of a comparison and their scores
+func (a App) viewComparison(comparison *judge.Comparison, n, total int) string {
+	view := fmt.Sprintf("\n  Comparison %d/%d: %s\n", n, total, truncate(comparison.Prompt, 60))
+	v := comparison.Verdict
+
+	for i, cand := range comparison.Candidates {
+		header := fmt.Sprintf("%s (%s)", cand.Agent, cand.Model)
+		if label := judge.Label(comparison.Candidates, i); label != "" {
+			header = label + ". " + header
+		}
+
+		if cand.Error != "" {
+			view += fmt.Sprintf("\n  %s [failed: %s]\n", header, cand.Error)
+			continue
+		}
+		header += fmt.Sprintf(" · %d in / %d out · $%.4f", cand.Usage.InputTokens, cand.Usage.OutputTokens, cand.Cost)
+		if v != nil {
+			if s := v.ScoreFor(i); s != nil {
+				header += fmt.Sprintf(" · score %.1f", s.Total)
+			}
+			if v.Pick == i {
+				header += " ★ picked"
+			}
+		}
+		view += "\n  " + header + "\n"
+
+		if v != nil {
+			if s := v.ScoreFor(i); s != nil {
+				view += "    " + viewCriteria(v.Rubric, s, a.rubrics()) + "\n"
+			}
+		}
+
+		lines := strings.Split(formatMarkdown(strings.TrimSpace(cand.Answer)), "\n")
+		if len(lines) > compareAnswerLines {
+			more := len(lines) - compareAnswerLines
+			lines = append(lines[:compareAnswerLines], fmt.Sprintf("… (%d more lines)", more))
+		}
+		for _, line := range lines {
+			view += "    │ " + line + "\n"
+		}
+	}
+
+	if v != nil {
+		view += fmt.Sprintf("\n  Judge %s (%s) with rubric %s picked %s", v.Judge, v.Model, v.Rubric, comparison.Candidates[v.Pick].Agent)
+		if v.Reason != "" {
+			view += ": " + v.Reason
+		}
+		view += "\n"
+	}
+	return view
+}
+
+// viewCriteria shows a candidate's score on each criterion, in the order of
+// the rubric when it is still configured, and the judge's comment
+func viewCriteria(rubricName string, s *judge.Score, rubrics []judge.Rubric) string {
+	var names []string
+	for _, r := range rubrics {
+		if r.Name == rubricName {
+			for _, c := range r.Criteria {
+				if _, ok := s.Criteria[c.Name]; ok {
+					names = append(names, c.Name)
+				}
+			}
+		}
+	}
+	if len(names) == 0 {
+		for name := range s.Criteria {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s %g", name, s.Criteria[name])
+	}
+	line := strings.Join(parts, " · ")
+	if s.Comment != "" {
+		line += " — " + s.Comment
+	}
+	return line
+}
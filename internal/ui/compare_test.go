@@ -0,0 +1,132 @@
+package ui
+
+import (
+	gocontext "context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// modelProvider answers each model with a fixed reply
+type modelProvider struct {
+	replies map[string]string
+}
+
+func (p *modelProvider) Name() string { return "fake" }
+
+func (p *modelProvider) ValidateConfig(map[string]string) error { return nil }
+
+func (p *modelProvider) SendMessage(ctx gocontext.Context, req *api.Request) (<-chan api.Response, error) {
+	ch := make(chan api.Response, 1)
+	ch <- api.Response{Content: p.replies[req.Model], Done: true, Usage: api.TokenUsage{InputTokens: 100, OutputTokens: 10}}
+	close(ch)
+	return ch, nil
+}
+
+// compareTestApp returns an app on the Compare tab whose agents use provider
+func compareTestApp(provider api.Provider) App {
+	app := InitialApp()
+	app.Tabs = append(app.Tabs, "Compare")
+	app.ActiveTab = len(app.Tabs) - 1
+	app.Agents[0].Model = "claude-sonnet-4-5"
+	app.Agents[1].Model = "gemini-2.5-pro"
+	app.Config = config.NewDefault()
+	app.NewProvider = func(*agent.Agent) (api.Provider, error) { return provider, nil }
+	return app
+}
+
+// runCmd runs a command, skipping the spinner batched with it, and applies
+// its message
+func runCmd(app App, cmd tea.Cmd) App {
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		msg = batch[0]()
+	}
+	model, _ := app.Update(msg)
+	return model.(App)
+}
+
+func TestCompareAndJudge(t *testing.T) {
+	provider := &modelProvider{replies: map[string]string{
+		"claude-sonnet-4-5": "Use a mutex.",
+		"gemini-2.5-pro":    "Use a channel.",
+		"gpt-4o":            `{"scores": [{"candidate": "A", "criteria": {"correctness": 6}}, {"candidate": "B", "criteria": {"correctness": 9, "minimal diff": 9, "explains tradeoffs": 6}, "comment": "idiomatic"}], "pick": "B", "reason": "no shared state"}`,
+	}}
+	app := compareTestApp(provider)
+
+	app = pressKey(app, "i")
+	app = typeText(app, "fix the race")
+	model, cmd := app.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	app = model.(App)
+	if cmd == nil {
+		t.Fatalf("sending should fan out, status %q", app.Status)
+	}
+	if app.Agents[0].Status != agent.StatusWorking || !strings.Contains(app.View(), "waiting for 2 agents") {
+		t.Error("both agents should work while the fan-out runs")
+	}
+
+	app = runCmd(app, cmd)
+	for _, ag := range app.Agents {
+		if ag.Status != agent.StatusReady {
+			t.Errorf("%s status = %s after the fan-out, want ready", ag.Name, ag.Status)
+		}
+	}
+	view := app.View()
+	for _, want := range []string{"Comparison 1/1: fix the race", "A. Claude (claude-sonnet-4-5)", "Use a mutex.", "B. Gemini", "Use a channel."} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() missing %q:\n%s", want, view)
+		}
+	}
+
+	// Without a judge configured, scoring explains how to set one
+	app = pressKey(app, "s")
+	if !strings.Contains(app.Status, "judge.agent") {
+		t.Errorf("Status = %q, want a hint to configure a judge", app.Status)
+	}
+
+	app.Config.Judge.Agent = "gpt-4o"
+	model, cmd = app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	app = model.(App)
+	if cmd == nil {
+		t.Fatalf("scoring should ask the judge, status %q", app.Status)
+	}
+	app = runCmd(app, cmd)
+
+	c := app.SelectedComparison()
+	if c.Verdict == nil || c.Verdict.Pick != 1 || c.Verdict.Judge != "gpt-4o" {
+		t.Fatalf("Verdict = %+v, want Gemini picked", c.Verdict)
+	}
+	view = app.View()
+	for _, want := range []string{"score 8.0 ★ picked", "correctness 9 · minimal diff 9 · explains tradeoffs 6 — idiomatic", "picked Gemini: no shared state"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() missing %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestCompareNeedsTwoAgents(t *testing.T) {
+	app := compareTestApp(&modelProvider{})
+	app.Agents[1].AssignTask("busy")
+
+	if _, err := app.SendCompare("fix the race"); err == nil {
+		t.Error("SendCompare() should need two free agents")
+	}
+}
+
+func TestCompareRubrics(t *testing.T) {
+	app := compareTestApp(&modelProvider{})
+	app.Config.Rubrics["security"] = []config.Criterion{{Name: "safe"}}
+	app.Config.Judge.Rubric = "security"
+
+	if got := app.CompareRubric().Name; got != "security" {
+		t.Errorf("CompareRubric() = %s, want the judge's default", got)
+	}
+	app = pressKey(app, "u")
+	if got := app.CompareRubric().Name; got != "default" {
+		t.Errorf("CompareRubric() = %s after u, want default", got)
+	}
+}
@@ -0,0 +1,36 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Confirm asks the user a yes or no question before an action. While it is
+// open it receives all keys except ctrl+c.
+type Confirm struct {
+	Question string
+	Yes      func(a *App) tea.Cmd // Runs the action
+	No       func(a *App)         // Undoes what was prepared for the action; may be nil
+}
+
+// updateConfirm handles keys while a confirmation is open
+func (a App) updateConfirm(msg tea.KeyMsg) (App, tea.Cmd) {
+	c := a.Confirm
+
+	switch msg.String() {
+	case "y":
+		a.Confirm = nil
+		cmd := c.Yes(&a)
+		return a, cmd
+	case "n", "esc":
+		a.Confirm = nil
+		if c.No != nil {
+			c.No(&a)
+		}
+	}
+	return a, nil
+}
+
+// viewConfirm renders the open confirmation
+func (a App) viewConfirm() string {
+	return "\n⚠ " + a.Confirm.Question + "\n  [y: yes] [n: no]\n"
+}
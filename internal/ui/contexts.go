@@ -0,0 +1,196 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/templates"
+)
+
+// updateContexts handles keys for the Contexts tab
+func (a App) updateContexts(msg tea.KeyMsg) (App, tea.Cmd) {
+	switch msg.String() {
+	case "j", "down":
+		if a.SelectedContext < len(a.Contexts)-1 {
+			a.SelectedContext++
+		}
+	case "k", "up":
+		if a.SelectedContext > 0 {
+			a.SelectedContext--
+		}
+	case "n":
+		all, err := templates.LoadAll(templates.Dirs(a.Root)...)
+		if err != nil {
+			a.Status = fmt.Sprintf("Error: %v", err)
+			break
+		}
+		label := fmt.Sprintf("Template and name (%s)", strings.Join(templates.Names(all), ", "))
+		a.OpenPrompt(label, func(a App, value string) App {
+			fields := strings.Fields(value)
+			if len(fields) == 0 {
+				return a
+			}
+			if err := a.NewContextFromTemplate(fields[0], strings.Join(fields[1:], " ")); err != nil {
+				a.Status = fmt.Sprintf("Error: %v", err)
+			}
+			return a
+		})
+	case "i":
+		if ctx := a.CurrentContext(); ctx != nil {
+			a.OpenPrompt(fmt.Sprintf("Include context in %s", ctx.Name), func(a App, value string) App {
+				if err := a.IncludeContext(strings.TrimSpace(value)); err != nil {
+					a.Status = fmt.Sprintf("Error: %v", err)
+				}
+				return a
+			})
+		}
+	case "r":
+		a.loadSelectedContext()
+		if ctx := a.CurrentContext(); ctx != nil {
+			if warning := a.RedactionWarning(ctx); warning != "" {
+				a.Status = warning
+			} else {
+				a.Status = fmt.Sprintf("No secrets found in %s", ctx.Name)
+			}
+		}
+	}
+	return a, nil
+}
+
+// findContextByName returns the context with the given name, or nil
+func (a *App) findContextByName(name string) *context.Context {
+	for _, ctx := range a.Contexts {
+		if ctx.Name == name {
+			return ctx
+		}
+	}
+	return nil
+}
+
+// resolveContext loads a context by ID from storage, or from the contexts in
+// memory when there is no store
+func (a *App) resolveContext(id string) (*context.Context, error) {
+	if a.Store != nil {
+		return a.Store.GetContext(id)
+	}
+	for _, ctx := range a.Contexts {
+		if ctx.ID == id {
+			return ctx, nil
+		}
+	}
+	return nil, fmt.Errorf("context not found: %s", id)
+}
+
+// IncludeContext layers the named context beneath the selected one
+func (a *App) IncludeContext(name string) error {
+	a.loadSelectedContext()
+	ctx := a.CurrentContext()
+	if ctx == nil {
+		return fmt.Errorf("no context selected")
+	}
+
+	inc := a.findContextByName(name)
+	if inc == nil {
+		return fmt.Errorf("context not found: %s", name)
+	}
+	if inc.ID == ctx.ID {
+		return fmt.Errorf("a context cannot include itself")
+	}
+
+	ctx.Include(inc.ID)
+
+	// Flattening checks for cycles before anything is saved
+	flat, err := ctx.Flatten(a.resolveContext)
+	if err != nil {
+		ctx.RemoveInclude(inc.ID)
+		return err
+	}
+
+	if a.Store != nil {
+		if err := a.Store.SaveContext(ctx); err != nil {
+			ctx.RemoveInclude(inc.ID)
+			return fmt.Errorf("failed to save context: %w", err)
+		}
+	}
+
+	a.Status = fmt.Sprintf("%s now includes %s (%d tokens with includes)", ctx.Name, inc.Name, flat.TotalTokens)
+	return nil
+}
+
+// includeNames returns the names of the contexts ctx includes
+func (a *App) includeNames(ctx *context.Context) []string {
+	var names []string
+	for _, id := range ctx.Includes {
+		name := id
+		for _, c := range a.Contexts {
+			if c.ID == id {
+				name = c.Name
+				break
+			}
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// NewContextFromTemplate creates, saves and selects a context built from the
+// named template. An empty name uses the template name.
+func (a *App) NewContextFromTemplate(templateName, name string) error {
+	all, err := templates.LoadAll(templates.Dirs(a.Root)...)
+	if err != nil {
+		return err
+	}
+
+	tmpl := templates.Find(all, templateName)
+	if tmpl == nil {
+		return fmt.Errorf("template not found: %s", templateName)
+	}
+
+	ctx, missing, err := tmpl.Instantiate(a.Root, name)
+	if err != nil {
+		return err
+	}
+
+	if a.Store != nil {
+		if err := a.Store.SaveContext(ctx); err != nil {
+			return fmt.Errorf("failed to save context: %w", err)
+		}
+	}
+
+	a.Contexts = append(a.Contexts, ctx)
+	a.SelectedContext = len(a.Contexts) - 1
+
+	a.Status = fmt.Sprintf("Created %s from %s (%d files, %d tokens)", ctx.Name, tmpl.Name, len(ctx.Files), ctx.TotalTokens)
+	if len(missing) > 0 {
+		a.Status += fmt.Sprintf("; skipped missing %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// RedactionWarning returns a warning listing what will be redacted when ctx
+// and the contexts it includes are sent to a provider, or "" if nothing will be
+func (a *App) RedactionWarning(ctx *context.Context) string {
+	if a.Redactor == nil {
+		return ""
+	}
+
+	flat, err := ctx.Flatten(a.resolveContext)
+	if err != nil {
+		return fmt.Sprintf("Cannot check %s for secrets: %v", ctx.Name, err)
+	}
+
+	_, report := a.Redactor.RedactContext(flat)
+	if !report.HasFindings() {
+		return ""
+	}
+
+	var locations []string
+	for _, f := range report.Findings {
+		locations = append(locations, fmt.Sprintf("%s:%d (%s)", f.Source, f.Line, f.Kind))
+	}
+
+	return fmt.Sprintf("⚠ %d secrets will be redacted before sending %s: %s\n  %s",
+		len(report.Findings), ctx.Name, report.Summary(), strings.Join(locations, "\n  "))
+}
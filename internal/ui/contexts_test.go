@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/redact"
+)
+
+func TestRedactionWarning(t *testing.T) {
+	app := InitialApp()
+
+	redactor, err := redact.New(nil, nil)
+	if err != nil {
+		t.Fatalf("redact.New() error = %v", err)
+	}
+	app.Redactor = redactor
+
+	ctx := app.CurrentContext()
+	if warning := app.RedactionWarning(ctx); warning != "" {
+		t.Errorf("Clean context should have no warning, got %q", warning)
+	}
+
+	file := context.NewFile("config/.env", ".env")
+	file.SetContent("API_TOKEN=abc123\n")
+	ctx.AddFile(file)
+
+	app.ActiveTab = 1
+	app = pressKey(app, "r")
+
+	if !strings.Contains(app.Status, "1 secrets will be redacted") || !strings.Contains(app.Status, "config/.env:1 (env_value)") {
+		t.Errorf("Status should warn about findings, got %q", app.Status)
+	}
+}
+
+func TestRedactionWarningIncludedContext(t *testing.T) {
+	app := InitialApp()
+	app.ActiveTab = 1
+
+	redactor, err := redact.New(nil, nil)
+	if err != nil {
+		t.Fatalf("redact.New() error = %v", err)
+	}
+	app.Redactor = redactor
+
+	secrets := context.NewContext("deploy", "")
+	env := context.NewFile("deploy/.env", ".env")
+	env.SetContent("API_TOKEN=abc123\n")
+	secrets.AddFile(env)
+	app.Contexts = append(app.Contexts, secrets)
+
+	if err := app.IncludeContext("deploy"); err != nil {
+		t.Fatalf("IncludeContext() error = %v", err)
+	}
+
+	app = pressKey(app, "r")
+	if !strings.Contains(app.Status, "1 secrets will be redacted") || !strings.Contains(app.Status, "deploy/.env:1 (env_value)") {
+		t.Errorf("Status should warn about secrets in included contexts, got %q", app.Status)
+	}
+}
+
+func TestRedactionWarningDisabled(t *testing.T) {
+	app := InitialApp()
+
+	file := context.NewFile("notes.md", "notes.md")
+	file.SetContent("ops@example.com")
+	app.CurrentContext().AddFile(file)
+
+	if warning := app.RedactionWarning(app.CurrentContext()); warning != "" {
+		t.Errorf("Without a redactor there should be no warning, got %q", warning)
+	}
+}
+
+func TestNewContextFromTemplate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	root := t.TempDir()
+	for rel, content := range map[string]string{
+		".aui/templates/api.yaml": "description: API work\ninclude: [\"api/*.go\"]\nsystem_prompt: Keep handlers small.\n",
+		"api/handler.go":          "package api\n",
+		"main.go":                 "package main\n",
+	} {
+		full := filepath.Join(root, rel)
+		os.MkdirAll(filepath.Dir(full), 0755)
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", rel, err)
+		}
+	}
+
+	app := InitialApp()
+	app.Root = root
+	app.ActiveTab = 1
+
+	app = pressKey(app, "n")
+	if app.Prompt == nil || !strings.Contains(app.Prompt.Label, "api, bug-fix, review") {
+		t.Fatalf("'n' should open a prompt listing templates, got %+v", app.Prompt)
+	}
+
+	app = pressKey(app, "api")
+	model, _ := app.Update(tea.KeyMsg{Type: tea.KeySpace})
+	app = model.(App)
+	app = pressKey(app, "handlers")
+	app = pressKey(app, "enter")
+
+	if len(app.Contexts) != 2 || app.SelectedContext != 1 {
+		t.Fatalf("Expected a new selected context, got %d contexts (selected %d)", len(app.Contexts), app.SelectedContext)
+	}
+
+	ctx := app.Contexts[1]
+	if ctx.Name != "handlers" || ctx.SystemPrompt != "Keep handlers small." || len(ctx.Files) != 1 || !ctx.HasFile("api/handler.go") {
+		t.Errorf("Context not built from template: %+v", ctx)
+	}
+	if !strings.Contains(app.Status, "Created handlers from api (1 files") {
+		t.Errorf("Status = %q", app.Status)
+	}
+
+	if err := app.NewContextFromTemplate("missing", ""); err == nil {
+		t.Error("Unknown template should fail")
+	}
+}
+
+func TestIncludeContext(t *testing.T) {
+	app := InitialApp()
+	app.ActiveTab = 1
+
+	overview := context.NewContext("project-overview", "")
+	readme := context.NewFile("README.md", "README.md")
+	readme.SetContent("# Project\n")
+	overview.AddFile(readme)
+	app.Contexts = append(app.Contexts, overview)
+
+	app = pressKey(app, "i")
+	app = pressKey(app, "project-overview")
+	app = pressKey(app, "enter")
+
+	feature := app.Contexts[0]
+	if !feature.IsIncluded(overview.ID) {
+		t.Fatalf("Expected %s to include project-overview, status %q", feature.Name, app.Status)
+	}
+	if !strings.Contains(app.View(), "includes: project-overview") {
+		t.Errorf("Contexts view should list includes, got:\n%s", app.View())
+	}
+
+	// Including back would create a cycle
+	app.SelectedContext = 1
+	if err := app.IncludeContext(feature.Name); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("IncludeContext() error = %v, want a cycle", err)
+	}
+	if overview.IsIncluded(feature.ID) {
+		t.Error("Cyclic include should be undone")
+	}
+
+	if err := app.IncludeContext("missing"); err == nil {
+		t.Error("Including an unknown context should fail")
+	}
+
+	app.SelectedContext = 0
+	app.ActiveTab = 2
+	if !strings.Contains(app.View(), "Includes: project-overview (3 tokens in total)") {
+		t.Errorf("Files view should show flattened tokens, got:\n%s", app.View())
+	}
+}
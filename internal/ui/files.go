@@ -0,0 +1,493 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/aui/internal/context"
+)
+
+// Expansion holds the import rings proposed for a file before they are added
+type Expansion struct {
+	Source *context.File
+	Rings  []context.Ring
+	Depth  int // Number of rings that will be added
+}
+
+// Proposal holds files suggested for the selected context, such as ranked
+// matches for a question or chunks of a large file, for the user to confirm
+type Proposal struct {
+	Title    string
+	Files    []*context.File
+	Selected []bool
+	Cursor   int
+	Replaces string           // Path removed from the context when anything is accepted
+	Chunks   []*context.Chunk // Chunk metadata of each proposed file, if any; saved for those accepted
+}
+
+// defaultChunkTokens is the target chunk size when splitting a large file
+const defaultChunkTokens = 2000
+
+// Default limits used when no configuration is loaded
+const (
+	defaultTokenBudget = 100000
+	defaultMaxFiles    = 20
+)
+
+// CurrentContext returns the context selected in the Contexts tab, or nil
+func (a *App) CurrentContext() *context.Context {
+	if a.SelectedContext < 0 || a.SelectedContext >= len(a.Contexts) {
+		return nil
+	}
+	return a.Contexts[a.SelectedContext]
+}
+
+// loadSelectedContext reloads the selected context's files from storage
+func (a *App) loadSelectedContext() {
+	ctx := a.CurrentContext()
+	if ctx == nil || a.Store == nil {
+		return
+	}
+
+	loaded, err := a.Store.GetContext(ctx.ID)
+	if err != nil {
+		a.Status = fmt.Sprintf("Failed to load context: %v", err)
+		return
+	}
+	a.Contexts[a.SelectedContext] = loaded
+}
+
+// saveSelectedContext persists the selected context if storage is available
+func (a *App) saveSelectedContext() {
+	ctx := a.CurrentContext()
+	if ctx == nil || a.Store == nil {
+		return
+	}
+
+	if err := a.Store.SaveContext(ctx); err != nil {
+		a.Status = fmt.Sprintf("Failed to save context: %v", err)
+	}
+}
+
+// ExpandFile proposes the import rings of a file in the selected context,
+// following imports up to depth and optionally reverse dependents
+func (a *App) ExpandFile(path string, depth int, reverse bool) error {
+	ctx := a.CurrentContext()
+	if ctx == nil {
+		return fmt.Errorf("no context selected")
+	}
+
+	file := ctx.GetFile(path)
+	if file == nil {
+		return fmt.Errorf("file not in context: %s", path)
+	}
+
+	rings, err := context.ExpandImports(a.Root, file, context.ExpandOptions{Depth: depth, Reverse: reverse})
+	if err != nil {
+		return err
+	}
+
+	a.Expansion = &Expansion{Source: file, Rings: rings, Depth: len(rings)}
+	return nil
+}
+
+// AcceptExpansion adds the files of the chosen rings to the selected context
+func (a *App) AcceptExpansion() {
+	ctx := a.CurrentContext()
+	if a.Expansion == nil || ctx == nil {
+		return
+	}
+
+	added := 0
+	for _, ring := range a.Expansion.Rings[:a.Expansion.Depth] {
+		for _, f := range ring.Files {
+			if !ctx.HasFile(f.Path) {
+				added++
+			}
+			ctx.AddFile(f)
+		}
+	}
+
+	a.Expansion = nil
+	a.saveSelectedContext()
+	a.Status = fmt.Sprintf("Added %d files to %s", added, ctx.Name)
+}
+
+// contextLimits returns the token budget and file limit for auto-selection
+func (a *App) contextLimits() (budget, maxFiles int) {
+	budget, maxFiles = defaultTokenBudget, defaultMaxFiles
+	if a.Config != nil {
+		if a.Config.Context.TokenBudget > 0 {
+			budget = a.Config.Context.TokenBudget
+		}
+		if a.Config.Context.MaxFiles > 0 {
+			maxFiles = a.Config.Context.MaxFiles
+		}
+	}
+	return budget, maxFiles
+}
+
+// ProposeFiles ranks the files under Root against a question and proposes the
+// best matches that fit the selected context's remaining token budget
+func (a *App) ProposeFiles(question string) error {
+	ctx := a.CurrentContext()
+	if ctx == nil {
+		return fmt.Errorf("no context selected")
+	}
+
+	candidates, err := context.ScanDirectory(a.Root, context.ScanOptions{})
+	if err != nil {
+		return err
+	}
+
+	// Files already in the context need not be proposed again
+	var fresh []*context.File
+	for _, f := range candidates {
+		if !ctx.HasFile(f.Path) {
+			fresh = append(fresh, f)
+		}
+	}
+
+	budget, maxFiles := a.contextLimits()
+	ranked := context.NewIndex(fresh).Rank(question)
+	files := context.SelectWithinBudget(ranked, maxFiles, budget-ctx.TotalTokens)
+
+	selected := make([]bool, len(files))
+	for i := range selected {
+		selected[i] = true
+	}
+
+	a.Proposal = &Proposal{Title: fmt.Sprintf("Suggested for %q", question), Files: files, Selected: selected}
+	return nil
+}
+
+// ProposeChunks splits a file of the selected context at function
+// boundaries so that only some of its chunks can be kept
+func (a *App) ProposeChunks(path string) error {
+	ctx := a.CurrentContext()
+	if ctx == nil {
+		return fmt.Errorf("no context selected")
+	}
+
+	file := ctx.GetFile(path)
+	if file == nil {
+		return fmt.Errorf("file not in context: %s", path)
+	}
+
+	chunks := context.ChunkByFunctions(file, defaultChunkTokens)
+	if len(chunks) < 2 {
+		return fmt.Errorf("%s is too small to chunk", path)
+	}
+
+	files := make([]*context.File, len(chunks))
+	for i, c := range chunks {
+		files[i] = c.ToFile(file.Language)
+	}
+
+	a.Proposal = &Proposal{
+		Title:    fmt.Sprintf("Chunks of %s", path),
+		Files:    files,
+		Selected: make([]bool, len(files)),
+		Replaces: path,
+		Chunks:   chunks,
+	}
+	return nil
+}
+
+// AddReference adds a file or line range such as "path:120-240" below Root
+// to the selected context, recording chunk metadata for ranges
+func (a *App) AddReference(ref string) error {
+	ctx := a.CurrentContext()
+	if ctx == nil {
+		return fmt.Errorf("no context selected")
+	}
+
+	file, chunk, err := context.LoadRange(a.Root, ref)
+	if err != nil {
+		return err
+	}
+
+	ctx.AddFile(file)
+	if chunk != nil && a.Store != nil {
+		if err := a.Store.AddChunk(chunk); err != nil {
+			return err
+		}
+	}
+	a.saveSelectedContext()
+	return nil
+}
+
+// AcceptProposal adds the selected proposed files to the selected context
+func (a *App) AcceptProposal() {
+	ctx := a.CurrentContext()
+	if a.Proposal == nil || ctx == nil {
+		return
+	}
+
+	p := a.Proposal
+	added := 0
+	var chunks []*context.Chunk
+	for i, f := range p.Files {
+		if p.Selected[i] {
+			ctx.AddFile(f)
+			added++
+			if i < len(p.Chunks) {
+				chunks = append(chunks, p.Chunks[i])
+			}
+		}
+	}
+
+	if added > 0 && p.Replaces != "" {
+		ctx.RemoveFile(p.Replaces)
+	}
+	if len(chunks) > 0 && a.Store != nil {
+		if err := a.Store.SaveChunks(chunks[0].Path, chunks); err != nil {
+			a.Status = fmt.Sprintf("Failed to save chunks: %v", err)
+		}
+	}
+
+	a.Proposal = nil
+	a.saveSelectedContext()
+	a.Status = fmt.Sprintf("Added %d files to %s", added, ctx.Name)
+}
+
+// updateProposal handles keys while ranked files are being reviewed
+func (a App) updateProposal(msg tea.KeyMsg) (App, tea.Cmd) {
+	p := a.Proposal
+
+	switch msg.String() {
+	case "j", "down":
+		if p.Cursor < len(p.Files)-1 {
+			p.Cursor++
+		}
+	case "k", "up":
+		if p.Cursor > 0 {
+			p.Cursor--
+		}
+	case " ", "space":
+		if p.Cursor < len(p.Files) {
+			p.Selected[p.Cursor] = !p.Selected[p.Cursor]
+		}
+	case "enter":
+		a.AcceptProposal()
+	case "esc":
+		a.Proposal = nil
+	}
+
+	return a, nil
+}
+
+// updateFiles handles keys for the Files tab
+func (a App) updateFiles(msg tea.KeyMsg) (App, tea.Cmd) {
+	if a.Proposal != nil {
+		return a.updateProposal(msg)
+	}
+
+	if a.Expansion != nil {
+		switch msg.String() {
+		case "+", "=":
+			if a.Expansion.Depth < len(a.Expansion.Rings) {
+				a.Expansion.Depth++
+			}
+		case "-":
+			if a.Expansion.Depth > 0 {
+				a.Expansion.Depth--
+			}
+		case "enter":
+			a.AcceptExpansion()
+		case "esc":
+			a.Expansion = nil
+		}
+		return a, nil
+	}
+
+	ctx := a.CurrentContext()
+	if ctx == nil {
+		return a, nil
+	}
+
+	switch msg.String() {
+	case "a":
+		a.OpenPrompt("Add file or range (path or path:120-240)", func(a App, ref string) App {
+			if err := a.AddReference(ref); err != nil {
+				a.Status = fmt.Sprintf("Cannot add %s: %v", ref, err)
+				return a
+			}
+			a.Status = fmt.Sprintf("Added %s to %s", ref, a.CurrentContext().Name)
+			a.proposeImports(ref)
+			return a
+		})
+	case "s":
+		a.OpenPrompt("Question", func(a App, question string) App {
+			if err := a.ProposeFiles(question); err != nil {
+				a.Status = fmt.Sprintf("Cannot suggest files: %v", err)
+			}
+			return a
+		})
+	case "j", "down":
+		if a.SelectedFile < len(ctx.Files)-1 {
+			a.SelectedFile++
+		}
+	case "k", "up":
+		if a.SelectedFile > 0 {
+			a.SelectedFile--
+		}
+	case "c":
+		if a.SelectedFile < len(ctx.Files) {
+			if err := a.ProposeChunks(ctx.Files[a.SelectedFile].Path); err != nil {
+				a.Status = fmt.Sprintf("Cannot chunk: %v", err)
+			}
+		}
+	case "m":
+		if a.SelectedFile < len(ctx.Files) {
+			cmd := a.summarizeFile(ctx.Files[a.SelectedFile])
+			return a, cmd
+		}
+	case "x", "X":
+		if a.SelectedFile < len(ctx.Files) {
+			reverse := msg.String() == "X"
+			if err := a.ExpandFile(ctx.Files[a.SelectedFile].Path, defaultExpandDepth, reverse); err != nil {
+				a.Status = fmt.Sprintf("Cannot expand: %v", err)
+			}
+		}
+	}
+
+	return a, nil
+}
+
+// proposeImports opens the import expansion of a Go file just added to the
+// selected context, or says why there is nothing to expand
+func (a *App) proposeImports(path string) {
+	if filepath.Ext(path) != ".go" {
+		return
+	}
+	if err := a.ExpandFile(path, defaultExpandDepth, false); err != nil {
+		a.Status += fmt.Sprintf("; cannot expand imports: %v", err)
+		return
+	}
+	if len(a.Expansion.Rings) == 0 {
+		a.Expansion = nil
+		a.Status += "; it has no local imports to add"
+	}
+}
+
+// defaultExpandDepth is the number of import rings proposed by default
+const defaultExpandDepth = 3
+
+// viewFiles renders the Files tab
+func (a App) viewFiles() string {
+	view := "Files:\n"
+
+	if a.Proposal != nil {
+		return view + a.viewProposal()
+	}
+
+	if a.Expansion != nil {
+		return view + a.viewExpansion()
+	}
+
+	ctx := a.CurrentContext()
+	if ctx == nil {
+		return view + "  No context selected. Choose one in the Contexts tab.\n"
+	}
+	if len(ctx.Files) == 0 {
+		return view + fmt.Sprintf("  Context %s has no files yet.\n", ctx.Name) + a.viewIncludes(ctx) +
+			"\n  [a: add file or range] [s: suggest files for a question]\n"
+	}
+
+	chunks := 0
+	for _, f := range ctx.Files {
+		if _, start, _, err := context.ParseLineRange(f.Path); err == nil && start > 0 {
+			chunks++
+		}
+	}
+	view += fmt.Sprintf("  Context: %s (%d files, %d chunks, %d tokens)\n", ctx.Name, len(ctx.Files)-chunks, chunks, ctx.TotalTokens)
+	view += a.viewIncludes(ctx)
+	for i, f := range ctx.Files {
+		cursor := "  "
+		if i == a.SelectedFile {
+			cursor = "> "
+		}
+		name := f.Path
+		if path, start, end, err := context.ParseLineRange(f.Path); err == nil && start > 0 {
+			name = fmt.Sprintf("%s lines %d-%d", path, start, end)
+		}
+		view += fmt.Sprintf("  %s%s (%s, %d tokens)\n", cursor, name, f.Language, f.Tokens)
+	}
+	view += "\n  [j/k: move] [a: add file or range] [s: suggest files] [c: chunk]\n"
+	view += "  [x: expand imports] [X: expand imports and dependents] [m: summarize]\n"
+
+	return view
+}
+
+// viewIncludes renders the contexts included by ctx with the token total
+// after flattening, or "" if it includes none
+func (a App) viewIncludes(ctx *context.Context) string {
+	if len(ctx.Includes) == 0 {
+		return ""
+	}
+
+	names := strings.Join(a.includeNames(ctx), ", ")
+	flat, err := ctx.Flatten(a.resolveContext)
+	if err != nil {
+		return fmt.Sprintf("  Includes: %s (%v)\n", names, err)
+	}
+	return fmt.Sprintf("  Includes: %s (%d tokens in total)\n", names, flat.TotalTokens)
+}
+
+// viewExpansion renders the proposed import rings with their token cost
+func (a App) viewExpansion() string {
+	e := a.Expansion
+	view := fmt.Sprintf("  Expand %s:\n", e.Source.Path)
+
+	if len(e.Rings) == 0 {
+		return view + "    No local imports found.\n\n  [esc: cancel]\n"
+	}
+
+	total := 0
+	for i, ring := range e.Rings {
+		marker := "[ ]"
+		if i < e.Depth {
+			marker = "[x]"
+			total += ring.Tokens
+		}
+		view += fmt.Sprintf("    %s Ring %d: %d files, %d tokens\n", marker, ring.Depth, len(ring.Files), ring.Tokens)
+		for _, f := range ring.Files {
+			view += fmt.Sprintf("          %s (%d tokens)\n", f.Path, f.Tokens)
+		}
+	}
+	view += fmt.Sprintf("\n  Adding %d rings: +%d tokens\n", e.Depth, total)
+	view += "  [+/-: change depth] [enter: add] [esc: cancel]\n"
+
+	return view
+}
+
+// viewProposal renders the files proposed for a question
+func (a App) viewProposal() string {
+	p := a.Proposal
+	view := fmt.Sprintf("  %s:\n", p.Title)
+
+	if len(p.Files) == 0 {
+		return view + "    No files found within the token budget.\n\n  [esc: cancel]\n"
+	}
+
+	total := 0
+	for i, f := range p.Files {
+		cursor := "  "
+		if i == p.Cursor {
+			cursor = "> "
+		}
+		marker := "[ ]"
+		if p.Selected[i] {
+			marker = "[x]"
+			total += f.Tokens
+		}
+		view += fmt.Sprintf("  %s%s %s (%d tokens)\n", cursor, marker, f.Path, f.Tokens)
+	}
+	view += fmt.Sprintf("\n  Selected: +%d tokens\n", total)
+	view += "  [j/k: move] [space: toggle] [enter: add] [esc: cancel]\n"
+
+	return view
+}
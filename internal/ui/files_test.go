@@ -0,0 +1,326 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/storage"
+)
+
+// filesTestApp returns an app rooted at a small Go module whose selected
+// context holds cmd/main.go, which imports api, which imports store
+func filesTestApp(t *testing.T) App {
+	t.Helper()
+
+	root := t.TempDir()
+	tree := map[string]string{
+		"go.mod":         "module example.com/app\n",
+		"cmd/main.go":    "package main\n\nimport \"example.com/app/api\"\n\nfunc main() { api.Run() }\n",
+		"api/api.go":     "package api\n\nimport \"example.com/app/store\"\n\nfunc Run() { store.Open() }\n",
+		"store/store.go": "package store\n\nfunc Open() {}\n",
+	}
+	for rel, content := range tree {
+		full := filepath.Join(root, rel)
+		os.MkdirAll(filepath.Dir(full), 0755)
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", rel, err)
+		}
+	}
+
+	seed, err := context.LoadFile(root, "cmd/main.go")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	app := InitialApp()
+	app.Root = root
+	app.Contexts[0].AddFile(seed)
+	app.ActiveTab = 2
+	return app
+}
+
+func pressKey(app App, key string) App {
+	var msg tea.KeyMsg
+	switch key {
+	case "enter":
+		msg = tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		msg = tea.KeyMsg{Type: tea.KeyEsc}
+	default:
+		msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	}
+	model, _ := app.Update(msg)
+	return model.(App)
+}
+
+func TestFilesTabListsContextFiles(t *testing.T) {
+	app := filesTestApp(t)
+
+	view := app.View()
+	if !strings.Contains(view, "> cmd/main.go (go,") || !strings.Contains(view, "(1 files, 0 chunks,") {
+		t.Errorf("Files tab should list context files with cursor, got:\n%s", view)
+	}
+
+	app.CurrentContext().RemoveFile("cmd/main.go")
+	if view := app.View(); !strings.Contains(view, "has no files yet") || strings.Contains(view, "coming soon") {
+		t.Errorf("Files tab of an empty context should say how to add files, got:\n%s", view)
+	}
+}
+
+func TestFilesTabExpandImports(t *testing.T) {
+	app := filesTestApp(t)
+
+	app = pressKey(app, "x")
+	if app.Expansion == nil {
+		t.Fatalf("Pressing x should propose an expansion, status: %s", app.Status)
+	}
+
+	if len(app.Expansion.Rings) != 2 {
+		t.Fatalf("Expected 2 rings, got %d", len(app.Expansion.Rings))
+	}
+
+	view := app.View()
+	for _, want := range []string{"Ring 1: 1 files", "Ring 2: 1 files", "api/api.go", "tokens"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expansion view should contain %q, got:\n%s", want, view)
+		}
+	}
+
+	// Only add the first ring
+	app = pressKey(app, "-")
+	if app.Expansion.Depth != 1 {
+		t.Errorf("After '-', Depth = %d, want 1", app.Expansion.Depth)
+	}
+
+	app = pressKey(app, "enter")
+	if app.Expansion != nil {
+		t.Error("Expansion should be cleared after accepting")
+	}
+
+	ctx := app.CurrentContext()
+	if !ctx.HasFile("api/api.go") {
+		t.Error("Accepted ring should be added to context")
+	}
+	if ctx.HasFile("store/store.go") {
+		t.Error("Rings beyond the chosen depth should not be added")
+	}
+}
+
+func TestFilesTabExpandCancel(t *testing.T) {
+	app := filesTestApp(t)
+
+	app = pressKey(app, "x")
+	app = pressKey(app, "esc")
+
+	if app.Expansion != nil {
+		t.Error("Expansion should be cleared after esc")
+	}
+	if len(app.CurrentContext().Files) != 1 {
+		t.Error("Cancelling should not add files")
+	}
+}
+
+func TestContextsTabSelection(t *testing.T) {
+	app := InitialApp()
+	app.AddContext("second", "")
+	app.ActiveTab = 1
+
+	app = pressKey(app, "j")
+	if app.SelectedContext != 1 {
+		t.Errorf("After j, SelectedContext = %d, want 1", app.SelectedContext)
+	}
+
+	app = pressKey(app, "j")
+	if app.SelectedContext != 1 {
+		t.Errorf("Selection should stop at last context, got %d", app.SelectedContext)
+	}
+
+	app = pressKey(app, "k")
+	if app.SelectedContext != 0 {
+		t.Errorf("After k, SelectedContext = %d, want 0", app.SelectedContext)
+	}
+}
+
+func TestFilesTabSuggestFiles(t *testing.T) {
+	app := filesTestApp(t)
+
+	app = pressKey(app, "s")
+	if app.Prompt == nil {
+		t.Fatal("Pressing s should open the question prompt")
+	}
+
+	for _, key := range []string{"s", "t", "o", "r", "e"} {
+		app = pressKey(app, key)
+	}
+	app = pressKey(app, "enter")
+
+	if app.Proposal == nil {
+		t.Fatalf("Submitting a question should propose files, status: %s", app.Status)
+	}
+	if len(app.Proposal.Files) == 0 || app.Proposal.Files[0].Path != "store/store.go" {
+		t.Fatalf("Top proposal should be store/store.go, got %v", app.Proposal.Files)
+	}
+	for _, f := range app.Proposal.Files {
+		if f.Path == "cmd/main.go" {
+			t.Error("Files already in the context should not be proposed")
+		}
+	}
+
+	view := app.View()
+	if !strings.Contains(view, `Suggested for "store"`) || !strings.Contains(view, "[x] store/store.go") {
+		t.Errorf("Proposal view should list selected files, got:\n%s", view)
+	}
+
+	// Deselect everything but the top file, then accept
+	for i := 1; i < len(app.Proposal.Files); i++ {
+		app.Proposal.Selected[i] = false
+	}
+	app = pressKey(app, "enter")
+
+	ctx := app.CurrentContext()
+	if !ctx.HasFile("store/store.go") {
+		t.Error("Accepted proposal should add the selected file")
+	}
+	if len(ctx.Files) != 2 {
+		t.Errorf("Context should have 2 files, got %d", len(ctx.Files))
+	}
+}
+
+func TestProposeFilesRespectsBudget(t *testing.T) {
+	app := filesTestApp(t)
+	app.Config = config.NewDefault()
+	app.Config.Context.TokenBudget = app.CurrentContext().TotalTokens + 1
+
+	if err := app.ProposeFiles("store api run open"); err != nil {
+		t.Fatalf("ProposeFiles() error = %v", err)
+	}
+
+	if len(app.Proposal.Files) != 0 {
+		t.Errorf("No file should fit the remaining budget, got %d", len(app.Proposal.Files))
+	}
+}
+
+func TestFilesTabAddRange(t *testing.T) {
+	app := filesTestApp(t)
+
+	app = pressKey(app, "a")
+	for _, r := range "api/api.go:1-3" {
+		app = pressKey(app, string(r))
+	}
+	app = pressKey(app, "enter")
+
+	ctx := app.CurrentContext()
+	file := ctx.GetFile("api/api.go:1-3")
+	if file == nil {
+		t.Fatalf("Range should be added to context, status: %s", app.Status)
+	}
+	if file.Content != "package api\n\nimport \"example.com/app/store\"\n" {
+		t.Errorf("Range content = %q", file.Content)
+	}
+
+	if err := app.AddReference("missing.go"); err == nil {
+		t.Error("Expected error adding missing file")
+	}
+}
+
+func TestFilesTabAddGoFileProposesImports(t *testing.T) {
+	app := filesTestApp(t)
+
+	app = pressKey(app, "a")
+	for _, r := range "api/api.go" {
+		app = pressKey(app, string(r))
+	}
+	app = pressKey(app, "enter")
+
+	if !app.CurrentContext().HasFile("api/api.go") {
+		t.Fatalf("File should be added to context, status: %s", app.Status)
+	}
+	if app.Expansion == nil || app.Expansion.Source.Path != "api/api.go" {
+		t.Fatalf("Adding a Go file should propose its imports, status: %s", app.Status)
+	}
+	if len(app.Expansion.Rings) != 1 || !strings.Contains(app.View(), "store/store.go") {
+		t.Errorf("Expansion should offer store/store.go, got:\n%s", app.View())
+	}
+
+	app = pressKey(app, "enter")
+	if !app.CurrentContext().HasFile("store/store.go") {
+		t.Error("Accepted imports should be added to context")
+	}
+
+	// A file without local imports only gets a status hint
+	app = pressKey(app, "a")
+	for _, r := range "go.mod" {
+		app = pressKey(app, string(r))
+	}
+	app = pressKey(app, "enter")
+	if app.Expansion != nil {
+		t.Error("Non-Go files should not propose imports")
+	}
+	if err := app.AddReference("store/store.go"); err != nil {
+		t.Fatal(err)
+	}
+	app.proposeImports("store/store.go")
+	if app.Expansion != nil || !strings.Contains(app.Status, "no local imports") {
+		t.Errorf("A Go file without imports should set a hint, status: %s", app.Status)
+	}
+}
+
+func TestFilesTabChunkFile(t *testing.T) {
+	app := filesTestApp(t)
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	app.Store = store
+
+	// cmd/main.go has a package clause, an import and a function
+	if err := app.ProposeChunks("cmd/main.go"); err == nil {
+		t.Fatal("Small file should not be chunked")
+	}
+
+	big := context.NewFile("big.go", "big.go")
+	var b strings.Builder
+	b.WriteString("package big\n")
+	for i := 0; i < 3; i++ {
+		b.WriteString("\nfunc F" + string(rune('A'+i)) + "() {\n" + strings.Repeat("\t_ = 1\n", 1500) + "}\n")
+	}
+	big.SetContent(b.String())
+	big.DetectLanguage()
+	app.CurrentContext().AddFile(big)
+
+	if err := app.ProposeChunks("big.go"); err != nil {
+		t.Fatalf("ProposeChunks() error = %v", err)
+	}
+	if len(app.Proposal.Files) < 3 {
+		t.Fatalf("Expected at least 3 chunks, got %d", len(app.Proposal.Files))
+	}
+
+	// Keep only the first chunk
+	app.Proposal.Selected[0] = true
+	app.AcceptProposal()
+
+	ctx := app.CurrentContext()
+	if ctx.HasFile("big.go") {
+		t.Error("Chunked file should be replaced by the selected chunks")
+	}
+	if len(ctx.Files) != 2 || !strings.HasPrefix(ctx.Files[1].Path, "big.go:1-") {
+		t.Errorf("Context files after chunking = %d, last = %s", len(ctx.Files), ctx.Files[len(ctx.Files)-1].Path)
+	}
+	chunks, err := store.ListChunks("big.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 1 || chunks[0].Ref() != ctx.Files[1].Path {
+		t.Errorf("saved chunks = %d, want only the selected one", len(chunks))
+	}
+	if view := app.View(); !strings.Contains(view, "(1 files, 1 chunks,") || !strings.Contains(view, fmt.Sprintf("big.go lines 1-%d (go,", chunks[0].EndLine)) {
+		t.Errorf("Files tab should list the chunk by its lines, got:\n%s", view)
+	}
+}
@@ -0,0 +1,156 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Field is one input of a form. Fields with Options are chosen with
+// left/right; other fields take typed text.
+type Field struct {
+	Label   string
+	Value   string
+	Options []string
+}
+
+// Form is a multi-field input shown below the active tab. While a form is
+// open it receives all keys except ctrl+c. If Submit returns an error the
+// form stays open and shows it.
+type Form struct {
+	Title    string
+	Fields   []Field
+	Focus    int
+	Error    string
+	OnChange func(f *Form, field int) // Called after a field's value changes
+	Submit   func(a App, f *Form) (App, error)
+}
+
+// OpenForm shows a form
+func (a *App) OpenForm(f *Form) {
+	a.Form = f
+}
+
+// Value returns the value of the field with the given label
+func (f *Form) Value(label string) string {
+	for _, field := range f.Fields {
+		if field.Label == label {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// Field returns the field with the given label, or nil
+func (f *Form) Field(label string) *Field {
+	for i := range f.Fields {
+		if f.Fields[i].Label == label {
+			return &f.Fields[i]
+		}
+	}
+	return nil
+}
+
+// copy returns a copy of the form that can be changed without affecting
+// earlier App values
+func (f *Form) copy() *Form {
+	c := *f
+	c.Fields = make([]Field, len(f.Fields))
+	for i, field := range f.Fields {
+		c.Fields[i] = field
+		c.Fields[i].Options = append([]string(nil), field.Options...)
+	}
+	return &c
+}
+
+// updateForm handles keys while a form is open
+func (a App) updateForm(msg tea.KeyMsg) (App, tea.Cmd) {
+	f := a.Form.copy()
+	field := &f.Fields[f.Focus]
+	changed := false
+
+	switch msg.Type {
+	case tea.KeyEnter:
+		a.Form = nil
+		next, err := f.Submit(a, f)
+		if err != nil {
+			f.Error = err.Error()
+			a.Form = f
+			return a, nil
+		}
+		return next, nil
+	case tea.KeyEsc:
+		a.Form = nil
+		return a, nil
+	case tea.KeyDown, tea.KeyTab:
+		f.Focus = (f.Focus + 1) % len(f.Fields)
+	case tea.KeyUp, tea.KeyShiftTab:
+		f.Focus = (f.Focus - 1 + len(f.Fields)) % len(f.Fields)
+	case tea.KeyLeft, tea.KeyRight:
+		if len(field.Options) > 0 {
+			step := 1
+			if msg.Type == tea.KeyLeft {
+				step = -1
+			}
+			field.Value = field.Options[(indexOf(field.Options, field.Value)+step+len(field.Options))%len(field.Options)]
+			changed = true
+		}
+	case tea.KeyBackspace:
+		if len(field.Options) == 0 && len(field.Value) > 0 {
+			runes := []rune(field.Value)
+			field.Value = string(runes[:len(runes)-1])
+			changed = true
+		}
+	case tea.KeySpace:
+		if len(field.Options) == 0 {
+			field.Value += " "
+			changed = true
+		}
+	case tea.KeyRunes:
+		if len(field.Options) == 0 {
+			field.Value += string(msg.Runes)
+			changed = true
+		}
+	}
+
+	if changed && f.OnChange != nil {
+		f.OnChange(f, f.Focus)
+	}
+	a.Form = f
+	return a, nil
+}
+
+// indexOf returns the position of s in list, or -1
+func indexOf(list []string, s string) int {
+	for i, item := range list {
+		if item == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// viewForm renders the open form
+func (a App) viewForm() string {
+	f := a.Form
+	view := "\n" + f.Title + ":\n"
+	for i, field := range f.Fields {
+		cursor := " "
+		value := field.Value
+		if len(field.Options) > 0 {
+			value = "< " + value + " >"
+		}
+		if i == f.Focus {
+			cursor = ">"
+			if len(field.Options) == 0 {
+				value += "█"
+			}
+		}
+		view += fmt.Sprintf("  %s %s: %s\n", cursor, field.Label, value)
+	}
+	if f.Error != "" {
+		view += "  Error: " + f.Error + "\n"
+	}
+	view += "  [up/down: field] [left/right: choose] [enter: save] [esc: cancel]\n"
+	return view
+}
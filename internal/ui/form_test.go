@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFormInput(t *testing.T) {
+	app := InitialApp()
+
+	var submitted string
+	app.OpenForm(&Form{
+		Title: "Test",
+		Fields: []Field{
+			{Label: "Text"},
+			{Label: "Choice", Value: "b", Options: []string{"a", "b", "c"}},
+		},
+		Submit: func(a App, f *Form) (App, error) {
+			if f.Value("Text") == "" {
+				return a, errors.New("text is required")
+			}
+			submitted = f.Value("Text") + "/" + f.Value("Choice")
+			return a, nil
+		},
+	})
+
+	first := app
+	app = typeText(app, "hi")
+	if first.Form.Value("Text") != "" {
+		t.Error("typing should not change earlier App values")
+	}
+
+	// Focus wraps around, and option fields ignore typing
+	app = pressKeyType(app, tea.KeyUp)
+	app = typeText(app, "x")
+	app = pressKeyType(app, tea.KeyBackspace)
+	app = pressKeyType(app, tea.KeyRight)
+	app = pressKeyType(app, tea.KeyRight)
+	if app.Form.Value("Choice") != "a" {
+		t.Errorf("Choice = %q, want a after wrapping", app.Form.Value("Choice"))
+	}
+	if !strings.Contains(app.View(), "> Choice: < a >") {
+		t.Error("View() should show the focused option field")
+	}
+
+	app = pressKeyType(app, tea.KeyDown)
+	app = pressKeyType(app, tea.KeyBackspace)
+	app = pressKeyType(app, tea.KeyBackspace)
+	app = pressKey(app, "enter")
+	if app.Form == nil || !strings.Contains(app.View(), "Error: text is required") {
+		t.Fatal("a failed submit should keep the form open and show the error")
+	}
+
+	app = typeText(app, "ok")
+	app = pressKey(app, "enter")
+	if app.Form != nil || submitted != "ok/a" {
+		t.Errorf("submitted = %q, form open = %v", submitted, app.Form != nil)
+	}
+}
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"strings"
+)
+
+// formatMarkdown renders markdown for the terminal without styling: code
+// blocks are indented behind a bar, headings are underlined, bullets become
+// "•" and emphasis markers are removed. Unterminated code blocks, as seen
+// while a reply is streaming, are rendered as code.
+func formatMarkdown(text string) string {
+	var out []string
+	inCode := false
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+			if inCode {
+				if lang := strings.TrimPrefix(trimmed, "```"); lang != "" {
+					out = append(out, "┌ "+lang)
+				} else {
+					out = append(out, "┌")
+				}
+			} else {
+				out = append(out, "└")
+			}
+			continue
+		}
+		if inCode {
+			out = append(out, "│ "+line)
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			heading := stripEmphasis(strings.TrimSpace(trimmed[level:]))
+			out = append(out, heading)
+			if level <= 2 {
+				underline := "="
+				if level == 2 {
+					underline = "-"
+				}
+				out = append(out, strings.Repeat(underline, len([]rune(heading))))
+			}
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* "):
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			out = append(out, indent+"• "+stripEmphasis(trimmed[2:]))
+		default:
+			out = append(out, stripEmphasis(line))
+		}
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// stripEmphasis removes bold markers outside inline code
+func stripEmphasis(line string) string {
+	parts := strings.Split(line, "`")
+	for i := 0; i < len(parts); i += 2 {
+		parts[i] = strings.ReplaceAll(parts[i], "**", "")
+		parts[i] = strings.ReplaceAll(parts[i], "__", "")
+	}
+	return strings.Join(parts, "`")
+}
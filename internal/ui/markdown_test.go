@@ -0,0 +1,30 @@
+package ui
+
+import (
+	"testing"
+)
+
+func TestFormatMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "Just text.", "Just text."},
+		{"heading", "# Fix\n## Steps", "Fix\n===\nSteps\n-----"},
+		{"small heading", "### Notes", "Notes"},
+		{"bullets", "- one\n  * two", "• one\n  • two"},
+		{"bold", "This is **important**, keep `__init__`", "This is important, keep `__init__`"},
+		{"code block", "```go\nx := 1\n```", "┌ go\n│ x := 1\n└"},
+		{"code keeps markdown", "```\n# not a heading\n- not a bullet\n```", "┌\n│ # not a heading\n│ - not a bullet\n└"},
+		{"unterminated code", "```sh\nmake", "┌ sh\n│ make"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatMarkdown(tt.in); got != tt.want {
+				t.Errorf("formatMarkdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/conversation"
+	"github.com/yourusername/aui/internal/patch"
+	"github.com/yourusername/aui/internal/tools"
+)
+
+// ANSI colors of the diff preview
+const (
+	colorAdded   = "\x1b[32m"
+	colorRemoved = "\x1b[31m"
+	colorHunk    = "\x1b[36m"
+	colorReset   = "\x1b[0m"
+)
+
+// Patches is the review of the code changes a chat reply proposes to the
+// files of the attached context. While it is open it takes the place of the
+// Chat tab and receives all keys except ctrl+c.
+type Patches struct {
+	Context  *context.Context // With its files loaded
+	Patches  []*patch.Patch
+	Selected [][]bool // By patch, then hunk
+	Cursor   int      // Hunk shown, counting across the patches
+}
+
+// hunkAt returns the patch and hunk indexes of the nth hunk
+func (r *Patches) hunkAt(n int) (int, int) {
+	for i, p := range r.Patches {
+		if n < len(p.Hunks) {
+			return i, n
+		}
+		n -= len(p.Hunks)
+	}
+	return -1, -1
+}
+
+// hunks returns the number of hunks across the patches
+func (r *Patches) hunks() int {
+	n := 0
+	for _, p := range r.Patches {
+		n += len(p.Hunks)
+	}
+	return n
+}
+
+// ReviewPatches finds the code changes in the last reply of the chat to
+// files of the attached context and opens them for review, every hunk
+// selected. Changes are diffed against the files on disk.
+func (a *App) ReviewPatches() error {
+	reply := a.lastReply()
+	if reply == nil {
+		return fmt.Errorf("no reply to apply")
+	}
+	if reply.Status == conversation.StatusStreaming {
+		return fmt.Errorf("the reply is still streaming")
+	}
+	ctx := a.ChatContext()
+	if ctx == nil {
+		return fmt.Errorf("attach a context to apply code to its files (press c)")
+	}
+	if a.Root == "" {
+		return fmt.Errorf("no project root to apply code to")
+	}
+	if a.Store != nil {
+		var err error
+		if ctx, err = a.Store.GetContext(ctx.ID); err != nil {
+			return fmt.Errorf("failed to load context: %w", err)
+		}
+	}
+
+	paths := make([]string, len(ctx.Files))
+	for i, f := range ctx.Files {
+		paths[i] = f.Path
+	}
+	patches := patch.Find(reply.Content, paths, func(path string) (string, error) {
+		full, err := tools.Resolve(a.Root, path)
+		if err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(full)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+		return string(data), nil
+	})
+	if len(patches) == 0 {
+		return fmt.Errorf("the reply has no changes to files of %s", ctx.Name)
+	}
+
+	r := &Patches{Context: ctx, Patches: patches, Selected: make([][]bool, len(patches))}
+	for i, p := range patches {
+		r.Selected[i] = make([]bool, len(p.Hunks))
+		for j := range p.Hunks {
+			r.Selected[i][j] = true
+		}
+	}
+	a.Patches = r
+	a.Status = ""
+	return nil
+}
+
+// ApplyPatches writes the selected hunks to disk, first copying each file
+// to a new directory under the backup directory, and refreshes the files in
+// the context. Files that diffs delete are removed from the context too. A
+// file whose hunks no longer match is left alone.
+func (a *App) ApplyPatches() error {
+	r := a.Patches
+	base := filepath.Join(os.TempDir(), "aui-backups")
+	if a.Config != nil && a.Config.Patches.BackupDir != "" {
+		base = a.Config.Patches.BackupDir
+	}
+	// Each run gets its own directory, named after the project, so runs in
+	// the same second or from other projects keep their backups
+	if err := os.MkdirAll(base, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	backupDir, err := os.MkdirTemp(base, filepath.Base(a.Root)+"-"+time.Now().Format("20060102-150405")+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	var applied, deleted, failed []string
+	hunks := 0
+	for i, p := range r.Patches {
+		var selected []*patch.Hunk
+		for j, h := range p.Hunks {
+			if r.Selected[i][j] {
+				selected = append(selected, h)
+			}
+		}
+		if len(selected) == 0 {
+			continue
+		}
+		if p.Delete {
+			if err := patch.RemoveFile(a.Root, p.Path, selected, backupDir); err != nil {
+				failed = append(failed, err.Error())
+				continue
+			}
+			deleted = append(deleted, p.Path)
+		} else {
+			if _, err := patch.ApplyFile(a.Root, p.Path, selected, backupDir); err != nil {
+				failed = append(failed, err.Error())
+				continue
+			}
+			applied = append(applied, p.Path)
+		}
+		hunks += len(selected)
+	}
+	if len(applied) == 0 && len(deleted) == 0 && len(failed) == 0 {
+		os.Remove(backupDir)
+		return fmt.Errorf("no hunks selected")
+	}
+	a.Patches = nil
+
+	status := fmt.Sprintf("Applied %d hunks to %d files (backups in %s)", hunks, len(applied)+len(deleted), backupDir)
+	if len(deleted) > 0 {
+		status += "; deleted " + strings.Join(deleted, ", ")
+	}
+	if len(failed) > 0 {
+		status += "; not applied: " + strings.Join(failed, "; ")
+	}
+	a.Status = status
+
+	if len(applied) == 0 && len(deleted) == 0 {
+		return nil
+	}
+	for _, path := range deleted {
+		r.Context.RemoveFile(path)
+	}
+	if err := r.Context.Refresh(a.Root, applied...); err != nil {
+		return fmt.Errorf("failed to refresh context: %w", err)
+	}
+	for i, ctx := range a.Contexts {
+		if ctx.ID == r.Context.ID {
+			a.Contexts[i] = r.Context
+		}
+	}
+	if a.Store != nil {
+		if err := a.Store.SaveContext(r.Context); err != nil {
+			return fmt.Errorf("failed to save context: %w", err)
+		}
+	}
+	return nil
+}
+
+// updatePatches handles keys while changes are being reviewed
+func (a App) updatePatches(msg tea.KeyMsg) (App, tea.Cmd) {
+	r := a.Patches
+
+	switch msg.String() {
+	case "j", "down":
+		if r.Cursor < r.hunks()-1 {
+			r.Cursor++
+		}
+	case "k", "up":
+		if r.Cursor > 0 {
+			r.Cursor--
+		}
+	case " ":
+		i, j := r.hunkAt(r.Cursor)
+		r.Selected[i][j] = !r.Selected[i][j]
+	case "a":
+		// Select everything unless everything already is
+		all := true
+		for _, selected := range r.Selected {
+			for _, s := range selected {
+				all = all && s
+			}
+		}
+		for _, selected := range r.Selected {
+			for j := range selected {
+				selected[j] = !all
+			}
+		}
+	case "enter":
+		if err := a.ApplyPatches(); err != nil {
+			a.Status = fmt.Sprintf("Error: %v", err)
+		}
+	case "esc":
+		a.Patches = nil
+		a.Status = "Discarded the changes"
+	}
+	return a, nil
+}
+
+// viewPatches renders the review of changes, with the diff of the hunk
+// under the cursor
+func (a App) viewPatches() string {
+	r := a.Patches
+	view := fmt.Sprintf("Changes in the reply to files of %s:\n\n", r.Context.Name)
+
+	n := 0
+	for i, p := range r.Patches {
+		added, removed := p.Added()
+		source := p.Source
+		if p.Delete {
+			source += ", deletes the file"
+		}
+		view += fmt.Sprintf("  %s %s (%s) +%d -%d\n", checkbox(r.Selected[i]), p.Path, source, added, removed)
+		for j, h := range p.Hunks {
+			cursor := " "
+			if n == r.Cursor {
+				cursor = ">"
+			}
+			mark := "[ ]"
+			if r.Selected[i][j] {
+				mark = "[x]"
+			}
+			view += fmt.Sprintf("    %s %s %s%s%s\n", cursor, mark, colorHunk, h.Header(), colorReset)
+			if n == r.Cursor {
+				view += a.viewHunk(h)
+			}
+			n++
+		}
+	}
+
+	view += "\n  [j/k: hunk] [space: select] [a: all] [enter: apply selected] [esc: discard]\n"
+	return view
+}
+
+// viewHunk renders the lines of a hunk in color, as many as fit
+func (a App) viewHunk(h *patch.Hunk) string {
+	lines := h.Lines
+	more := 0
+	if limit := a.Height - 20; a.Height > 0 && limit > 0 && len(lines) > limit {
+		more = len(lines) - limit
+		lines = lines[:limit]
+	}
+
+	view := ""
+	for _, l := range lines {
+		switch l.Kind {
+		case '+':
+			view += "        " + colorAdded + "+" + l.Text + colorReset + "\n"
+		case '-':
+			view += "        " + colorRemoved + "-" + l.Text + colorReset + "\n"
+		default:
+			view += "         " + l.Text + "\n"
+		}
+	}
+	if more > 0 {
+		view += fmt.Sprintf("        … %d more lines\n", more)
+	}
+	return view
+}
+
+// checkbox returns "[x]" if every hunk is selected, "[~]" if some are and
+// "[ ]" if none are
+func checkbox(selected []bool) string {
+	count := 0
+	for _, s := range selected {
+		if s {
+			count++
+		}
+	}
+	switch count {
+	case len(selected):
+		return "[x]"
+	case 0:
+		return "[ ]"
+	}
+	return "[~]"
+}
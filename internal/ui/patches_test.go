@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/aui/internal/config"
+	"github.com/yourusername/aui/internal/context"
+)
+
+const patchesMain = "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n\n// Filler keeps the changes apart\n// so they make two hunks,\n// since each hunk shows three\n// unchanged lines around it.\n\nfunc helper() int {\n\treturn 1\n}\n"
+
+func TestApplyPatches(t *testing.T) {
+	reply := "Change `main.go`:\n\n```go\n" +
+		strings.Replace(strings.Replace(patchesMain, `"hello"`, `"hello, world"`, 1), "return 1", "return 2", 1) +
+		"```\n"
+	app := chatTestApp(&streamProvider{chunks: []string{reply}})
+	app.Root = t.TempDir()
+	app.Config = config.NewDefault()
+	app.Config.Patches.BackupDir = t.TempDir()
+
+	path := filepath.Join(app.Root, "main.go")
+	if err := os.WriteFile(path, []byte(patchesMain), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := context.LoadFile(app.Root, "main.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Contexts[0].AddFile(f)
+
+	app = pressKey(app, "p")
+	if app.Patches != nil || !strings.Contains(app.Status, "no reply") {
+		t.Errorf("'p' without a reply should fail, status %q", app.Status)
+	}
+
+	app, cmd := sendChat(t, app, "Say hello to the world")
+	app = runPipelineSteps(app, cmd)
+	app = pressKey(app, "esc")
+	app = pressKey(app, "p")
+	if app.Patches == nil {
+		t.Fatalf("'p' should open the changes, status %q", app.Status)
+	}
+
+	view := app.View()
+	for _, want := range []string{"[x] main.go (code block) +2 -2", "> [x] " + colorHunk + "@@ -3,7 +3,7 @@", colorAdded + "+\tfmt.Println(\"hello, world\")"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() should contain %q:\n%s", want, view)
+		}
+	}
+
+	// Keep the first hunk only
+	app = pressKey(app, "j")
+	model, _ := app.Update(tea.KeyMsg{Type: tea.KeySpace})
+	app = model.(App)
+	if view := app.View(); !strings.Contains(view, "[~] main.go") || !strings.Contains(view, "> [ ] ") {
+		t.Errorf("View() should show the second hunk unselected:\n%s", view)
+	}
+	app = pressKey(app, "enter")
+	if app.Patches != nil || !strings.HasPrefix(app.Status, "Applied 1 hunks to 1 files") {
+		t.Fatalf("enter should apply the hunk, status %q", app.Status)
+	}
+
+	data, _ := os.ReadFile(path)
+	want := strings.Replace(patchesMain, `"hello"`, `"hello, world"`, 1)
+	if string(data) != want {
+		t.Errorf("main.go = %q, want %q", data, want)
+	}
+	backups, _ := filepath.Glob(filepath.Join(app.Config.Patches.BackupDir, "*", "main.go"))
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v, want one", backups)
+	}
+	if backup, _ := os.ReadFile(backups[0]); string(backup) != patchesMain {
+		t.Errorf("backup = %q, want the original", backup)
+	}
+	if run := filepath.Base(filepath.Dir(backups[0])); !strings.HasPrefix(run, filepath.Base(app.Root)+"-") {
+		t.Errorf("backup directory = %s, want it named after the project", run)
+	}
+	if f := app.Contexts[0].GetFile("main.go"); f.Hash != context.HashContent(want) {
+		t.Error("the context's copy of main.go should be refreshed")
+	}
+}
+
+func TestDiscardPatches(t *testing.T) {
+	app := chatTestApp(&streamProvider{chunks: []string{"No code, sorry."}})
+	app.Root = t.TempDir()
+
+	app, cmd := sendChat(t, app, "Fix it")
+	app = runPipelineSteps(app, cmd)
+	app = pressKey(app, "esc")
+	app = pressKey(app, "p")
+	if app.Patches != nil || !strings.Contains(app.Status, "no changes to files of bug-fix-auth") {
+		t.Errorf("status = %q, want no changes found", app.Status)
+	}
+}
+
+func TestApplyPatchesDelete(t *testing.T) {
+	reply := "```diff\n--- a/old.go\n+++ /dev/null\n@@ -1,3 +0,0 @@\n-package main\n-\n-func old() {}\n```\n"
+	app := chatTestApp(&streamProvider{chunks: []string{reply}})
+	app.Root = t.TempDir()
+	app.Config = config.NewDefault()
+	app.Config.Patches.BackupDir = t.TempDir()
+
+	path := filepath.Join(app.Root, "old.go")
+	if err := os.WriteFile(path, []byte("package main\n\nfunc old() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := context.LoadFile(app.Root, "old.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Contexts[0].AddFile(f)
+
+	app, cmd := sendChat(t, app, "Remove old.go")
+	app = runPipelineSteps(app, cmd)
+	app = pressKey(app, "esc")
+	app = pressKey(app, "p")
+	if view := app.View(); !strings.Contains(view, "old.go (diff, deletes the file)") {
+		t.Fatalf("View() should say the diff deletes the file:\n%s", view)
+	}
+	app = pressKey(app, "enter")
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("old.go should be deleted, status %q", app.Status)
+	}
+	if app.Contexts[0].HasFile("old.go") || !strings.Contains(app.Status, "deleted old.go") {
+		t.Errorf("old.go should leave the context, status %q", app.Status)
+	}
+	if backups, _ := filepath.Glob(filepath.Join(app.Config.Patches.BackupDir, "*", "old.go")); len(backups) != 1 {
+		t.Errorf("backups = %v, want the deleted file", backups)
+	}
+}
@@ -0,0 +1,444 @@
+package ui
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/context"
+	"github.com/yourusername/aui/internal/orchestrator"
+	"github.com/yourusername/aui/internal/pipeline"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// Pipelines is the state of the Pipelines tab
+type Pipelines struct {
+	Pipelines []*pipeline.Pipeline
+	Selected  int // Index into Pipelines
+	Agent     int // Index into App.Agents, for steps that name no agent
+	Context   int // Index into App.Contexts, or -1 for no context
+	Input     string
+	Editing   bool            // The input has focus and receives all keys
+	Runs      []*pipeline.Run // Oldest first
+	Shown     int             // Index into Runs
+	Step      int             // Step of the shown run whose output is shown
+
+	active *activeRun
+}
+
+// activeRun is the pipeline run in progress
+type activeRun struct {
+	run     *pipeline.Run
+	agents  []*agent.Agent // Agent of each step
+	request func(prompt string) (*api.Request, error)
+	cancel  gocontext.CancelFunc
+	ctx     gocontext.Context
+}
+
+// pipelineStepMsg delivers the outcome of one pipeline step
+type pipelineStepMsg struct {
+	Run    *pipeline.Run
+	Step   int
+	Output string
+	Usage  api.TokenUsage
+	Cost   float64
+	Err    error
+}
+
+// pipelines returns the Pipelines tab state, creating it on first use
+func (a *App) pipelines() *Pipelines {
+	if a.Pipelines == nil {
+		a.Pipelines = &Pipelines{Context: a.SelectedContext, Shown: -1}
+		if a.Pipelines.Context >= len(a.Contexts) {
+			a.Pipelines.Context = -1
+		}
+	}
+	return a.Pipelines
+}
+
+// loadPipelines reads the pipeline definitions, and the earlier runs the
+// first time the tab is shown
+func (a *App) loadPipelines() {
+	p := a.pipelines()
+
+	all, err := pipeline.LoadAll(pipeline.Dirs(a.Root)...)
+	if err != nil {
+		a.Status = fmt.Sprintf("Failed to load pipelines: %v", err)
+		all = pipeline.Builtin
+	}
+	p.Pipelines = all
+	if p.Selected >= len(all) {
+		p.Selected = 0
+	}
+
+	if p.Runs == nil && a.Store != nil {
+		runs, err := a.Store.ListPipelineRuns()
+		if err != nil {
+			a.Status = fmt.Sprintf("Failed to load pipeline runs: %v", err)
+			return
+		}
+		p.Runs = runs
+		p.Shown = len(runs) - 1
+	}
+}
+
+// PipelineAgent returns the agent for steps that name none, or nil
+func (a *App) PipelineAgent() *agent.Agent {
+	p := a.pipelines()
+	if p.Agent < 0 || p.Agent >= len(a.Agents) {
+		return nil
+	}
+	return a.Agents[p.Agent]
+}
+
+// PipelineContext returns the context attached to every step, or nil
+func (a *App) PipelineContext() *context.Context {
+	p := a.pipelines()
+	if p.Context < 0 || p.Context >= len(a.Contexts) {
+		return nil
+	}
+	return a.Contexts[p.Context]
+}
+
+// ShownRun returns the pipeline run shown, or nil
+func (a *App) ShownRun() *pipeline.Run {
+	p := a.pipelines()
+	if p.Shown < 0 || p.Shown >= len(p.Runs) {
+		return nil
+	}
+	return p.Runs[p.Shown]
+}
+
+// updatePipelines handles keys for the Pipelines tab
+func (a App) updatePipelines(msg tea.KeyMsg) (App, tea.Cmd) {
+	p := a.pipelines()
+
+	if p.Editing {
+		return a.updatePipelineInput(msg)
+	}
+
+	switch msg.String() {
+	case "j", "down":
+		if p.Selected < len(p.Pipelines)-1 {
+			p.Selected++
+		}
+	case "k", "up":
+		if p.Selected > 0 {
+			p.Selected--
+		}
+	case "g":
+		if len(a.Agents) > 0 {
+			p.Agent = (p.Agent + 1) % len(a.Agents)
+		}
+	case "c":
+		// Cycle through the contexts and then no context
+		p.Context++
+		if p.Context >= len(a.Contexts) {
+			p.Context = -1
+		}
+	case "s":
+		if run := a.ShownRun(); run != nil {
+			p.Step = (p.Step + 1) % len(run.Steps)
+		}
+	case "x":
+		if p.active == nil {
+			a.Status = "Error: no pipeline is running"
+			break
+		}
+		p.active.cancel()
+		a.Status = "Cancelling the pipeline..."
+	case "[":
+		if p.Shown > 0 {
+			p.Shown--
+			p.Step = 0
+		}
+	case "]":
+		if p.Shown < len(p.Runs)-1 {
+			p.Shown++
+			p.Step = 0
+		}
+	case "i", "enter":
+		p.Editing = true
+	}
+	return a, nil
+}
+
+// updatePipelineInput handles keys while the input has focus
+func (a App) updatePipelineInput(msg tea.KeyMsg) (App, tea.Cmd) {
+	p := a.Pipelines
+
+	switch msg.Type {
+	case tea.KeyEnter:
+		if msg.Alt {
+			p.Input += "\n"
+			break
+		}
+		input := strings.TrimSpace(p.Input)
+		if input == "" {
+			break
+		}
+		cmd, err := a.RunPipeline(input)
+		if err != nil {
+			a.Status = fmt.Sprintf("Error: %v", err)
+			break
+		}
+		p.Input = ""
+		p.Editing = false
+		return a, cmd
+	case tea.KeyEsc:
+		p.Editing = false
+	case tea.KeyBackspace:
+		if len(p.Input) > 0 {
+			runes := []rune(p.Input)
+			p.Input = string(runes[:len(runes)-1])
+		}
+	case tea.KeySpace:
+		p.Input += " "
+	case tea.KeyRunes:
+		p.Input += string(msg.Runes)
+	}
+	return a, nil
+}
+
+// RunPipeline starts the selected pipeline with input and returns the
+// command that runs its first step
+func (a *App) RunPipeline(input string) (tea.Cmd, error) {
+	p := a.pipelines()
+	if p.active != nil {
+		return nil, fmt.Errorf("pipeline %s is still running", p.active.run.Pipeline)
+	}
+	if p.Selected >= len(p.Pipelines) {
+		return nil, fmt.Errorf("no pipeline selected")
+	}
+	def := p.Pipelines[p.Selected]
+
+	agents, err := orchestrator.PipelineAgents(def, a.Agents, a.Catalog, a.PipelineAgent())
+	if err != nil {
+		return nil, err
+	}
+	for _, ag := range agents {
+		if ag.Status == agent.StatusWorking {
+			return nil, fmt.Errorf("%s is still working", ag.Name)
+		}
+	}
+
+	ctx := a.PipelineContext()
+	contextID := ""
+	if ctx != nil {
+		contextID = ctx.ID
+	}
+	request, redactions, err := a.sharedRequest(agents, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	run := pipeline.NewRun(def, input, contextID)
+	runCtx, cancel := gocontext.WithCancel(gocontext.Background())
+	p.active = &activeRun{run: run, agents: agents, request: request, cancel: cancel, ctx: runCtx}
+	p.Runs = append(p.Runs, run)
+	p.Shown = len(p.Runs) - 1
+	p.Step = 0
+
+	a.Status = ""
+	if redactions > 0 {
+		a.Status = fmt.Sprintf("⚠ %d secrets were redacted before sending", redactions)
+	}
+	return tea.Batch(a.nextPipelineStep(), a.startSpinner()), nil
+}
+
+// nextPipelineStep starts the next step of the active run and returns the
+// command that runs it, or nil once the run is over
+func (a *App) nextPipelineStep() tea.Cmd {
+	p := a.Pipelines
+	active := p.active
+
+	i := active.run.Next()
+	if i < 0 {
+		a.endPipeline()
+		return nil
+	}
+
+	ag := active.agents[i]
+	prompt, err := active.run.Start(i, ag.ID, ag.Name, ag.Model)
+	a.savePipelineRun(active.run)
+	if err != nil {
+		a.endPipeline()
+		return nil
+	}
+	ag.AssignTask(fmt.Sprintf("pipeline %s: %s", active.run.Pipeline, active.run.Steps[i].Name))
+	p.Step = i
+
+	// The request is made from a copy, since the agent changes on the UI
+	// goroutine while it works
+	snapshot := *ag
+	o := a.orchestrator()
+	providerFor := a.providerFor
+	run := active.run
+	return func() tea.Msg {
+		output, usage, cost, err := o.RunStep(active.ctx, &snapshot, prompt, active.request, providerFor)
+		return pipelineStepMsg{Run: run, Step: i, Output: output, Usage: usage, Cost: cost, Err: err}
+	}
+}
+
+// handlePipelineStep records the outcome of a step and starts the next one
+func (a App) handlePipelineStep(msg pipelineStepMsg) (App, tea.Cmd) {
+	p := a.pipelines()
+	if p.active == nil || p.active.run != msg.Run {
+		return a, nil
+	}
+	ag := p.active.agents[msg.Step]
+
+	switch {
+	case errors.Is(msg.Err, gocontext.Canceled):
+		msg.Run.Cancel()
+		ag.Cancel()
+	case msg.Err != nil:
+		msg.Run.Finish(msg.Step, msg.Output, msg.Usage, msg.Cost, msg.Err)
+		ag.SetError(msg.Err.Error())
+	default:
+		msg.Run.Finish(msg.Step, msg.Output, msg.Usage, msg.Cost, nil)
+		ag.CompleteTask()
+	}
+	if ag.ID != "" {
+		a.saveAgent(ag)
+	}
+	a.savePipelineRun(msg.Run)
+
+	cmd := a.nextPipelineStep()
+	return a, cmd
+}
+
+// endPipeline clears the active run and reports how it ended
+func (a *App) endPipeline() {
+	p := a.Pipelines
+	run := p.active.run
+	p.active.cancel()
+	p.active = nil
+
+	switch run.Status {
+	case pipeline.StatusDone:
+		usage, cost := run.Usage()
+		a.Status = fmt.Sprintf("Pipeline %s done (%d in / %d out tokens, $%.4f)", run.Pipeline, usage.InputTokens, usage.OutputTokens, cost)
+	case pipeline.StatusCancelled:
+		a.Status = fmt.Sprintf("Cancelled pipeline %s", run.Pipeline)
+	default:
+		for _, s := range run.Steps {
+			if s.Status == pipeline.StatusFailed {
+				a.Status = fmt.Sprintf("Pipeline %s failed at step %s: %s", run.Pipeline, s.Name, s.Error)
+			}
+		}
+	}
+}
+
+// savePipelineRun persists a run if there is a store
+func (a *App) savePipelineRun(run *pipeline.Run) {
+	if a.Store == nil {
+		return
+	}
+	if err := a.Store.SavePipelineRun(run); err != nil {
+		a.Status = fmt.Sprintf("Failed to save pipeline run: %v", err)
+	}
+}
+
+// viewPipelines renders the Pipelines tab
+func (a App) viewPipelines() string {
+	p := a.Pipelines
+	if p == nil {
+		p = &Pipelines{Pipelines: pipeline.Builtin, Shown: -1}
+	}
+
+	view := "Pipelines:\n"
+	for i, def := range p.Pipelines {
+		bullet := "•"
+		if i == p.Selected {
+			bullet = ">"
+		}
+		steps := make([]string, len(def.Steps))
+		for j, s := range def.Steps {
+			steps[j] = s.Name
+		}
+		view += fmt.Sprintf("  %s %s - %s\n", bullet, def.Name, def.Description)
+		view += fmt.Sprintf("      %s\n", strings.Join(steps, " → "))
+	}
+
+	agentName := "none"
+	if p.Agent >= 0 && p.Agent < len(a.Agents) {
+		agentName = a.Agents[p.Agent].Name
+	}
+	contextName := "no context"
+	if p.Context >= 0 && p.Context < len(a.Contexts) {
+		contextName = "context " + a.Contexts[p.Context].Name
+	}
+	view += fmt.Sprintf("\n  Steps without an agent use %s · %s\n", agentName, contextName)
+
+	if p.Shown >= 0 && p.Shown < len(p.Runs) {
+		view += a.viewPipelineRun(p.Runs[p.Shown], p.Shown+1, len(p.Runs), p.Step)
+	}
+
+	view += "\n"
+	if p.Editing {
+		lines := strings.Split(p.Input+"█", "\n")
+		view += "  > " + strings.Join(lines, "\n    ") + "\n"
+		view += "  [enter: run] [alt+enter: new line] [esc: stop typing]\n"
+	} else {
+		view += "  [j/k: select] [i: type input and run] [g: next agent] [c: next context]\n"
+		view += "  [x: cancel] [s: next step output] [[/]: previous/next run]\n"
+	}
+	return view
+}
+
+// viewPipelineRun renders the step timeline of a run and the output of one
+// of its steps
+func (a App) viewPipelineRun(run *pipeline.Run, n, total, shownStep int) string {
+	view := fmt.Sprintf("\n  Run %d/%d: %s %q · %s\n", n, total, run.Pipeline, truncate(run.Input, 50), run.Status)
+
+	now := time.Now()
+	for i, s := range run.Steps {
+		marker := map[pipeline.Status]string{
+			pipeline.StatusDone:      "✓",
+			pipeline.StatusFailed:    "✗",
+			pipeline.StatusCancelled: "-",
+			pipeline.StatusSkipped:   "-",
+		}[s.Status]
+		if s.Status == pipeline.StatusRunning {
+			marker = spinnerFrames[a.Spinner%len(spinnerFrames)]
+		}
+		if marker == "" {
+			marker = "·"
+		}
+
+		pointer := " "
+		if i == shownStep {
+			pointer = ">"
+		}
+		line := fmt.Sprintf("  %s %s %-12s %-10s %-9s", pointer, marker, s.Name, s.Agent, s.Status)
+		if !s.StartedAt.IsZero() {
+			line += " " + s.Duration(now).Round(time.Second).String()
+		}
+		view += strings.TrimRight(line, " ") + "\n"
+	}
+
+	if shownStep < 0 || shownStep >= len(run.Steps) {
+		return view
+	}
+	s := run.Steps[shownStep]
+	switch {
+	case s.Error != "":
+		view += fmt.Sprintf("\n  %s failed: %s\n", s.Name, s.Error)
+	case s.Status == pipeline.StatusDone:
+		view += fmt.Sprintf("\n  %s (%s) · %d in / %d out · $%.4f:\n", s.Name, s.Model, s.Usage.InputTokens, s.Usage.OutputTokens, s.Cost)
+		lines := strings.Split(formatMarkdown(strings.TrimSpace(s.Output)), "\n")
+		if len(lines) > compareAnswerLines {
+			more := len(lines) - compareAnswerLines
+			lines = append(lines[:compareAnswerLines], fmt.Sprintf("… (%d more lines)", more))
+		}
+		for _, line := range lines {
+			view += "    │ " + line + "\n"
+		}
+	}
+	return view
+}
@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/aui/internal/agent"
+	"github.com/yourusername/aui/internal/pipeline"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// pipelinesTestApp returns an app on the Pipelines tab with a two-step
+// pipeline whose agents use provider
+func pipelinesTestApp(provider api.Provider) App {
+	app := compareTestApp(provider)
+	app.Tabs[app.ActiveTab] = "Pipelines"
+	app.Pipelines = &Pipelines{Shown: -1, Context: -1, Pipelines: []*pipeline.Pipeline{{
+		Name: "plan-review",
+		Steps: []pipeline.Step{
+			{Name: "plan", Prompt: "plan {{.Input}}"},
+			{Name: "review", Agent: "Gemini", Prompt: "review {{.Previous}}"},
+		},
+	}}}
+	return app
+}
+
+// runPipelineSteps applies the messages of a pipeline's commands until the
+// run is over
+func runPipelineSteps(app App, cmd tea.Cmd) App {
+	for cmd != nil {
+		msg := cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			msg = batch[0]()
+		}
+		var model tea.Model
+		model, cmd = app.Update(msg)
+		app = model.(App)
+	}
+	return app
+}
+
+func TestRunPipeline(t *testing.T) {
+	provider := &modelProvider{replies: map[string]string{
+		"claude-sonnet-4-5": "1. add a form",
+		"gemini-2.5-pro":    "looks good",
+	}}
+	app := pipelinesTestApp(provider)
+
+	app = pressKey(app, "i")
+	app = typeText(app, "add login")
+	model, cmd := app.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	app = model.(App)
+	if cmd == nil {
+		t.Fatalf("entering input should start the pipeline, status %q", app.Status)
+	}
+	if app.Agents[0].Status != agent.StatusWorking {
+		t.Error("the agent of the first step should be working")
+	}
+	if view := app.View(); !strings.Contains(view, "plan         Claude") || !strings.Contains(view, "running") {
+		t.Errorf("View() should show the running step:\n%s", view)
+	}
+
+	app = runPipelineSteps(app, cmd)
+
+	run := app.ShownRun()
+	if run == nil || run.Status != pipeline.StatusDone {
+		t.Fatalf("run = %+v, want done", run)
+	}
+	if run.Steps[0].Prompt != "plan add login" || run.Steps[1].Agent != "Gemini" || run.Output() != "looks good" {
+		t.Errorf("steps = %+v, %+v", run.Steps[0], run.Steps[1])
+	}
+	for _, ag := range app.Agents {
+		if ag.Status != agent.StatusReady {
+			t.Errorf("%s status = %s after the run, want ready", ag.Name, ag.Status)
+		}
+	}
+
+	view := app.View()
+	for _, want := range []string{"Run 1/1: plan-review \"add login\" · done", "✓ plan", "> ✓ review", "looks good", "Pipeline plan-review done"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() missing %q:\n%s", want, view)
+		}
+	}
+
+	app = pressKey(app, "s")
+	if view := app.View(); !strings.Contains(view, "1. add a form") {
+		t.Errorf("'s' should show the output of the next step:\n%s", view)
+	}
+}
+
+func TestRunPipelineCancel(t *testing.T) {
+	app := pipelinesTestApp(&streamProvider{block: true})
+
+	cmd, err := app.RunPipeline("add login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.RunPipeline("again"); err == nil {
+		t.Error("RunPipeline() should refuse a second run while one is active")
+	}
+
+	app = pressKey(app, "x")
+	app = runPipelineSteps(app, cmd)
+
+	run := app.ShownRun()
+	if run.Status != pipeline.StatusCancelled || run.Steps[0].Status != pipeline.StatusCancelled || run.Steps[1].Status != pipeline.StatusSkipped {
+		t.Errorf("run = %s, steps %s/%s", run.Status, run.Steps[0].Status, run.Steps[1].Status)
+	}
+	if app.Agents[0].Status == agent.StatusWorking {
+		t.Error("cancelling should free the agent")
+	}
+}
+
+func TestRunPipelineUnknownAgent(t *testing.T) {
+	app := pipelinesTestApp(&modelProvider{})
+	app.Pipelines.Pipelines[0].Steps[1].Agent = "Nobody"
+
+	if _, err := app.RunPipeline("add login"); err == nil || !strings.Contains(err.Error(), "Nobody") {
+		t.Errorf("RunPipeline() error = %v, want the unknown agent named", err)
+	}
+}
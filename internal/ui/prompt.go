@@ -0,0 +1,47 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Prompt is a single-line text input shown below the active tab. While a
+// prompt is open it receives all keys except ctrl+c.
+type Prompt struct {
+	Label  string
+	Value  string
+	Submit func(a App, value string) App
+}
+
+// OpenPrompt shows a prompt that calls submit with the entered value
+func (a *App) OpenPrompt(label string, submit func(a App, value string) App) {
+	a.Prompt = &Prompt{Label: label, Submit: submit}
+}
+
+// updatePrompt handles keys while a prompt is open
+func (a App) updatePrompt(msg tea.KeyMsg) (App, tea.Cmd) {
+	p := a.Prompt
+
+	switch msg.Type {
+	case tea.KeyEnter:
+		a.Prompt = nil
+		return p.Submit(a, p.Value), nil
+	case tea.KeyEsc:
+		a.Prompt = nil
+	case tea.KeyBackspace:
+		if len(p.Value) > 0 {
+			runes := []rune(p.Value)
+			a.Prompt = &Prompt{Label: p.Label, Value: string(runes[:len(runes)-1]), Submit: p.Submit}
+		}
+	case tea.KeySpace:
+		a.Prompt = &Prompt{Label: p.Label, Value: p.Value + " ", Submit: p.Submit}
+	case tea.KeyRunes:
+		a.Prompt = &Prompt{Label: p.Label, Value: p.Value + string(msg.Runes), Submit: p.Submit}
+	}
+
+	return a, nil
+}
+
+// viewPrompt renders the open prompt
+func (a App) viewPrompt() string {
+	return "\n" + a.Prompt.Label + ": " + a.Prompt.Value + "█\n"
+}
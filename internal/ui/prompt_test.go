@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPromptCapturesKeys(t *testing.T) {
+	app := InitialApp()
+
+	var submitted string
+	app.OpenPrompt("Name", func(a App, value string) App {
+		submitted = value
+		return a
+	})
+
+	// Keys that normally quit or switch tabs are typed into the prompt
+	for _, key := range []string{"q", "h", "l"} {
+		app = pressKey(app, key)
+	}
+	model, _ := app.Update(tea.KeyMsg{Type: tea.KeySpace})
+	app = model.(App)
+	app = pressKey(app, "x")
+	model, _ = app.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	app = model.(App)
+
+	if app.Quitting || app.ActiveTab != 0 {
+		t.Error("Keys should go to the prompt while it is open")
+	}
+
+	if !strings.Contains(app.View(), "Name: qhl █") {
+		t.Errorf("View should render the prompt, got:\n%s", app.View())
+	}
+
+	app = pressKey(app, "enter")
+	if submitted != "qhl " {
+		t.Errorf("Submitted value = %q, want %q", submitted, "qhl ")
+	}
+	if app.Prompt != nil {
+		t.Error("Prompt should close after submit")
+	}
+}
+
+func TestPromptEscCancels(t *testing.T) {
+	app := InitialApp()
+
+	called := false
+	app.OpenPrompt("Name", func(a App, value string) App {
+		called = true
+		return a
+	})
+
+	app = pressKey(app, "esc")
+
+	if app.Prompt != nil {
+		t.Error("Prompt should close on esc")
+	}
+	if called {
+		t.Error("Submit should not be called on esc")
+	}
+}
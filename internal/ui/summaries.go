@@ -0,0 +1,50 @@
+package ui
+
+import (
+	gocontext "context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/aui/internal/context"
+)
+
+// summaryMsg reports the result of summarizing a file
+type summaryMsg struct {
+	Path    string
+	Tokens  int // Tokens of the full file
+	Summary *context.Summary
+	Cached  bool
+	Err     error
+}
+
+// summarizeFile returns a command that summarizes a file with the configured
+// summarize agent, or nil if there is none
+func (a *App) summarizeFile(f *context.File) tea.Cmd {
+	if a.Summarizer == nil {
+		a.Status = "No summarize agent configured (set summarize.agent in the config)"
+		return nil
+	}
+
+	a.Status = fmt.Sprintf("Summarizing %s...", f.Path)
+	summarizer := a.Summarizer
+	return func() tea.Msg {
+		summary, cached, err := summarizer.Summarize(gocontext.Background(), f)
+		return summaryMsg{Path: f.Path, Tokens: f.Tokens, Summary: summary, Cached: cached, Err: err}
+	}
+}
+
+// handleSummary shows the outcome of a summarize command
+func (a App) handleSummary(msg summaryMsg) App {
+	if msg.Err != nil {
+		a.Status = fmt.Sprintf("Cannot summarize: %v", msg.Err)
+		return a
+	}
+
+	source := "new"
+	if msg.Cached {
+		source = "cached"
+	}
+	a.Status = fmt.Sprintf("Summarized %s: %d → %d tokens (%s); used when the context is over budget",
+		msg.Path, msg.Tokens, msg.Summary.Tokens, source)
+	return a
+}
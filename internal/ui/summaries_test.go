@@ -0,0 +1,50 @@
+package ui
+
+import (
+	gocontext "context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yourusername/aui/internal/summarize"
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// replyProvider answers every request with a fixed reply
+type replyProvider struct {
+	reply string
+}
+
+func (p *replyProvider) Name() string { return "fake" }
+
+func (p *replyProvider) ValidateConfig(map[string]string) error { return nil }
+
+func (p *replyProvider) SendMessage(_ gocontext.Context, _ *api.Request) (<-chan api.Response, error) {
+	ch := make(chan api.Response, 1)
+	ch <- api.Response{Content: p.reply, Done: true}
+	close(ch)
+	return ch, nil
+}
+
+func TestSummarizeSelectedFile(t *testing.T) {
+	app := filesTestApp(t)
+
+	app = pressKey(app, "m")
+	if !strings.Contains(app.Status, "No summarize agent configured") {
+		t.Errorf("Status = %q, want a missing agent hint", app.Status)
+	}
+
+	app.Summarizer = &summarize.Summarizer{Provider: &replyProvider{reply: "Entry point."}, Model: "cheap"}
+
+	model, cmd := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("m")})
+	app = model.(App)
+	if cmd == nil || !strings.Contains(app.Status, "Summarizing cmd/main.go") {
+		t.Fatalf("'m' should start summarizing, status %q", app.Status)
+	}
+
+	model, _ = app.Update(cmd())
+	app = model.(App)
+	if !strings.Contains(app.Status, "Summarized cmd/main.go") || !strings.Contains(app.Status, "(new)") {
+		t.Errorf("Status = %q", app.Status)
+	}
+}
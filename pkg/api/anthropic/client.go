@@ -0,0 +1,248 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// DefaultBaseURL is the Anthropic API endpoint
+const DefaultBaseURL = "https://api.anthropic.com"
+
+// apiVersion is the Messages API version sent with every request
+const apiVersion = "2023-06-01"
+
+// AnthropicClient talks to the Anthropic Messages API
+type AnthropicClient struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the given API key
+func NewClient(apiKey string) *AnthropicClient {
+	return &AnthropicClient{
+		apiKey:     apiKey,
+		baseURL:    DefaultBaseURL,
+		httpClient: http.DefaultClient,
+	}
+}
+
+// WithBaseURL points the client at another endpoint, such as a proxy
+func (c *AnthropicClient) WithBaseURL(url string) *AnthropicClient {
+	c.baseURL = url
+	return c
+}
+
+// WithHTTPClient replaces the HTTP client used for requests
+func (c *AnthropicClient) WithHTTPClient(client *http.Client) *AnthropicClient {
+	c.httpClient = client
+	return c
+}
+
+// Name returns the provider name
+func (c *AnthropicClient) Name() string {
+	return "anthropic"
+}
+
+// models are the suggested models, most capable first
+var models = []string{"claude-sonnet-4-5", "claude-opus-4-1", "claude-haiku-4-5", "claude-3-5-haiku-latest"}
+
+// Models returns the suggested models
+func (c *AnthropicClient) Models() []string {
+	return models
+}
+
+// ValidateConfig checks that an API key is configured
+func (c *AnthropicClient) ValidateConfig(config map[string]string) error {
+	if config["anthropic"] == "" {
+		return fmt.Errorf("anthropic API key is required")
+	}
+	return nil
+}
+
+// message is a Messages API message. Content is a string, or a list of
+// blocks when the message carries tool calls or results.
+type message struct {
+	Role    string      `json:"role"`
+	Content interface{} `json:"content"`
+}
+
+// block is a content block of a message
+type block struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`          // tool_use
+	Name      string          `json:"name,omitempty"`        // tool_use
+	Input     json.RawMessage `json:"input,omitempty"`       // tool_use
+	ToolUseID string          `json:"tool_use_id,omitempty"` // tool_result
+	Content   string          `json:"content,omitempty"`     // tool_result
+	IsError   bool            `json:"is_error,omitempty"`    // tool_result
+}
+
+// tool is a tool the model may use
+type tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+// request is a Messages API request body
+type request struct {
+	Model         string    `json:"model"`
+	System        string    `json:"system,omitempty"`
+	Messages      []message `json:"messages"`
+	MaxTokens     int       `json:"max_tokens"`
+	Stream        bool      `json:"stream"`
+	Temperature   *float64  `json:"temperature,omitempty"`
+	TopP          *float64  `json:"top_p,omitempty"`
+	StopSequences []string  `json:"stop_sequences,omitempty"`
+	Thinking      *thinking `json:"thinking,omitempty"`
+	Tools         []tool    `json:"tools,omitempty"`
+}
+
+// thinking enables extended thinking with a token budget
+type thinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
+// event is a streamed Messages API event; only the fields used are decoded
+type event struct {
+	Type    string `json:"type"`
+	Message struct {
+		Usage usage `json:"usage"`
+	} `json:"message"`
+	Index        int   `json:"index"`
+	ContentBlock block `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+	} `json:"delta"`
+	Usage usage `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// usage is the token usage reported in message_start and message_delta
+type usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// SendMessage sends a request and streams the reply
+func (c *AnthropicClient) SendMessage(ctx context.Context, req *api.Request) (<-chan api.Response, error) {
+	body := request{
+		Model:         req.Model,
+		System:        req.System,
+		MaxTokens:     req.MaxTokens,
+		Stream:        true,
+		Temperature:   req.Temperature,
+		TopP:          req.TopP,
+		StopSequences: req.StopSequences,
+	}
+	if body.MaxTokens == 0 {
+		body.MaxTokens = api.DefaultMaxTokens
+	}
+	if req.Reasoning != nil {
+		body.Thinking = &thinking{Type: "enabled", BudgetTokens: req.Reasoning.Budget()}
+		// The thinking budget counts towards max_tokens
+		if body.MaxTokens <= body.Thinking.BudgetTokens {
+			body.MaxTokens = body.Thinking.BudgetTokens + api.DefaultMaxTokens
+		}
+	}
+	for _, t := range req.Tools {
+		body.Tools = append(body.Tools, tool{Name: t.Name, Description: t.Description, InputSchema: t.Parameters})
+	}
+	for _, m := range req.Messages {
+		body.Messages = append(body.Messages, message{Role: string(m.Role), Content: content(m)})
+	}
+
+	payload, err := api.WithExtensions(body, req.Extensions)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{
+		"x-api-key":         c.apiKey,
+		"anthropic-version": apiVersion,
+	}
+
+	resp, err := api.PostJSON(ctx, c.httpClient, c.Name(), c.baseURL+"/v1/messages", headers, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return api.Stream(ctx, resp.Body, req.Stream, func(send func(string), call func(api.ToolCall)) (api.TokenUsage, error) {
+		var total api.TokenUsage
+		// Tool calls by block index, whose input arrives in pieces
+		calls := make(map[int]*api.ToolCall)
+		inputs := make(map[int]*strings.Builder)
+
+		err := api.ReadSSE(resp.Body, func(_, data string) error {
+			var ev event
+			if err := json.Unmarshal([]byte(data), &ev); err != nil {
+				return fmt.Errorf("failed to decode event: %w", err)
+			}
+
+			switch ev.Type {
+			case "message_start":
+				total.InputTokens = ev.Message.Usage.InputTokens
+			case "content_block_start":
+				if ev.ContentBlock.Type == "tool_use" {
+					calls[ev.Index] = &api.ToolCall{ID: ev.ContentBlock.ID, Name: ev.ContentBlock.Name}
+					inputs[ev.Index] = &strings.Builder{}
+				}
+			case "content_block_delta":
+				switch ev.Delta.Type {
+				case "text_delta":
+					send(ev.Delta.Text)
+				case "input_json_delta":
+					if input := inputs[ev.Index]; input != nil {
+						input.WriteString(ev.Delta.PartialJSON)
+					}
+				}
+			case "content_block_stop":
+				if c := calls[ev.Index]; c != nil {
+					c.Input = json.RawMessage(inputs[ev.Index].String())
+					call(*c)
+					delete(calls, ev.Index)
+				}
+			case "message_delta":
+				total.OutputTokens = ev.Usage.OutputTokens
+			case "error":
+				return fmt.Errorf("anthropic stream error: %s: %s", ev.Error.Type, ev.Error.Message)
+			}
+			return nil
+		})
+
+		return total, err
+	}), nil
+}
+
+// content returns the content of a message: its text, or blocks for the text
+// and any tool calls or results
+func content(m api.Message) interface{} {
+	if len(m.ToolCalls) == 0 && len(m.ToolResults) == 0 {
+		return m.Content
+	}
+
+	var blocks []block
+	if m.Content != "" {
+		blocks = append(blocks, block{Type: "text", Text: m.Content})
+	}
+	for _, c := range m.ToolCalls {
+		blocks = append(blocks, block{Type: "tool_use", ID: c.ID, Name: c.Name, Input: c.Arguments()})
+	}
+	for _, r := range m.ToolResults {
+		blocks = append(blocks, block{Type: "tool_result", ToolUseID: r.CallID, Content: r.Content, IsError: r.IsError})
+	}
+	return blocks
+}
@@ -0,0 +1,227 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yourusername/aui/pkg/api"
+)
+
+const testStream = `event: message_start
+data: {"type":"message_start","message":{"usage":{"input_tokens":12,"output_tokens":1}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" there"}}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":5}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+
+func TestSendMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("path = %s, want /v1/messages", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "test-key" || r.Header.Get("anthropic-version") != apiVersion {
+			t.Errorf("missing auth headers: %v", r.Header)
+		}
+
+		var body request
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if body.Model != "claude-3-5-haiku" || body.System != "Be brief." || !body.Stream || body.MaxTokens != api.DefaultMaxTokens {
+			t.Errorf("request = %+v", body)
+		}
+		if len(body.Messages) != 1 || body.Messages[0].Role != "user" || body.Messages[0].Content != "Hi" {
+			t.Errorf("messages = %+v", body.Messages)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, testStream)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key").WithBaseURL(server.URL)
+
+	responses, err := client.SendMessage(context.Background(), &api.Request{
+		Model:    "claude-3-5-haiku",
+		System:   "Be brief.",
+		Messages: []api.Message{{Role: api.RoleUser, Content: "Hi"}},
+		Stream:   true,
+	})
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	text, usage, err := api.Collect(responses)
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if text != "Hello there" {
+		t.Errorf("text = %q, want %q", text, "Hello there")
+	}
+	if usage.InputTokens != 12 || usage.OutputTokens != 5 {
+		t.Errorf("usage = %+v, want 12 in, 5 out", usage)
+	}
+}
+
+func TestSendMessageStreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+	}))
+	defer server.Close()
+
+	responses, err := NewClient("k").WithBaseURL(server.URL).SendMessage(context.Background(), &api.Request{Model: "m"})
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if _, _, err := api.Collect(responses); err == nil {
+		t.Error("Collect() should return the stream error")
+	}
+}
+
+func TestModels(t *testing.T) {
+	var lister api.ModelLister = NewClient("")
+	if len(lister.Models()) == 0 {
+		t.Error("Models() should suggest at least one model")
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	client := NewClient("")
+	if err := client.ValidateConfig(map[string]string{}); err == nil {
+		t.Error("ValidateConfig() without a key should fail")
+	}
+	if err := client.ValidateConfig(map[string]string{"anthropic": "k"}); err != nil {
+		t.Errorf("ValidateConfig() error = %v", err)
+	}
+}
+
+func TestSendMessageParams(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		io.WriteString(w, testStream)
+	}))
+	defer server.Close()
+
+	temperature, topP := 0.2, 0.9
+	responses, err := NewClient("k").WithBaseURL(server.URL).SendMessage(context.Background(), &api.Request{
+		Model:         "claude-sonnet-4",
+		MaxTokens:     2000,
+		Temperature:   &temperature,
+		TopP:          &topP,
+		StopSequences: []string{"END"},
+		Reasoning:     &api.Reasoning{BudgetTokens: 4000},
+		Extensions:    map[string]interface{}{"top_k": 40},
+	})
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	api.Collect(responses)
+
+	if body["temperature"] != 0.2 || body["top_p"] != 0.9 || body["top_k"] != float64(40) {
+		t.Errorf("sampling params = %v", body)
+	}
+	if stop := body["stop_sequences"].([]interface{}); len(stop) != 1 || stop[0] != "END" {
+		t.Errorf("stop_sequences = %v", body["stop_sequences"])
+	}
+	thinking := body["thinking"].(map[string]interface{})
+	if thinking["type"] != "enabled" || thinking["budget_tokens"] != float64(4000) {
+		t.Errorf("thinking = %v", thinking)
+	}
+	// max_tokens must exceed the thinking budget
+	if body["max_tokens"] != float64(4000+api.DefaultMaxTokens) {
+		t.Errorf("max_tokens = %v", body["max_tokens"])
+	}
+}
+
+const toolStream = `event: message_start
+data: {"type":"message_start","message":{"usage":{"input_tokens":20,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Let me look."}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"read_file","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"path\": \"ma"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"in.go\"}"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":1}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":30}}
+
+`
+
+func TestSendMessageTools(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		io.WriteString(w, toolStream)
+	}))
+	defer server.Close()
+
+	req := &api.Request{
+		Model:    "claude-sonnet-4",
+		Messages: []api.Message{{Role: api.RoleUser, Content: "What does main do?"}},
+		Tools: []api.Tool{{
+			Name:        "read_file",
+			Description: "Read a file",
+			Parameters:  map[string]interface{}{"type": "object"},
+		}},
+	}
+	req.AppendToolTurn("Checking.", []api.ToolCall{{ID: "toolu_0", Name: "list_dir"}}, []api.ToolResult{{CallID: "toolu_0", Name: "list_dir", Content: "main.go"}})
+
+	responses, err := NewClient("k").WithBaseURL(server.URL).SendMessage(context.Background(), req)
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	text, calls, _, err := api.CollectCalls(responses)
+	if err != nil {
+		t.Fatalf("CollectCalls() error = %v", err)
+	}
+	if text != "Let me look." || len(calls) != 1 {
+		t.Fatalf("CollectCalls() = %q, %+v", text, calls)
+	}
+	if calls[0].ID != "toolu_1" || calls[0].Name != "read_file" || string(calls[0].Input) != `{"path": "main.go"}` {
+		t.Errorf("call = %+v", calls[0])
+	}
+
+	tools := body["tools"].([]interface{})
+	if spec := tools[0].(map[string]interface{}); spec["name"] != "read_file" || spec["input_schema"] == nil {
+		t.Errorf("tools = %v", tools)
+	}
+	messages := body["messages"].([]interface{})
+	use := messages[1].(map[string]interface{})["content"].([]interface{})
+	if len(use) != 2 || use[1].(map[string]interface{})["type"] != "tool_use" || use[1].(map[string]interface{})["id"] != "toolu_0" {
+		t.Errorf("assistant content = %v", use)
+	}
+	result := messages[2].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+	if result["type"] != "tool_result" || result["tool_use_id"] != "toolu_0" || result["content"] != "main.go" {
+		t.Errorf("tool result = %v", result)
+	}
+}
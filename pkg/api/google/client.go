@@ -0,0 +1,244 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// DefaultBaseURL is the Gemini API endpoint
+const DefaultBaseURL = "https://generativelanguage.googleapis.com"
+
+// GoogleClient talks to the Gemini generateContent API
+type GoogleClient struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the given API key
+func NewClient(apiKey string) *GoogleClient {
+	return &GoogleClient{
+		apiKey:     apiKey,
+		baseURL:    DefaultBaseURL,
+		httpClient: http.DefaultClient,
+	}
+}
+
+// WithBaseURL points the client at another endpoint
+func (c *GoogleClient) WithBaseURL(url string) *GoogleClient {
+	c.baseURL = url
+	return c
+}
+
+// WithHTTPClient replaces the HTTP client used for requests
+func (c *GoogleClient) WithHTTPClient(client *http.Client) *GoogleClient {
+	c.httpClient = client
+	return c
+}
+
+// Name returns the provider name
+func (c *GoogleClient) Name() string {
+	return "google"
+}
+
+// models are the suggested models, most capable first
+var models = []string{"gemini-2.5-pro", "gemini-2.5-flash", "gemini-2.0-flash"}
+
+// Models returns the suggested models
+func (c *GoogleClient) Models() []string {
+	return models
+}
+
+// ValidateConfig checks that an API key is configured
+func (c *GoogleClient) ValidateConfig(config map[string]string) error {
+	if config["google"] == "" {
+		return fmt.Errorf("google API key is required")
+	}
+	return nil
+}
+
+// part is a piece of Gemini content: text, a function call or the response
+// to one
+type part struct {
+	Text             string            `json:"text,omitempty"`
+	FunctionCall     *functionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *functionResponse `json:"functionResponse,omitempty"`
+}
+
+// functionCall is the model asking for a function to be called
+type functionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+// functionResponse is the result of a function call
+type functionResponse struct {
+	ID       string                 `json:"id,omitempty"`
+	Name     string                 `json:"name"`
+	Response map[string]interface{} `json:"response"`
+}
+
+// tool declares the functions the model may call
+type tool struct {
+	FunctionDeclarations []functionDeclaration `json:"functionDeclarations"`
+}
+
+// functionDeclaration describes a function and its parameters
+type functionDeclaration struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// content is a Gemini conversation turn
+type content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []part `json:"parts"`
+}
+
+// generationConfig holds Gemini sampling settings
+type generationConfig struct {
+	MaxOutputTokens int             `json:"maxOutputTokens,omitempty"`
+	Temperature     *float64        `json:"temperature,omitempty"`
+	TopP            *float64        `json:"topP,omitempty"`
+	StopSequences   []string        `json:"stopSequences,omitempty"`
+	ThinkingConfig  *thinkingConfig `json:"thinkingConfig,omitempty"`
+}
+
+// thinkingConfig sets the thinking token budget
+type thinkingConfig struct {
+	ThinkingBudget int `json:"thinkingBudget"`
+}
+
+// request is a generateContent request body
+type request struct {
+	Contents          []content         `json:"contents"`
+	SystemInstruction *content          `json:"systemInstruction,omitempty"`
+	GenerationConfig  *generationConfig `json:"generationConfig,omitempty"`
+	Tools             []tool            `json:"tools,omitempty"`
+}
+
+// chunk is a streamed generateContent response
+type chunk struct {
+	Candidates []struct {
+		Content content `json:"content"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+// SendMessage sends a request and streams the reply
+func (c *GoogleClient) SendMessage(ctx context.Context, req *api.Request) (<-chan api.Response, error) {
+	var body request
+	if req.System != "" {
+		body.SystemInstruction = &content{Parts: []part{{Text: req.System}}}
+	}
+	config := generationConfig{
+		MaxOutputTokens: req.MaxTokens,
+		Temperature:     req.Temperature,
+		TopP:            req.TopP,
+		StopSequences:   req.StopSequences,
+	}
+	if req.Reasoning != nil {
+		config.ThinkingConfig = &thinkingConfig{ThinkingBudget: req.Reasoning.Budget()}
+	}
+	if config.MaxOutputTokens > 0 || config.Temperature != nil || config.TopP != nil ||
+		len(config.StopSequences) > 0 || config.ThinkingConfig != nil {
+		body.GenerationConfig = &config
+	}
+	if len(req.Tools) > 0 {
+		var declarations []functionDeclaration
+		for _, t := range req.Tools {
+			declarations = append(declarations, functionDeclaration{Name: t.Name, Description: t.Description, Parameters: t.Parameters})
+		}
+		body.Tools = []tool{{FunctionDeclarations: declarations}}
+	}
+	for _, m := range req.Messages {
+		role := "user"
+		if m.Role == api.RoleAssistant {
+			role = "model"
+		}
+		body.Contents = append(body.Contents, content{Role: role, Parts: parts(m)})
+	}
+
+	payload, err := api.WithExtensions(body, req.Extensions)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/v1beta/models/%s:streamGenerateContent?alt=sse", c.baseURL, url.PathEscape(req.Model))
+	headers := map[string]string{"x-goog-api-key": c.apiKey}
+
+	resp, err := api.PostJSON(ctx, c.httpClient, c.Name(), endpoint, headers, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return api.Stream(ctx, resp.Body, req.Stream, func(send func(string), call func(api.ToolCall)) (api.TokenUsage, error) {
+		var total api.TokenUsage
+		calls := 0
+
+		err := api.ReadSSE(resp.Body, func(_, data string) error {
+			var msg chunk
+			if err := json.Unmarshal([]byte(data), &msg); err != nil {
+				return fmt.Errorf("failed to decode chunk: %w", err)
+			}
+
+			for _, candidate := range msg.Candidates {
+				for _, p := range candidate.Content.Parts {
+					send(p.Text)
+					if fc := p.FunctionCall; fc != nil {
+						// Calls arrive whole; older models give them no ID
+						calls++
+						id := fc.ID
+						if id == "" {
+							id = fmt.Sprintf("call_%d", calls)
+						}
+						call(api.ToolCall{ID: id, Name: fc.Name, Input: fc.Args})
+					}
+				}
+			}
+			if msg.UsageMetadata.PromptTokenCount > 0 {
+				total = api.TokenUsage{
+					InputTokens:  msg.UsageMetadata.PromptTokenCount,
+					OutputTokens: msg.UsageMetadata.CandidatesTokenCount,
+				}
+			}
+			return nil
+		})
+
+		return total, err
+	}), nil
+}
+
+// parts returns the parts of a message: its text, and any function calls
+// or responses
+func parts(m api.Message) []part {
+	if len(m.ToolCalls) == 0 && len(m.ToolResults) == 0 {
+		return []part{{Text: m.Content}}
+	}
+
+	var ps []part
+	if m.Content != "" {
+		ps = append(ps, part{Text: m.Content})
+	}
+	for _, c := range m.ToolCalls {
+		ps = append(ps, part{FunctionCall: &functionCall{Name: c.Name, Args: c.Arguments()}})
+	}
+	for _, r := range m.ToolResults {
+		response := map[string]interface{}{"content": r.Content}
+		if r.IsError {
+			response = map[string]interface{}{"error": r.Content}
+		}
+		ps = append(ps, part{FunctionResponse: &functionResponse{Name: r.Name, Response: response}})
+	}
+	return ps
+}
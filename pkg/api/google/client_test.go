@@ -0,0 +1,141 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yourusername/aui/pkg/api"
+)
+
+const testStream = `data: {"candidates":[{"content":{"parts":[{"text":"Hello"}],"role":"model"}}],"usageMetadata":{"promptTokenCount":7,"candidatesTokenCount":1}}
+
+data: {"candidates":[{"content":{"parts":[{"text":" there"}],"role":"model"},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":7,"candidatesTokenCount":3}}
+
+`
+
+func TestSendMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1beta/models/gemini-1.5-flash:streamGenerateContent" || r.URL.Query().Get("alt") != "sse" {
+			t.Errorf("url = %s", r.URL)
+		}
+		if r.Header.Get("x-goog-api-key") != "test-key" {
+			t.Errorf("missing API key header")
+		}
+
+		var body request
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if body.SystemInstruction == nil || body.SystemInstruction.Parts[0].Text != "Be brief." {
+			t.Errorf("systemInstruction = %+v", body.SystemInstruction)
+		}
+		if len(body.Contents) != 2 || body.Contents[0].Role != "user" || body.Contents[1].Role != "model" {
+			t.Errorf("contents = %+v", body.Contents)
+		}
+		if body.GenerationConfig == nil || body.GenerationConfig.MaxOutputTokens != 100 {
+			t.Errorf("generationConfig = %+v", body.GenerationConfig)
+		}
+
+		io.WriteString(w, testStream)
+	}))
+	defer server.Close()
+
+	responses, err := NewClient("test-key").WithBaseURL(server.URL).SendMessage(context.Background(), &api.Request{
+		Model:     "gemini-1.5-flash",
+		System:    "Be brief.",
+		MaxTokens: 100,
+		Stream:    true,
+		Messages: []api.Message{
+			{Role: api.RoleUser, Content: "Hi"},
+			{Role: api.RoleAssistant, Content: "Hello"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	text, usage, err := api.Collect(responses)
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if text != "Hello there" || usage.InputTokens != 7 || usage.OutputTokens != 3 {
+		t.Errorf("Collect() = %q %+v", text, usage)
+	}
+}
+
+func TestSendMessageParams(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		io.WriteString(w, testStream)
+	}))
+	defer server.Close()
+
+	topP := 0.8
+	responses, err := NewClient("k").WithBaseURL(server.URL).SendMessage(context.Background(), &api.Request{
+		Model:         "gemini-2.5-flash",
+		TopP:          &topP,
+		StopSequences: []string{"END"},
+		Reasoning:     &api.Reasoning{Effort: "low"},
+		Extensions: map[string]interface{}{
+			"generationConfig": map[string]interface{}{"topK": 20},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	api.Collect(responses)
+
+	config := body["generationConfig"].(map[string]interface{})
+	if config["topP"] != 0.8 || config["topK"] != float64(20) {
+		t.Errorf("generationConfig = %v", config)
+	}
+	if thinking := config["thinkingConfig"].(map[string]interface{}); thinking["thinkingBudget"] != float64(1024) {
+		t.Errorf("thinkingConfig = %v", thinking)
+	}
+}
+
+func TestSendMessageTools(t *testing.T) {
+	var body request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		io.WriteString(w, `data: {"candidates":[{"content":{"parts":[{"functionCall":{"name":"list_dir","args":{"path":"cmd"}}}],"role":"model"}}],"usageMetadata":{"promptTokenCount":5,"candidatesTokenCount":2}}`+"\n\n")
+	}))
+	defer server.Close()
+
+	req := &api.Request{
+		Model:    "gemini-2.5-pro",
+		Messages: []api.Message{{Role: api.RoleUser, Content: "Where is main?"}},
+		Tools:    []api.Tool{{Name: "list_dir", Description: "List a directory"}},
+	}
+	req.AppendToolTurn("", []api.ToolCall{{ID: "call_1", Name: "list_dir"}}, []api.ToolResult{{CallID: "call_1", Name: "list_dir", Content: "cmd/"}})
+
+	responses, err := NewClient("k").WithBaseURL(server.URL).SendMessage(context.Background(), req)
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	_, calls, _, err := api.CollectCalls(responses)
+	if err != nil {
+		t.Fatalf("CollectCalls() error = %v", err)
+	}
+	if len(calls) != 1 || calls[0].ID == "" || calls[0].Name != "list_dir" || string(calls[0].Input) != `{"path":"cmd"}` {
+		t.Errorf("calls = %+v", calls)
+	}
+
+	if len(body.Tools) != 1 || body.Tools[0].FunctionDeclarations[0].Name != "list_dir" {
+		t.Errorf("tools = %+v", body.Tools)
+	}
+	if len(body.Contents) != 3 {
+		t.Fatalf("contents = %+v", body.Contents)
+	}
+	if fc := body.Contents[1].Parts[0].FunctionCall; body.Contents[1].Role != "model" || fc == nil || fc.Name != "list_dir" {
+		t.Errorf("function call = %+v", body.Contents[1])
+	}
+	if fr := body.Contents[2].Parts[0].FunctionResponse; fr == nil || fr.Name != "list_dir" || fr.Response["content"] != "cmd/" {
+		t.Errorf("function response = %+v", body.Contents[2])
+	}
+}
@@ -0,0 +1,233 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/yourusername/aui/pkg/api"
+)
+
+// DefaultBaseURL is the OpenAI API endpoint
+const DefaultBaseURL = "https://api.openai.com"
+
+// OpenAIClient talks to the OpenAI Chat Completions API
+type OpenAIClient struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the given API key
+func NewClient(apiKey string) *OpenAIClient {
+	return &OpenAIClient{
+		apiKey:     apiKey,
+		baseURL:    DefaultBaseURL,
+		httpClient: http.DefaultClient,
+	}
+}
+
+// WithBaseURL points the client at another endpoint, such as a compatible server
+func (c *OpenAIClient) WithBaseURL(url string) *OpenAIClient {
+	c.baseURL = url
+	return c
+}
+
+// WithHTTPClient replaces the HTTP client used for requests
+func (c *OpenAIClient) WithHTTPClient(client *http.Client) *OpenAIClient {
+	c.httpClient = client
+	return c
+}
+
+// Name returns the provider name
+func (c *OpenAIClient) Name() string {
+	return "openai"
+}
+
+// models are the suggested models, most capable first
+var models = []string{"gpt-4o", "gpt-4o-mini", "gpt-4.1", "o3", "o4-mini"}
+
+// Models returns the suggested models
+func (c *OpenAIClient) Models() []string {
+	return models
+}
+
+// ValidateConfig checks that an API key is configured
+func (c *OpenAIClient) ValidateConfig(config map[string]string) error {
+	if config["openai"] == "" {
+		return fmt.Errorf("openai API key is required")
+	}
+	return nil
+}
+
+// message is a Chat Completions message. Tool results are messages of
+// their own with the "tool" role.
+type message struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content,omitempty"`
+	ToolCalls  []toolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+// toolCall is a function call made by the assistant. In streamed chunks
+// only Index is always set and Arguments arrives in pieces.
+type toolCall struct {
+	Index    int    `json:"index,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// tool is a function the model may call
+type tool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description,omitempty"`
+		Parameters  map[string]interface{} `json:"parameters"`
+	} `json:"function"`
+}
+
+// request is a Chat Completions request body
+type request struct {
+	Model           string         `json:"model"`
+	Messages        []message      `json:"messages"`
+	MaxTokens       int            `json:"max_completion_tokens,omitempty"`
+	Stream          bool           `json:"stream"`
+	StreamOptions   *streamOptions `json:"stream_options,omitempty"`
+	Temperature     *float64       `json:"temperature,omitempty"`
+	TopP            *float64       `json:"top_p,omitempty"`
+	Stop            []string       `json:"stop,omitempty"`
+	ReasoningEffort string         `json:"reasoning_effort,omitempty"`
+	Tools           []tool         `json:"tools,omitempty"`
+}
+
+// streamOptions asks for usage in the final streamed chunk
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// chunk is a streamed Chat Completions chunk
+type chunk struct {
+	Choices []struct {
+		Delta struct {
+			Content   string     `json:"content"`
+			ToolCalls []toolCall `json:"tool_calls"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// SendMessage sends a request and streams the reply
+func (c *OpenAIClient) SendMessage(ctx context.Context, req *api.Request) (<-chan api.Response, error) {
+	body := request{
+		Model:         req.Model,
+		MaxTokens:     req.MaxTokens,
+		Stream:        true,
+		StreamOptions: &streamOptions{IncludeUsage: true},
+		Temperature:   req.Temperature,
+		TopP:          req.TopP,
+		Stop:          req.StopSequences,
+	}
+	if req.Reasoning != nil {
+		body.ReasoningEffort = req.Reasoning.Level()
+	}
+	if req.System != "" {
+		body.Messages = append(body.Messages, message{Role: "system", Content: req.System})
+	}
+	for _, t := range req.Tools {
+		var spec tool
+		spec.Type = "function"
+		spec.Function.Name, spec.Function.Description, spec.Function.Parameters = t.Name, t.Description, t.Parameters
+		body.Tools = append(body.Tools, spec)
+	}
+	for _, m := range req.Messages {
+		body.Messages = append(body.Messages, messages(m)...)
+	}
+
+	payload, err := api.WithExtensions(body, req.Extensions)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{"Authorization": "Bearer " + c.apiKey}
+
+	resp, err := api.PostJSON(ctx, c.httpClient, c.Name(), c.baseURL+"/v1/chat/completions", headers, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return api.Stream(ctx, resp.Body, req.Stream, func(send func(string), call func(api.ToolCall)) (api.TokenUsage, error) {
+		var total api.TokenUsage
+		var calls []*toolCall
+
+		err := api.ReadSSE(resp.Body, func(_, data string) error {
+			if data == "[DONE]" {
+				return nil
+			}
+
+			var msg chunk
+			if err := json.Unmarshal([]byte(data), &msg); err != nil {
+				return fmt.Errorf("failed to decode chunk: %w", err)
+			}
+
+			for _, choice := range msg.Choices {
+				send(choice.Delta.Content)
+				for _, delta := range choice.Delta.ToolCalls {
+					for len(calls) <= delta.Index {
+						calls = append(calls, &toolCall{})
+					}
+					c := calls[delta.Index]
+					if delta.ID != "" {
+						c.ID = delta.ID
+					}
+					if delta.Function.Name != "" {
+						c.Function.Name = delta.Function.Name
+					}
+					c.Function.Arguments += delta.Function.Arguments
+				}
+			}
+			if msg.Usage != nil {
+				total = api.TokenUsage{InputTokens: msg.Usage.PromptTokens, OutputTokens: msg.Usage.CompletionTokens}
+			}
+			return nil
+		})
+
+		for _, c := range calls {
+			call(api.ToolCall{ID: c.ID, Name: c.Function.Name, Input: json.RawMessage(c.Function.Arguments)})
+		}
+		return total, err
+	}), nil
+}
+
+// messages returns the Chat Completions messages for a message: one, or one
+// per result for a message carrying tool results
+func messages(m api.Message) []message {
+	if len(m.ToolResults) > 0 {
+		var results []message
+		for _, r := range m.ToolResults {
+			content := r.Content
+			if r.IsError {
+				content = "Error: " + content
+			}
+			results = append(results, message{Role: "tool", Content: content, ToolCallID: r.CallID})
+		}
+		return results
+	}
+
+	msg := message{Role: string(m.Role), Content: m.Content}
+	for _, c := range m.ToolCalls {
+		var tc toolCall
+		tc.ID, tc.Type = c.ID, "function"
+		tc.Function.Name, tc.Function.Arguments = c.Name, string(c.Arguments())
+		msg.ToolCalls = append(msg.ToolCalls, tc)
+	}
+	return []message{msg}
+}
@@ -0,0 +1,177 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yourusername/aui/pkg/api"
+)
+
+const testStream = `data: {"choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}
+
+data: {"choices":[{"index":0,"delta":{"content":"Hello"}}]}
+
+data: {"choices":[{"index":0,"delta":{"content":" there"}}]}
+
+data: {"choices":[],"usage":{"prompt_tokens":9,"completion_tokens":4,"total_tokens":13}}
+
+data: [DONE]
+
+`
+
+func TestSendMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+
+		var body request
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if body.Model != "gpt-4o-mini" || !body.Stream || body.StreamOptions == nil || !body.StreamOptions.IncludeUsage {
+			t.Errorf("request = %+v", body)
+		}
+		if len(body.Messages) != 3 || body.Messages[0].Role != "system" || body.Messages[2].Role != "assistant" {
+			t.Errorf("messages = %+v", body.Messages)
+		}
+
+		io.WriteString(w, testStream)
+	}))
+	defer server.Close()
+
+	responses, err := NewClient("test-key").WithBaseURL(server.URL).SendMessage(context.Background(), &api.Request{
+		Model:  "gpt-4o-mini",
+		System: "Be brief.",
+		Messages: []api.Message{
+			{Role: api.RoleUser, Content: "Hi"},
+			{Role: api.RoleAssistant, Content: "Hello"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	var count int
+	var text string
+	var usage api.TokenUsage
+	for resp := range responses {
+		count++
+		text += resp.Content
+		usage = resp.Usage
+	}
+
+	// Without Stream the reply arrives as a single response
+	if count != 1 || text != "Hello there" {
+		t.Errorf("got %d responses with %q, want 1 with %q", count, text, "Hello there")
+	}
+	if usage.InputTokens != 9 || usage.OutputTokens != 4 {
+		t.Errorf("usage = %+v", usage)
+	}
+}
+
+func TestSendMessageStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		io.WriteString(w, `{"error":{"message":"Incorrect API key provided"}}`)
+	}))
+	defer server.Close()
+
+	_, err := NewClient("bad").WithBaseURL(server.URL).SendMessage(context.Background(), &api.Request{Model: "m"})
+	statusErr, ok := err.(*api.StatusError)
+	if !ok || statusErr.StatusCode != http.StatusUnauthorized || statusErr.Message != "Incorrect API key provided" {
+		t.Errorf("SendMessage() error = %v, want 401 StatusError", err)
+	}
+}
+
+func TestSendMessageParams(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		io.WriteString(w, testStream)
+	}))
+	defer server.Close()
+
+	temperature := 0.5
+	responses, err := NewClient("k").WithBaseURL(server.URL).SendMessage(context.Background(), &api.Request{
+		Model:         "o3-mini",
+		Temperature:   &temperature,
+		StopSequences: []string{"END"},
+		Reasoning:     &api.Reasoning{BudgetTokens: 20000},
+		Extensions:    map[string]interface{}{"seed": 7},
+	})
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	api.Collect(responses)
+
+	if body["temperature"] != 0.5 || body["reasoning_effort"] != "high" || body["seed"] != float64(7) {
+		t.Errorf("params = %v", body)
+	}
+	if _, ok := body["top_p"]; ok {
+		t.Error("unset top_p should be omitted")
+	}
+	if stop := body["stop"].([]interface{}); len(stop) != 1 {
+		t.Errorf("stop = %v", body["stop"])
+	}
+}
+
+const toolStream = `data: {"choices":[{"index":0,"delta":{"role":"assistant","content":null,"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"grep","arguments":""}}]}}]}
+
+data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"pattern\":"}}]}}]}
+
+data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"TODO\"}"}}]}}]}
+
+data: {"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}
+
+data: [DONE]
+
+`
+
+func TestSendMessageTools(t *testing.T) {
+	var body request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		io.WriteString(w, toolStream)
+	}))
+	defer server.Close()
+
+	req := &api.Request{
+		Model:    "gpt-4o",
+		Messages: []api.Message{{Role: api.RoleUser, Content: "Any TODOs?"}},
+		Tools:    []api.Tool{{Name: "grep", Parameters: map[string]interface{}{"type": "object"}}},
+	}
+	req.AppendToolTurn("", []api.ToolCall{{ID: "call_0", Name: "list_dir"}}, []api.ToolResult{{CallID: "call_0", Content: "no such dir", IsError: true}})
+
+	responses, err := NewClient("k").WithBaseURL(server.URL).SendMessage(context.Background(), req)
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	_, calls, _, err := api.CollectCalls(responses)
+	if err != nil {
+		t.Fatalf("CollectCalls() error = %v", err)
+	}
+	if len(calls) != 1 || calls[0].ID != "call_1" || calls[0].Name != "grep" || string(calls[0].Input) != `{"pattern":"TODO"}` {
+		t.Errorf("calls = %+v", calls)
+	}
+
+	if len(body.Tools) != 1 || body.Tools[0].Type != "function" || body.Tools[0].Function.Name != "grep" {
+		t.Errorf("tools = %+v", body.Tools)
+	}
+	if len(body.Messages) != 3 {
+		t.Fatalf("messages = %+v", body.Messages)
+	}
+	if calls := body.Messages[1].ToolCalls; len(calls) != 1 || calls[0].ID != "call_0" || calls[0].Function.Arguments != "{}" {
+		t.Errorf("assistant tool calls = %+v", calls)
+	}
+	if result := body.Messages[2]; result.Role != "tool" || result.ToolCallID != "call_0" || result.Content != "Error: no such dir" {
+		t.Errorf("tool result = %+v", result)
+	}
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Provider is implemented by every AI API client
+type Provider interface {
+	Name() string
+	SendMessage(ctx context.Context, req *Request) (<-chan Response, error)
+	ValidateConfig(config map[string]string) error
+}
+
+// ModelLister is implemented by providers that can suggest models to use
+type ModelLister interface {
+	Models() []string
+}
+
+// Role identifies the author of a message
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Message is one turn of a conversation. An assistant message may ask for
+// tools to be run; the user message after it then carries their results.
+type Message struct {
+	Role        Role
+	Content     string
+	ToolCalls   []ToolCall
+	ToolResults []ToolResult
+}
+
+// Request is a provider-neutral completion request
+type Request struct {
+	Model     string
+	System    string
+	Messages  []Message
+	Stream    bool // Deliver content as it arrives rather than in one response
+	MaxTokens int
+
+	// Sampling parameters; nil or empty leaves the provider default
+	Temperature   *float64
+	TopP          *float64
+	StopSequences []string
+	Reasoning     *Reasoning
+
+	// Tools the model may call; see RunTools for answering the calls
+	Tools []Tool
+
+	// Extensions are provider-specific fields merged into the request body
+	// as-is, e.g. {"top_k": 40} for Anthropic. Nested objects are merged.
+	Extensions map[string]interface{}
+}
+
+// Reasoning enables extended thinking. Providers that take a token budget
+// use BudgetTokens, or one derived from Effort; providers that take an
+// effort level use Effort, or one derived from BudgetTokens.
+type Reasoning struct {
+	Effort       string // "low", "medium" or "high"
+	BudgetTokens int
+}
+
+// reasoning budgets used for each effort level
+var effortBudgets = map[string]int{"low": 1024, "medium": 8192, "high": 24576}
+
+// Budget returns the thinking token budget
+func (r *Reasoning) Budget() int {
+	if r.BudgetTokens > 0 {
+		return r.BudgetTokens
+	}
+	if budget, ok := effortBudgets[r.Effort]; ok {
+		return budget
+	}
+	return effortBudgets["medium"]
+}
+
+// Level returns the effort level
+func (r *Reasoning) Level() string {
+	if _, ok := effortBudgets[r.Effort]; ok {
+		return r.Effort
+	}
+	switch {
+	case r.BudgetTokens == 0:
+		return "medium"
+	case r.BudgetTokens <= effortBudgets["low"]:
+		return "low"
+	case r.BudgetTokens <= effortBudgets["medium"]:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
+// TokenUsage counts the tokens consumed by a request
+type TokenUsage struct {
+	InputTokens  int
+	OutputTokens int
+}
+
+// Add returns the sum of two usages
+func (u TokenUsage) Add(other TokenUsage) TokenUsage {
+	return TokenUsage{
+		InputTokens:  u.InputTokens + other.InputTokens,
+		OutputTokens: u.OutputTokens + other.OutputTokens,
+	}
+}
+
+// Response is a piece of a reply. Content holds the text since the previous
+// response; the last response on a channel has Done set and the total Usage,
+// or Error set if the request failed. A reply that asks for tools to be run
+// lists the calls in the ToolCalls of its last response.
+type Response struct {
+	Content    string
+	Done       bool
+	Error      error
+	Usage      TokenUsage
+	ToolCalls  []ToolCall
+	Wait       time.Duration // Set while the request waits to be retried, see Send
+	WaitReason string        // Why the request is retried, see RetryReason
+	Metadata   map[string]interface{}
+}
+
+// DefaultMaxTokens is used when a request does not set MaxTokens
+const DefaultMaxTokens = 4096
+
+// Collect reads a response channel to the end and returns the full text
+func Collect(responses <-chan Response) (string, TokenUsage, error) {
+	text, _, usage, err := CollectCalls(responses)
+	return text, usage, err
+}
+
+// StatusError is returned when a provider answers with a non-2xx status
+type StatusError struct {
+	Provider   string
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s API error (status %d): %s", e.Provider, e.StatusCode, e.Message)
+}
@@ -0,0 +1,294 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how failed provider calls are retried. Waits grow
+// exponentially from InitialInterval up to MaxInterval, each randomized by
+// up to Jitter of its length; when a rate limit is hit, a wait the provider
+// asks for through Retry-After or rate-limit headers is used instead. Server
+// errors always back off, since their reset headers only describe the rate
+// limit. No retry is attempted once MaxElapsed has passed since the first
+// attempt.
+type RetryPolicy struct {
+	MaxAttempts     int
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+	Jitter          float64 // Between 0 and 1
+	MaxElapsed      time.Duration
+}
+
+// DefaultRetryPolicy is used by providers unless configured otherwise
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:     6,
+	InitialInterval: time.Second,
+	MaxInterval:     30 * time.Second,
+	Multiplier:      2,
+	Jitter:          0.5,
+	MaxElapsed:      2 * time.Minute,
+}
+
+// Backoff returns the wait before retry number n, counting from 0
+func (p RetryPolicy) Backoff(n int) time.Duration {
+	wait := float64(p.InitialInterval)
+	for i := 0; i < n; i++ {
+		wait *= p.Multiplier
+		if wait >= float64(p.MaxInterval) {
+			wait = float64(p.MaxInterval)
+			break
+		}
+	}
+
+	if p.Jitter > 0 {
+		wait += wait * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(wait)
+}
+
+// RetryableStatus reports whether a request that failed with an HTTP status
+// may succeed if sent again: timeouts, rate limits and server errors,
+// including Anthropic's 529 overloaded
+func RetryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests:
+		return true
+	}
+	return code >= 500 && code != http.StatusNotImplemented
+}
+
+// IsRetryable reports whether err is worth retrying. Client errors such as a
+// bad API key or an invalid request are fatal; so is cancellation.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return RetryableStatus(statusErr.StatusCode)
+	}
+	// Anything else failed before a response arrived, e.g. a reset connection
+	return true
+}
+
+// RetryReason describes why a request failed with a retryable error, e.g.
+// "rate limit" or "server error"
+func RetryReason(err error) string {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return "connection error"
+	}
+	switch code := statusErr.StatusCode; {
+	case code == http.StatusTooManyRequests:
+		return "rate limit"
+	case code == 529 || code == http.StatusServiceUnavailable:
+		return "overloaded server"
+	case code == http.StatusRequestTimeout || code == http.StatusGatewayTimeout:
+		return "timeout"
+	case code >= 500:
+		return "server error"
+	default:
+		return fmt.Sprintf("status %d", code)
+	}
+}
+
+// RetryNotify is called before each retry with the wait and the reason
+type RetryNotify func(wait time.Duration, err error)
+
+// retryNotifyKey is the context key of the RetryNotify
+type retryNotifyKey struct{}
+
+// WithRetryNotify returns a context whose requests report their retries to fn
+func WithRetryNotify(ctx context.Context, fn RetryNotify) context.Context {
+	return context.WithValue(ctx, retryNotifyKey{}, fn)
+}
+
+// RetryTransport is an http.RoundTripper that retries requests failing with
+// a retryable error according to Policy. Only the request is retried: once
+// a response arrives its body is streamed to the caller as-is.
+type RetryTransport struct {
+	Base   http.RoundTripper // Defaults to http.DefaultTransport
+	Policy RetryPolicy
+
+	// sleep waits for d or until ctx is done, replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewRetryClient returns an HTTP client that retries with policy
+func NewRetryClient(policy RetryPolicy) *http.Client {
+	return &http.Client{Transport: &RetryTransport{Policy: policy}}
+}
+
+// RoundTrip sends req, retrying while the error is retryable, attempts and
+// time remain, and the body can be sent again
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	sleep := t.sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+	notify, _ := req.Context().Value(retryNotifyKey{}).(RetryNotify)
+
+	start := time.Now()
+	for attempt := 0; ; attempt++ {
+		resp, err := base.RoundTrip(req)
+
+		var reason error
+		switch {
+		case err != nil:
+			if !IsRetryable(err) || req.Context().Err() != nil {
+				return nil, err
+			}
+			reason = err
+		case RetryableStatus(resp.StatusCode):
+			reason = &StatusError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+		default:
+			return resp, nil
+		}
+
+		wait := t.Policy.Backoff(attempt)
+		if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+			if after, ok := RetryAfter(resp.Header, time.Now()); ok {
+				wait = after
+			}
+		}
+
+		retry := attempt+1 < t.Policy.MaxAttempts &&
+			time.Since(start)+wait <= t.Policy.MaxElapsed &&
+			(req.Body == nil || req.GetBody != nil)
+		if !retry {
+			return resp, err
+		}
+
+		if resp != nil {
+			// Drain a little so the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+		if notify != nil {
+			notify(wait, reason)
+		}
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// sleepContext waits for d, returning early with the error of ctx if it is
+// done first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RetryAfter returns how long a response asks the client to wait before
+// trying again. It reads Retry-After, as seconds or an HTTP date, the
+// millisecond retry-after-ms, and the rate-limit reset headers of OpenAI
+// (x-ratelimit-reset-*, as durations) and Anthropic
+// (anthropic-ratelimit-*-reset, as timestamps), taking the longest reset.
+func RetryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(h.Get("Retry-After-Ms"), 64); err == nil {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.ParseFloat(v, 64); err == nil {
+			return time.Duration(secs * float64(time.Second)), true
+		}
+		if at, err := http.ParseTime(v); err == nil {
+			return nonNegative(at.Sub(now)), true
+		}
+	}
+
+	var longest time.Duration
+	found := false
+	for name, values := range h {
+		lower := strings.ToLower(name)
+		var wait time.Duration
+		switch {
+		case strings.HasPrefix(lower, "x-ratelimit-reset-"):
+			d, err := time.ParseDuration(values[0])
+			if err != nil {
+				continue
+			}
+			wait = d
+		case strings.HasPrefix(lower, "anthropic-ratelimit-") && strings.HasSuffix(lower, "-reset"):
+			at, err := time.Parse(time.RFC3339, values[0])
+			if err != nil {
+				continue
+			}
+			wait = nonNegative(at.Sub(now))
+		default:
+			continue
+		}
+		if !found || wait > longest {
+			longest, found = wait, true
+		}
+	}
+	return longest, found
+}
+
+// nonNegative returns d, or 0 if d is negative
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// Send calls provider.SendMessage in the background and returns a channel
+// carrying its responses. While the request is waiting to be retried the
+// channel receives responses with only Wait set; if the request fails the
+// channel receives a single Error response.
+func Send(ctx context.Context, provider Provider, req *Request) <-chan Response {
+	ch := make(chan Response, 16)
+
+	go func() {
+		defer close(ch)
+
+		notifyCtx := WithRetryNotify(ctx, func(wait time.Duration, err error) {
+			select {
+			case ch <- Response{Wait: wait, WaitReason: RetryReason(err)}:
+			case <-ctx.Done():
+			}
+		})
+
+		responses, err := provider.SendMessage(notifyCtx, req)
+		if err != nil {
+			ch <- Response{Error: err}
+			return
+		}
+		for resp := range responses {
+			ch <- resp
+		}
+	}()
+
+	return ch
+}
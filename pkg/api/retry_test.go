@@ -0,0 +1,233 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// retryServer answers with statuses in turn, then 200, and records the
+// request bodies
+func retryServer(t *testing.T, headers http.Header, statuses ...int) (*httptest.Server, *[]string) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) <= len(statuses) {
+			for k, v := range headers {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(statuses[len(bodies)-1])
+			io.WriteString(w, `{"error": {"message": "busy"}}`)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(server.Close)
+	return server, &bodies
+}
+
+// testTransport returns a retry transport that records its waits instead of
+// sleeping
+func testTransport(policy RetryPolicy, waits *[]time.Duration) *RetryTransport {
+	return &RetryTransport{Policy: policy, sleep: func(ctx context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return ctx.Err()
+	}}
+}
+
+func TestRetryTransport(t *testing.T) {
+	server, bodies := retryServer(t, http.Header{"Retry-After": {"12"}}, http.StatusTooManyRequests, 529)
+
+	var waits, notified []time.Duration
+	client := &http.Client{Transport: testTransport(DefaultRetryPolicy, &waits)}
+	ctx := WithRetryNotify(context.Background(), func(wait time.Duration, err error) {
+		notified = append(notified, wait)
+	})
+
+	resp, err := PostJSON(ctx, client, "test", server.URL, nil, map[string]int{"a": 1})
+	if err != nil {
+		t.Fatalf("PostJSON() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if len(*bodies) != 3 || (*bodies)[2] != `{"a":1}` {
+		t.Errorf("server got %q, want the body sent 3 times", *bodies)
+	}
+	// Only the rate limit waits as asked; the overloaded server backs off
+	if len(waits) != 2 || waits[0] != 12*time.Second || waits[1] > 3*time.Second || len(notified) != 2 {
+		t.Errorf("waits = %v, notified = %v, want Retry-After then a backoff", waits, notified)
+	}
+}
+
+func TestRetryTransportGivesUp(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  http.Header
+		statuses []int
+		policy   RetryPolicy
+		requests int
+	}{
+		{"fatal status", nil, []int{http.StatusUnauthorized}, DefaultRetryPolicy, 1},
+		{"bad request", nil, []int{http.StatusBadRequest}, DefaultRetryPolicy, 1},
+		{"max attempts", nil, []int{500, 500, 500}, RetryPolicy{MaxAttempts: 2, MaxElapsed: time.Minute}, 2},
+		{"max elapsed", http.Header{"Retry-After": {"600"}}, []int{http.StatusTooManyRequests}, DefaultRetryPolicy, 1},
+	}
+
+	for _, tt := range tests {
+		server, bodies := retryServer(t, tt.headers, tt.statuses...)
+		var waits []time.Duration
+		client := &http.Client{Transport: testTransport(tt.policy, &waits)}
+
+		_, err := PostJSON(context.Background(), client, "test", server.URL, nil, map[string]int{})
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.Message != "busy" {
+			t.Errorf("%s: PostJSON() error = %v, want the provider's status error", tt.name, err)
+		}
+		if len(*bodies) != tt.requests {
+			t.Errorf("%s: %d requests, want %d", tt.name, len(*bodies), tt.requests)
+		}
+	}
+}
+
+func TestRetryTransportCancelled(t *testing.T) {
+	server, bodies := retryServer(t, nil, 503, 503)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var waits []time.Duration
+	transport := testTransport(DefaultRetryPolicy, &waits)
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		cancel()
+		return sleepContext(ctx, d)
+	}
+
+	_, err := PostJSON(ctx, &http.Client{Transport: transport}, "test", server.URL, nil, nil)
+	if !errors.Is(err, context.Canceled) || len(*bodies) != 1 {
+		t.Errorf("PostJSON() = %v after %d requests, want cancelled after 1", err, len(*bodies))
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		headers http.Header
+		want    time.Duration
+		ok      bool
+	}{
+		{http.Header{"Retry-After": {"7"}}, 7 * time.Second, true},
+		{http.Header{"Retry-After": {"Wed, 01 Jan 2025 12:00:30 GMT"}}, 30 * time.Second, true},
+		{http.Header{"Retry-After-Ms": {"250"}, "Retry-After": {"1"}}, 250 * time.Millisecond, true},
+		{http.Header{"X-Ratelimit-Reset-Requests": {"1s"}, "X-Ratelimit-Reset-Tokens": {"6m0s"}}, 6 * time.Minute, true},
+		{http.Header{"Anthropic-Ratelimit-Tokens-Reset": {"2025-01-01T12:00:05Z"}}, 5 * time.Second, true},
+		{http.Header{"Anthropic-Ratelimit-Requests-Reset": {"2024-12-31T12:00:00Z"}}, 0, true},
+		{http.Header{"Content-Type": {"application/json"}}, 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := RetryAfter(tt.headers, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("RetryAfter(%v) = %v, %v; want %v, %v", tt.headers, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRetryReason(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&StatusError{StatusCode: 429}, "rate limit"},
+		{&StatusError{StatusCode: 529}, "overloaded server"},
+		{&StatusError{StatusCode: 500}, "server error"},
+		{&StatusError{StatusCode: 408}, "timeout"},
+		{&StatusError{StatusCode: 409}, "status 409"},
+		{errors.New("connection reset by peer"), "connection error"},
+	}
+
+	for _, tt := range tests {
+		if got := RetryReason(tt.err); got != tt.want {
+			t.Errorf("RetryReason(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialInterval: time.Second, MaxInterval: 10 * time.Second, Multiplier: 2, Jitter: 0.5}
+
+	for n, base := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second} {
+		for i := 0; i < 20; i++ {
+			if got := policy.Backoff(n); got < base/2 || got > base*3/2 {
+				t.Errorf("Backoff(%d) = %v, want within 50%% of %v", n, got, base)
+			}
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&StatusError{StatusCode: 429}, true},
+		{&StatusError{StatusCode: 529}, true},
+		{&StatusError{StatusCode: 503}, true},
+		{&StatusError{StatusCode: 401}, false},
+		{&StatusError{StatusCode: 400}, false},
+		{errors.New("connection reset by peer"), true},
+		{context.Canceled, false},
+		{nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// httpProvider posts to a server and returns its body as the reply
+type httpProvider struct {
+	client *http.Client
+	url    string
+}
+
+func (p *httpProvider) Name() string { return "test" }
+
+func (p *httpProvider) ValidateConfig(map[string]string) error { return nil }
+
+func (p *httpProvider) SendMessage(ctx context.Context, req *Request) (<-chan Response, error) {
+	resp, err := PostJSON(ctx, p.client, p.Name(), p.url, nil, req)
+	if err != nil {
+		return nil, err
+	}
+	return Stream(ctx, resp.Body, true, func(send func(string), _ func(ToolCall)) (TokenUsage, error) {
+		data, err := io.ReadAll(resp.Body)
+		send(string(data))
+		return TokenUsage{}, err
+	}), nil
+}
+
+func TestSend(t *testing.T) {
+	server, _ := retryServer(t, http.Header{"Retry-After": {"3"}}, http.StatusTooManyRequests)
+	var waits []time.Duration
+	provider := &httpProvider{client: &http.Client{Transport: testTransport(DefaultRetryPolicy, &waits)}, url: server.URL}
+
+	var got []Response
+	for resp := range Send(context.Background(), provider, &Request{}) {
+		got = append(got, resp)
+	}
+	if len(got) != 3 || got[0].Wait != 3*time.Second || got[0].WaitReason != "rate limit" || got[1].Content != "ok" || !got[2].Done {
+		t.Errorf("Send() responses = %+v, want a wait, the reply and Done", got)
+	}
+
+	provider.url = server.URL + "/missing"
+	server.Close()
+	text, _, err := Collect(Send(context.Background(), provider, &Request{}))
+	if err == nil || text != "" {
+		t.Errorf("Collect(Send()) = %q, %v; want the send error", text, err)
+	}
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ReadSSE reads a server-sent event stream, calling fn with the event type
+// and data of each event. Reading stops at the end of the stream or at the
+// first error returned by fn.
+func ReadSSE(r io.Reader, fn func(event, data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	var event string
+	var data []string
+
+	dispatch := func() error {
+		if len(data) == 0 {
+			event = ""
+			return nil
+		}
+		err := fn(event, strings.Join(data, "\n"))
+		event, data = "", nil
+		return err
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case line == "":
+			if err := dispatch(); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, ":"):
+			// Comment, used as a keep-alive
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return dispatch()
+}
+
+// WithExtensions returns body, which must encode to a JSON object, with
+// extensions merged in. Nested objects are merged key by key; any other
+// value replaces the one in body.
+func WithExtensions(body interface{}, extensions map[string]interface{}) (interface{}, error) {
+	if len(extensions) == 0 {
+		return body, nil
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	var merged map[string]interface{}
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	mergeMaps(merged, extensions)
+	return merged, nil
+}
+
+// mergeMaps merges src into dst recursively
+func mergeMaps(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if srcMap, ok := v.(map[string]interface{}); ok {
+			if dstMap, ok := dst[k].(map[string]interface{}); ok {
+				mergeMaps(dstMap, srcMap)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
+
+// PostJSON sends body as JSON and returns the response, turning a non-2xx
+// status into a *StatusError with the provider's error message
+func PostJSON(ctx context.Context, client *http.Client, provider, url string, headers map[string]string, body interface{}) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, &StatusError{Provider: provider, StatusCode: resp.StatusCode, Message: errorMessage(resp.Body)}
+	}
+
+	return resp, nil
+}
+
+// errorMessage extracts the message from an error body, which all supported
+// providers shape as {"error": {"message": "..."}}
+func errorMessage(r io.Reader) string {
+	data, _ := io.ReadAll(io.LimitReader(r, 64*1024))
+
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &body) == nil && body.Error.Message != "" {
+		return body.Error.Message
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// Stream runs read in a goroutine and returns the channel it sends to. Read
+// calls send for each piece of content and call for each complete tool call,
+// and returns the final usage; the channel then receives a Done response
+// with the tool calls, or an Error response if read failed, and is closed.
+// When stream is false content is buffered and delivered with the Done
+// response.
+func Stream(ctx context.Context, body io.ReadCloser, stream bool, read func(send func(string), call func(ToolCall)) (TokenUsage, error)) <-chan Response {
+	ch := make(chan Response, 16)
+
+	go func() {
+		defer close(ch)
+		defer body.Close()
+
+		emit := func(resp Response) {
+			select {
+			case ch <- resp:
+			case <-ctx.Done():
+			}
+		}
+		// The last response is still delivered after cancellation if the
+		// channel has room, so readers learn why the stream ended
+		finish := func(resp Response) {
+			select {
+			case ch <- resp:
+			case <-ctx.Done():
+				select {
+				case ch <- resp:
+				default:
+				}
+			}
+		}
+
+		var buffered strings.Builder
+		send := func(content string) {
+			if content == "" {
+				return
+			}
+			if stream {
+				emit(Response{Content: content})
+			} else {
+				buffered.WriteString(content)
+			}
+		}
+
+		var calls []ToolCall
+		call := func(c ToolCall) {
+			calls = append(calls, c)
+		}
+
+		usage, err := read(send, call)
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			finish(Response{Content: buffered.String(), Error: err, Usage: usage})
+			return
+		}
+
+		finish(Response{Content: buffered.String(), Done: true, Usage: usage, ToolCalls: calls})
+	}()
+
+	return ch
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadSSE(t *testing.T) {
+	stream := ": keep-alive\n\nevent: ping\ndata: {\"a\":1}\n\ndata: line one\ndata: line two\n\ndata: [DONE]"
+
+	var got []string
+	err := ReadSSE(strings.NewReader(stream), func(event, data string) error {
+		got = append(got, event+"|"+data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadSSE() error = %v", err)
+	}
+
+	want := []string{"ping|{\"a\":1}", "|line one\nline two", "|[DONE]"}
+	if strings.Join(got, ";") != strings.Join(want, ";") {
+		t.Errorf("ReadSSE() events = %q, want %q", got, want)
+	}
+}
+
+func TestReadSSEStopsOnError(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+	err := ReadSSE(strings.NewReader("data: 1\n\ndata: 2\n\n"), func(_, _ string) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("ReadSSE() = %v after %d calls, want stop after 1", err, calls)
+	}
+}
+
+func TestStreamAndCollect(t *testing.T) {
+	for _, stream := range []bool{true, false} {
+		body := io.NopCloser(strings.NewReader(""))
+		ch := Stream(context.Background(), body, stream, func(send func(string), _ func(ToolCall)) (TokenUsage, error) {
+			send("Hello")
+			send("")
+			send(", world")
+			return TokenUsage{InputTokens: 3, OutputTokens: 2}, nil
+		})
+
+		var pieces int
+		var last Response
+		for resp := range ch {
+			pieces++
+			last = resp
+		}
+		if stream && pieces != 3 || !stream && pieces != 1 {
+			t.Errorf("stream=%v: got %d responses", stream, pieces)
+		}
+		if !last.Done || last.Usage.OutputTokens != 2 {
+			t.Errorf("stream=%v: last response = %+v, want Done with usage", stream, last)
+		}
+	}
+
+	ch := Stream(context.Background(), io.NopCloser(strings.NewReader("")), true, func(send func(string), _ func(ToolCall)) (TokenUsage, error) {
+		send("partial")
+		return TokenUsage{}, errors.New("connection reset")
+	})
+	text, _, err := Collect(ch)
+	if err == nil || text != "partial" {
+		t.Errorf("Collect() = %q, %v; want partial text and an error", text, err)
+	}
+}
+
+func TestStreamCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := Stream(ctx, io.NopCloser(strings.NewReader("")), true, func(send func(string), _ func(ToolCall)) (TokenUsage, error) {
+		send("partial")
+		cancel()
+		return TokenUsage{}, nil
+	})
+
+	var last Response
+	for resp := range ch {
+		last = resp
+	}
+	if !errors.Is(last.Error, context.Canceled) {
+		t.Errorf("last response = %+v, want a cancellation error", last)
+	}
+}
+
+func TestTokenUsageAdd(t *testing.T) {
+	got := TokenUsage{InputTokens: 1, OutputTokens: 2}.Add(TokenUsage{InputTokens: 10, OutputTokens: 20})
+	if got.InputTokens != 11 || got.OutputTokens != 22 {
+		t.Errorf("Add() = %+v", got)
+	}
+}
+
+func TestPostJSONStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" || r.Header.Get("X-Key") != "secret" {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+		io.WriteString(w, `{"error": {"type": "rate_limit_error", "message": "slow down"}}`)
+	}))
+	defer server.Close()
+
+	_, err := PostJSON(context.Background(), server.Client(), "test", server.URL, map[string]string{"X-Key": "secret"}, map[string]int{"a": 1})
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("PostJSON() error = %v, want *StatusError", err)
+	}
+	if statusErr.StatusCode != http.StatusTooManyRequests || statusErr.Message != "slow down" {
+		t.Errorf("StatusError = %+v", statusErr)
+	}
+}
+
+func TestWithExtensions(t *testing.T) {
+	body := struct {
+		Model  string                 `json:"model"`
+		Config map[string]interface{} `json:"config"`
+	}{Model: "m", Config: map[string]interface{}{"a": 1}}
+
+	merged, err := WithExtensions(body, map[string]interface{}{
+		"top_k":  40,
+		"config": map[string]interface{}{"b": 2},
+	})
+	if err != nil {
+		t.Fatalf("WithExtensions() error = %v", err)
+	}
+
+	m := merged.(map[string]interface{})
+	config := m["config"].(map[string]interface{})
+	if m["model"] != "m" || m["top_k"] != 40 || config["a"] != float64(1) || config["b"] != 2 {
+		t.Errorf("WithExtensions() = %v", m)
+	}
+
+	same, _ := WithExtensions(body, nil)
+	if _, ok := same.(map[string]interface{}); ok {
+		t.Error("WithExtensions() without extensions should return body unchanged")
+	}
+}
+
+func TestReasoning(t *testing.T) {
+	tests := []struct {
+		reasoning Reasoning
+		budget    int
+		level     string
+	}{
+		{Reasoning{Effort: "high"}, 24576, "high"},
+		{Reasoning{Effort: "low"}, 1024, "low"},
+		{Reasoning{BudgetTokens: 5000}, 5000, "medium"},
+		{Reasoning{BudgetTokens: 512}, 512, "low"},
+		{Reasoning{BudgetTokens: 30000}, 30000, "high"},
+		{Reasoning{}, 8192, "medium"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.reasoning.Budget(); got != tt.budget {
+			t.Errorf("%+v.Budget() = %d, want %d", tt.reasoning, got, tt.budget)
+		}
+		if got := tt.reasoning.Level(); got != tt.level {
+			t.Errorf("%+v.Level() = %s, want %s", tt.reasoning, got, tt.level)
+		}
+	}
+}
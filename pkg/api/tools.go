@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Tool is a function a model may ask to call. Parameters is the JSON schema
+// of its input, which must describe an object.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{}
+}
+
+// ToolCall is a model asking for a tool to be run
+type ToolCall struct {
+	ID    string // Pairs the call with its result; generated for providers that have none
+	Name  string
+	Input json.RawMessage // JSON object matching the tool's parameters
+}
+
+// ToolResult is the outcome of a tool call, sent back to the model
+type ToolResult struct {
+	CallID  string
+	Name    string // Tool that was called, which some providers need
+	Content string
+	IsError bool
+}
+
+// CollectCalls reads a response channel to the end like Collect and also
+// returns the tool calls the reply asked for
+func CollectCalls(responses <-chan Response) (string, []ToolCall, TokenUsage, error) {
+	var b strings.Builder
+	var calls []ToolCall
+	var usage TokenUsage
+
+	for resp := range responses {
+		b.WriteString(resp.Content)
+		if resp.Error != nil {
+			return b.String(), nil, resp.Usage, resp.Error
+		}
+		if resp.Done {
+			calls = resp.ToolCalls
+			usage = resp.Usage
+		}
+	}
+
+	return b.String(), calls, usage, nil
+}
+
+// AppendToolTurn adds a reply that asked for tools and the results of its
+// calls to the conversation of a request, so it can be sent again
+func (r *Request) AppendToolTurn(reply string, calls []ToolCall, results []ToolResult) {
+	r.Messages = append(r.Messages,
+		Message{Role: RoleAssistant, Content: reply, ToolCalls: calls},
+		Message{Role: RoleUser, ToolResults: results},
+	)
+}
+
+// Arguments returns the input of a call, or an empty object if the model
+// sent none
+func (c ToolCall) Arguments() json.RawMessage {
+	if len(strings.TrimSpace(string(c.Input))) == 0 {
+		return json.RawMessage("{}")
+	}
+	return c.Input
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCollectCalls(t *testing.T) {
+	ch := Stream(context.Background(), io.NopCloser(strings.NewReader("")), true, func(send func(string), call func(ToolCall)) (TokenUsage, error) {
+		send("Looking")
+		call(ToolCall{ID: "1", Name: "read_file", Input: []byte(`{"path":"go.mod"}`)})
+		call(ToolCall{ID: "2", Name: "list_dir"})
+		return TokenUsage{InputTokens: 4, OutputTokens: 2}, nil
+	})
+
+	text, calls, usage, err := CollectCalls(ch)
+	if err != nil || text != "Looking" || usage.OutputTokens != 2 {
+		t.Fatalf("CollectCalls() = %q, %+v, %v", text, usage, err)
+	}
+	if len(calls) != 2 || calls[0].Name != "read_file" || string(calls[1].Arguments()) != "{}" {
+		t.Errorf("calls = %+v", calls)
+	}
+}
+
+func TestAppendToolTurn(t *testing.T) {
+	req := &Request{Messages: []Message{{Role: RoleUser, Content: "Hi"}}}
+	req.AppendToolTurn("Let me check", []ToolCall{{ID: "1", Name: "list_dir"}}, []ToolResult{{CallID: "1", Content: "a.go"}})
+
+	if len(req.Messages) != 3 {
+		t.Fatalf("messages = %+v", req.Messages)
+	}
+	if m := req.Messages[1]; m.Role != RoleAssistant || m.Content != "Let me check" || len(m.ToolCalls) != 1 {
+		t.Errorf("reply = %+v", m)
+	}
+	if m := req.Messages[2]; m.Role != RoleUser || len(m.ToolResults) != 1 || m.ToolResults[0].CallID != "1" {
+		t.Errorf("results = %+v", m)
+	}
+}